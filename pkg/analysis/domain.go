@@ -0,0 +1,295 @@
+// Package analysis finds the domain and range of functions of one variable.
+package analysis
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/quizizz/cas/pkg/ast"
+	"github.com/quizizz/cas/pkg/simplify"
+	"github.com/quizizz/cas/pkg/solve"
+)
+
+// Domain returns the values of variable for which expr is defined: square
+// roots and other even roots need a non-negative argument, logarithms a
+// positive one, denominators must not vanish and arcsin and arccos need
+// an argument between -1 and 1. Each condition is solved with
+// solve.SolveInequality, so sqrt(x - 2)/(x - 5) has the domain
+// [2, 5) U (5, inf). A condition linear in one square root, such as
+// sqrt(x) - 1 > 0 for ln(sqrt(x) - 1), is first rewritten as one on the
+// radicand; a condition that is still not polynomial or rational is an
+// error.
+func Domain(expr ast.Expr, variable string) (solve.IntervalSet, error) {
+	conditions, err := constraints(expr, variable)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := solve.DefaultSolveOptions()
+	opts.Variable = variable
+	domain := solve.RealLine()
+	for _, condition := range conditions {
+		set, err := solve.SolveInequality(unnest(condition, variable), opts)
+		if err != nil {
+			return nil, fmt.Errorf("cannot solve domain condition %s: %w", condition, err)
+		}
+		domain = domain.Intersect(set)
+	}
+	return domain, nil
+}
+
+// constraints returns the conditions on variable for expr and each of its
+// subexpressions to be defined
+func constraints(expr ast.Expr, variable string) ([]*ast.Eq, error) {
+	var conditions []*ast.Eq
+	for _, child := range ast.Children(expr) {
+		inner, err := constraints(child, variable)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, inner...)
+	}
+	if !containsVariable(expr, variable) {
+		return conditions, nil
+	}
+
+	// require adds the condition u (relation) c with u reduced to a
+	// polynomial of the same sign where possible
+	require := func(u ast.Expr, relation ast.EqType, c int64) {
+		if c == 0 {
+			u = sameSign(u)
+		}
+		conditions = append(conditions, ast.NewEq(u, ast.NewInt(c), relation))
+	}
+
+	switch e := expr.(type) {
+	case *ast.Pow:
+		base, exponent := e.Base(), e.Exponent()
+		if containsVariable(exponent, variable) {
+			// b^u with a variable exponent is only defined for b > 0
+			if containsVariable(base, variable) {
+				require(base, ast.EqGreater, 0)
+			}
+			break
+		}
+		value, ok := ast.ConstantValue(exponent)
+		if !ok {
+			return nil, fmt.Errorf("exponent %s is not constant", exponent)
+		}
+		// An odd root is defined for every real number, so only a fraction
+		// in lowest terms with an even denominator, or an irrational
+		// exponent, needs a non-negative base
+		evenRoot := !isWhole(value)
+		if r, ok := ast.ExactValue(exponent); ok {
+			evenRoot = r.Denom().Bit(0) == 0
+		}
+		switch {
+		case evenRoot && value < 0:
+			require(base, ast.EqGreater, 0)
+		case evenRoot:
+			require(base, ast.EqGreaterEqual, 0)
+		case value < 0:
+			require(base, ast.EqNotEqual, 0)
+		}
+
+	case *ast.Func:
+		args := e.Args()
+		switch canonicalName(e.Name()) {
+		case "sqrt":
+			require(args[0], ast.EqGreaterEqual, 0)
+		case "ln":
+			require(args[0], ast.EqGreater, 0)
+		case "log":
+			require(args[0], ast.EqGreater, 0)
+			if len(args) == 2 && containsVariable(args[1], variable) {
+				require(args[1], ast.EqGreater, 0)
+				conditions = append(conditions, ast.NewEq(args[1], ast.NewInt(1), ast.EqNotEqual))
+			}
+		case "arcsin", "arccos":
+			require(args[0], ast.EqGreaterEqual, -1)
+			require(args[0], ast.EqLessEqual, 1)
+		case "arctanh":
+			require(args[0], ast.EqGreater, -1)
+			require(args[0], ast.EqLess, 1)
+		case "arccosh":
+			require(args[0], ast.EqGreaterEqual, 1)
+		case "arcsec", "arccsc":
+			// |u| >= 1
+			conditions = append(conditions, ast.NewEq(ast.NewPow(args[0], ast.NewInt(2)), ast.NewInt(1), ast.EqGreaterEqual))
+		case "csch", "coth":
+			require(args[0], ast.EqNotEqual, 0)
+		case "sin", "cos", "arctan", "arccot", "exp", "abs", "sinh", "cosh", "tanh", "sech", "arcsinh":
+		default:
+			// tan, sec, csc and cot are undefined at infinitely many points
+			return nil, fmt.Errorf("domain of %s is not supported", e.Name())
+		}
+	}
+	return conditions, nil
+}
+
+// unnest rewrites a condition that is linear in one square root,
+// a*sqrt(v) + b (relation) 0 with constant a and b, as a condition on v.
+// For t = sqrt(v) >= 0, t > k holds for every v >= 0 when k < 0 and
+// means v > k^2 otherwise, so sqrt(x) - 1 > 0 becomes x > 1; v >= 0 is
+// the square root's own condition. Other conditions are returned as they
+// are.
+func unnest(condition *ast.Eq, variable string) *ast.Eq {
+	root := findRoot(condition.Left(), variable)
+	if root == nil {
+		return condition
+	}
+
+	// f(t) = lhs - rhs with t in place of the root must be a*t + b
+	t := ast.NewVar(variable + "_root")
+	f := ast.Replace(ast.NewAdd(condition.Left(), ast.NewMul(ast.NewInt(-1), condition.Right())), root, t)
+	at := func(value int64) ast.Expr {
+		return simplify.Simplify(ast.Substitute(f, t.Name(), ast.NewInt(value)))
+	}
+	b := at(0)
+	a := simplify.Simplify(ast.NewAdd(at(1), ast.NewMul(ast.NewInt(-1), b)))
+	aValue, okA := ast.ConstantValue(a)
+	bValue, okB := ast.ConstantValue(b)
+	twoValue, okTwo := ast.ConstantValue(at(2))
+	if !okA || !okB || !okTwo || aValue == 0 ||
+		math.Abs(twoValue-(2*aValue+bValue)) > 1e-9*math.Max(1, math.Abs(twoValue)) {
+		return condition
+	}
+
+	relation := condition.EqType()
+	if aValue < 0 {
+		relation = flipped(relation)
+	}
+	k := -bValue / aValue
+	v := radicand(root)
+	always := ast.NewEq(v, ast.NewInt(0), ast.EqGreaterEqual)
+	never := ast.NewEq(ast.NewInt(1), ast.NewInt(0), ast.EqEqual)
+	switch {
+	case relation == ast.EqGreater && k < 0,
+		relation == ast.EqGreaterEqual && k <= 0,
+		relation == ast.EqNotEqual && k < 0:
+		return always
+	case relation == ast.EqLess && k <= 0,
+		relation == ast.EqLessEqual && k < 0,
+		relation == ast.EqEqual && k < 0:
+		return never
+	}
+	square := tidy(ast.NewPow(ast.NewMul(ast.NewInt(-1), b, ast.NewPow(a, ast.NewInt(-1))), ast.NewInt(2)))
+	return unnest(ast.NewEq(v, square, relation), variable)
+}
+
+// findRoot returns the first square root in expr whose radicand depends on
+// variable, or nil
+func findRoot(expr ast.Expr, variable string) ast.Expr {
+	if radicand(expr) != nil && containsVariable(expr, variable) {
+		return expr
+	}
+	for _, child := range ast.Children(expr) {
+		if root := findRoot(child, variable); root != nil {
+			return root
+		}
+	}
+	return nil
+}
+
+// radicand returns v when expr is sqrt(v) or v^(1/2), and nil otherwise
+func radicand(expr ast.Expr) ast.Expr {
+	switch e := expr.(type) {
+	case *ast.Func:
+		if e.Name() == "sqrt" && len(e.Args()) == 1 {
+			return e.Args()[0]
+		}
+	case *ast.Pow:
+		if value, ok := ast.ConstantValue(e.Exponent()); ok && value == 0.5 {
+			return e.Base()
+		}
+	}
+	return nil
+}
+
+// flipped returns the relation that holds after multiplying both sides by
+// a negative number
+func flipped(relation ast.EqType) ast.EqType {
+	switch relation {
+	case ast.EqLess:
+		return ast.EqGreater
+	case ast.EqGreater:
+		return ast.EqLess
+	case ast.EqLessEqual:
+		return ast.EqGreaterEqual
+	case ast.EqGreaterEqual:
+		return ast.EqLessEqual
+	}
+	return relation
+}
+
+// sameSign returns a simpler expression with the same sign as expr wherever
+// both are defined, so that conditions on rational functions and roots
+// become polynomial ones: 1/(x - 1) has the sign of x - 1, sqrt(x) that of
+// x, ln(x) that of x - 1 and e^x is positive.
+func sameSign(expr ast.Expr) ast.Expr {
+	switch e := expr.(type) {
+	case *ast.Mul:
+		factors := e.Terms()
+		for i, factor := range factors {
+			factors[i] = sameSign(factor)
+		}
+		return ast.NewMul(factors...)
+	case *ast.Pow:
+		if len(e.Exponent().Variables()) > 0 {
+			if value, ok := ast.ConstantValue(e.Base()); ok && value > 0 {
+				return ast.NewInt(1)
+			}
+			return expr
+		}
+		value, ok := ast.ConstantValue(e.Exponent())
+		switch {
+		case !ok || value == 0:
+			return expr
+		case !isWhole(value):
+			return sameSign(e.Base())
+		case value < 0:
+			return ast.NewPow(sameSign(e.Base()), ast.NewInt(int64(-value)))
+		}
+	case *ast.Func:
+		switch canonicalName(e.Name()) {
+		case "sqrt":
+			return sameSign(e.Args()[0])
+		case "exp":
+			return ast.NewInt(1)
+		case "ln", "log":
+			// A logarithm to a base above one has the sign of u - 1
+			args := e.Args()
+			if len(args) == 2 {
+				base, ok := ast.ConstantValue(args[1])
+				if !ok || base <= 1 {
+					return expr
+				}
+			}
+			return ast.NewAdd(args[0], ast.NewInt(-1))
+		}
+	}
+	return expr
+}
+
+func isWhole(f float64) bool {
+	return f == float64(int64(f))
+}
+
+// canonicalName maps the short names of the inverse functions to their
+// long ones
+func canonicalName(name string) string {
+	switch name {
+	case "asin", "acos", "atan", "asec", "acsc", "acot", "asinh", "acosh", "atanh":
+		return "arc" + name[1:]
+	}
+	return name
+}
+
+func containsVariable(expr ast.Expr, variable string) bool {
+	for _, v := range expr.Variables() {
+		if v == variable {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/quizizz/cas/pkg/parser"
+)
+
+func TestDomain(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x^2 + 1", "(-inf, inf)"},
+		{"sqrt(x - 2)/(x - 5)", "[2, 5) U (5, inf)"},
+		{"1/x", "(-inf, 0) U (0, inf)"},
+		{"1/sqrt(x)", "(0, inf)"},
+		{"x^(1/2)", "[0, inf)"},
+		{"\\sqrt[3]{x}", "(-inf, inf)"},
+		{"x^(1/3)", "(-inf, inf)"},
+		{"x^(-1/3)", "(-inf, 0) U (0, inf)"},
+		{"x^(2/3)", "(-inf, inf)"},
+		{"\\sqrt[4]{x}", "[0, inf)"},
+		{"x^(-2/4)", "(0, inf)"},
+		{"sqrt(4 - x^2)", "[-2, 2]"},
+		{"ln(x^2 - 1)", "(-inf, -1) U (1, inf)"},
+		{"ln((x - 1)/(x + 2))", "(-inf, -2) U (1, inf)"},
+		{"log(x - 1, x)", "(1, inf)"},
+		{"arcsin(2x - 1)", "[0, 1]"},
+		{"1/(x^2 + 1)", "(-inf, inf)"},
+		{"e^x + sin(x)", "(-inf, inf)"},
+		{"sech(x) + coth(x - 1)", "(-inf, 1) U (1, inf)"},
+		{"ln(sqrt(x) - 1)", "(1, inf)"},
+		{"sqrt(sqrt(x) - 1)", "[1, inf)"},
+		{"sqrt(3 - 2sqrt(x))", "[0, 9/4]"},
+		{"1/(sqrt(x) - 2)", "[0, 4) U (4, inf)"},
+		{"ln(sqrt(x) + 1)", "[0, inf)"},
+		{"sqrt(-1 - sqrt(x))", "{}"},
+		{"sqrt(x^3 - x)", "[-1, 0] U [1, inf)"},
+		{"1/(x^3 - x)", "(-inf, -1) U (-1, 0) U (0, 1) U (1, inf)"},
+		{"ln(ln(x))", "(1, inf)"},
+		{"1/(abs(x) - 1)", "(-inf, -1) U (-1, 1) U (1, inf)"},
+		{"sqrt(sqrt(x^3 - x) - 1)", "[1.3247179572447465, inf)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			expr, err := parser.Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+
+			result, err := Domain(expr, "x")
+			if err != nil {
+				t.Fatalf("Domain error: %v", err)
+			}
+			if got := result.String(); got != tt.expected {
+				t.Errorf("Domain(%s) = %s, want %s", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDomainErrors(t *testing.T) {
+	tests := []string{
+		"tan(x)",
+		"sqrt(x - a)",
+		"ln(sqrt(x) - x)",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			expr, err := parser.Parse(input)
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+			if _, err := Domain(expr, "x"); err == nil {
+				t.Errorf("Domain(%s) should fail", input)
+			}
+		})
+	}
+}
@@ -0,0 +1,598 @@
+package analysis
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/quizizz/cas/pkg/ast"
+	"github.com/quizizz/cas/pkg/calculus"
+	"github.com/quizizz/cas/pkg/simplify"
+	"github.com/quizizz/cas/pkg/solve"
+)
+
+// Range returns the values expr takes as variable runs over its domain.
+// The image is built from the inside out: the values of each argument are
+// mapped through the function applied to it, one monotone piece at a time.
+// Constant shifts and scales, powers and roots, exponentials, logarithms,
+// absolute values, the inverse trigonometric and hyperbolic functions,
+// sin and cos, and polynomials up to degree 2 are supported, so
+// sqrt(4 - x^2) has the range [0, 2] and 1/x has (-inf, 0) U (0, inf).
+func Range(expr ast.Expr, variable string) (solve.IntervalSet, error) {
+	domain, err := Domain(expr, variable)
+	if err != nil {
+		return nil, err
+	}
+	return image(expr, variable, domain)
+}
+
+// image returns the values expr takes as variable runs over set
+func image(expr ast.Expr, variable string, set solve.IntervalSet) (solve.IntervalSet, error) {
+	if len(set) == 0 {
+		return solve.IntervalSet{}, nil
+	}
+	if !containsVariable(expr, variable) {
+		return point(tidy(expr)), nil
+	}
+
+	switch e := expr.(type) {
+	case *ast.Var:
+		return set, nil
+
+	case *ast.Add:
+		constants, rest := splitConstants(e.Terms(), variable)
+		if len(rest) == 1 && len(constants) > 0 {
+			inner, err := image(rest[0], variable, set)
+			if err != nil {
+				return nil, err
+			}
+			return shift(ast.NewAdd(constants...)).apply(inner), nil
+		}
+
+	case *ast.Mul:
+		constants, rest := splitConstants(e.Terms(), variable)
+		if len(rest) == 1 && len(constants) > 0 {
+			inner, err := image(rest[0], variable, set)
+			if err != nil {
+				return nil, err
+			}
+			f, err := scale(ast.NewMul(constants...))
+			if err != nil {
+				return nil, err
+			}
+			return f.apply(inner), nil
+		}
+
+	case *ast.Pow:
+		base, exponent := e.Base(), e.Exponent()
+		switch {
+		case !containsVariable(exponent, variable):
+			inner, err := image(base, variable, set)
+			if err != nil {
+				return nil, err
+			}
+			f, err := power(exponent)
+			if err != nil {
+				return nil, err
+			}
+			return f.apply(inner), nil
+		case !containsVariable(base, variable):
+			inner, err := image(exponent, variable, set)
+			if err != nil {
+				return nil, err
+			}
+			f, err := exponential(base)
+			if err != nil {
+				return nil, err
+			}
+			return f.apply(inner), nil
+		}
+
+	case *ast.Func:
+		if len(e.Args()) == 1 {
+			inner, err := image(e.Args()[0], variable, set)
+			if err != nil {
+				return nil, err
+			}
+			return applyFunc(e.Name(), inner)
+		}
+		if canonicalName(e.Name()) == "log" && len(e.Args()) == 2 && !containsVariable(e.Args()[1], variable) {
+			inner, err := image(e.Args()[0], variable, set)
+			if err != nil {
+				return nil, err
+			}
+			f, err := logarithm(e.Args()[1])
+			if err != nil {
+				return nil, err
+			}
+			return f.apply(inner), nil
+		}
+	}
+
+	return polynomialImage(expr, variable, set)
+}
+
+// point returns the set holding c alone
+func point(c ast.Expr) solve.IntervalSet {
+	return solve.IntervalSet{{Lower: c, Upper: c, LowerClosed: true, UpperClosed: true}}
+}
+
+// splitConstants separates the terms that do not depend on variable from
+// those that do
+func splitConstants(terms []ast.Expr, variable string) (constants, rest []ast.Expr) {
+	for _, term := range terms {
+		if containsVariable(term, variable) {
+			rest = append(rest, term)
+		} else {
+			constants = append(constants, term)
+		}
+	}
+	return constants, rest
+}
+
+// applyFunc maps set through the named function of one argument
+func applyFunc(name string, set solve.IntervalSet) (solve.IntervalSet, error) {
+	switch name := canonicalName(name); name {
+	case "sin", "cos":
+		// A full period covers every value; shorter intervals are not
+		// handled
+		for _, interval := range set {
+			lower, upper := boundValues(interval)
+			if upper-lower < 2*math.Pi {
+				return nil, fmt.Errorf("range of %s over %s is not supported", name, set)
+			}
+		}
+		return solve.IntervalSet{{Lower: ast.NewInt(-1), Upper: ast.NewInt(1), LowerClosed: true, UpperClosed: true}}, nil
+	case "sqrt":
+		return monotone{
+			domain: solve.IntervalSet{{Lower: ast.NewInt(0), LowerClosed: true}},
+			at:     unary(name),
+		}.apply(set), nil
+	case "exp":
+		f, err := exponential(ast.E)
+		if err != nil {
+			return nil, err
+		}
+		return f.apply(set), nil
+	case "ln", "log":
+		return monotone{
+			domain: solve.IntervalSet{{Lower: ast.NewInt(0)}},
+			at:     unary(name),
+		}.apply(set), nil
+	case "abs", "cosh":
+		return monotone{
+			breaks:     []ast.Expr{ast.NewInt(0)},
+			increasing: func(x float64) bool { return x > 0 },
+			at:         unary(name),
+		}.apply(set), nil
+	case "arcsin", "arccos":
+		return monotone{
+			domain:     solve.IntervalSet{{Lower: ast.NewInt(-1), Upper: ast.NewInt(1), LowerClosed: true, UpperClosed: true}},
+			increasing: func(float64) bool { return name == "arcsin" },
+			at:         unary(name),
+		}.apply(set), nil
+	case "arctan":
+		return monotone{
+			at:    unary(name),
+			lower: tidy(ast.NewMul(ast.NewRational(-1, 2), ast.Pi)),
+			upper: tidy(ast.NewMul(ast.NewRational(1, 2), ast.Pi)),
+		}.apply(set), nil
+	case "tanh":
+		return monotone{
+			at:    unary(name),
+			lower: ast.NewInt(-1),
+			upper: ast.NewInt(1),
+		}.apply(set), nil
+	case "sinh", "arcsinh":
+		return monotone{at: unary(name)}.apply(set), nil
+	case "arccosh":
+		return monotone{
+			domain: solve.IntervalSet{{Lower: ast.NewInt(1), LowerClosed: true}},
+			at:     unary(name),
+		}.apply(set), nil
+	case "arctanh":
+		return monotone{
+			domain: solve.IntervalSet{{Lower: ast.NewInt(-1), Upper: ast.NewInt(1)}},
+			at:     unary(name),
+		}.apply(set), nil
+	}
+	return nil, fmt.Errorf("range of %s is not supported", name)
+}
+
+// monotone describes a function of one argument that is continuous and
+// monotone between its breaks, the points where it turns or is undefined
+type monotone struct {
+	// domain restricts the argument; nil allows every real number
+	domain solve.IntervalSet
+	// breaks are the turning and singular points in increasing order
+	breaks []ast.Expr
+	// increasing reports the direction between breaks, around x; nil means
+	// increasing everywhere
+	increasing func(x float64) bool
+	// at returns the value at a finite argument, or nil where it is
+	// infinite
+	at func(x ast.Expr) ast.Expr
+	// lower and upper are the limits as the argument tends to -inf and
+	// inf, nil when those are infinite
+	lower, upper ast.Expr
+	// constant is the only value of a constant function such as 0*u
+	constant ast.Expr
+}
+
+// apply returns the values f takes over set
+func (f monotone) apply(set solve.IntervalSet) solve.IntervalSet {
+	if f.domain != nil {
+		set = set.Intersect(f.domain)
+	}
+	if f.constant != nil && len(set) > 0 {
+		return point(f.constant)
+	}
+	result := solve.IntervalSet{}
+	for _, interval := range set {
+		for _, piece := range splitAt(interval, f.breaks) {
+			result = result.Union(f.applyPiece(piece))
+		}
+	}
+	return result
+}
+
+// applyPiece maps an interval on which f is monotone
+func (f monotone) applyPiece(interval solve.Interval) solve.IntervalSet {
+	lower, upper := boundValues(interval)
+	var mid float64
+	switch {
+	case math.IsInf(lower, -1) && math.IsInf(upper, 1):
+		mid = 0
+	case math.IsInf(lower, -1):
+		mid = upper - 1
+	case math.IsInf(upper, 1):
+		mid = lower + 1
+	default:
+		mid = (lower + upper) / 2
+	}
+
+	from := f.valueAt(interval.Lower, f.lower)
+	to := f.valueAt(interval.Upper, f.upper)
+	fromClosed, toClosed := interval.LowerClosed, interval.UpperClosed
+	if f.increasing != nil && !f.increasing(mid) {
+		from, to = to, from
+		fromClosed, toClosed = toClosed, fromClosed
+	}
+	return solve.IntervalSet{{
+		Lower:       from,
+		Upper:       to,
+		LowerClosed: fromClosed && from != nil,
+		UpperClosed: toClosed && to != nil,
+	}}
+}
+
+// valueAt returns f at a bound, or its limit at an infinite one
+func (f monotone) valueAt(bound, limit ast.Expr) ast.Expr {
+	if bound == nil {
+		return limit
+	}
+	return f.at(bound)
+}
+
+// splitAt cuts interval at the breaks that lie strictly inside it
+func splitAt(interval solve.Interval, breaks []ast.Expr) []solve.Interval {
+	var pieces []solve.Interval
+	for _, b := range breaks {
+		x, _ := ast.ConstantValue(b)
+		lower, upper := boundValues(interval)
+		if x <= lower || x >= upper {
+			continue
+		}
+		pieces = append(pieces, solve.Interval{Lower: interval.Lower, Upper: b, LowerClosed: interval.LowerClosed, UpperClosed: true})
+		interval.Lower, interval.LowerClosed = b, true
+	}
+	return append(pieces, interval)
+}
+
+// shift returns u + c
+func shift(c ast.Expr) monotone {
+	return monotone{at: func(x ast.Expr) ast.Expr { return tidy(ast.NewAdd(x, c)) }}
+}
+
+// scale returns k*u
+func scale(k ast.Expr) (monotone, error) {
+	value, ok := ast.ConstantValue(k)
+	if !ok {
+		return monotone{}, fmt.Errorf("coefficient %s is not constant", k)
+	}
+	if value == 0 {
+		return monotone{constant: ast.NewInt(0)}, nil
+	}
+	return monotone{
+		increasing: func(float64) bool { return value > 0 },
+		at:         func(x ast.Expr) ast.Expr { return tidy(ast.NewMul(k, x)) },
+	}, nil
+}
+
+// power returns u^n for a constant n
+func power(n ast.Expr) (monotone, error) {
+	value, ok := ast.ConstantValue(n)
+	if !ok {
+		return monotone{}, fmt.Errorf("exponent %s is not constant", n)
+	}
+	zero := ast.NewInt(0)
+	at := func(x ast.Expr) ast.Expr {
+		switch {
+		case isZero(x) && value < 0:
+			return nil
+		case isZero(x):
+			return zero
+		}
+		return tidy(ast.NewPow(x, n))
+	}
+
+	switch {
+	case value == 0:
+		return monotone{constant: ast.NewInt(1)}, nil
+	case !isWhole(value) && value > 0:
+		return monotone{domain: solve.IntervalSet{{Lower: zero, LowerClosed: true}}, at: at}, nil
+	case !isWhole(value):
+		return monotone{
+			domain:     solve.IntervalSet{{Lower: zero}},
+			increasing: func(float64) bool { return false },
+			at:         at,
+			upper:      zero,
+		}, nil
+	}
+
+	even := int64(value)%2 == 0
+	f := monotone{breaks: []ast.Expr{zero}, at: at}
+	switch {
+	case value > 0 && even:
+		f.increasing = func(x float64) bool { return x > 0 }
+	case value < 0 && even:
+		f.increasing = func(x float64) bool { return x < 0 }
+		f.lower, f.upper = zero, zero
+	case value < 0:
+		f.increasing = func(float64) bool { return false }
+		f.lower, f.upper = zero, zero
+	}
+	return f, nil
+}
+
+// exponential returns b^u for a constant b > 0
+func exponential(b ast.Expr) (monotone, error) {
+	value, ok := ast.ConstantValue(b)
+	if !ok {
+		return monotone{}, fmt.Errorf("base %s is not constant", b)
+	}
+	if value <= 0 {
+		return monotone{}, fmt.Errorf("range of %s^x is not supported", b)
+	}
+	f := monotone{at: func(x ast.Expr) ast.Expr { return tidy(ast.NewPow(b, x)) }}
+	switch {
+	case value > 1:
+		f.lower = ast.NewInt(0)
+	case value < 1:
+		f.increasing = func(float64) bool { return false }
+		f.upper = ast.NewInt(0)
+	default:
+		f.constant = ast.NewInt(1)
+	}
+	return f, nil
+}
+
+// logarithm returns log_b(u) for a constant base b > 0 other than 1
+func logarithm(b ast.Expr) (monotone, error) {
+	value, ok := ast.ConstantValue(b)
+	if !ok {
+		return monotone{}, fmt.Errorf("base %s is not constant", b)
+	}
+	if value <= 0 || value == 1 {
+		return monotone{}, fmt.Errorf("range of log base %s is not supported", b)
+	}
+	return monotone{
+		domain:     solve.IntervalSet{{Lower: ast.NewInt(0)}},
+		increasing: func(float64) bool { return value > 1 },
+		at: func(x ast.Expr) ast.Expr {
+			value := ast.NewFunc("log", x, b)
+			if _, ok := ast.ConstantValue(value); !ok {
+				return nil
+			}
+			return tidy(value)
+		},
+	}, nil
+}
+
+// unary returns the named function applied to a constant argument, or nil
+// at a singular point such as ln(0)
+func unary(name string) func(ast.Expr) ast.Expr {
+	return func(x ast.Expr) ast.Expr {
+		value := ast.NewFunc(name, x)
+		if _, ok := ast.ConstantValue(value); !ok {
+			return nil
+		}
+		return tidy(value)
+	}
+}
+
+// polynomialImage handles polynomials of degree up to 2 in variable, which
+// are monotone on either side of the root of their derivative
+func polynomialImage(expr ast.Expr, variable string, set solve.IntervalSet) (solve.IntervalSet, error) {
+	unsupported := fmt.Errorf("range of %s is not supported", expr)
+	first, err := calculus.Derivative(expr, variable)
+	if err != nil {
+		return nil, unsupported
+	}
+	first = simplify.Simplify(first)
+
+	at := func(x ast.Expr) ast.Expr {
+		return tidy(ast.Substitute(expr, variable, x))
+	}
+	if !containsVariable(first, variable) {
+		slope, ok := ast.ConstantValue(first)
+		if !ok {
+			return nil, unsupported
+		}
+		if slope == 0 {
+			return point(at(interiorPoint(set))), nil
+		}
+		return monotone{increasing: func(float64) bool { return slope > 0 }, at: at}.apply(set), nil
+	}
+
+	second, err := calculus.Derivative(first, variable)
+	if err != nil {
+		return nil, unsupported
+	}
+	curvature, ok := ast.ConstantValue(simplify.Simplify(second))
+	if !ok || curvature == 0 {
+		return nil, unsupported
+	}
+	opts := solve.DefaultSolveOptions()
+	opts.Variable = variable
+	roots := solve.Solve(first, opts).Solutions
+	if len(roots) != 1 {
+		return nil, unsupported
+	}
+	vertex := tidy(roots[0].Value)
+	turn, ok := ast.ConstantValue(vertex)
+	if !ok {
+		return nil, unsupported
+	}
+	return monotone{
+		breaks:     []ast.Expr{vertex},
+		increasing: func(x float64) bool { return (x > turn) == (curvature > 0) },
+		at:         at,
+	}.apply(set), nil
+}
+
+// interiorPoint returns a point of set, taken inside its first interval
+// so that it avoids excluded endpoints such as 0 for x/x
+func interiorPoint(set solve.IntervalSet) ast.Expr {
+	interval := set[0]
+	switch {
+	case interval.Lower == nil && interval.Upper == nil:
+		return ast.NewInt(1)
+	case interval.Lower == nil:
+		return tidy(ast.NewAdd(interval.Upper, ast.NewInt(-1)))
+	case interval.Upper == nil:
+		return tidy(ast.NewAdd(interval.Lower, ast.NewInt(1)))
+	}
+	return tidy(ast.NewMul(ast.NewRational(1, 2), ast.NewAdd(interval.Lower, interval.Upper)))
+}
+
+// boundValues returns the numeric bounds of interval, with infinities for
+// nil bounds
+func boundValues(interval solve.Interval) (float64, float64) {
+	lower, upper := math.Inf(-1), math.Inf(1)
+	if interval.Lower != nil {
+		lower, _ = ast.ConstantValue(interval.Lower)
+	}
+	if interval.Upper != nil {
+		upper, _ = ast.ConstantValue(interval.Upper)
+	}
+	return lower, upper
+}
+
+// isZero reports whether expr is a constant equal to zero
+func isZero(expr ast.Expr) bool {
+	value, ok := ast.ConstantValue(expr)
+	return ok && value == 0
+}
+
+// tidy simplifies a constant bound, writing it as a fraction with a small
+// denominator, or such a fraction of pi, when its value is one. A sum of a
+// rational and a rational multiple of pi, as a bound of arcsin(x) + 1, is
+// combined exactly rather than folded into a float.
+func tidy(expr ast.Expr) ast.Expr {
+	if a, b, ok := piLinear(expr); ok {
+		return piExpr(a, b)
+	}
+	expr = simplify.Simplify(expr)
+	value, ok := ast.ConstantValue(expr)
+	if !ok {
+		return expr
+	}
+	if r, ok := smallFraction(value, 100); ok {
+		return r
+	}
+	if r, ok := smallFraction(value/math.Pi, 12); ok {
+		// Simplify would fold the product into a float
+		return ast.NewMul(r, ast.Pi)
+	}
+	return expr
+}
+
+// smallFraction returns value as an integer or a fraction whose
+// denominator is at most maxDen, if it is one
+func smallFraction(value float64, maxDen int64) (ast.Expr, bool) {
+	for den := int64(1); den <= maxDen; den++ {
+		num := math.Round(value * float64(den))
+		if math.Abs(num/float64(den)-value) > 1e-12*math.Max(1, math.Abs(value)) {
+			continue
+		}
+		if den == 1 {
+			return ast.NewInt(int64(num)), true
+		}
+		return ast.NewRationalFromInts(big.NewInt(int64(num)), big.NewInt(den)), true
+	}
+	return nil, false
+}
+
+// piLinear writes a constant built from integers, rationals and pi with
+// sums and products as a + b*pi with rational a and b
+func piLinear(expr ast.Expr) (*big.Rat, *big.Rat, bool) {
+	switch e := expr.(type) {
+	case *ast.Int:
+		return new(big.Rat).SetInt(e.IntValue()), new(big.Rat), true
+	case *ast.Rational:
+		return new(big.Rat).SetFrac(e.Numerator(), e.Denominator()), new(big.Rat), true
+	case *ast.Const:
+		if e.Name() == ast.Pi.Name() {
+			return new(big.Rat), big.NewRat(1, 1), true
+		}
+	case *ast.Add:
+		a, b := new(big.Rat), new(big.Rat)
+		for _, term := range e.Terms() {
+			ta, tb, ok := piLinear(term)
+			if !ok {
+				return nil, nil, false
+			}
+			a.Add(a, ta)
+			b.Add(b, tb)
+		}
+		return a, b, true
+	case *ast.Mul:
+		// At most one factor may involve pi
+		a, b := big.NewRat(1, 1), new(big.Rat)
+		for _, factor := range e.Terms() {
+			fa, fb, ok := piLinear(factor)
+			if !ok || fb.Sign() != 0 && b.Sign() != 0 {
+				return nil, nil, false
+			}
+			b.Add(new(big.Rat).Mul(a, fb), new(big.Rat).Mul(b, fa))
+			a.Mul(a, fa)
+		}
+		return a, b, true
+	}
+	return nil, nil, false
+}
+
+// piExpr builds a + b*pi, leaving out a zero part
+func piExpr(a, b *big.Rat) ast.Expr {
+	rational := func(r *big.Rat) ast.Expr {
+		if r.IsInt() {
+			i, _ := ast.NewIntFromString(r.Num().String())
+			return i
+		}
+		return ast.NewRationalFromInts(r.Num(), r.Denom())
+	}
+	var multiple ast.Expr
+	switch {
+	case b.Sign() == 0:
+		return rational(a)
+	case b.Cmp(big.NewRat(1, 1)) == 0:
+		multiple = ast.Pi
+	default:
+		multiple = ast.NewMul(rational(b), ast.Pi)
+	}
+	if a.Sign() == 0 {
+		return multiple
+	}
+	return ast.NewAdd(rational(a), multiple)
+}
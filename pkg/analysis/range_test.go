@@ -0,0 +1,88 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/quizizz/cas/pkg/parser"
+)
+
+func TestRange(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"5", "[5, 5]"},
+		{"3x + 2", "(-inf, inf)"},
+		{"x^2 - 4x + 1", "[-3, inf)"},
+		{"x^2 + x", "[-1/4, inf)"},
+		{"3 - x^2", "(-inf, 3]"},
+		{"x*(x - 2)", "[-1, inf)"},
+		{"-(x - 1)^2 + 3", "(-inf, 3]"},
+		{"x^3", "(-inf, inf)"},
+		{"1/x", "(-inf, 0) U (0, inf)"},
+		{"1/x^2", "(0, inf)"},
+		{"1/(x - 1) + 2", "(-inf, 2) U (2, inf)"},
+		{"1/(x^2 + 1)", "(0, 1]"},
+		{"sqrt(x) + 1", "[1, inf)"},
+		{"-sqrt(x)", "(-inf, 0]"},
+		{"sqrt(4 - x^2)", "[0, 2]"},
+		{"sqrt(x^2 - 4x + 5)", "[1, inf)"},
+		{"abs(x - 3)", "[0, inf)"},
+		{"e^x", "(0, inf)"},
+		{"2^x + 1", "(1, inf)"},
+		{"e^(-x^2)", "(0, 1]"},
+		{"e^(1/x)", "(0, 1) U (1, inf)"},
+		{"ln(x)", "(-inf, inf)"},
+		{"ln(x^2 + 1)", "[0, inf)"},
+		{"sin(x)", "[-1, 1]"},
+		{"2cos(x) + 1", "[-1, 3]"},
+		{"arcsin(x)", "[-1/2*pi, 1/2*pi]"},
+		{"arccos(x)/2", "[0, 1/2*pi]"},
+		{"arctan(x^2)", "[0, 1/2*pi)"},
+		{"tanh(x)", "(-1, 1)"},
+		{"cosh(x)", "[1, inf)"},
+		{"x/x", "[1, 1]"},
+		{"2x/x", "[2, 2]"},
+		{"\\arcsin(x)+1", "[1+-1/2*pi, 1+1/2*pi]"},
+		{"2\\arctan(x)-3", "(-3+-1*pi, -3+pi)"},
+		{"\\log_2(x+1)", "(-inf, inf)"},
+		{"\\log_2(x^2+4)", "[2, inf)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			expr, err := parser.Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+
+			result, err := Range(expr, "x")
+			if err != nil {
+				t.Fatalf("Range error: %v", err)
+			}
+			if got := result.String(); got != tt.expected {
+				t.Errorf("Range(%s) = %s, want %s", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRangeErrors(t *testing.T) {
+	tests := []string{
+		"x/(x + 1)",
+		"x^3 - x",
+		"sin(arcsin(x))",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			expr, err := parser.Parse(input)
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+			if _, err := Range(expr, "x"); err == nil {
+				t.Errorf("Range(%s) should fail", input)
+			}
+		})
+	}
+}
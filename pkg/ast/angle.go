@@ -0,0 +1,164 @@
+package ast
+
+import (
+	"math/big"
+	"strings"
+)
+
+// Angle represents an angle written in degrees, and optionally minutes and
+// seconds, as in 45° or 12°30'. It keeps its notation for display and
+// evaluates in radians, so sin(30°) is 1/2.
+type Angle struct {
+	degrees Expr
+	// minutes and seconds are nil when not written
+	minutes Expr
+	seconds Expr
+	source
+}
+
+// NewAngle creates an angle of the given number of degrees
+func NewAngle(degrees Expr) *Angle {
+	return &Angle{degrees: degrees}
+}
+
+// NewAngleDMS creates an angle written in degrees, minutes and seconds.
+// Minutes or seconds that were not written are nil.
+func NewAngleDMS(degrees, minutes, seconds Expr) *Angle {
+	return &Angle{degrees: degrees, minutes: minutes, seconds: seconds}
+}
+
+func (a *Angle) String() string {
+	var sb strings.Builder
+	degrees := a.degrees.String()
+	switch a.degrees.Type() {
+	case TypeAdd, TypeMul, TypePow, TypePlusMinus:
+		degrees = "(" + degrees + ")"
+	}
+	sb.WriteString(degrees + "°")
+	if a.minutes != nil {
+		sb.WriteString(a.minutes.String() + "'")
+	}
+	if a.seconds != nil {
+		sb.WriteString(a.seconds.String() + "''")
+	}
+	return sb.String()
+}
+
+func (a *Angle) LaTeX() string {
+	var sb strings.Builder
+	degrees := a.degrees.LaTeX()
+	switch a.degrees.Type() {
+	case TypeAdd, TypeMul, TypePow, TypePlusMinus:
+		degrees = "(" + degrees + ")"
+	}
+	sb.WriteString(degrees + "^{\\circ}")
+	if a.minutes != nil {
+		sb.WriteString(a.minutes.LaTeX() + "'")
+	}
+	if a.seconds != nil {
+		sb.WriteString(a.seconds.LaTeX() + "''")
+	}
+	return sb.String()
+}
+
+// Eval returns the angle in radians
+func (a *Angle) Eval(vars map[string]*big.Float) (*big.Float, error) {
+	return a.Radians().Eval(vars)
+}
+
+func (a *Angle) Simplify() Expr {
+	return &Angle{degrees: a.degrees.Simplify(), minutes: simplifyPart(a.minutes), seconds: simplifyPart(a.seconds)}
+}
+
+func (a *Angle) Equal(other Expr) bool {
+	if other.Type() != TypeAngle {
+		return false
+	}
+	otherAngle := other.(*Angle)
+	return a.degrees.Equal(otherAngle.degrees) &&
+		equalPart(a.minutes, otherAngle.minutes) && equalPart(a.seconds, otherAngle.seconds)
+}
+
+func (a *Angle) Clone() Expr {
+	return &Angle{degrees: a.degrees.Clone(), minutes: a.Minutes(), seconds: a.Seconds(), source: a.source}
+}
+
+func (a *Angle) Variables() []string {
+	vars := a.degrees.Variables()
+	for _, part := range []Expr{a.minutes, a.seconds} {
+		if part != nil {
+			vars = append(vars, part.Variables()...)
+		}
+	}
+	return removeDuplicates(vars)
+}
+
+func (a *Angle) Type() ExprType {
+	return TypeAngle
+}
+
+// Degrees returns a copy of the whole degrees as written
+func (a *Angle) Degrees() Expr {
+	return a.degrees.Clone()
+}
+
+// Minutes returns a copy of the minutes, or nil if none were written
+func (a *Angle) Minutes() Expr {
+	if a.minutes == nil {
+		return nil
+	}
+	return a.minutes.Clone()
+}
+
+// Seconds returns a copy of the seconds, or nil if none were written
+func (a *Angle) Seconds() Expr {
+	if a.seconds == nil {
+		return nil
+	}
+	return a.seconds.Clone()
+}
+
+// TotalDegrees returns the angle in degrees, counting a minute as 1/60 and
+// a second as 1/3600 of a degree
+func (a *Angle) TotalDegrees() Expr {
+	if a.minutes == nil && a.seconds == nil {
+		return a.degrees.Clone()
+	}
+	terms := []Expr{a.degrees.Clone()}
+	if a.minutes != nil {
+		terms = append(terms, NewMul(a.minutes.Clone(), NewRational(1, 60)))
+	}
+	if a.seconds != nil {
+		terms = append(terms, NewMul(a.seconds.Clone(), NewRational(1, 3600)))
+	}
+	return NewAdd(terms...)
+}
+
+// Radians returns the angle converted to radians, as degrees times pi/180
+func (a *Angle) Radians() Expr {
+	return NewMul(a.TotalDegrees(), Pi.Clone(), NewRational(1, 180))
+}
+
+// simplifyPart simplifies an optional part of an expression
+func simplifyPart(part Expr) Expr {
+	if part == nil {
+		return nil
+	}
+	return part.Simplify()
+}
+
+// equalPart compares optional parts of two expressions
+func equalPart(a, b Expr) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Equal(b)
+}
+
+// clonePart copies an optional part of an expression
+func clonePart(part Expr) Expr {
+	if part == nil {
+		return nil
+	}
+	return part.Clone()
+}
@@ -0,0 +1,44 @@
+package ast
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAngle(t *testing.T) {
+	angle := NewAngleDMS(NewInt(12), NewInt(30), nil)
+
+	if got, want := angle.String(), "12°30'"; got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+	if got, want := angle.LaTeX(), "12^{\\circ}30'"; got != want {
+		t.Errorf("LaTeX() = %s, want %s", got, want)
+	}
+	if !angle.Equal(angle.Clone()) {
+		t.Errorf("Clone() is not equal to the original")
+	}
+	if angle.Equal(NewAngle(NewRational(25, 2))) {
+		t.Errorf("angles written differently should not be structurally equal")
+	}
+
+	value, err := angle.Eval(nil)
+	if err != nil {
+		t.Fatalf("Eval() error: %v", err)
+	}
+	if got, _ := value.Float64(); math.Abs(got-12.5*math.Pi/180) > 1e-12 {
+		t.Errorf("Eval() = %v, want 12.5° in radians", got)
+	}
+
+	sine, err := NewFunc("sin", NewAngle(NewInt(30))).Eval(nil)
+	if err != nil {
+		t.Fatalf("Eval() error: %v", err)
+	}
+	if got, _ := sine.Float64(); math.Abs(got-0.5) > 1e-12 {
+		t.Errorf("sin(30°) = %v, want 0.5", got)
+	}
+
+	substituted := Substitute(NewAngle(NewVar("x")), "x", NewInt(45))
+	if got, want := substituted.String(), "45°"; got != want {
+		t.Errorf("Substitute() = %s, want %s", got, want)
+	}
+}
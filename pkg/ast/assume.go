@@ -0,0 +1,79 @@
+package ast
+
+import "math"
+
+// Property is something known about the values a variable takes
+type Property uint8
+
+const (
+	// Real variables take only real values
+	Real Property = 1 << iota
+	// Positive variables are real and greater than zero
+	Positive
+	// Integer variables are whole numbers
+	Integer
+	// Nonzero variables are never zero
+	Nonzero
+)
+
+// Assumptions records the properties assumed of each variable, as built by
+// Assume("x", Positive, Integer). A variable with no entry, like every
+// variable of the nil Assumptions, may take any value.
+type Assumptions map[string]Property
+
+// Assume returns assumptions that the named variable has the given
+// properties
+func Assume(name string, properties ...Property) Assumptions {
+	return Assumptions(nil).Assume(name, properties...)
+}
+
+// Assume returns a copy of a that also assumes the named variable has the
+// given properties, so assumptions on several variables can be chained:
+// Assume("x", Positive).Assume("n", Integer)
+func (a Assumptions) Assume(name string, properties ...Property) Assumptions {
+	result := make(Assumptions, len(a)+1)
+	for variable, known := range a {
+		result[variable] = known
+	}
+	for _, property := range properties {
+		result[name] |= property
+		if property&Positive != 0 {
+			result[name] |= Real | Nonzero
+		}
+		if property&Integer != 0 {
+			result[name] |= Real
+		}
+	}
+	return result
+}
+
+// Has reports whether the named variable is assumed to have property, or
+// one that implies it: positive variables are real and nonzero, and
+// integer ones real
+func (a Assumptions) Has(name string, property Property) bool {
+	return a[name]&property == property
+}
+
+// Allows reports whether value is consistent with the assumptions on the
+// named variable
+func (a Assumptions) Allows(name string, value float64) bool {
+	switch {
+	case a.Has(name, Positive) && value <= 0:
+		return false
+	case a.Has(name, Nonzero) && value == 0:
+		return false
+	case a.Has(name, Integer) && math.Abs(value-math.Round(value)) > 1e-9:
+		return false
+	}
+	return true
+}
+
+// AllReal reports whether every variable of expr is assumed real
+func (a Assumptions) AllReal(expr Expr) bool {
+	for _, name := range expr.Variables() {
+		if !a.Has(name, Real) {
+			return false
+		}
+	}
+	return true
+}
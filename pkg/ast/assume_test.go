@@ -0,0 +1,59 @@
+package ast
+
+import "testing"
+
+func TestAssume(t *testing.T) {
+	base := Assume("x", Positive)
+	assumptions := base.Assume("n", Integer)
+
+	tests := []struct {
+		name     string
+		property Property
+		want     bool
+	}{
+		{"x", Positive, true},
+		{"x", Real, true},
+		{"x", Nonzero, true},
+		{"x", Integer, false},
+		{"n", Integer, true},
+		{"n", Real, true},
+		{"n", Nonzero, false},
+		{"y", Real, false},
+	}
+	for _, tt := range tests {
+		if got := assumptions.Has(tt.name, tt.property); got != tt.want {
+			t.Errorf("Has(%s, %d) = %v, want %v", tt.name, tt.property, got, tt.want)
+		}
+	}
+	if base.Has("n", Integer) {
+		t.Errorf("Assume() should not change the assumptions it extends")
+	}
+
+	allows := []struct {
+		name  string
+		value float64
+		want  bool
+	}{
+		{"x", 2.5, true},
+		{"x", 0, false},
+		{"x", -1, false},
+		{"n", -3, true},
+		{"n", 0.5, false},
+		{"y", -0.5, true},
+	}
+	for _, tt := range allows {
+		if got := assumptions.Allows(tt.name, tt.value); got != tt.want {
+			t.Errorf("Allows(%s, %v) = %v, want %v", tt.name, tt.value, got, tt.want)
+		}
+	}
+
+	if !assumptions.AllReal(NewMul(NewVar("x"), NewVar("n"))) {
+		t.Errorf("AllReal(x*n) = false, want true")
+	}
+	if assumptions.AllReal(NewAdd(NewVar("x"), NewVar("y"))) {
+		t.Errorf("AllReal(x+y) = true, want false")
+	}
+	if !Assumptions(nil).AllReal(NewInt(4)) {
+		t.Errorf("AllReal(4) = false, want true")
+	}
+}
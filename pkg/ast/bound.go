@@ -0,0 +1,145 @@
+package ast
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// Sums and integrals are functions that bind a variable in their first
+// argument:
+//
+//	sum(body, i, lower, upper)   the sum of body for i = lower, ..., upper
+//	int(body, x)                 the antiderivative of body in x
+//	int(body, x, lower, upper)   the definite integral of body from lower to upper
+
+// maxSumTerms bounds the number of terms a sum is evaluated with
+const maxSumTerms = 100000
+
+// simpsonIntervals is the number of subintervals a definite integral is
+// evaluated with
+const simpsonIntervals = 256
+
+// NewSum creates the sum of body for variable running from lower to upper
+func NewSum(body Expr, variable string, lower, upper Expr) *Func {
+	return NewFunc("sum", body, NewVar(variable), lower, upper)
+}
+
+// NewIntegral creates the integral of body with respect to variable. With
+// no limits the integral is indefinite; otherwise limits are the lower and
+// upper limit.
+func NewIntegral(body Expr, variable string, limits ...Expr) *Func {
+	return NewFunc("int", append([]Expr{body, NewVar(variable)}, limits...)...)
+}
+
+// BoundVariable returns the variable a sum or integral binds in its body
+func (f *Func) BoundVariable() (string, bool) {
+	if (f.name != "sum" && f.name != "int") || len(f.args) < 2 {
+		return "", false
+	}
+	v, ok := f.args[1].(*Var)
+	if !ok {
+		return "", false
+	}
+	return v.name, true
+}
+
+// boundVariables returns the free variables of a sum or integral: those of
+// its body other than the bound variable, and those of its limits
+func (f *Func) boundVariables(bound string) []string {
+	vars := []string{}
+	for _, name := range f.args[0].Variables() {
+		if name != bound {
+			vars = append(vars, name)
+		}
+	}
+	for _, limit := range f.args[2:] {
+		vars = append(vars, limit.Variables()...)
+	}
+	return removeDuplicates(vars)
+}
+
+// evalBound evaluates a sum or a definite integral
+func (f *Func) evalBound(bound string, vars map[string]*big.Float) (*big.Float, error) {
+	if len(f.args) != 4 {
+		if f.name == "int" && len(f.args) == 2 {
+			return nil, fmt.Errorf("cannot evaluate an indefinite integral")
+		}
+		return nil, fmt.Errorf("%s expects 2 or 4 arguments, got %d", f.name, len(f.args))
+	}
+
+	lower, err := f.args[2].Eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	upper, err := f.args[3].Eval(vars)
+	if err != nil {
+		return nil, err
+	}
+
+	// The body sees the caller's variables with the bound one added
+	scope := make(map[string]*big.Float, len(vars)+1)
+	for name, value := range vars {
+		scope[name] = value
+	}
+	body := func(at *big.Float) (*big.Float, error) {
+		scope[bound] = at
+		return f.args[0].Eval(scope)
+	}
+
+	if f.name == "sum" {
+		return evaluateSum(body, lower, upper)
+	}
+	return evaluateIntegral(body, lower, upper)
+}
+
+// evaluateSum adds body(k) for the integers k from lower to upper
+func evaluateSum(body func(*big.Float) (*big.Float, error), lower, upper *big.Float) (*big.Float, error) {
+	if !lower.IsInt() || !upper.IsInt() {
+		return nil, fmt.Errorf("sum: limits must be integers")
+	}
+	from, _ := lower.Int64()
+	to, _ := upper.Int64()
+	if to-from >= maxSumTerms {
+		return nil, fmt.Errorf("sum: too many terms (%d)", to-from+1)
+	}
+
+	total := new(big.Float)
+	for k := from; k <= to; k++ {
+		term, err := body(new(big.Float).SetInt64(k))
+		if err != nil {
+			return nil, err
+		}
+		total.Add(total, term)
+	}
+	return total, nil
+}
+
+// evaluateIntegral approximates the integral of body from lower to upper
+// with Simpson's rule
+func evaluateIntegral(body func(*big.Float) (*big.Float, error), lower, upper *big.Float) (*big.Float, error) {
+	n := big.NewFloat(simpsonIntervals)
+	h := new(big.Float).Sub(upper, lower)
+	h.Quo(h, n)
+
+	total := new(big.Float)
+	for i := 0; i <= simpsonIntervals; i++ {
+		at := new(big.Float).Mul(h, big.NewFloat(float64(i)))
+		at.Add(at, lower)
+		value, err := body(at)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case i == 0 || i == simpsonIntervals:
+		case i%2 == 1:
+			value.Mul(value, big.NewFloat(4))
+		default:
+			value.Mul(value, big.NewFloat(2))
+		}
+		total.Add(total, value)
+	}
+
+	total.Mul(total, h)
+	return total.Quo(total, big.NewFloat(3)), nil
+}
@@ -0,0 +1,122 @@
+package ast
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Chain represents a chained relation such as 1 < x <= 5, read as the
+// conjunction of its adjacent comparisons
+type Chain struct {
+	operands  []Expr
+	relations []EqType
+	source
+}
+
+// NewChain creates a chained relation. There must be exactly one relation
+// between each pair of adjacent operands, and the order relations must all
+// point the same way: 1 < x > 2 says nothing about how 1 and 2 compare.
+func NewChain(operands []Expr, relations []EqType) (*Chain, error) {
+	if len(operands) < 2 || len(relations) != len(operands)-1 {
+		return nil, fmt.Errorf("chain of %d operands needs %d relations, got %d", len(operands), len(operands)-1, len(relations))
+	}
+	var less, greater bool
+	for _, rel := range relations {
+		less = less || rel == EqLess || rel == EqLessEqual
+		greater = greater || rel == EqGreater || rel == EqGreaterEqual
+	}
+	if less && greater {
+		return nil, fmt.Errorf("chain mixes < and > relations")
+	}
+	return &Chain{operands: operands, relations: relations}, nil
+}
+
+func (c *Chain) String() string {
+	var sb strings.Builder
+	sb.WriteString(c.operands[0].String())
+	for i, rel := range c.relations {
+		sb.WriteString(rel.String())
+		sb.WriteString(c.operands[i+1].String())
+	}
+	return sb.String()
+}
+
+func (c *Chain) LaTeX() string {
+	parts := []string{c.operands[0].LaTeX()}
+	for i, rel := range c.relations {
+		parts = append(parts, rel.latex(), c.operands[i+1].LaTeX())
+	}
+	return strings.Join(parts, " ")
+}
+
+// Eval returns 1 when every link of the chain holds and 0 otherwise
+func (c *Chain) Eval(vars map[string]*big.Float) (*big.Float, error) {
+	for _, link := range c.Links() {
+		holds, err := link.Eval(vars)
+		if err != nil {
+			return nil, err
+		}
+		if holds.Sign() == 0 {
+			return big.NewFloat(0), nil
+		}
+	}
+	return big.NewFloat(1), nil
+}
+
+func (c *Chain) Simplify() Expr {
+	return &Chain{operands: mapElements(c.operands, func(e Expr) Expr { return e.Simplify() }), relations: c.Relations()}
+}
+
+func (c *Chain) Equal(other Expr) bool {
+	if other.Type() != TypeChain {
+		return false
+	}
+	otherChain := other.(*Chain)
+	if len(c.operands) != len(otherChain.operands) {
+		return false
+	}
+	for i, rel := range c.relations {
+		if rel != otherChain.relations[i] {
+			return false
+		}
+	}
+	for i, operand := range c.operands {
+		if !operand.Equal(otherChain.operands[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Chain) Clone() Expr {
+	return &Chain{operands: c.Operands(), relations: c.Relations(), source: c.source}
+}
+
+func (c *Chain) Variables() []string {
+	return elementVariables(c.operands)
+}
+
+func (c *Chain) Type() ExprType {
+	return TypeChain
+}
+
+// Operands returns a copy of the compared expressions, left to right
+func (c *Chain) Operands() []Expr {
+	return mapElements(c.operands, func(e Expr) Expr { return e.Clone() })
+}
+
+// Relations returns a copy of the relations between adjacent operands
+func (c *Chain) Relations() []EqType {
+	return append([]EqType(nil), c.relations...)
+}
+
+// Links returns the chain as separate comparisons, so -2 < x <= 7 gives
+// -2 < x and x <= 7
+func (c *Chain) Links() []*Eq {
+	links := make([]*Eq, len(c.relations))
+	for i, rel := range c.relations {
+		links[i] = NewEq(c.operands[i].Clone(), c.operands[i+1].Clone(), rel)
+	}
+	return links
+}
@@ -0,0 +1,56 @@
+package ast
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestChain(t *testing.T) {
+	// -2 < 3x + 1 <= 7
+	middle := NewAdd(NewMul(NewInt(3), NewVar("x")), NewInt(1))
+	chain, err := NewChain([]Expr{NewInt(-2), middle, NewInt(7)}, []EqType{EqLess, EqLessEqual})
+	if err != nil {
+		t.Fatalf("NewChain error: %v", err)
+	}
+
+	if got, want := chain.String(), "-2<3*x+1<=7"; got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+	if got, want := chain.LaTeX(), "-2 < 3 \\cdot x+1 \\le 7"; got != want {
+		t.Errorf("LaTeX() = %s, want %s", got, want)
+	}
+	if !chain.Equal(chain.Clone()) {
+		t.Errorf("Clone() is not equal to the original")
+	}
+
+	links := chain.Links()
+	if len(links) != 2 || links[0].String() != "-2<3*x+1" || links[1].String() != "3*x+1<=7" {
+		t.Errorf("Links() = %v, want [-2<3*x+1 3*x+1<=7]", links)
+	}
+
+	tests := []struct {
+		x        float64
+		expected int64
+	}{
+		{0, 1},
+		{2, 1},
+		{-1, 0},
+		{3, 0},
+	}
+	for _, tt := range tests {
+		result, err := chain.Eval(map[string]*big.Float{"x": big.NewFloat(tt.x)})
+		if err != nil {
+			t.Fatalf("Eval error: %v", err)
+		}
+		if got, _ := result.Int64(); got != tt.expected {
+			t.Errorf("Eval(x=%v) = %d, want %d", tt.x, got, tt.expected)
+		}
+	}
+
+	if _, err := NewChain([]Expr{NewInt(1), NewVar("x")}, []EqType{EqLess, EqLess}); err == nil {
+		t.Errorf("NewChain should reject mismatched relations")
+	}
+	if _, err := NewChain([]Expr{NewInt(1), NewVar("x"), NewInt(2)}, []EqType{EqLess, EqGreater}); err == nil {
+		t.Errorf("NewChain should reject relations pointing opposite ways")
+	}
+}
@@ -0,0 +1,89 @@
+package ast
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Derivative represents an unevaluated derivative such as y' or dy/dx.
+// It appears in differential equations, where the derivative of an unknown
+// function cannot be computed symbolically.
+type Derivative struct {
+	expr     Expr
+	variable string
+	order    int
+	source
+}
+
+// NewDerivative creates the order-th derivative of expr with respect to variable
+func NewDerivative(expr Expr, variable string, order int) *Derivative {
+	return &Derivative{expr: expr, variable: variable, order: order}
+}
+
+func (d *Derivative) String() string {
+	if _, ok := d.expr.(*Var); ok {
+		return d.expr.String() + strings.Repeat("'", d.order)
+	}
+	if d.order == 1 {
+		return fmt.Sprintf("d/d%s(%s)", d.variable, d.expr.String())
+	}
+	return fmt.Sprintf("d^%d/d%s^%d(%s)", d.order, d.variable, d.order, d.expr.String())
+}
+
+func (d *Derivative) LaTeX() string {
+	if _, ok := d.expr.(*Var); ok {
+		return d.expr.LaTeX() + strings.Repeat("'", d.order)
+	}
+	if d.order == 1 {
+		return fmt.Sprintf("\\frac{d}{d%s}\\left(%s\\right)", d.variable, d.expr.LaTeX())
+	}
+	return fmt.Sprintf("\\frac{d^{%d}}{d%s^{%d}}\\left(%s\\right)", d.order, d.variable, d.order, d.expr.LaTeX())
+}
+
+func (d *Derivative) Eval(vars map[string]*big.Float) (*big.Float, error) {
+	// A derivative value can be supplied directly, e.g. vars["y'"]
+	if val, ok := vars[d.String()]; ok {
+		return new(big.Float).Copy(val), nil
+	}
+	return nil, fmt.Errorf("cannot evaluate unknown derivative %s", d.String())
+}
+
+func (d *Derivative) Simplify() Expr {
+	return &Derivative{expr: d.expr.Simplify(), variable: d.variable, order: d.order}
+}
+
+func (d *Derivative) Equal(other Expr) bool {
+	if other.Type() != TypeDerivative {
+		return false
+	}
+	otherDeriv := other.(*Derivative)
+	return d.variable == otherDeriv.variable && d.order == otherDeriv.order && d.expr.Equal(otherDeriv.expr)
+}
+
+func (d *Derivative) Clone() Expr {
+	return &Derivative{expr: d.expr.Clone(), variable: d.variable, order: d.order, source: d.source}
+}
+
+func (d *Derivative) Variables() []string {
+	return removeDuplicates(append(d.expr.Variables(), d.variable))
+}
+
+func (d *Derivative) Type() ExprType {
+	return TypeDerivative
+}
+
+// Operand returns the expression being differentiated
+func (d *Derivative) Operand() Expr {
+	return d.expr.Clone()
+}
+
+// Variable returns the variable of differentiation
+func (d *Derivative) Variable() string {
+	return d.variable
+}
+
+// Order returns the order of the derivative
+func (d *Derivative) Order() int {
+	return d.order
+}
@@ -0,0 +1,71 @@
+package ast
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestDerivative(t *testing.T) {
+	tests := []struct {
+		name          string
+		expr          *Derivative
+		expectedStr   string
+		expectedLaTeX string
+	}{
+		{"prime", NewDerivative(NewVar("y"), "x", 1), "y'", "y'"},
+		{"double prime", NewDerivative(NewVar("y"), "x", 2), "y''", "y''"},
+		{"leibniz", NewDerivative(NewFunc("f", NewVar("t")), "t", 1), "d/dt(f(t))", "\\frac{d}{dt}\\left(\\mathrm{f}(t)\\right)"},
+		{"higher order", NewDerivative(NewFunc("f", NewVar("t")), "t", 3), "d^3/dt^3(f(t))", "\\frac{d^{3}}{dt^{3}}\\left(\\mathrm{f}(t)\\right)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.expr.String(); got != tt.expectedStr {
+				t.Errorf("String() = %s, want %s", got, tt.expectedStr)
+			}
+			if got := tt.expr.LaTeX(); got != tt.expectedLaTeX {
+				t.Errorf("LaTeX() = %s, want %s", got, tt.expectedLaTeX)
+			}
+			if !tt.expr.Equal(tt.expr.Clone()) {
+				t.Errorf("Clone() is not equal to the original")
+			}
+		})
+	}
+}
+
+func TestDerivativeEqual(t *testing.T) {
+	d := NewDerivative(NewVar("y"), "x", 1)
+
+	if d.Equal(NewDerivative(NewVar("y"), "t", 1)) {
+		t.Errorf("derivatives with different variables should differ")
+	}
+	if d.Equal(NewDerivative(NewVar("y"), "x", 2)) {
+		t.Errorf("derivatives of different order should differ")
+	}
+	if d.Equal(NewVar("y")) {
+		t.Errorf("a derivative should not equal its operand")
+	}
+}
+
+func TestDerivativeEval(t *testing.T) {
+	d := NewDerivative(NewVar("y"), "x", 1)
+
+	if _, err := d.Eval(map[string]*big.Float{"y": big.NewFloat(2)}); err == nil {
+		t.Errorf("expected an error when y' has no value")
+	}
+
+	result, err := d.Eval(map[string]*big.Float{"y'": big.NewFloat(3)})
+	if err != nil {
+		t.Fatalf("Eval returned error: %v", err)
+	}
+	if result.Cmp(big.NewFloat(3)) != 0 {
+		t.Errorf("Eval() = %s, want 3", result.String())
+	}
+}
+
+func TestDerivativeVariables(t *testing.T) {
+	vars := NewDerivative(NewVar("y"), "x", 1).Variables()
+	if len(vars) != 2 || vars[0] != "y" || vars[1] != "x" {
+		t.Errorf("Variables() = %v, want [y x]", vars)
+	}
+}
@@ -0,0 +1,144 @@
+package ast
+
+import (
+	"math"
+	"math/big"
+)
+
+// maxExactExponent bounds the integer powers ExactValue multiplies out
+const maxExactExponent = 1 << 10
+
+// ExactValue converts an expression built from integers, rationals and
+// decimals by sums, products and integer powers to an exact rational. A
+// decimal is read by its shortest spelling, so 0.1 becomes 1/10.
+func ExactValue(expr Expr) (*big.Rat, bool) {
+	switch e := expr.(type) {
+	case *Int:
+		return new(big.Rat).SetInt(e.value), true
+	case *Rational:
+		return new(big.Rat).SetFrac(e.numerator, e.denominator), true
+	case *Float:
+		return new(big.Rat).SetString(e.String())
+	case *Add:
+		sum := new(big.Rat)
+		for _, term := range e.terms {
+			r, ok := ExactValue(term)
+			if !ok {
+				return nil, false
+			}
+			sum.Add(sum, r)
+		}
+		return sum, true
+	case *Mul:
+		product := big.NewRat(1, 1)
+		for _, factor := range e.factors {
+			r, ok := ExactValue(factor)
+			if !ok {
+				return nil, false
+			}
+			product.Mul(product, r)
+		}
+		return product, true
+	case *Pow:
+		base, ok := ExactValue(e.base)
+		exponent, isInt := e.exponent.(*Int)
+		if !ok || !isInt || !exponent.value.IsInt64() {
+			return nil, false
+		}
+		n := exponent.value.Int64()
+		if n < 0 {
+			if base.Sign() == 0 {
+				return nil, false
+			}
+			base.Inv(base)
+			n = -n
+		}
+		if n > maxExactExponent {
+			return nil, false
+		}
+		return new(big.Rat).SetFrac(
+			new(big.Int).Exp(base.Num(), big.NewInt(n), nil),
+			new(big.Int).Exp(base.Denom(), big.NewInt(n), nil),
+		), true
+	}
+	return nil, false
+}
+
+// NewNumber returns r as an Int when it is whole and as a Rational otherwise
+func NewNumber(r *big.Rat) Expr {
+	if r.IsInt() {
+		return &Int{value: new(big.Int).Set(r.Num())}
+	}
+	return NewRationalFromInts(r.Num(), r.Denom())
+}
+
+// PowerOfTen returns 10^n exactly
+func PowerOfTen(n int) *big.Rat {
+	if n < 0 {
+		return new(big.Rat).Inv(PowerOfTen(-n))
+	}
+	return new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil))
+}
+
+// IntegerLog returns k when x = b^k for an integer b > 1, with x an
+// integer or the reciprocal of one, written as 1/8 or 8^-1
+func IntegerLog(x, b Expr) (int64, bool) {
+	bInt, ok := b.(*Int)
+	if !ok || bInt.value.Cmp(big.NewInt(1)) <= 0 {
+		return 0, false
+	}
+
+	var target *big.Int
+	sign := int64(1)
+	switch v := x.(type) {
+	case *Int:
+		target = v.value
+	case *Rational:
+		if v.numerator.Cmp(big.NewInt(1)) != 0 {
+			return 0, false
+		}
+		target, sign = v.denominator, -1
+	case *Pow:
+		base, ok := v.base.(*Int)
+		if !ok || !IsMinusOne(v.exponent) {
+			return 0, false
+		}
+		target, sign = base.value, -1
+	default:
+		return 0, false
+	}
+	if target.Sign() <= 0 {
+		return 0, false
+	}
+
+	power := big.NewInt(1)
+	for k := int64(0); power.Cmp(target) <= 0; k++ {
+		if power.Cmp(target) == 0 {
+			return sign * k, true
+		}
+		power.Mul(power, bInt.value)
+	}
+	return 0, false
+}
+
+// ConstantValue evaluates an expression without variables to a finite float
+func ConstantValue(expr Expr) (float64, bool) {
+	if len(expr.Variables()) > 0 {
+		return 0, false
+	}
+	value, err := expr.Eval(map[string]*big.Float{})
+	if err != nil {
+		return 0, false
+	}
+	f, _ := value.Float64()
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, false
+	}
+	return f, true
+}
+
+// IsMinusOne reports whether expr is the integer -1
+func IsMinusOne(expr Expr) bool {
+	i, ok := expr.(*Int)
+	return ok && i.value.Cmp(big.NewInt(-1)) == 0
+}
@@ -0,0 +1,82 @@
+package ast
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestExactValue(t *testing.T) {
+	tests := []struct {
+		name string
+		expr Expr
+		want string
+		ok   bool
+	}{
+		{"integer", NewInt(3), "3/1", true},
+		{"decimal", NewFloat(0.1), "1/10", true},
+		{"sum", NewAdd(NewInt(1), NewRational(1, 2)), "3/2", true},
+		{"reciprocal", NewMul(NewInt(3), NewPow(NewInt(4), NewInt(-1))), "3/4", true},
+		{"power", NewPow(NewRational(2, 3), NewInt(2)), "4/9", true},
+		{"division by zero", NewPow(NewInt(0), NewInt(-1)), "", false},
+		{"huge power", NewPow(NewInt(2), NewInt(1<<20)), "", false},
+		{"root", NewFunc("sqrt", NewInt(2)), "", false},
+		{"variable", NewVar("x"), "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ExactValue(tt.expr)
+			if ok != tt.ok {
+				t.Fatalf("ExactValue(%s) ok = %v, want %v", tt.expr, ok, tt.ok)
+			}
+			if ok && got.String() != tt.want {
+				t.Errorf("ExactValue(%s) = %s, want %s", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewNumber(t *testing.T) {
+	if got := NewNumber(big.NewRat(6, 2)); got.String() != "3" || got.Type() != TypeInt {
+		t.Errorf("NewNumber(6/2) = %s, want the integer 3", got)
+	}
+	if got := NewNumber(big.NewRat(-2, 4)); !got.Equal(NewRational(-1, 2)) {
+		t.Errorf("NewNumber(-2/4) = %s, want -1/2", got)
+	}
+	if got := PowerOfTen(-2); got.Cmp(big.NewRat(1, 100)) != 0 {
+		t.Errorf("PowerOfTen(-2) = %s, want 1/100", got)
+	}
+}
+
+func TestIntegerLog(t *testing.T) {
+	tests := []struct {
+		x, b Expr
+		want int64
+		ok   bool
+	}{
+		{NewInt(8), NewInt(2), 3, true},
+		{NewInt(1), NewInt(10), 0, true},
+		{NewRational(1, 8), NewInt(2), -3, true},
+		{NewPow(NewInt(8), NewInt(-1)), NewInt(2), -3, true},
+		{NewInt(6), NewInt(2), 0, false},
+		{NewInt(8), NewInt(1), 0, false},
+		{NewInt(-8), NewInt(2), 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := IntegerLog(tt.x, tt.b)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("IntegerLog(%s, %s) = %d, %v, want %d, %v", tt.x, tt.b, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestConstantValue(t *testing.T) {
+	if got, ok := ConstantValue(NewMul(NewInt(2), NewRational(1, 4))); !ok || got != 0.5 {
+		t.Errorf("ConstantValue(2*1/4) = %v, %v, want 0.5", got, ok)
+	}
+	if _, ok := ConstantValue(NewAdd(NewVar("x"), NewInt(1))); ok {
+		t.Errorf("ConstantValue(x+1) should fail")
+	}
+	if _, ok := ConstantValue(NewPow(NewInt(0), NewInt(-1))); ok {
+		t.Errorf("ConstantValue(0^-1) should fail")
+	}
+}
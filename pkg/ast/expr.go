@@ -50,6 +50,17 @@ const (
 	TypeLog
 	TypeAbs
 	TypeEq
+	TypeDerivative
+	TypeMatrix
+	TypeVector
+	TypeTuple
+	TypeSet
+	TypeChain
+	TypePlusMinus
+	TypeAngle
+	TypeUnit
+	TypeQuantity
+	TypePiecewise
 )
 
 // String returns the string representation of the expression type
@@ -81,6 +92,28 @@ func (t ExprType) String() string {
 		return "Abs"
 	case TypeEq:
 		return "Eq"
+	case TypeDerivative:
+		return "Derivative"
+	case TypeMatrix:
+		return "Matrix"
+	case TypeVector:
+		return "Vector"
+	case TypeTuple:
+		return "Tuple"
+	case TypeSet:
+		return "Set"
+	case TypeChain:
+		return "Chain"
+	case TypePlusMinus:
+		return "PlusMinus"
+	case TypeAngle:
+		return "Angle"
+	case TypeUnit:
+		return "Unit"
+	case TypeQuantity:
+		return "Quantity"
+	case TypePiecewise:
+		return "Piecewise"
 	default:
 		return "Unknown"
 	}
@@ -0,0 +1,68 @@
+package ast
+
+// GreekLetter is a Greek letter a variable can be named after. Name is both
+// the variable name and the LaTeX command, as in \alpha, and Char is the
+// Unicode letter.
+type GreekLetter struct {
+	Name string
+	Char string
+}
+
+// GreekLetters is the Greek alphabet, lowercase then uppercase
+var GreekLetters = []GreekLetter{
+	{"alpha", "α"}, {"beta", "β"}, {"gamma", "γ"}, {"delta", "δ"},
+	{"epsilon", "ε"}, {"zeta", "ζ"}, {"eta", "η"}, {"theta", "θ"},
+	{"iota", "ι"}, {"kappa", "κ"}, {"lambda", "λ"}, {"mu", "μ"},
+	{"nu", "ν"}, {"xi", "ξ"}, {"omicron", "ο"}, {"pi", "π"},
+	{"rho", "ρ"}, {"sigma", "σ"}, {"tau", "τ"}, {"upsilon", "υ"},
+	{"phi", "φ"}, {"chi", "χ"}, {"psi", "ψ"}, {"omega", "ω"},
+
+	{"Alpha", "Α"}, {"Beta", "Β"}, {"Gamma", "Γ"}, {"Delta", "Δ"},
+	{"Epsilon", "Ε"}, {"Zeta", "Ζ"}, {"Eta", "Η"}, {"Theta", "Θ"},
+	{"Iota", "Ι"}, {"Kappa", "Κ"}, {"Lambda", "Λ"}, {"Mu", "Μ"},
+	{"Nu", "Ν"}, {"Xi", "Ξ"}, {"Omicron", "Ο"}, {"Pi", "Π"},
+	{"Rho", "Ρ"}, {"Sigma", "Σ"}, {"Tau", "Τ"}, {"Upsilon", "Υ"},
+	{"Phi", "Φ"}, {"Chi", "Χ"}, {"Psi", "Ψ"}, {"Omega", "Ω"},
+}
+
+// GreekVariants are the other forms of a letter, named by their LaTeX
+// command, and the letter each is read as
+var GreekVariants = []struct {
+	GreekLetter
+	Letter string
+}{
+	{GreekLetter{"varepsilon", "ϵ"}, "epsilon"},
+	{GreekLetter{"vartheta", "ϑ"}, "theta"},
+	{GreekLetter{"varkappa", "ϰ"}, "kappa"},
+	{GreekLetter{"varpi", "ϖ"}, "pi"},
+	{GreekLetter{"varrho", "ϱ"}, "rho"},
+	{GreekLetter{"varsigma", "ς"}, "sigma"},
+	{GreekLetter{"varphi", "ϕ"}, "phi"},
+}
+
+// GreekName returns the variable a Greek letter, given as its Unicode
+// character or its LaTeX command without the backslash, is read as
+func GreekName(letter string) (string, bool) {
+	for _, g := range GreekLetters {
+		if letter == g.Char || letter == g.Name {
+			return g.Name, true
+		}
+	}
+	for _, v := range GreekVariants {
+		if letter == v.Char || letter == v.Name {
+			return v.Letter, true
+		}
+	}
+	return "", false
+}
+
+// GreekChar returns the Unicode character of the Greek letter a variable
+// is named after
+func GreekChar(name string) (string, bool) {
+	for _, g := range GreekLetters {
+		if name == g.Name {
+			return g.Char, true
+		}
+	}
+	return "", false
+}
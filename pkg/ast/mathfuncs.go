@@ -138,4 +138,112 @@ func evaluateTanh(x *big.Float) (*big.Float, error) {
 	xFloat, _ := x.Float64()
 	result := math.Tanh(xFloat)
 	return big.NewFloat(result), nil
-}
\ No newline at end of file
+}
+
+// evaluateSech computes the hyperbolic secant function
+func evaluateSech(x *big.Float) (*big.Float, error) {
+	xFloat, _ := x.Float64()
+	return big.NewFloat(1 / math.Cosh(xFloat)), nil
+}
+
+// evaluateCsch computes the hyperbolic cosecant function
+func evaluateCsch(x *big.Float) (*big.Float, error) {
+	xFloat, _ := x.Float64()
+	if xFloat == 0 {
+		return nil, fmt.Errorf("csch: undefined at 0")
+	}
+	return big.NewFloat(1 / math.Sinh(xFloat)), nil
+}
+
+// evaluateCoth computes the hyperbolic cotangent function
+func evaluateCoth(x *big.Float) (*big.Float, error) {
+	xFloat, _ := x.Float64()
+	if xFloat == 0 {
+		return nil, fmt.Errorf("coth: undefined at 0")
+	}
+	return big.NewFloat(1 / math.Tanh(xFloat)), nil
+}
+
+// evaluateSec computes the secant function
+func evaluateSec(x *big.Float) (*big.Float, error) {
+	xFloat, _ := x.Float64()
+	cos := math.Cos(xFloat)
+	if cos == 0 {
+		return nil, fmt.Errorf("sec: undefined where cos is 0")
+	}
+	return big.NewFloat(1 / cos), nil
+}
+
+// evaluateCsc computes the cosecant function
+func evaluateCsc(x *big.Float) (*big.Float, error) {
+	xFloat, _ := x.Float64()
+	sin := math.Sin(xFloat)
+	if sin == 0 {
+		return nil, fmt.Errorf("csc: undefined where sin is 0")
+	}
+	return big.NewFloat(1 / sin), nil
+}
+
+// evaluateCot computes the cotangent function
+func evaluateCot(x *big.Float) (*big.Float, error) {
+	xFloat, _ := x.Float64()
+	sin := math.Sin(xFloat)
+	if sin == 0 {
+		return nil, fmt.Errorf("cot: undefined where sin is 0")
+	}
+	return big.NewFloat(math.Cos(xFloat) / sin), nil
+}
+
+// evaluateArcsec computes the arcsecant function
+func evaluateArcsec(x *big.Float) (*big.Float, error) {
+	xFloat, _ := x.Float64()
+	if xFloat > -1 && xFloat < 1 {
+		return nil, fmt.Errorf("arcsec: domain error (argument must satisfy |x| >= 1)")
+	}
+	result := math.Acos(1 / xFloat)
+	return big.NewFloat(result), nil
+}
+
+// evaluateArccsc computes the arccosecant function
+func evaluateArccsc(x *big.Float) (*big.Float, error) {
+	xFloat, _ := x.Float64()
+	if xFloat > -1 && xFloat < 1 {
+		return nil, fmt.Errorf("arccsc: domain error (argument must satisfy |x| >= 1)")
+	}
+	result := math.Asin(1 / xFloat)
+	return big.NewFloat(result), nil
+}
+
+// evaluateArccot computes the arccotangent function, with values in (0, π)
+func evaluateArccot(x *big.Float) (*big.Float, error) {
+	xFloat, _ := x.Float64()
+	result := math.Pi/2 - math.Atan(xFloat)
+	return big.NewFloat(result), nil
+}
+
+// evaluateArcsinh computes the inverse hyperbolic sine function
+func evaluateArcsinh(x *big.Float) (*big.Float, error) {
+	xFloat, _ := x.Float64()
+	result := math.Asinh(xFloat)
+	return big.NewFloat(result), nil
+}
+
+// evaluateArccosh computes the inverse hyperbolic cosine function
+func evaluateArccosh(x *big.Float) (*big.Float, error) {
+	xFloat, _ := x.Float64()
+	if xFloat < 1 {
+		return nil, fmt.Errorf("arccosh: domain error (argument must be >= 1)")
+	}
+	result := math.Acosh(xFloat)
+	return big.NewFloat(result), nil
+}
+
+// evaluateArctanh computes the inverse hyperbolic tangent function
+func evaluateArctanh(x *big.Float) (*big.Float, error) {
+	xFloat, _ := x.Float64()
+	if xFloat <= -1 || xFloat >= 1 {
+		return nil, fmt.Errorf("arctanh: domain error (argument must be in (-1, 1))")
+	}
+	result := math.Atanh(xFloat)
+	return big.NewFloat(result), nil
+}
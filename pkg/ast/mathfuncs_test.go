@@ -31,6 +31,16 @@ func TestTrigonometricFunctions(t *testing.T) {
 		{"arccos(0)", "arccos", 0, math.Pi / 2, 1e-15},
 		{"arctan(0)", "arctan", 0, 0, 1e-15},
 		{"arctan(1)", "arctan", 1, math.Pi / 4, 1e-15},
+
+		// Reciprocal trigonometric functions and their inverses
+		{"sec(0)", "sec", 0, 1, 1e-15},
+		{"sec(π/3)", "sec", math.Pi / 3, 2, 1e-14},
+		{"csc(π/2)", "csc", math.Pi / 2, 1, 1e-15},
+		{"cot(π/4)", "cot", math.Pi / 4, 1, 1e-15},
+		{"arcsec(2)", "arcsec", 2, math.Pi / 3, 1e-15},
+		{"arccsc(1)", "arccsc", 1, math.Pi / 2, 1e-15},
+		{"arccot(1)", "arccot", 1, math.Pi / 4, 1e-15},
+		{"arccot(-1)", "arccot", -1, 3 * math.Pi / 4, 1e-15},
 	}
 
 	for _, tt := range tests {
@@ -52,6 +62,18 @@ func TestTrigonometricFunctions(t *testing.T) {
 				result, err = evaluateArccos(input)
 			case "arctan":
 				result, err = evaluateArctan(input)
+			case "sec":
+				result, err = evaluateSec(input)
+			case "csc":
+				result, err = evaluateCsc(input)
+			case "cot":
+				result, err = evaluateCot(input)
+			case "arcsec":
+				result, err = evaluateArcsec(input)
+			case "arccsc":
+				result, err = evaluateArccsc(input)
+			case "arccot":
+				result, err = evaluateArccot(input)
 			default:
 				t.Fatalf("Unknown function: %s", tt.function)
 			}
@@ -84,6 +106,15 @@ func TestHyperbolicFunctions(t *testing.T) {
 		{"cosh(1)", "cosh", 1, math.Cosh(1), 1e-15},
 		{"tanh(0)", "tanh", 0, 0, 1e-15},
 		{"tanh(1)", "tanh", 1, math.Tanh(1), 1e-15},
+		{"arcsinh(0)", "arcsinh", 0, 0, 1e-15},
+		{"arcsinh(1)", "arcsinh", 1, math.Asinh(1), 1e-15},
+		{"arccosh(1)", "arccosh", 1, 0, 1e-15},
+		{"arccosh(2)", "arccosh", 2, math.Acosh(2), 1e-15},
+		{"arctanh(0.5)", "arctanh", 0.5, math.Atanh(0.5), 1e-15},
+		{"sech(0)", "sech", 0, 1, 1e-15},
+		{"sech(1)", "sech", 1, 1 / math.Cosh(1), 1e-15},
+		{"csch(1)", "csch", 1, 1 / math.Sinh(1), 1e-15},
+		{"coth(1)", "coth", 1, 1 / math.Tanh(1), 1e-15},
 	}
 
 	for _, tt := range tests {
@@ -99,6 +130,18 @@ func TestHyperbolicFunctions(t *testing.T) {
 				result, err = evaluateCosh(input)
 			case "tanh":
 				result, err = evaluateTanh(input)
+			case "arcsinh":
+				result, err = evaluateArcsinh(input)
+			case "arccosh":
+				result, err = evaluateArccosh(input)
+			case "arctanh":
+				result, err = evaluateArctanh(input)
+			case "sech":
+				result, err = evaluateSech(input)
+			case "csch":
+				result, err = evaluateCsch(input)
+			case "coth":
+				result, err = evaluateCoth(input)
 			default:
 				t.Fatalf("Unknown function: %s", tt.function)
 			}
@@ -286,6 +329,14 @@ func TestDomainErrors(t *testing.T) {
 		{"arcsin(-2)", func() (*big.Float, error) { return evaluateArcsin(big.NewFloat(-2)) }},
 		{"arccos(1.5)", func() (*big.Float, error) { return evaluateArccos(big.NewFloat(1.5)) }},
 		{"arccos(-1.5)", func() (*big.Float, error) { return evaluateArccos(big.NewFloat(-1.5)) }},
+		{"csc(0)", func() (*big.Float, error) { return evaluateCsc(big.NewFloat(0)) }},
+		{"cot(0)", func() (*big.Float, error) { return evaluateCot(big.NewFloat(0)) }},
+		{"arcsec(0.5)", func() (*big.Float, error) { return evaluateArcsec(big.NewFloat(0.5)) }},
+		{"arccsc(0)", func() (*big.Float, error) { return evaluateArccsc(big.NewFloat(0)) }},
+		{"arccosh(0)", func() (*big.Float, error) { return evaluateArccosh(big.NewFloat(0)) }},
+		{"arctanh(1)", func() (*big.Float, error) { return evaluateArctanh(big.NewFloat(1)) }},
+		{"csch(0)", func() (*big.Float, error) { return evaluateCsch(big.NewFloat(0)) }},
+		{"coth(0)", func() (*big.Float, error) { return evaluateCoth(big.NewFloat(0)) }},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,225 @@
+package ast
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Matrix represents a rectangular array of expressions
+type Matrix struct {
+	rows [][]Expr
+	source
+}
+
+// NewMatrix creates a matrix from its rows. All rows must have the same length.
+func NewMatrix(rows [][]Expr) (*Matrix, error) {
+	if len(rows) == 0 || len(rows[0]) == 0 {
+		return nil, fmt.Errorf("matrix must have at least one entry")
+	}
+	for i, row := range rows {
+		if len(row) != len(rows[0]) {
+			return nil, fmt.Errorf("matrix row %d has %d entries, expected %d", i+1, len(row), len(rows[0]))
+		}
+	}
+	return &Matrix{rows: rows}, nil
+}
+
+func (m *Matrix) String() string {
+	rowStrs := make([]string, len(m.rows))
+	for i, row := range m.rows {
+		entryStrs := make([]string, len(row))
+		for j, entry := range row {
+			entryStrs[j] = entry.String()
+		}
+		rowStrs[i] = "[" + strings.Join(entryStrs, ", ") + "]"
+	}
+	return "[" + strings.Join(rowStrs, ", ") + "]"
+}
+
+func (m *Matrix) LaTeX() string {
+	rowStrs := make([]string, len(m.rows))
+	for i, row := range m.rows {
+		entryStrs := make([]string, len(row))
+		for j, entry := range row {
+			entryStrs[j] = entry.LaTeX()
+		}
+		rowStrs[i] = strings.Join(entryStrs, " & ")
+	}
+	return fmt.Sprintf("\\begin{pmatrix}%s\\end{pmatrix}", strings.Join(rowStrs, " \\\\ "))
+}
+
+func (m *Matrix) Eval(vars map[string]*big.Float) (*big.Float, error) {
+	return nil, fmt.Errorf("cannot evaluate %dx%d matrix to a number", m.RowCount(), m.ColCount())
+}
+
+func (m *Matrix) Simplify() Expr {
+	return &Matrix{rows: mapRows(m.rows, func(e Expr) Expr { return e.Simplify() })}
+}
+
+func (m *Matrix) Equal(other Expr) bool {
+	if other.Type() != TypeMatrix {
+		return false
+	}
+	otherMatrix := other.(*Matrix)
+	if m.RowCount() != otherMatrix.RowCount() || m.ColCount() != otherMatrix.ColCount() {
+		return false
+	}
+	for i, row := range m.rows {
+		for j, entry := range row {
+			if !entry.Equal(otherMatrix.rows[i][j]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (m *Matrix) Clone() Expr {
+	return &Matrix{rows: mapRows(m.rows, func(e Expr) Expr { return e.Clone() }), source: m.source}
+}
+
+func (m *Matrix) Variables() []string {
+	vars := []string{}
+	for _, row := range m.rows {
+		for _, entry := range row {
+			vars = append(vars, entry.Variables()...)
+		}
+	}
+	return removeDuplicates(vars)
+}
+
+func (m *Matrix) Type() ExprType {
+	return TypeMatrix
+}
+
+// RowCount returns the number of rows
+func (m *Matrix) RowCount() int {
+	return len(m.rows)
+}
+
+// ColCount returns the number of columns
+func (m *Matrix) ColCount() int {
+	return len(m.rows[0])
+}
+
+// At returns a copy of the entry in row i and column j (zero-based)
+func (m *Matrix) At(i, j int) Expr {
+	return m.rows[i][j].Clone()
+}
+
+// Rows returns a copy of the entries, row by row
+func (m *Matrix) Rows() [][]Expr {
+	return mapRows(m.rows, func(e Expr) Expr { return e.Clone() })
+}
+
+// Vector represents an ordered list of components, written as a column
+type Vector struct {
+	elements []Expr
+	source
+}
+
+// NewVector creates a vector from its components
+func NewVector(elements ...Expr) *Vector {
+	return &Vector{elements: elements}
+}
+
+func (v *Vector) String() string {
+	elemStrs := make([]string, len(v.elements))
+	for i, elem := range v.elements {
+		elemStrs[i] = elem.String()
+	}
+	return "[" + strings.Join(elemStrs, ", ") + "]"
+}
+
+func (v *Vector) LaTeX() string {
+	elemStrs := make([]string, len(v.elements))
+	for i, elem := range v.elements {
+		elemStrs[i] = elem.LaTeX()
+	}
+	return fmt.Sprintf("\\begin{pmatrix}%s\\end{pmatrix}", strings.Join(elemStrs, " \\\\ "))
+}
+
+func (v *Vector) Eval(vars map[string]*big.Float) (*big.Float, error) {
+	return nil, fmt.Errorf("cannot evaluate vector of length %d to a number", len(v.elements))
+}
+
+func (v *Vector) Simplify() Expr {
+	elements := make([]Expr, len(v.elements))
+	for i, elem := range v.elements {
+		elements[i] = elem.Simplify()
+	}
+	return &Vector{elements: elements}
+}
+
+func (v *Vector) Equal(other Expr) bool {
+	if other.Type() != TypeVector {
+		return false
+	}
+	otherVector := other.(*Vector)
+	if len(v.elements) != len(otherVector.elements) {
+		return false
+	}
+	for i, elem := range v.elements {
+		if !elem.Equal(otherVector.elements[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (v *Vector) Clone() Expr {
+	return &Vector{elements: v.Elements(), source: v.source}
+}
+
+func (v *Vector) Variables() []string {
+	vars := []string{}
+	for _, elem := range v.elements {
+		vars = append(vars, elem.Variables()...)
+	}
+	return removeDuplicates(vars)
+}
+
+func (v *Vector) Type() ExprType {
+	return TypeVector
+}
+
+// Len returns the number of components
+func (v *Vector) Len() int {
+	return len(v.elements)
+}
+
+// At returns a copy of the i-th component (zero-based)
+func (v *Vector) At(i int) Expr {
+	return v.elements[i].Clone()
+}
+
+// Elements returns a copy of the components
+func (v *Vector) Elements() []Expr {
+	result := make([]Expr, len(v.elements))
+	for i, elem := range v.elements {
+		result[i] = elem.Clone()
+	}
+	return result
+}
+
+// AsColumn returns the vector as an n×1 matrix
+func (v *Vector) AsColumn() *Matrix {
+	rows := make([][]Expr, len(v.elements))
+	for i, elem := range v.elements {
+		rows[i] = []Expr{elem.Clone()}
+	}
+	return &Matrix{rows: rows}
+}
+
+// mapRows applies fn to every entry of a matrix, returning new rows
+func mapRows(rows [][]Expr, fn func(Expr) Expr) [][]Expr {
+	result := make([][]Expr, len(rows))
+	for i, row := range rows {
+		result[i] = make([]Expr, len(row))
+		for j, entry := range row {
+			result[i][j] = fn(entry)
+		}
+	}
+	return result
+}
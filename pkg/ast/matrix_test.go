@@ -0,0 +1,78 @@
+package ast
+
+import "testing"
+
+func TestMatrix(t *testing.T) {
+	m, err := NewMatrix([][]Expr{
+		{NewInt(1), NewVar("x")},
+		{NewRational(1, 2), NewInt(4)},
+	})
+	if err != nil {
+		t.Fatalf("NewMatrix error: %v", err)
+	}
+
+	if got, want := m.String(), "[[1, x], [1/2, 4]]"; got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+	if got, want := m.LaTeX(), "\\begin{pmatrix}1 & x \\\\ \\frac{1}{2} & 4\\end{pmatrix}"; got != want {
+		t.Errorf("LaTeX() = %s, want %s", got, want)
+	}
+	if m.RowCount() != 2 || m.ColCount() != 2 {
+		t.Errorf("shape = %dx%d, want 2x2", m.RowCount(), m.ColCount())
+	}
+	if !m.Equal(m.Clone()) {
+		t.Errorf("Clone() is not equal to the original")
+	}
+	if vars := m.Variables(); len(vars) != 1 || vars[0] != "x" {
+		t.Errorf("Variables() = %v, want [x]", vars)
+	}
+	if _, err := m.Eval(nil); err == nil {
+		t.Errorf("Eval() should fail for a matrix")
+	}
+
+	substituted := Substitute(m, "x", NewInt(3))
+	if got, want := substituted.String(), "[[1, 3], [1/2, 4]]"; got != want {
+		t.Errorf("Substitute() = %s, want %s", got, want)
+	}
+}
+
+func TestNewMatrixErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		rows [][]Expr
+	}{
+		{"empty", [][]Expr{}},
+		{"empty row", [][]Expr{{}}},
+		{"ragged", [][]Expr{{NewInt(1), NewInt(2)}, {NewInt(3)}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewMatrix(tt.rows); err == nil {
+				t.Errorf("NewMatrix(%v) should fail", tt.rows)
+			}
+		})
+	}
+}
+
+func TestVector(t *testing.T) {
+	v := NewVector(NewInt(1), NewVar("y"), NewInt(3))
+
+	if got, want := v.String(), "[1, y, 3]"; got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+	if got, want := v.LaTeX(), "\\begin{pmatrix}1 \\\\ y \\\\ 3\\end{pmatrix}"; got != want {
+		t.Errorf("LaTeX() = %s, want %s", got, want)
+	}
+	if !v.Equal(v.Clone()) {
+		t.Errorf("Clone() is not equal to the original")
+	}
+	if v.Equal(NewVector(NewInt(1), NewVar("y"))) {
+		t.Errorf("vectors of different lengths should differ")
+	}
+
+	column := v.AsColumn()
+	if column.RowCount() != 3 || column.ColCount() != 1 {
+		t.Errorf("AsColumn() shape = %dx%d, want 3x1", column.RowCount(), column.ColCount())
+	}
+}
@@ -8,6 +8,7 @@ import (
 // Int represents an integer constant
 type Int struct {
 	value *big.Int
+	source
 }
 
 // NewInt creates a new integer expression
@@ -52,7 +53,7 @@ func (i *Int) Equal(other Expr) bool {
 
 func (i *Int) Clone() Expr {
 	newVal := new(big.Int).Set(i.value)
-	return &Int{value: newVal}
+	return &Int{value: newVal, source: i.source}
 }
 
 func (i *Int) Variables() []string {
@@ -77,6 +78,7 @@ func (i *Int) IntValue() *big.Int {
 // Float represents a floating-point constant
 type Float struct {
 	value *big.Float
+	source
 }
 
 // NewFloat creates a new float expression
@@ -118,7 +120,7 @@ func (f *Float) Equal(other Expr) bool {
 }
 
 func (f *Float) Clone() Expr {
-	return &Float{value: new(big.Float).Copy(f.value)}
+	return &Float{value: new(big.Float).Copy(f.value), source: f.source}
 }
 
 func (f *Float) Variables() []string {
@@ -137,6 +139,7 @@ func (f *Float) Value() *big.Float {
 type Rational struct {
 	numerator   *big.Int
 	denominator *big.Int
+	source
 }
 
 // NewRational creates a new rational expression
@@ -216,6 +219,7 @@ func (r *Rational) Clone() Expr {
 	return &Rational{
 		numerator:   new(big.Int).Set(r.numerator),
 		denominator: new(big.Int).Set(r.denominator),
+		source:      r.source,
 	}
 }
 
@@ -10,6 +10,7 @@ import (
 // Add represents addition of terms
 type Add struct {
 	terms []Expr
+	source
 }
 
 // NewAdd creates a new addition expression
@@ -133,7 +134,7 @@ func (a *Add) Clone() Expr {
 	for i, term := range a.terms {
 		clonedTerms[i] = term.Clone()
 	}
-	return &Add{terms: clonedTerms}
+	return &Add{terms: clonedTerms, source: a.source}
 }
 
 func (a *Add) Variables() []string {
@@ -166,6 +167,7 @@ func (a *Add) AddTerm(term Expr) Seq {
 // Mul represents multiplication of factors
 type Mul struct {
 	factors []Expr
+	source
 }
 
 // NewMul creates a new multiplication expression
@@ -204,8 +206,8 @@ func (m *Mul) String() string {
 	parts := make([]string, len(m.factors))
 	for i, factor := range m.factors {
 		factorStr := factor.String()
-		// Add parentheses around addition/subtraction
-		if factor.Type() == TypeAdd {
+		// Add parentheses around addition/subtraction and quantities
+		if factor.Type() == TypeAdd || factor.Type() == TypePlusMinus || factor.Type() == TypeQuantity {
 			factorStr = "(" + factorStr + ")"
 		}
 		parts[i] = factorStr
@@ -224,8 +226,8 @@ func (m *Mul) LaTeX() string {
 	parts := make([]string, len(m.factors))
 	for i, factor := range m.factors {
 		factorStr := factor.LaTeX()
-		// Add parentheses around addition/subtraction
-		if factor.Type() == TypeAdd {
+		// Add parentheses around addition/subtraction and quantities
+		if factor.Type() == TypeAdd || factor.Type() == TypePlusMinus || factor.Type() == TypeQuantity {
 			factorStr = "(" + factorStr + ")"
 		}
 		parts[i] = factorStr
@@ -329,7 +331,7 @@ func (m *Mul) Clone() Expr {
 	for i, factor := range m.factors {
 		clonedFactors[i] = factor.Clone()
 	}
-	return &Mul{factors: clonedFactors}
+	return &Mul{factors: clonedFactors, source: m.source}
 }
 
 func (m *Mul) Variables() []string {
@@ -363,6 +365,7 @@ func (m *Mul) AddTerm(term Expr) Seq {
 type Pow struct {
 	base     Expr
 	exponent Expr
+	source
 }
 
 // NewPow creates a new power expression
@@ -376,8 +379,9 @@ func (p *Pow) String() string {
 	baseStr := p.base.String()
 	expStr := p.exponent.String()
 
-	// Add parentheses around complex base expressions
-	if p.base.Type() == TypeAdd || p.base.Type() == TypeMul {
+	// Add parentheses around complex base expressions, and around a power,
+	// which would otherwise read as the power of a power: x^(a^b)
+	if p.base.Type() == TypeAdd || p.base.Type() == TypeMul || p.base.Type() == TypePow || p.base.Type() == TypePlusMinus || p.base.Type() == TypeQuantity {
 		baseStr = "(" + baseStr + ")"
 	}
 
@@ -393,8 +397,9 @@ func (p *Pow) LaTeX() string {
 	baseStr := p.base.LaTeX()
 	expStr := p.exponent.LaTeX()
 
-	// Add parentheses around complex base expressions
-	if p.base.Type() == TypeAdd || p.base.Type() == TypeMul {
+	// Add parentheses around complex base expressions, and around a power,
+	// which would otherwise read as the power of a power: x^(a^b)
+	if p.base.Type() == TypeAdd || p.base.Type() == TypeMul || p.base.Type() == TypePow || p.base.Type() == TypePlusMinus || p.base.Type() == TypeQuantity {
 		baseStr = "(" + baseStr + ")"
 	}
 
@@ -422,7 +427,10 @@ func (p *Pow) Eval(vars map[string]*big.Float) (*big.Float, error) {
 				result.Mul(result, baseVal)
 			}
 		} else {
-			// Negative integer exponent
+			// Negative integer exponent; 0^-n is undefined, not infinite
+			if baseVal.Sign() == 0 {
+				return nil, fmt.Errorf("division by zero")
+			}
 			result.SetInt64(1)
 			for i := int64(0); i < -expInt; i++ {
 				result.Mul(result, baseVal)
@@ -504,7 +512,7 @@ func (p *Pow) Equal(other Expr) bool {
 }
 
 func (p *Pow) Clone() Expr {
-	return &Pow{base: p.base.Clone(), exponent: p.exponent.Clone()}
+	return &Pow{base: p.base.Clone(), exponent: p.exponent.Clone(), source: p.source}
 }
 
 func (p *Pow) Variables() []string {
@@ -564,11 +572,26 @@ func (et EqType) String() string {
 	}
 }
 
+// latex returns the LaTeX spelling of the relation
+func (et EqType) latex() string {
+	switch et {
+	case EqLessEqual:
+		return "\\le"
+	case EqGreaterEqual:
+		return "\\ge"
+	case EqNotEqual:
+		return "\\ne"
+	default:
+		return et.String()
+	}
+}
+
 // Eq represents an equation or inequality
 type Eq struct {
 	left   Expr
 	right  Expr
 	eqType EqType
+	source
 }
 
 // NewEq creates a new equation/inequality expression
@@ -581,16 +604,7 @@ func (e *Eq) String() string {
 }
 
 func (e *Eq) LaTeX() string {
-	op := e.eqType.String()
-	switch e.eqType {
-	case EqLessEqual:
-		op = "\\le"
-	case EqGreaterEqual:
-		op = "\\ge"
-	case EqNotEqual:
-		op = "\\ne"
-	}
-	return fmt.Sprintf("%s %s %s", e.left.LaTeX(), op, e.right.LaTeX())
+	return fmt.Sprintf("%s %s %s", e.left.LaTeX(), e.eqType.latex(), e.right.LaTeX())
 }
 
 func (e *Eq) Eval(vars map[string]*big.Float) (*big.Float, error) {
@@ -652,7 +666,7 @@ func (e *Eq) Equal(other Expr) bool {
 }
 
 func (e *Eq) Clone() Expr {
-	return &Eq{left: e.left.Clone(), right: e.right.Clone(), eqType: e.eqType}
+	return &Eq{left: e.left.Clone(), right: e.right.Clone(), eqType: e.eqType, source: e.source}
 }
 
 func (e *Eq) Variables() []string {
@@ -843,3 +857,31 @@ func isOne(expr Expr) bool {
 	}
 	return false
 }
+
+// Addends returns the terms of a sum, flattening nested sums, or expr
+// itself when it is not a sum
+func Addends(expr Expr) []Expr {
+	add, ok := expr.(*Add)
+	if !ok {
+		return []Expr{expr}
+	}
+	var terms []Expr
+	for _, term := range add.Terms() {
+		terms = append(terms, Addends(term)...)
+	}
+	return terms
+}
+
+// Factors returns the factors of a product, flattening nested products, or
+// expr itself when it is not a product
+func Factors(expr Expr) []Expr {
+	mul, ok := expr.(*Mul)
+	if !ok {
+		return []Expr{expr}
+	}
+	var factors []Expr
+	for _, factor := range mul.Terms() {
+		factors = append(factors, Factors(factor)...)
+	}
+	return factors
+}
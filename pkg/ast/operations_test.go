@@ -282,4 +282,18 @@ func TestExprVariables(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+func TestAddendsAndFactors(t *testing.T) {
+	x, y := NewVar("x"), NewVar("y")
+	sum := NewAdd(NewInt(1), NewAdd(x, y))
+	if got := Addends(sum); len(got) != 3 || !got[2].Equal(y) {
+		t.Errorf("Addends(%s) = %v, want [1 x y]", sum, got)
+	}
+	product := NewMul(NewInt(2), NewMul(x, y))
+	if got := Factors(product); len(got) != 3 || !got[1].Equal(x) {
+		t.Errorf("Factors(%s) = %v, want [2 x y]", product, got)
+	}
+	if got := Factors(x); len(got) != 1 || !got[0].Equal(x) {
+		t.Errorf("Factors(x) = %v, want [x]", got)
+	}
+}
@@ -0,0 +1,129 @@
+package ast
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Piecewise represents a function defined by cases, as in
+// {x^2 if x<0; 2*x otherwise}. Each value applies where its condition, a
+// relation such as x < 0 or a chain such as 0 <= x < 1, holds. Cases are
+// tried in order, and a nil condition (otherwise) always holds.
+type Piecewise struct {
+	values     []Expr
+	conditions []Expr
+	source
+}
+
+// NewPiecewise creates a piecewise expression from its values and their
+// conditions, which must have the same length; a nil condition stands for
+// otherwise
+func NewPiecewise(values, conditions []Expr) *Piecewise {
+	return &Piecewise{values: values, conditions: conditions}
+}
+
+func (p *Piecewise) String() string {
+	cases := make([]string, len(p.values))
+	for i, value := range p.values {
+		if p.conditions[i] == nil {
+			cases[i] = value.String() + " otherwise"
+		} else {
+			cases[i] = value.String() + " if " + p.conditions[i].String()
+		}
+	}
+	return "{" + strings.Join(cases, "; ") + "}"
+}
+
+func (p *Piecewise) LaTeX() string {
+	cases := make([]string, len(p.values))
+	for i, value := range p.values {
+		if p.conditions[i] == nil {
+			cases[i] = value.LaTeX() + " & \\text{otherwise}"
+		} else {
+			cases[i] = value.LaTeX() + " & " + p.conditions[i].LaTeX()
+		}
+	}
+	return "\\begin{cases} " + strings.Join(cases, " \\\\ ") + " \\end{cases}"
+}
+
+// Eval evaluates the value of the first case whose condition holds
+func (p *Piecewise) Eval(vars map[string]*big.Float) (*big.Float, error) {
+	i, err := p.Case(vars)
+	if err != nil {
+		return nil, err
+	}
+	return p.values[i].Eval(vars)
+}
+
+// Case returns the index of the first case whose condition holds for vars
+func (p *Piecewise) Case(vars map[string]*big.Float) (int, error) {
+	for i, condition := range p.conditions {
+		if condition == nil {
+			return i, nil
+		}
+		holds, err := condition.Eval(vars)
+		if err != nil {
+			return 0, err
+		}
+		if holds.Sign() != 0 {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("%s is undefined: no case holds", p.String())
+}
+
+func (p *Piecewise) Simplify() Expr {
+	return &Piecewise{
+		values:     mapElements(p.values, func(e Expr) Expr { return e.Simplify() }),
+		conditions: mapElements(p.conditions, simplifyPart),
+	}
+}
+
+func (p *Piecewise) Equal(other Expr) bool {
+	otherPiecewise, ok := other.(*Piecewise)
+	if !ok || len(p.values) != len(otherPiecewise.values) {
+		return false
+	}
+	for i, value := range p.values {
+		if !value.Equal(otherPiecewise.values[i]) || !equalPart(p.conditions[i], otherPiecewise.conditions[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *Piecewise) Clone() Expr {
+	return &Piecewise{values: p.Values(), conditions: p.Conditions(), source: p.source}
+}
+
+func (p *Piecewise) Variables() []string {
+	var vars []string
+	for i, value := range p.values {
+		vars = append(vars, value.Variables()...)
+		if p.conditions[i] != nil {
+			vars = append(vars, p.conditions[i].Variables()...)
+		}
+	}
+	return removeDuplicates(vars)
+}
+
+func (p *Piecewise) Type() ExprType {
+	return TypePiecewise
+}
+
+// Len returns the number of cases
+func (p *Piecewise) Len() int {
+	return len(p.values)
+}
+
+// Values returns copies of the values of the cases, in order
+func (p *Piecewise) Values() []Expr {
+	return mapElements(p.values, func(e Expr) Expr { return e.Clone() })
+}
+
+// Conditions returns copies of the conditions of the cases, in order, with
+// nil for otherwise
+func (p *Piecewise) Conditions() []Expr {
+	return mapElements(p.conditions, clonePart)
+}
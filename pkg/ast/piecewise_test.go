@@ -0,0 +1,59 @@
+package ast
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestPiecewise(t *testing.T) {
+	x := NewVar("x")
+	pw := NewPiecewise(
+		[]Expr{NewPow(x, NewInt(2)), NewMul(NewInt(2), x)},
+		[]Expr{NewEq(x, NewInt(0), EqLess), nil},
+	)
+
+	if got, want := pw.String(), "{x^2 if x<0; 2*x otherwise}"; got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+	if got, want := pw.LaTeX(), "\\begin{cases} x^{2} & x < 0 \\\\ 2 \\cdot x & \\text{otherwise} \\end{cases}"; got != want {
+		t.Errorf("LaTeX() = %s, want %s", got, want)
+	}
+	if !pw.Equal(pw.Clone()) {
+		t.Errorf("Clone() is not equal to the original")
+	}
+
+	tests := []struct {
+		x    int64
+		want int64
+	}{
+		{-3, 9},
+		{0, 0},
+		{4, 8},
+	}
+	for _, tt := range tests {
+		value, err := pw.Eval(map[string]*big.Float{"x": big.NewFloat(float64(tt.x))})
+		if err != nil {
+			t.Fatalf("Eval(x=%d) error: %v", tt.x, err)
+		}
+		if got, _ := value.Int64(); got != tt.want {
+			t.Errorf("Eval(x=%d) = %d, want %d", tt.x, got, tt.want)
+		}
+	}
+
+	unit, err := NewChain([]Expr{NewInt(0), x, NewInt(1)}, []EqType{EqLessEqual, EqLess})
+	if err != nil {
+		t.Fatalf("NewChain() error: %v", err)
+	}
+	partial := NewPiecewise([]Expr{NewInt(1)}, []Expr{unit})
+	if _, err := partial.Eval(map[string]*big.Float{"x": big.NewFloat(2)}); err == nil {
+		t.Errorf("Eval() should fail where no case holds")
+	}
+
+	substituted := Substitute(pw, "x", NewVar("t"))
+	if got, want := substituted.String(), "{t^2 if t<0; 2*t otherwise}"; got != want {
+		t.Errorf("Substitute() = %s, want %s", got, want)
+	}
+	if vars := pw.Variables(); len(vars) != 1 || vars[0] != "x" {
+		t.Errorf("Variables() = %v, want [x]", vars)
+	}
+}
@@ -0,0 +1,271 @@
+package ast
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// PlusMinus represents a ± b, which stands for both a + b and a - b, as in
+// the roots (-3 ± sqrt(5))/2. The unary ±b has no left operand.
+type PlusMinus struct {
+	left  Expr
+	right Expr
+	source
+}
+
+// NewPlusMinus creates left ± right, or ±right when left is nil
+func NewPlusMinus(left, right Expr) *PlusMinus {
+	return &PlusMinus{left: left, right: right}
+}
+
+func (p *PlusMinus) String() string {
+	right := p.right.String()
+	if p.right.Type() == TypeAdd || p.right.Type() == TypePlusMinus {
+		right = "(" + right + ")"
+	}
+	if p.left == nil {
+		return "±" + right
+	}
+	return p.left.String() + "±" + right
+}
+
+func (p *PlusMinus) LaTeX() string {
+	right := p.right.LaTeX()
+	if p.right.Type() == TypeAdd || p.right.Type() == TypePlusMinus {
+		right = "(" + right + ")"
+	}
+	if p.left == nil {
+		return "\\pm " + right
+	}
+	return p.left.LaTeX() + " \\pm " + right
+}
+
+// Eval fails, as a ± b has two values; ExpandPlusMinus gives both
+func (p *PlusMinus) Eval(vars map[string]*big.Float) (*big.Float, error) {
+	return nil, fmt.Errorf("cannot evaluate %s to a single number", p.String())
+}
+
+func (p *PlusMinus) Simplify() Expr {
+	right := p.right.Simplify()
+	if p.left == nil {
+		return &PlusMinus{right: right}
+	}
+	return &PlusMinus{left: p.left.Simplify(), right: right}
+}
+
+func (p *PlusMinus) Equal(other Expr) bool {
+	if other.Type() != TypePlusMinus {
+		return false
+	}
+	otherPM := other.(*PlusMinus)
+	if (p.left == nil) != (otherPM.left == nil) {
+		return false
+	}
+	if p.left != nil && !p.left.Equal(otherPM.left) {
+		return false
+	}
+	return p.right.Equal(otherPM.right)
+}
+
+func (p *PlusMinus) Clone() Expr {
+	return &PlusMinus{left: p.Left(), right: p.right.Clone(), source: p.source}
+}
+
+func (p *PlusMinus) Variables() []string {
+	if p.left == nil {
+		return p.right.Variables()
+	}
+	return removeDuplicates(append(p.left.Variables(), p.right.Variables()...))
+}
+
+func (p *PlusMinus) Type() ExprType {
+	return TypePlusMinus
+}
+
+// Left returns a copy of the left operand, or nil for the unary ±b
+func (p *PlusMinus) Left() Expr {
+	if p.left == nil {
+		return nil
+	}
+	return p.left.Clone()
+}
+
+// Right returns a copy of the operand that is added and subtracted
+func (p *PlusMinus) Right() Expr {
+	return p.right.Clone()
+}
+
+// ExpandPlusMinus returns the expressions that expr stands for: the two
+// branches, with + and with -, when it contains a ±, and expr alone
+// otherwise. Every ± in an expression takes the same sign, as in the
+// quadratic formula, so there are never more than two branches.
+func ExpandPlusMinus(expr Expr) []Expr {
+	plus, found := withSign(expr, 1)
+	if !found {
+		return []Expr{plus}
+	}
+	minus, _ := withSign(expr, -1)
+	return []Expr{plus, minus}
+}
+
+// withSign returns a copy of expr with every ± replaced by the sign, and
+// whether there was one
+func withSign(expr Expr, sign int) (Expr, bool) {
+	found := false
+	signed := func(e Expr) Expr {
+		result, ok := withSign(e, sign)
+		found = found || ok
+		return result
+	}
+
+	var result Expr
+	switch e := expr.(type) {
+	case *PlusMinus:
+		found = true
+		right := signed(e.right)
+		if sign < 0 {
+			right = negateTerm(right)
+		}
+		if e.left == nil {
+			return right, true
+		}
+		result = &Add{terms: []Expr{signed(e.left), right}}
+	case *Add:
+		result = &Add{terms: mapElements(e.terms, signed)}
+	case *Mul:
+		result = &Mul{factors: mapElements(e.factors, signed)}
+	case *Pow:
+		result = &Pow{base: signed(e.base), exponent: signed(e.exponent)}
+	case *Func:
+		result = &Func{name: e.name, args: mapElements(e.args, signed)}
+	case *Eq:
+		result = &Eq{left: signed(e.left), right: signed(e.right), eqType: e.eqType}
+	case *Matrix:
+		result = &Matrix{rows: mapRows(e.rows, signed)}
+	case *Vector:
+		result = &Vector{elements: mapElements(e.elements, signed)}
+	case *Tuple:
+		result = &Tuple{elements: mapElements(e.elements, signed)}
+	case *Set:
+		result = &Set{elements: mapElements(e.elements, signed)}
+	case *Chain:
+		result = &Chain{operands: mapElements(e.operands, signed), relations: e.Relations()}
+	case *Quantity:
+		result = &Quantity{value: signed(e.value), unit: e.unit.Clone()}
+	case *Piecewise:
+		result = &Piecewise{values: mapElements(e.values, signed), conditions: mapElements(e.conditions, clonePart)}
+	default:
+		return expr.Clone(), false
+	}
+	return result, found
+}
+
+// CollapsePlusMinus writes a pair of expressions that differ only in the
+// sign of one term as one ± expression, so 1 + sqrt(2) and 1 - sqrt(2) give
+// 1 ± sqrt(2), and 2 and -2 give ±2. Equations x = a and x = b collapse to
+// x = a ± b in the same way.
+func CollapsePlusMinus(a, b Expr) (Expr, bool) {
+	eqA, okA := a.(*Eq)
+	eqB, okB := b.(*Eq)
+	if okA || okB {
+		if !okA || !okB || eqA.eqType != EqEqual || eqB.eqType != EqEqual || !eqA.left.Equal(eqB.left) {
+			return nil, false
+		}
+		right, ok := CollapsePlusMinus(eqA.right, eqB.right)
+		if !ok {
+			return nil, false
+		}
+		return NewEq(eqA.Left(), right, EqEqual), true
+	}
+
+	termsA, termsB := Addends(a), Addends(b)
+	if len(termsA) != len(termsB) {
+		return nil, false
+	}
+
+	var center []Expr
+	var offset Expr
+	for i, termA := range termsA {
+		if termA.Equal(termsB[i]) {
+			center = append(center, termA.Clone())
+			continue
+		}
+		magnitudeA, negativeA := splitSign(termA)
+		magnitudeB, negativeB := splitSign(termsB[i])
+		if offset != nil || negativeA == negativeB || !magnitudeA.Equal(magnitudeB) {
+			return nil, false
+		}
+		offset = magnitudeA
+	}
+	if offset == nil {
+		return nil, false
+	}
+
+	switch len(center) {
+	case 0:
+		return NewPlusMinus(nil, offset), true
+	case 1:
+		return NewPlusMinus(center[0], offset), true
+	default:
+		return NewPlusMinus(NewAdd(center...), offset), true
+	}
+}
+
+// splitSign returns the magnitude of a term and whether it is negated by
+// its sign or a negative leading coefficient, so -3*sqrt(2) gives
+// 3*sqrt(2) and true
+func splitSign(term Expr) (Expr, bool) {
+	switch t := term.(type) {
+	case *Int, *Rational, *Float:
+		if negated, ok := negateNumber(t); ok && isNegativeNumber(t) {
+			return negated, true
+		}
+	case *Mul:
+		if len(t.factors) > 1 && isNegativeNumber(t.factors[0]) {
+			rest := mapElements(t.factors[1:], func(e Expr) Expr { return e.Clone() })
+			if IsMinusOne(t.factors[0]) {
+				if len(rest) == 1 {
+					return rest[0], true
+				}
+				return &Mul{factors: rest}, true
+			}
+			coefficient, _ := negateNumber(t.factors[0])
+			return &Mul{factors: append([]Expr{coefficient}, rest...)}, true
+		}
+	}
+	return term.Clone(), false
+}
+
+// negateTerm returns -term, folding the sign into a number
+func negateTerm(term Expr) Expr {
+	if negated, ok := negateNumber(term); ok {
+		return negated
+	}
+	return &Mul{factors: []Expr{NewInt(-1), term}}
+}
+
+// negateNumber returns the negation of a numeric literal
+func negateNumber(expr Expr) (Expr, bool) {
+	switch n := expr.(type) {
+	case *Int:
+		return &Int{value: new(big.Int).Neg(n.value)}, true
+	case *Rational:
+		return &Rational{numerator: new(big.Int).Neg(n.numerator), denominator: new(big.Int).Set(n.denominator)}, true
+	case *Float:
+		return &Float{value: new(big.Float).Neg(n.value)}, true
+	}
+	return nil, false
+}
+
+// isNegativeNumber reports whether expr is a numeric literal below zero
+func isNegativeNumber(expr Expr) bool {
+	switch n := expr.(type) {
+	case *Int:
+		return n.value.Sign() < 0
+	case *Rational:
+		return n.numerator.Sign() < 0
+	case *Float:
+		return n.value.Sign() < 0
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package ast
+
+import "testing"
+
+func TestPlusMinus(t *testing.T) {
+	root := NewPlusMinus(NewInt(1), NewFunc("sqrt", NewInt(2)))
+
+	if got, want := root.String(), "1±sqrt(2)"; got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+	if got, want := NewPlusMinus(nil, NewInt(2)).LaTeX(), "\\pm 2"; got != want {
+		t.Errorf("LaTeX() = %s, want %s", got, want)
+	}
+	if !root.Equal(root.Clone()) {
+		t.Errorf("Clone() is not equal to the original")
+	}
+	if root.Equal(NewPlusMinus(nil, NewFunc("sqrt", NewInt(2)))) {
+		t.Errorf("a binary ± should not equal a unary one")
+	}
+	if _, err := root.Eval(nil); err == nil {
+		t.Errorf("Eval() should fail for a ±")
+	}
+
+	// Every ± takes the same sign, so x = (-3 ± sqrt(5))/2 has two branches
+	eq := NewEq(NewVar("x"), NewMul(NewPlusMinus(NewInt(-3), NewFunc("sqrt", NewInt(5))), NewPow(NewInt(2), NewInt(-1))), EqEqual)
+	branches := ExpandPlusMinus(eq)
+	if len(branches) != 2 {
+		t.Fatalf("ExpandPlusMinus(%s) gave %d branches, want 2", eq, len(branches))
+	}
+	if got, want := branches[0].String(), "x=(-3+sqrt(5))*2^-1"; got != want {
+		t.Errorf("plus branch = %s, want %s", got, want)
+	}
+	if got, want := branches[1].String(), "x=(-3+-1*sqrt(5))*2^-1"; got != want {
+		t.Errorf("minus branch = %s, want %s", got, want)
+	}
+	if got := ExpandPlusMinus(NewVar("x")); len(got) != 1 {
+		t.Errorf("ExpandPlusMinus(x) gave %d branches, want 1", len(got))
+	}
+
+	substituted := Substitute(NewPlusMinus(NewVar("x"), NewInt(1)), "x", NewInt(5))
+	if got, want := substituted.String(), "5±1"; got != want {
+		t.Errorf("Substitute() = %s, want %s", got, want)
+	}
+}
+
+func TestCollapsePlusMinus(t *testing.T) {
+	sqrt2 := NewFunc("sqrt", NewInt(2))
+	tests := []struct {
+		name     string
+		a, b     Expr
+		expected string
+	}{
+		{"opposite numbers", NewInt(2), NewInt(-2), "±2"},
+		{"surd", NewAdd(NewInt(1), sqrt2), NewAdd(NewInt(1), NewMul(NewInt(-1), sqrt2)), "1±sqrt(2)"},
+		{"surd with coefficient", NewAdd(NewInt(1), NewMul(NewRational(-1, 2), sqrt2)), NewAdd(NewInt(1), NewMul(NewRational(1, 2), sqrt2)), "1±1/2*sqrt(2)"},
+		{"equations", NewEq(NewVar("x"), NewInt(3), EqEqual), NewEq(NewVar("x"), NewInt(-3), EqEqual), "x=±3"},
+		{"not symmetric", NewInt(1), NewInt(3), ""},
+		{"two terms differ", NewAdd(NewInt(1), sqrt2), NewAdd(NewInt(-1), NewMul(NewInt(-1), sqrt2)), ""},
+		{"different variables", NewEq(NewVar("x"), NewInt(3), EqEqual), NewEq(NewVar("y"), NewInt(-3), EqEqual), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collapsed, ok := CollapsePlusMinus(tt.a, tt.b)
+			if tt.expected == "" {
+				if ok {
+					t.Errorf("CollapsePlusMinus(%s, %s) = %s, want none", tt.a, tt.b, collapsed)
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("CollapsePlusMinus(%s, %s) failed, want %s", tt.a, tt.b, tt.expected)
+			}
+			if got := collapsed.String(); got != tt.expected {
+				t.Errorf("CollapsePlusMinus(%s, %s) = %s, want %s", tt.a, tt.b, got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package ast
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Unit represents a unit of measure such as m or km inside the unit of a
+// Quantity. It is a symbol only; package units knows what it measures.
+type Unit struct {
+	symbol string
+	source
+}
+
+// NewUnit creates a unit from its symbol
+func NewUnit(symbol string) *Unit {
+	return &Unit{symbol: symbol}
+}
+
+func (u *Unit) String() string {
+	return u.symbol
+}
+
+func (u *Unit) LaTeX() string {
+	return "\\mathrm{" + u.symbol + "}"
+}
+
+func (u *Unit) Eval(vars map[string]*big.Float) (*big.Float, error) {
+	return nil, fmt.Errorf("cannot evaluate unit %s to a number", u.symbol)
+}
+
+func (u *Unit) Simplify() Expr {
+	return u.Clone()
+}
+
+func (u *Unit) Equal(other Expr) bool {
+	otherUnit, ok := other.(*Unit)
+	return ok && u.symbol == otherUnit.symbol
+}
+
+func (u *Unit) Clone() Expr {
+	return &Unit{symbol: u.symbol, source: u.source}
+}
+
+// Variables returns no names, as a unit is not a variable
+func (u *Unit) Variables() []string {
+	return []string{}
+}
+
+func (u *Unit) Type() ExprType {
+	return TypeUnit
+}
+
+// Name returns the symbol of the unit
+func (u *Unit) Name() string {
+	return u.symbol
+}
+
+// Quantity represents a measurement, a value times a unit, as in
+// 9.8 m/s^2. The unit is a product of powers of Unit symbols.
+type Quantity struct {
+	value Expr
+	unit  Expr
+	source
+}
+
+// NewQuantity creates the quantity value times unit
+func NewQuantity(value, unit Expr) *Quantity {
+	return &Quantity{value: value, unit: unit}
+}
+
+func (q *Quantity) String() string {
+	return wrapSum(q.value.String(), q.value) + " " + formatUnit(q.unit, "*", "/", Expr.String)
+}
+
+func (q *Quantity) LaTeX() string {
+	return wrapSum(q.value.LaTeX(), q.value) + "\\," + q.UnitLaTeX()
+}
+
+// UnitLaTeX returns the unit as LaTeX, with negative powers written after
+// a slash, as in \mathrm{m}/\mathrm{s}^{2}
+func (q *Quantity) UnitLaTeX() string {
+	return formatUnit(q.unit, " \\cdot ", "/", Expr.LaTeX)
+}
+
+// Eval fails, as the number a quantity stands for depends on its unit;
+// package units converts quantities to a common unit
+func (q *Quantity) Eval(vars map[string]*big.Float) (*big.Float, error) {
+	return nil, fmt.Errorf("cannot evaluate quantity %s without converting its unit", q.String())
+}
+
+func (q *Quantity) Simplify() Expr {
+	return &Quantity{value: q.value.Simplify(), unit: q.unit.Clone()}
+}
+
+func (q *Quantity) Equal(other Expr) bool {
+	otherQuantity, ok := other.(*Quantity)
+	return ok && q.value.Equal(otherQuantity.value) && q.unit.Equal(otherQuantity.unit)
+}
+
+func (q *Quantity) Clone() Expr {
+	return &Quantity{value: q.value.Clone(), unit: q.unit.Clone(), source: q.source}
+}
+
+// Variables returns the variables of the value; unit symbols are not
+// variables
+func (q *Quantity) Variables() []string {
+	return q.value.Variables()
+}
+
+func (q *Quantity) Type() ExprType {
+	return TypeQuantity
+}
+
+// Value returns a copy of the number of units
+func (q *Quantity) Value() Expr {
+	return q.value.Clone()
+}
+
+// Unit returns a copy of the unit
+func (q *Quantity) Unit() Expr {
+	return q.unit.Clone()
+}
+
+// wrapSum parenthesizes the text of a value that is a sum
+func wrapSum(text string, value Expr) string {
+	switch value.Type() {
+	case TypeAdd, TypePlusMinus:
+		return "(" + text + ")"
+	}
+	return text
+}
+
+// formatUnit writes a product of unit powers, joining the factors with
+// positive powers by times and putting those with negative powers after
+// over, as in kg*m/s^2. Units with only negative powers are written as
+// they are, as in s^-1.
+func formatUnit(unit Expr, times, over string, format func(Expr) string) string {
+	factors := []Expr{unit}
+	if mul, ok := unit.(*Mul); ok {
+		factors = mul.factors
+	}
+
+	var numerator, denominator []string
+	for _, factor := range factors {
+		if pow, ok := factor.(*Pow); ok {
+			if exponent, ok := pow.exponent.(*Int); ok && exponent.value.Sign() < 0 {
+				if exponent.value.Cmp(big.NewInt(-1)) == 0 {
+					denominator = append(denominator, format(pow.base))
+				} else {
+					positive := &Pow{base: pow.base, exponent: &Int{value: new(big.Int).Neg(exponent.value)}}
+					denominator = append(denominator, format(positive))
+				}
+				continue
+			}
+		}
+		numerator = append(numerator, format(factor))
+	}
+
+	if len(numerator) == 0 {
+		return strings.Join(mapStrings(factors, format), times)
+	}
+	result := strings.Join(numerator, times)
+	for _, factor := range denominator {
+		result += over + factor
+	}
+	return result
+}
+
+// mapStrings formats each expression
+func mapStrings(exprs []Expr, format func(Expr) string) []string {
+	result := make([]string, len(exprs))
+	for i, expr := range exprs {
+		result[i] = format(expr)
+	}
+	return result
+}
@@ -0,0 +1,38 @@
+package ast
+
+import "testing"
+
+func TestQuantity(t *testing.T) {
+	acceleration := NewMul(NewUnit("kg"), NewUnit("m"), NewPow(NewUnit("s"), NewInt(-2)))
+	quantity := NewQuantity(NewVar("x"), acceleration)
+
+	if got, want := quantity.String(), "x kg*m/s^2"; got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+	if got, want := quantity.LaTeX(), "x\\,\\mathrm{kg} \\cdot \\mathrm{m}/\\mathrm{s}^{2}"; got != want {
+		t.Errorf("LaTeX() = %s, want %s", got, want)
+	}
+	if got, want := NewQuantity(NewInt(10), NewPow(NewUnit("s"), NewInt(-1))).String(), "10 s^-1"; got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+	if got, want := NewQuantity(NewAdd(NewInt(1), NewVar("x")), NewUnit("m")).String(), "(1+x) m"; got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+	if !quantity.Equal(quantity.Clone()) {
+		t.Errorf("Clone() is not equal to the original")
+	}
+	if quantity.Equal(NewQuantity(NewVar("x"), NewUnit("N"))) {
+		t.Errorf("quantities in different units should not be structurally equal")
+	}
+	if vars := quantity.Variables(); len(vars) != 1 || vars[0] != "x" {
+		t.Errorf("Variables() = %v, want [x]", vars)
+	}
+	if _, err := quantity.Eval(nil); err == nil {
+		t.Errorf("Eval() should fail for a quantity")
+	}
+
+	substituted := Substitute(quantity, "x", NewInt(3))
+	if got, want := substituted.String(), "3 kg*m/s^2"; got != want {
+		t.Errorf("Substitute() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,100 @@
+package ast
+
+// Span is the part of the source text an expression was parsed from, as
+// byte offsets into the input. End is exclusive.
+type Span struct {
+	Start int
+	End   int
+}
+
+// source records where a node was parsed from. It is embedded in every node
+// type so the span travels with the node and is copied by Clone.
+type source struct {
+	span  Span
+	known bool
+}
+
+// Span returns the source span of the node and whether one was recorded
+func (s *source) Span() (Span, bool) {
+	return s.span, s.known
+}
+
+// SetSpan records the source span of the node
+func (s *source) SetSpan(span Span) {
+	s.span, s.known = span, true
+}
+
+// Spanned is implemented by expressions that can carry a source span. Every
+// node type in this package implements it.
+type Spanned interface {
+	Span() (Span, bool)
+	SetSpan(span Span)
+}
+
+// SpanOf returns the source span of expr, if one was recorded
+func SpanOf(expr Expr) (Span, bool) {
+	if s, ok := expr.(Spanned); ok {
+		return s.Span()
+	}
+	return Span{}, false
+}
+
+// Children returns the direct subexpressions of expr in written order.
+// Unlike accessors such as Terms, the children are not cloned, so they can
+// be inspected in place, for example to read their spans.
+func Children(expr Expr) []Expr {
+	switch e := expr.(type) {
+	case *Add:
+		return append([]Expr(nil), e.terms...)
+	case *Mul:
+		return append([]Expr(nil), e.factors...)
+	case *Pow:
+		return []Expr{e.base, e.exponent}
+	case *Func:
+		return append([]Expr(nil), e.args...)
+	case *Eq:
+		return []Expr{e.left, e.right}
+	case *Chain:
+		return append([]Expr(nil), e.operands...)
+	case *PlusMinus:
+		if e.left == nil {
+			return []Expr{e.right}
+		}
+		return []Expr{e.left, e.right}
+	case *Quantity:
+		return []Expr{e.value, e.unit}
+	case *Piecewise:
+		var children []Expr
+		for i, value := range e.values {
+			children = append(children, value)
+			if e.conditions[i] != nil {
+				children = append(children, e.conditions[i])
+			}
+		}
+		return children
+	case *Angle:
+		children := []Expr{e.degrees}
+		for _, part := range []Expr{e.minutes, e.seconds} {
+			if part != nil {
+				children = append(children, part)
+			}
+		}
+		return children
+	case *Derivative:
+		return []Expr{e.expr}
+	case *Matrix:
+		var entries []Expr
+		for _, row := range e.rows {
+			entries = append(entries, row...)
+		}
+		return entries
+	case *Vector:
+		return append([]Expr(nil), e.elements...)
+	case *Tuple:
+		return append([]Expr(nil), e.elements...)
+	case *Set:
+		return append([]Expr(nil), e.elements...)
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,80 @@
+package ast
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestSpanSurvivesClone(t *testing.T) {
+	matrix, _ := NewMatrix([][]Expr{{NewInt(1)}})
+	chain, _ := NewChain([]Expr{NewInt(1), NewVar("x"), NewInt(2)}, []EqType{EqLess, EqLess})
+	nodes := []Expr{
+		NewInt(1),
+		NewFloat(0.5),
+		NewRational(1, 2),
+		NewVar("x"),
+		NewConst("c", big.NewFloat(3)),
+		NewAdd(NewVar("x"), NewInt(1)),
+		NewMul(NewInt(2), NewVar("x")),
+		NewPow(NewVar("x"), NewInt(2)),
+		NewEq(NewVar("x"), NewInt(2), EqEqual),
+		NewFunc("sin", NewVar("x")),
+		NewDerivative(NewVar("y"), "x", 1),
+		matrix,
+		NewVector(NewInt(1), NewInt(2)),
+		NewTuple(NewInt(1), NewInt(2)),
+		NewSet(NewInt(1), NewInt(2)),
+		chain,
+	}
+
+	for _, node := range nodes {
+		if _, ok := SpanOf(node); ok {
+			t.Errorf("new %s node should not have a span", node.Type())
+		}
+
+		span := Span{Start: 3, End: 7}
+		node.(Spanned).SetSpan(span)
+		if got, ok := SpanOf(node.Clone()); !ok || got != span {
+			t.Errorf("clone of %s node has span %v (%v), want %v", node.Type(), got, ok, span)
+		}
+	}
+}
+
+func TestChildren(t *testing.T) {
+	x := NewVar("x")
+	tests := []struct {
+		name     string
+		expr     Expr
+		expected []Expr
+	}{
+		{"add", NewAdd(x, NewInt(1)), []Expr{x, NewInt(1)}},
+		{"power", NewPow(x, NewInt(2)), []Expr{x, NewInt(2)}},
+		{"function", NewFunc("sin", x), []Expr{x}},
+		{"equation", NewEq(x, NewInt(2), EqEqual), []Expr{x, NewInt(2)}},
+		{"derivative", NewDerivative(x, "t", 1), []Expr{x}},
+		{"plus minus", NewPlusMinus(x, NewInt(1)), []Expr{x, NewInt(1)}},
+		{"unary plus minus", NewPlusMinus(nil, NewInt(1)), []Expr{NewInt(1)}},
+		{"leaf", x, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			children := Children(tt.expr)
+			if len(children) != len(tt.expected) {
+				t.Fatalf("Children(%s) = %v, want %v", tt.expr, children, tt.expected)
+			}
+			for i := range children {
+				if !children[i].Equal(tt.expected[i]) {
+					t.Errorf("Children(%s)[%d] = %s, want %s", tt.expr, i, children[i], tt.expected[i])
+				}
+			}
+		})
+	}
+
+	// Children are the nodes themselves, not copies
+	add := NewAdd(x, NewInt(1))
+	Children(add)[0].(Spanned).SetSpan(Span{Start: 0, End: 1})
+	if _, ok := SpanOf(x); !ok {
+		t.Errorf("Children should return the nodes of the expression")
+	}
+}
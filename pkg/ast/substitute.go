@@ -0,0 +1,133 @@
+package ast
+
+// Substitute returns a copy of expr with every occurrence of the named
+// variable replaced by value. The original expression is left unchanged.
+func Substitute(expr Expr, name string, value Expr) Expr {
+	return SubstituteAll(expr, map[string]Expr{name: value})
+}
+
+// SubstituteAll replaces several variables at once. Replacements are applied
+// simultaneously, so a value that mentions another substituted variable is
+// not rewritten a second time.
+func SubstituteAll(expr Expr, values map[string]Expr) Expr {
+	switch e := expr.(type) {
+	case *Var:
+		if value, ok := values[e.name]; ok {
+			return value.Clone()
+		}
+		return e.Clone()
+	case *Add:
+		terms := make([]Expr, len(e.terms))
+		for i, term := range e.terms {
+			terms[i] = SubstituteAll(term, values)
+		}
+		return &Add{terms: terms}
+	case *Mul:
+		factors := make([]Expr, len(e.factors))
+		for i, factor := range e.factors {
+			factors[i] = SubstituteAll(factor, values)
+		}
+		return &Mul{factors: factors}
+	case *Pow:
+		return &Pow{base: SubstituteAll(e.base, values), exponent: SubstituteAll(e.exponent, values)}
+	case *Func:
+		if bound, ok := e.BoundVariable(); ok {
+			if _, shadowed := values[bound]; shadowed {
+				return substituteBound(e, bound, values)
+			}
+		}
+		args := make([]Expr, len(e.args))
+		for i, arg := range e.args {
+			args[i] = SubstituteAll(arg, values)
+		}
+		return &Func{name: e.name, args: args}
+	case *Eq:
+		return &Eq{left: SubstituteAll(e.left, values), right: SubstituteAll(e.right, values), eqType: e.eqType}
+	case *Matrix:
+		return &Matrix{rows: mapRows(e.rows, func(entry Expr) Expr { return SubstituteAll(entry, values) })}
+	case *Vector:
+		elements := make([]Expr, len(e.elements))
+		for i, elem := range e.elements {
+			elements[i] = SubstituteAll(elem, values)
+		}
+		return &Vector{elements: elements}
+	case *Tuple:
+		return &Tuple{elements: mapElements(e.elements, func(elem Expr) Expr { return SubstituteAll(elem, values) })}
+	case *Set:
+		return &Set{elements: mapElements(e.elements, func(elem Expr) Expr { return SubstituteAll(elem, values) })}
+	case *Chain:
+		operands := mapElements(e.operands, func(operand Expr) Expr { return SubstituteAll(operand, values) })
+		return &Chain{operands: operands, relations: e.Relations()}
+	case *PlusMinus:
+		if e.left == nil {
+			return &PlusMinus{right: SubstituteAll(e.right, values)}
+		}
+		return &PlusMinus{left: SubstituteAll(e.left, values), right: SubstituteAll(e.right, values)}
+	case *Quantity:
+		return &Quantity{value: SubstituteAll(e.value, values), unit: e.unit.Clone()}
+	case *Piecewise:
+		substitute := func(part Expr) Expr {
+			if part == nil {
+				return nil
+			}
+			return SubstituteAll(part, values)
+		}
+		return &Piecewise{values: mapElements(e.values, substitute), conditions: mapElements(e.conditions, substitute)}
+	case *Angle:
+		substitute := func(part Expr) Expr {
+			if part == nil {
+				return nil
+			}
+			return SubstituteAll(part, values)
+		}
+		return &Angle{degrees: SubstituteAll(e.degrees, values), minutes: substitute(e.minutes), seconds: substitute(e.seconds)}
+	case *Derivative:
+		// An unknown derivative is replaced as a whole, keyed by its
+		// prime notation (e.g. "y'")
+		if value, ok := values[e.String()]; ok {
+			return value.Clone()
+		}
+		return e.Clone()
+	default:
+		return expr.Clone()
+	}
+}
+
+// Replace returns a copy of expr with every occurrence of target, inside
+// sums, products, powers and function arguments, replaced by replacement
+func Replace(expr, target, replacement Expr) Expr {
+	if expr.Equal(target) {
+		return replacement.Clone()
+	}
+
+	replace := func(e Expr) Expr { return Replace(e, target, replacement) }
+	switch e := expr.(type) {
+	case *Add:
+		return &Add{terms: mapElements(e.terms, replace)}
+	case *Mul:
+		return NewMul(mapElements(e.factors, replace)...)
+	case *Pow:
+		return &Pow{base: replace(e.base), exponent: replace(e.exponent)}
+	case *Func:
+		return &Func{name: e.name, args: mapElements(e.args, replace)}
+	}
+	return expr.Clone()
+}
+
+// substituteBound replaces variables in a sum or integral whose bound
+// variable is among them. The bound variable is left alone in the body and
+// replaced only in the limits.
+func substituteBound(f *Func, bound string, values map[string]Expr) Expr {
+	inner := make(map[string]Expr, len(values))
+	for name, value := range values {
+		if name != bound {
+			inner[name] = value
+		}
+	}
+
+	args := []Expr{SubstituteAll(f.args[0], inner), f.args[1].Clone()}
+	for _, limit := range f.args[2:] {
+		args = append(args, SubstituteAll(limit, values))
+	}
+	return &Func{name: f.name, args: args}
+}
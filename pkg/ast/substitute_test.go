@@ -0,0 +1,54 @@
+package ast
+
+import "testing"
+
+func TestSubstitute(t *testing.T) {
+	expr := NewAdd(NewPow(NewVar("x"), NewInt(2)), NewMul(NewInt(3), NewVar("y")))
+
+	result := Substitute(expr, "x", NewInt(4))
+	if result.String() != "4^2+3*y" {
+		t.Errorf("Substitute(x=4) = %s, want 4^2+3*y", result.String())
+	}
+
+	// The original expression must be unchanged
+	if expr.String() != "x^2+3*y" {
+		t.Errorf("Substitute modified the original expression: %s", expr.String())
+	}
+}
+
+func TestSubstituteAllIsSimultaneous(t *testing.T) {
+	expr := NewAdd(NewVar("x"), NewVar("y"))
+
+	result := SubstituteAll(expr, map[string]Expr{
+		"x": NewVar("y"),
+		"y": NewInt(1),
+	})
+	if result.String() != "y+1" {
+		t.Errorf("SubstituteAll = %s, want y+1", result.String())
+	}
+}
+
+func TestSubstituteDerivative(t *testing.T) {
+	expr := NewAdd(NewDerivative(NewVar("y"), "x", 1), NewVar("y"))
+
+	result := SubstituteAll(expr, map[string]Expr{
+		"y'": NewVar("p"),
+		"y":  NewInt(2),
+	})
+	if result.String() != "p+2" {
+		t.Errorf("SubstituteAll = %s, want p+2", result.String())
+	}
+}
+
+func TestReplace(t *testing.T) {
+	abs := NewFunc("abs", NewVar("x"))
+	expr := NewAdd(NewMul(NewInt(2), abs), NewPow(abs, NewInt(2)))
+
+	result := Replace(expr, abs, NewVar("u"))
+	if result.String() != "2*u+u^2" {
+		t.Errorf("Replace = %s, want 2*u+u^2", result.String())
+	}
+	if expr.String() != "2*abs(x)+abs(x)^2" {
+		t.Errorf("Replace modified the original expression: %s", expr.String())
+	}
+}
@@ -9,6 +9,7 @@ import (
 // Var represents a variable
 type Var struct {
 	name string
+	source
 }
 
 // NewVar creates a new variable expression
@@ -45,7 +46,7 @@ func (v *Var) Equal(other Expr) bool {
 }
 
 func (v *Var) Clone() Expr {
-	return &Var{name: v.name}
+	return &Var{name: v.name, source: v.source}
 }
 
 func (v *Var) Variables() []string {
@@ -64,6 +65,7 @@ func (v *Var) Name() string {
 type Const struct {
 	name  string
 	value *big.Float
+	source
 }
 
 // Common mathematical constants
@@ -122,8 +124,9 @@ func (c *Const) Equal(other Expr) bool {
 
 func (c *Const) Clone() Expr {
 	return &Const{
-		name:  c.name,
-		value: new(big.Float).Copy(c.value),
+		name:   c.name,
+		value:  new(big.Float).Copy(c.value),
+		source: c.source,
 	}
 }
 
@@ -147,6 +150,7 @@ func (c *Const) Value() *big.Float {
 type Func struct {
 	name string
 	args []Expr
+	source
 }
 
 // NewFunc creates a new function expression
@@ -176,10 +180,24 @@ func (f *Func) LaTeX() string {
 		if len(f.args) == 1 {
 			return fmt.Sprintf("\\log{%s}", f.args[0].LaTeX())
 		}
+		if len(f.args) == 2 {
+			return fmt.Sprintf("\\log_{%s}{%s}", f.args[1].LaTeX(), f.args[0].LaTeX())
+		}
 	case "ln":
 		if len(f.args) == 1 {
 			return fmt.Sprintf("\\ln{%s}", f.args[0].LaTeX())
 		}
+	case "sum":
+		if len(f.args) == 4 {
+			return fmt.Sprintf("\\sum_{%s=%s}^{%s}{%s}", f.args[1].LaTeX(), f.args[2].LaTeX(), f.args[3].LaTeX(), f.args[0].LaTeX())
+		}
+	case "int":
+		if len(f.args) == 2 {
+			return fmt.Sprintf("\\int{%s} d%s", f.args[0].LaTeX(), f.args[1].LaTeX())
+		}
+		if len(f.args) == 4 {
+			return fmt.Sprintf("\\int_{%s}^{%s}{%s} d%s", f.args[2].LaTeX(), f.args[3].LaTeX(), f.args[0].LaTeX(), f.args[1].LaTeX())
+		}
 	}
 
 	// Default function representation
@@ -191,6 +209,11 @@ func (f *Func) LaTeX() string {
 }
 
 func (f *Func) Eval(vars map[string]*big.Float) (*big.Float, error) {
+	// Sums and integrals evaluate their body with a bound variable
+	if bound, ok := f.BoundVariable(); ok {
+		return f.evalBound(bound, vars)
+	}
+
 	// Evaluate arguments first
 	argVals := make([]*big.Float, len(f.args))
 	for i, arg := range f.args {
@@ -246,6 +269,21 @@ func (f *Func) Eval(vars map[string]*big.Float) (*big.Float, error) {
 			return nil, fmt.Errorf("tan expects 1 argument, got %d", len(argVals))
 		}
 		return evaluateTan(argVals[0])
+	case "sec":
+		if len(argVals) != 1 {
+			return nil, fmt.Errorf("sec expects 1 argument, got %d", len(argVals))
+		}
+		return evaluateSec(argVals[0])
+	case "csc":
+		if len(argVals) != 1 {
+			return nil, fmt.Errorf("csc expects 1 argument, got %d", len(argVals))
+		}
+		return evaluateCsc(argVals[0])
+	case "cot":
+		if len(argVals) != 1 {
+			return nil, fmt.Errorf("cot expects 1 argument, got %d", len(argVals))
+		}
+		return evaluateCot(argVals[0])
 	case "arcsin", "asin":
 		if len(argVals) != 1 {
 			return nil, fmt.Errorf("arcsin expects 1 argument, got %d", len(argVals))
@@ -261,6 +299,21 @@ func (f *Func) Eval(vars map[string]*big.Float) (*big.Float, error) {
 			return nil, fmt.Errorf("arctan expects 1 argument, got %d", len(argVals))
 		}
 		return evaluateArctan(argVals[0])
+	case "arcsec", "asec":
+		if len(argVals) != 1 {
+			return nil, fmt.Errorf("arcsec expects 1 argument, got %d", len(argVals))
+		}
+		return evaluateArcsec(argVals[0])
+	case "arccsc", "acsc":
+		if len(argVals) != 1 {
+			return nil, fmt.Errorf("arccsc expects 1 argument, got %d", len(argVals))
+		}
+		return evaluateArccsc(argVals[0])
+	case "arccot", "acot":
+		if len(argVals) != 1 {
+			return nil, fmt.Errorf("arccot expects 1 argument, got %d", len(argVals))
+		}
+		return evaluateArccot(argVals[0])
 	case "sinh":
 		if len(argVals) != 1 {
 			return nil, fmt.Errorf("sinh expects 1 argument, got %d", len(argVals))
@@ -276,6 +329,36 @@ func (f *Func) Eval(vars map[string]*big.Float) (*big.Float, error) {
 			return nil, fmt.Errorf("tanh expects 1 argument, got %d", len(argVals))
 		}
 		return evaluateTanh(argVals[0])
+	case "sech":
+		if len(argVals) != 1 {
+			return nil, fmt.Errorf("sech expects 1 argument, got %d", len(argVals))
+		}
+		return evaluateSech(argVals[0])
+	case "csch":
+		if len(argVals) != 1 {
+			return nil, fmt.Errorf("csch expects 1 argument, got %d", len(argVals))
+		}
+		return evaluateCsch(argVals[0])
+	case "coth":
+		if len(argVals) != 1 {
+			return nil, fmt.Errorf("coth expects 1 argument, got %d", len(argVals))
+		}
+		return evaluateCoth(argVals[0])
+	case "arcsinh", "asinh":
+		if len(argVals) != 1 {
+			return nil, fmt.Errorf("arcsinh expects 1 argument, got %d", len(argVals))
+		}
+		return evaluateArcsinh(argVals[0])
+	case "arccosh", "acosh":
+		if len(argVals) != 1 {
+			return nil, fmt.Errorf("arccosh expects 1 argument, got %d", len(argVals))
+		}
+		return evaluateArccosh(argVals[0])
+	case "arctanh", "atanh":
+		if len(argVals) != 1 {
+			return nil, fmt.Errorf("arctanh expects 1 argument, got %d", len(argVals))
+		}
+		return evaluateArctanh(argVals[0])
 	default:
 		return nil, fmt.Errorf("unsupported function: %s", f.name)
 	}
@@ -310,10 +393,14 @@ func (f *Func) Clone() Expr {
 	for i, arg := range f.args {
 		clonedArgs[i] = arg.Clone()
 	}
-	return &Func{name: f.name, args: clonedArgs}
+	return &Func{name: f.name, args: clonedArgs, source: f.source}
 }
 
 func (f *Func) Variables() []string {
+	if bound, ok := f.BoundVariable(); ok {
+		return f.boundVariables(bound)
+	}
+
 	vars := []string{}
 	for _, arg := range f.args {
 		vars = append(vars, arg.Variables()...)
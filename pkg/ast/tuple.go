@@ -0,0 +1,191 @@
+package ast
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Tuple represents an ordered list of expressions, such as the point (2, -3)
+type Tuple struct {
+	elements []Expr
+	source
+}
+
+// NewTuple creates a tuple from its components
+func NewTuple(elements ...Expr) *Tuple {
+	return &Tuple{elements: elements}
+}
+
+func (t *Tuple) String() string {
+	return "(" + joinStrings(t.elements) + ")"
+}
+
+func (t *Tuple) LaTeX() string {
+	return "\\left(" + joinLaTeX(t.elements) + "\\right)"
+}
+
+func (t *Tuple) Eval(vars map[string]*big.Float) (*big.Float, error) {
+	return nil, fmt.Errorf("cannot evaluate tuple of length %d to a number", len(t.elements))
+}
+
+func (t *Tuple) Simplify() Expr {
+	return &Tuple{elements: mapElements(t.elements, func(e Expr) Expr { return e.Simplify() })}
+}
+
+func (t *Tuple) Equal(other Expr) bool {
+	if other.Type() != TypeTuple {
+		return false
+	}
+	otherTuple := other.(*Tuple)
+	if len(t.elements) != len(otherTuple.elements) {
+		return false
+	}
+	for i, elem := range t.elements {
+		if !elem.Equal(otherTuple.elements[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *Tuple) Clone() Expr {
+	return &Tuple{elements: t.Elements(), source: t.source}
+}
+
+func (t *Tuple) Variables() []string {
+	return elementVariables(t.elements)
+}
+
+func (t *Tuple) Type() ExprType {
+	return TypeTuple
+}
+
+// Len returns the number of components
+func (t *Tuple) Len() int {
+	return len(t.elements)
+}
+
+// At returns a copy of the i-th component (zero-based)
+func (t *Tuple) At(i int) Expr {
+	return t.elements[i].Clone()
+}
+
+// Elements returns a copy of the components
+func (t *Tuple) Elements() []Expr {
+	return mapElements(t.elements, func(e Expr) Expr { return e.Clone() })
+}
+
+// Set represents an unordered collection of expressions, such as the
+// solution list x = 2, x = -1
+type Set struct {
+	elements []Expr
+	source
+}
+
+// NewSet creates a set from its members. Members keep their written order
+// for display but order is ignored by Equal.
+func NewSet(elements ...Expr) *Set {
+	return &Set{elements: elements}
+}
+
+func (s *Set) String() string {
+	return "{" + joinStrings(s.elements) + "}"
+}
+
+func (s *Set) LaTeX() string {
+	return "\\left\\{" + joinLaTeX(s.elements) + "\\right\\}"
+}
+
+func (s *Set) Eval(vars map[string]*big.Float) (*big.Float, error) {
+	return nil, fmt.Errorf("cannot evaluate set of %d elements to a number", len(s.elements))
+}
+
+func (s *Set) Simplify() Expr {
+	return &Set{elements: mapElements(s.elements, func(e Expr) Expr { return e.Simplify() })}
+}
+
+// Equal reports whether both sets contain structurally equal members,
+// regardless of order or repetition
+func (s *Set) Equal(other Expr) bool {
+	if other.Type() != TypeSet {
+		return false
+	}
+	otherSet := other.(*Set)
+	return containsAll(s.elements, otherSet.elements) && containsAll(otherSet.elements, s.elements)
+}
+
+func (s *Set) Clone() Expr {
+	return &Set{elements: s.Elements(), source: s.source}
+}
+
+func (s *Set) Variables() []string {
+	return elementVariables(s.elements)
+}
+
+func (s *Set) Type() ExprType {
+	return TypeSet
+}
+
+// Len returns the number of members as written
+func (s *Set) Len() int {
+	return len(s.elements)
+}
+
+// Elements returns a copy of the members in written order
+func (s *Set) Elements() []Expr {
+	return mapElements(s.elements, func(e Expr) Expr { return e.Clone() })
+}
+
+// containsAll reports whether every element of a is equal to some element of b
+func containsAll(a, b []Expr) bool {
+	for _, x := range a {
+		found := false
+		for _, y := range b {
+			if x.Equal(y) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// mapElements applies fn to every element, returning a new slice
+func mapElements(elements []Expr, fn func(Expr) Expr) []Expr {
+	result := make([]Expr, len(elements))
+	for i, elem := range elements {
+		result[i] = fn(elem)
+	}
+	return result
+}
+
+// elementVariables collects the variables of all elements
+func elementVariables(elements []Expr) []string {
+	vars := []string{}
+	for _, elem := range elements {
+		vars = append(vars, elem.Variables()...)
+	}
+	return removeDuplicates(vars)
+}
+
+// joinStrings renders elements separated by commas
+func joinStrings(elements []Expr) string {
+	strs := make([]string, len(elements))
+	for i, elem := range elements {
+		strs[i] = elem.String()
+	}
+	return strings.Join(strs, ", ")
+}
+
+// joinLaTeX renders elements as LaTeX separated by commas
+func joinLaTeX(elements []Expr) string {
+	strs := make([]string, len(elements))
+	for i, elem := range elements {
+		strs[i] = elem.LaTeX()
+	}
+	return strings.Join(strs, ", ")
+}
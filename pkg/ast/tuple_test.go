@@ -0,0 +1,61 @@
+package ast
+
+import "testing"
+
+func TestTuple(t *testing.T) {
+	point := NewTuple(NewInt(2), NewInt(-3))
+
+	if got, want := point.String(), "(2, -3)"; got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+	if got, want := point.LaTeX(), "\\left(2, -3\\right)"; got != want {
+		t.Errorf("LaTeX() = %s, want %s", got, want)
+	}
+	if !point.Equal(point.Clone()) {
+		t.Errorf("Clone() is not equal to the original")
+	}
+	if point.Equal(NewTuple(NewInt(-3), NewInt(2))) {
+		t.Errorf("tuples with swapped components should differ")
+	}
+	if point.Equal(NewSet(NewInt(2), NewInt(-3))) {
+		t.Errorf("a tuple should not equal a set")
+	}
+	if _, err := point.Eval(nil); err == nil {
+		t.Errorf("Eval() should fail for a tuple")
+	}
+
+	substituted := Substitute(NewTuple(NewVar("x"), NewInt(1)), "x", NewInt(5))
+	if got, want := substituted.String(), "(5, 1)"; got != want {
+		t.Errorf("Substitute() = %s, want %s", got, want)
+	}
+}
+
+func TestSet(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     *Set
+		expected bool
+	}{
+		{"same order", NewSet(NewInt(1), NewInt(2)), NewSet(NewInt(1), NewInt(2)), true},
+		{"different order", NewSet(NewInt(1), NewInt(2)), NewSet(NewInt(2), NewInt(1)), true},
+		{"repeated member", NewSet(NewInt(1), NewInt(1)), NewSet(NewInt(1)), true},
+		{"different members", NewSet(NewInt(1), NewInt(2)), NewSet(NewInt(1), NewInt(3)), false},
+		{"subset", NewSet(NewInt(1)), NewSet(NewInt(1), NewInt(2)), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.Equal(tt.b); got != tt.expected {
+				t.Errorf("%s.Equal(%s) = %v, want %v", tt.a, tt.b, got, tt.expected)
+			}
+		})
+	}
+
+	solutions := NewSet(NewEq(NewVar("x"), NewInt(2), EqEqual), NewEq(NewVar("x"), NewInt(-1), EqEqual))
+	if got, want := solutions.String(), "{x=2, x=-1}"; got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+	if vars := solutions.Variables(); len(vars) != 1 || vars[0] != "x" {
+		t.Errorf("Variables() = %v, want [x]", vars)
+	}
+}
@@ -56,6 +56,20 @@ func differentiate(expr ast.Expr, variable string) (ast.Expr, error) {
 		// d/dx(f(g)) = f'(g) * g' (chain rule)
 		return differentiateFunc(e, variable)
 
+	case *ast.Piecewise:
+		// Each case is differentiated on its own region
+		return differentiatePiecewise(e, variable)
+
+	case *ast.Derivative:
+		// d/dx(y') = y'', other variables are held constant
+		if e.Variable() == variable {
+			return ast.NewDerivative(e.Operand(), variable, e.Order()+1), nil
+		}
+		if !containsVariable(e, variable) {
+			return ast.NewInt(0), nil
+		}
+		return nil, fmt.Errorf("cannot differentiate %s with respect to %s", e.String(), variable)
+
 	default:
 		return nil, fmt.Errorf("cannot differentiate expression of type %T", expr)
 	}
@@ -78,6 +92,21 @@ func differentiateAdd(add *ast.Add, variable string) (ast.Expr, error) {
 	return simplify.Collect(result), nil
 }
 
+// differentiatePiecewise differentiates the value of each case and keeps
+// its condition. The result says nothing about the points where the cases
+// meet, where the function may not be differentiable.
+func differentiatePiecewise(pw *ast.Piecewise, variable string) (ast.Expr, error) {
+	values := pw.Values()
+	for i, value := range values {
+		derivative, err := differentiate(value, variable)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = derivative
+	}
+	return ast.NewPiecewise(values, pw.Conditions()), nil
+}
+
 // differentiateMul handles multiplication (product rule)
 func differentiateMul(mul *ast.Mul, variable string) (ast.Expr, error) {
 	factors := mul.Terms()
@@ -202,6 +231,9 @@ func differentiatePow(pow *ast.Pow, variable string) (ast.Expr, error) {
 // differentiateFunc handles function derivatives (chain rule)
 func differentiateFunc(fn *ast.Func, variable string) (ast.Expr, error) {
 	args := fn.Args()
+	if fn.Name() == "log" && len(args) == 2 {
+		return differentiateLogBase(args[0], args[1], variable)
+	}
 	if len(args) != 1 {
 		return nil, fmt.Errorf("differentiation of multi-argument functions not yet supported")
 	}
@@ -223,6 +255,25 @@ func differentiateFunc(fn *ast.Func, variable string) (ast.Expr, error) {
 	return simplify.Collect(result), nil
 }
 
+// differentiateLogBase differentiates log_b(u), the two-argument log(u, b)
+func differentiateLogBase(arg, base ast.Expr, variable string) (ast.Expr, error) {
+	if containsVariable(base, variable) {
+		// Change of base: log_b(u) = ln(u)/ln(b)
+		quotient := ast.NewMul(ast.NewFunc("ln", arg), ast.NewPow(ast.NewFunc("ln", base), ast.NewInt(-1)))
+		return differentiate(quotient, variable)
+	}
+
+	argPrime, err := differentiate(arg, variable)
+	if err != nil {
+		return nil, err
+	}
+
+	// d/dx(log_b(u)) = u'/(u * ln(b))
+	denominator := ast.NewMul(arg, ast.NewFunc("ln", base))
+	result := ast.NewMul(ast.NewPow(denominator, ast.NewInt(-1)), argPrime)
+	return simplify.Collect(result), nil
+}
+
 // getFunctionDerivative returns the derivative of standard mathematical functions
 func getFunctionDerivative(funcName string, arg ast.Expr) (ast.Expr, error) {
 	switch funcName {
@@ -279,6 +330,21 @@ func getFunctionDerivative(funcName string, arg ast.Expr) (ast.Expr, error) {
 		onePlusUSquared := ast.NewAdd(ast.NewInt(1), uSquared)
 		return ast.NewPow(onePlusUSquared, ast.NewInt(-1)), nil
 
+	case "arcsec":
+		// d/dx(arcsec(u)) = 1/(|u|√(u²-1))
+		return ast.NewPow(ast.NewMul(ast.NewFunc("abs", arg), sqrtOfSquareMinusOne(arg)), ast.NewInt(-1)), nil
+
+	case "arccsc":
+		// d/dx(arccsc(u)) = -1/(|u|√(u²-1))
+		denominator := ast.NewMul(ast.NewFunc("abs", arg), sqrtOfSquareMinusOne(arg))
+		return ast.NewMul(ast.NewInt(-1), ast.NewPow(denominator, ast.NewInt(-1))), nil
+
+	case "arccot":
+		// d/dx(arccot(u)) = -1/(1+u²)
+		uSquared := ast.NewPow(arg, ast.NewInt(2))
+		onePlusUSquared := ast.NewAdd(ast.NewInt(1), uSquared)
+		return ast.NewMul(ast.NewInt(-1), ast.NewPow(onePlusUSquared, ast.NewInt(-1))), nil
+
 	case "sinh":
 		// d/dx(sinh(u)) = cosh(u)
 		return ast.NewFunc("cosh", arg), nil
@@ -293,6 +359,40 @@ func getFunctionDerivative(funcName string, arg ast.Expr) (ast.Expr, error) {
 		coshSquared := ast.NewPow(cosh, ast.NewInt(2))
 		return ast.NewPow(coshSquared, ast.NewInt(-1)), nil
 
+	case "sech":
+		// d/dx(sech(u)) = -sech(u)tanh(u)
+		sech := ast.NewFunc("sech", arg)
+		tanh := ast.NewFunc("tanh", arg)
+		return ast.NewMul(ast.NewInt(-1), sech, tanh), nil
+
+	case "csch":
+		// d/dx(csch(u)) = -csch(u)coth(u)
+		csch := ast.NewFunc("csch", arg)
+		coth := ast.NewFunc("coth", arg)
+		return ast.NewMul(ast.NewInt(-1), csch, coth), nil
+
+	case "coth":
+		// d/dx(coth(u)) = -csch²(u)
+		csch := ast.NewFunc("csch", arg)
+		cschSquared := ast.NewPow(csch, ast.NewInt(2))
+		return ast.NewMul(ast.NewInt(-1), cschSquared), nil
+
+	case "arcsinh":
+		// d/dx(arcsinh(u)) = 1/√(u²+1)
+		uSquared := ast.NewPow(arg, ast.NewInt(2))
+		sqrt := ast.NewFunc("sqrt", ast.NewAdd(uSquared, ast.NewInt(1)))
+		return ast.NewPow(sqrt, ast.NewInt(-1)), nil
+
+	case "arccosh":
+		// d/dx(arccosh(u)) = 1/√(u²-1)
+		return ast.NewPow(sqrtOfSquareMinusOne(arg), ast.NewInt(-1)), nil
+
+	case "arctanh":
+		// d/dx(arctanh(u)) = 1/(1-u²)
+		uSquared := ast.NewPow(arg, ast.NewInt(2))
+		oneMinusUSquared := ast.NewAdd(ast.NewInt(1), ast.NewMul(ast.NewInt(-1), uSquared))
+		return ast.NewPow(oneMinusUSquared, ast.NewInt(-1)), nil
+
 	case "ln":
 		// d/dx(ln(u)) = 1/u
 		return ast.NewPow(arg, ast.NewInt(-1)), nil
@@ -323,6 +423,12 @@ func getFunctionDerivative(funcName string, arg ast.Expr) (ast.Expr, error) {
 	}
 }
 
+// sqrtOfSquareMinusOne builds √(u²-1)
+func sqrtOfSquareMinusOne(arg ast.Expr) ast.Expr {
+	uSquared := ast.NewPow(arg, ast.NewInt(2))
+	return ast.NewFunc("sqrt", ast.NewAdd(uSquared, ast.NewInt(-1)))
+}
+
 // containsVariable checks if an expression contains a specific variable
 func containsVariable(expr ast.Expr, variable string) bool {
 	variables := expr.Variables()
@@ -1,6 +1,7 @@
 package calculus
 
 import (
+	"math"
 	"testing"
 
 	"github.com/quizizz/cas/pkg/ast"
@@ -307,6 +308,9 @@ func TestHyperbolicDerivatives(t *testing.T) {
 		{"sinh derivative", ast.NewFunc("sinh", ast.NewVar("x")), "cosh(x)"},
 		{"cosh derivative", ast.NewFunc("cosh", ast.NewVar("x")), "sinh(x)"},
 		{"tanh derivative", ast.NewFunc("tanh", ast.NewVar("x")), "cosh(x)^(-2)"},
+		{"sech derivative", ast.NewFunc("sech", ast.NewVar("x")), "-sech(x)tanh(x)"},
+		{"csch derivative", ast.NewFunc("csch", ast.NewVar("x")), "-csch(x)coth(x)"},
+		{"coth derivative", ast.NewFunc("coth", ast.NewVar("x")), "-csch(x)^2"},
 	}
 
 	for _, tt := range tests {
@@ -373,6 +377,54 @@ func TestInverseTrignometricDerivatives(t *testing.T) {
 	}
 }
 
+func TestReciprocalAndInverseDerivatives(t *testing.T) {
+	checkDerivativesNumerically(t, []string{
+		"sec(x)", "csc(x)", "cot(x)", "sec(x^2)",
+		"arcsec(x)", "arccsc(x)", "arccot(x)", "arcsec(-x)",
+		"arcsinh(x)", "arccosh(x)", "arctanh(x/3)",
+		"\\sech x", "\\csch(x^2)", "\\coth 2x",
+	})
+}
+
+func TestLogBaseDerivatives(t *testing.T) {
+	checkDerivativesNumerically(t, []string{
+		"\\log_2 x", "\\log_{3}(x^2+1)", "\\log_b(x)", "\\log_x 5", "\\log_x(x^3)",
+	})
+
+	expr, _ := parser.Parse("\\log_2 x")
+	derivative, err := Derivative(expr, "x")
+	if err != nil {
+		t.Fatalf("Derivative(%s) error: %v", expr, err)
+	}
+	if got, want := derivative.String(), "(x*ln(2))^-1"; got != want {
+		t.Errorf("d/dx(%s) = %s, expected %s", expr, got, want)
+	}
+}
+
+// checkDerivativesNumerically checks the derivative of each input, which is
+// defined at x = 1.5 (and b = 2), against a central difference
+func checkDerivativesNumerically(t *testing.T, inputs []string) {
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			expr, err := parser.Parse(input)
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+			derivative, err := Derivative(expr, "x")
+			if err != nil {
+				t.Fatalf("Derivative(%s) error: %v", expr, err)
+			}
+
+			const x, h = 1.5, 1e-6
+			at := func(x float64) map[string]float64 { return map[string]float64{"x": x, "b": 2} }
+			want := (evalAt(t, expr, at(x+h)) - evalAt(t, expr, at(x-h))) / (2 * h)
+			if got := evalAt(t, derivative, at(x)); math.Abs(got-want) > 1e-6 {
+				t.Errorf("d/dx(%s) = %s = %v at x = %v, expected %v", expr, derivative, got, x, want)
+			}
+		})
+	}
+}
+
 func TestNthDerivative(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -573,6 +625,27 @@ func TestEdgeCases(t *testing.T) {
 	}
 }
 
+func TestAbsDerivative(t *testing.T) {
+	// d/dx |u| = u/|u| * u', away from u = 0
+	checkDerivativesNumerically(t, []string{
+		"abs(x)", "abs(x-2)", "abs(-3*x)", "abs(x^2-4)", "abs(x^2+1)", "abs(x)^3", "ln(abs(x-2))",
+	})
+}
+
+func TestPiecewiseDerivative(t *testing.T) {
+	expr, err := parser.Parse(`\begin{cases} x^2 & x < 0 \\ \sin x & \text{otherwise} \end{cases}`)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	result, err := Derivative(expr, "x")
+	if err != nil {
+		t.Fatalf("Derivative error: %v", err)
+	}
+	if got, want := result.String(), "{2*x if x<0; cos(x) otherwise}"; got != want {
+		t.Errorf("d/dx(%s) = %s, want %s", expr, got, want)
+	}
+}
+
 func TestComplexExpressions(t *testing.T) {
 	tests := []struct {
 		name string
@@ -0,0 +1,376 @@
+package calculus
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/quizizz/cas/pkg/ast"
+	"github.com/quizizz/cas/pkg/expand"
+	"github.com/quizizz/cas/pkg/simplify"
+)
+
+// maxIntegrationDepth bounds how often an integrand may be rewritten by
+// expansion or simplification before giving up
+const maxIntegrationDepth = 8
+
+// Integrate computes an antiderivative of expr with respect to variable.
+// The constant of integration is omitted. Supported integrands are sums and
+// constant multiples of powers, exponentials and trigonometric functions of a
+// linear argument, along with products of a polynomial and such a function.
+func Integrate(expr ast.Expr, variable string) (ast.Expr, error) {
+	integral, err := integrate(expr, variable, 0)
+	if err != nil {
+		return nil, err
+	}
+	return simplify.Simplify(integral), nil
+}
+
+// integrate implements the integration rules
+func integrate(expr ast.Expr, variable string, depth int) (ast.Expr, error) {
+	if depth > maxIntegrationDepth {
+		return nil, fmt.Errorf("cannot integrate %s with respect to %s", expr.String(), variable)
+	}
+
+	// ∫c dx = c*x
+	if !containsVariable(expr, variable) {
+		return ast.NewMul(expr, ast.NewVar(variable)), nil
+	}
+
+	switch e := expr.(type) {
+	case *ast.Var:
+		// ∫x dx = x^2/2
+		return ast.NewMul(ast.NewRational(1, 2), ast.NewPow(e, ast.NewInt(2))), nil
+
+	case *ast.Add:
+		// ∫(f + g) dx = ∫f dx + ∫g dx
+		terms := e.Terms()
+		integrals := make([]ast.Expr, len(terms))
+		for i, term := range terms {
+			integral, err := integrate(term, variable, depth)
+			if err != nil {
+				return nil, err
+			}
+			integrals[i] = integral
+		}
+		return ast.NewAdd(integrals...), nil
+
+	case *ast.Mul:
+		return integrateMul(e, variable, depth)
+
+	case *ast.Pow:
+		return integratePow(e, variable, depth)
+
+	case *ast.Func:
+		return integrateFunc(e, variable, depth)
+
+	default:
+		return nil, fmt.Errorf("cannot integrate expression of type %T", expr)
+	}
+}
+
+// integrateMul pulls out constant factors and handles the remaining product
+func integrateMul(mul *ast.Mul, variable string, depth int) (ast.Expr, error) {
+	var constants, dependent []ast.Expr
+	for _, factor := range mul.Terms() {
+		if containsVariable(factor, variable) {
+			dependent = append(dependent, factor)
+		} else {
+			constants = append(constants, factor)
+		}
+	}
+
+	var integral ast.Expr
+	var err error
+	switch {
+	case len(dependent) == 1:
+		integral, err = integrate(dependent[0], variable, depth)
+	case len(dependent) == 2 && isPolynomialIn(dependent[0], variable) && isTabularFactor(dependent[1], variable):
+		integral, err = integrateByParts(dependent[0], dependent[1], variable, depth)
+	case len(dependent) == 2 && isPolynomialIn(dependent[1], variable) && isTabularFactor(dependent[0], variable):
+		integral, err = integrateByParts(dependent[1], dependent[0], variable, depth)
+	default:
+		return integrateRewritten(mul, variable, depth)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(constants) == 0 {
+		return integral, nil
+	}
+	return ast.NewMul(append(constants, integral)...), nil
+}
+
+// integrateRewritten retries with an expanded or simplified integrand, so
+// that x*(x+1) becomes x^2+x and x*x^2 becomes x^3
+func integrateRewritten(expr ast.Expr, variable string, depth int) (ast.Expr, error) {
+	if expanded := expand.Expand(expr); expanded.String() != expr.String() {
+		if integral, err := integrate(expanded, variable, depth+1); err == nil {
+			return integral, nil
+		}
+	}
+	if simplified := simplify.Simplify(expr); simplified.String() != expr.String() {
+		return integrate(simplified, variable, depth+1)
+	}
+	return nil, fmt.Errorf("cannot integrate %s with respect to %s", expr.String(), variable)
+}
+
+// integratePow handles powers of a linear expression and exponentials
+func integratePow(pow *ast.Pow, variable string, depth int) (ast.Expr, error) {
+	base, exponent := pow.Base(), pow.Exponent()
+
+	if !containsVariable(exponent, variable) {
+		slope, ok := linearSlope(base, variable)
+		if !ok {
+			if arctan, ok := integrateReciprocalQuadratic(base, exponent, variable); ok {
+				return arctan, nil
+			}
+			return integrateRewritten(pow, variable, depth)
+		}
+
+		if n, ok := ast.ConstantValue(exponent); ok && n == -1 {
+			// ∫1/u dx = ln|u|/a
+			return overSlope(ast.NewFunc("ln", ast.NewFunc("abs", base)), slope), nil
+		}
+
+		// ∫u^n dx = u^(n+1)/((n+1)a)
+		raised := simplify.Simplify(ast.NewAdd(exponent, ast.NewInt(1)))
+		return overSlope(ast.NewPow(base, raised), ast.NewMul(raised, slope)), nil
+	}
+
+	if !containsVariable(base, variable) {
+		slope, ok := linearSlope(exponent, variable)
+		if !ok {
+			return nil, fmt.Errorf("cannot integrate %s with respect to %s", pow.String(), variable)
+		}
+
+		// ∫e^u dx = e^u/a and ∫b^u dx = b^u/(a ln b)
+		if c, isConst := base.(*ast.Const); !isConst || c.Name() != ast.E.Name() {
+			slope = ast.NewMul(slope, ast.NewFunc("ln", base))
+		}
+		return overSlope(pow.Clone(), slope), nil
+	}
+
+	return nil, fmt.Errorf("cannot integrate %s with respect to %s", pow.String(), variable)
+}
+
+// integrateReciprocalQuadratic handles ∫1/(k*x^2 + c) dx = arctan(x*sqrt(k/c))/sqrt(k*c)
+// for positive k and c
+func integrateReciprocalQuadratic(base, exponent ast.Expr, variable string) (ast.Expr, bool) {
+	if n, ok := ast.ConstantValue(exponent); !ok || n != -1 {
+		return nil, false
+	}
+
+	first, err := differentiate(base, variable)
+	if err != nil {
+		return nil, false
+	}
+	second, err := differentiate(first, variable)
+	if err != nil {
+		return nil, false
+	}
+	second = simplify.Simplify(second)
+	if containsVariable(second, variable) {
+		return nil, false
+	}
+
+	zero := ast.NewInt(0)
+	c := exactConstant(ast.Substitute(base, variable, zero))
+	slopeAtZero, ok := ast.ConstantValue(ast.Substitute(first, variable, zero))
+	if !ok || math.Abs(slopeAtZero) > 1e-9 {
+		return nil, false
+	}
+	k := exactConstant(ast.NewMul(ast.NewRational(1, 2), second))
+
+	kValue, kOk := ast.ConstantValue(k)
+	cValue, cOk := ast.ConstantValue(c)
+	if !kOk || !cOk || kValue <= 0 || cValue <= 0 {
+		return nil, false
+	}
+
+	factor := exactConstant(ast.NewFunc("sqrt", ast.NewMul(k, ast.NewPow(c, ast.NewInt(-1)))))
+	arctan := ast.NewFunc("arctan", scale(factor, ast.NewVar(variable)))
+	return ast.NewMul(exactConstant(ast.NewPow(ast.NewFunc("sqrt", ast.NewMul(k, c)), ast.NewInt(-1))), arctan), true
+}
+
+// integrateFunc handles elementary functions of a linear argument
+func integrateFunc(fn *ast.Func, variable string, depth int) (ast.Expr, error) {
+	args := fn.Args()
+	if len(args) != 1 {
+		return nil, fmt.Errorf("integration of multi-argument functions not supported")
+	}
+
+	arg := args[0]
+	if fn.Name() == "sqrt" {
+		return integratePow(ast.NewPow(arg, ast.NewRational(1, 2)), variable, depth)
+	}
+
+	slope, ok := linearSlope(arg, variable)
+	if !ok {
+		return nil, fmt.Errorf("cannot integrate %s with respect to %s", fn.String(), variable)
+	}
+
+	var antiderivative ast.Expr
+	switch fn.Name() {
+	case "sin":
+		// ∫sin(u) dx = -cos(u)/a
+		antiderivative = ast.NewMul(ast.NewInt(-1), ast.NewFunc("cos", arg))
+	case "cos":
+		// ∫cos(u) dx = sin(u)/a
+		antiderivative = ast.NewFunc("sin", arg)
+	case "tan":
+		// ∫tan(u) dx = -ln|cos(u)|/a
+		antiderivative = ast.NewMul(ast.NewInt(-1), ast.NewFunc("ln", ast.NewFunc("abs", ast.NewFunc("cos", arg))))
+	case "sec":
+		// ∫sec(u) dx = ln|sec(u)+tan(u)|/a
+		antiderivative = ast.NewFunc("ln", ast.NewFunc("abs", ast.NewAdd(ast.NewFunc("sec", arg), ast.NewFunc("tan", arg))))
+	case "csc":
+		// ∫csc(u) dx = -ln|csc(u)+cot(u)|/a
+		antiderivative = ast.NewMul(ast.NewInt(-1), ast.NewFunc("ln", ast.NewFunc("abs", ast.NewAdd(ast.NewFunc("csc", arg), ast.NewFunc("cot", arg)))))
+	case "cot":
+		// ∫cot(u) dx = ln|sin(u)|/a
+		antiderivative = ast.NewFunc("ln", ast.NewFunc("abs", ast.NewFunc("sin", arg)))
+	case "sinh":
+		antiderivative = ast.NewFunc("cosh", arg)
+	case "cosh":
+		antiderivative = ast.NewFunc("sinh", arg)
+	case "exp":
+		antiderivative = ast.NewPow(ast.E, arg)
+	default:
+		return nil, fmt.Errorf("integral of function %s not implemented", fn.Name())
+	}
+
+	return overSlope(antiderivative, slope), nil
+}
+
+// integrateByParts applies repeated integration by parts to poly*g, where
+// poly is a polynomial and g can be integrated indefinitely (tabular method)
+func integrateByParts(poly, g ast.Expr, variable string, depth int) (ast.Expr, error) {
+	var terms []ast.Expr
+	sign := int64(1)
+	current := g
+
+	for i := 0; i <= maxIntegrationDepth; i++ {
+		integral, err := integrate(current, variable, depth)
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, ast.NewMul(ast.NewInt(sign), poly.Clone(), integral.Clone()))
+
+		derivative, err := differentiate(poly, variable)
+		if err != nil {
+			return nil, err
+		}
+		poly = simplify.Simplify(derivative)
+		if value, ok := ast.ConstantValue(poly); ok && value == 0 {
+			return ast.NewAdd(terms...), nil
+		}
+
+		current = integral
+		sign = -sign
+	}
+
+	return nil, fmt.Errorf("polynomial degree too high for integration by parts")
+}
+
+// overSlope divides an antiderivative by the chain-rule factor, skipping a
+// factor of one
+func overSlope(expr, slope ast.Expr) ast.Expr {
+	value, ok := ast.ConstantValue(slope)
+	if ok && value == 1 {
+		return expr
+	}
+	if ok {
+		return ast.NewMul(exactConstant(ast.NewPow(slope, ast.NewInt(-1))), expr)
+	}
+	return ast.NewMul(ast.NewPow(slope, ast.NewInt(-1)), expr)
+}
+
+// exactConstant folds a constant expression into an Int or a
+// small-denominator Rational when its value allows it
+func exactConstant(expr ast.Expr) ast.Expr {
+	value, ok := ast.ConstantValue(expr)
+	if !ok || math.Abs(value) > 1e15 {
+		return simplify.Simplify(expr)
+	}
+	if rounded := math.Round(value); math.Abs(value-rounded) < 1e-9 {
+		return ast.NewInt(int64(rounded))
+	}
+	for den := int64(2); den <= 1000; den++ {
+		num := math.Round(value * float64(den))
+		if math.Abs(value-num/float64(den)) < 1e-12 {
+			return ast.NewRational(int64(num), den)
+		}
+	}
+	return simplify.Simplify(expr)
+}
+
+// linearSlope returns a when expr = a*x + b for constant a ≠ 0
+func linearSlope(expr ast.Expr, variable string) (ast.Expr, bool) {
+	derivative, err := differentiate(expr, variable)
+	if err != nil {
+		return nil, false
+	}
+	slope := simplify.Simplify(derivative)
+	if containsVariable(slope, variable) {
+		return nil, false
+	}
+	if value, ok := ast.ConstantValue(slope); ok && value == 0 {
+		return nil, false
+	}
+	return slope, true
+}
+
+// isPolynomialIn reports whether expr is a polynomial in variable
+func isPolynomialIn(expr ast.Expr, variable string) bool {
+	if !containsVariable(expr, variable) {
+		return true
+	}
+	switch e := expr.(type) {
+	case *ast.Var:
+		return true
+	case *ast.Add:
+		for _, term := range e.Terms() {
+			if !isPolynomialIn(term, variable) {
+				return false
+			}
+		}
+		return true
+	case *ast.Mul:
+		for _, factor := range e.Terms() {
+			if !isPolynomialIn(factor, variable) {
+				return false
+			}
+		}
+		return true
+	case *ast.Pow:
+		n, ok := ast.ConstantValue(e.Exponent())
+		return ok && n >= 0 && n == math.Trunc(n) && isPolynomialIn(e.Base(), variable)
+	default:
+		return false
+	}
+}
+
+// isTabularFactor reports whether expr can be integrated repeatedly without
+// growing, i.e. an exponential, sine or cosine of a linear argument
+func isTabularFactor(expr ast.Expr, variable string) bool {
+	switch e := expr.(type) {
+	case *ast.Pow:
+		if containsVariable(e.Base(), variable) {
+			return false
+		}
+		_, ok := linearSlope(e.Exponent(), variable)
+		return ok
+	case *ast.Func:
+		switch e.Name() {
+		case "sin", "cos", "exp", "sinh", "cosh":
+			args := e.Args()
+			if len(args) != 1 {
+				return false
+			}
+			_, ok := linearSlope(args[0], variable)
+			return ok
+		}
+	}
+	return false
+}
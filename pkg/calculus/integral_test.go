@@ -0,0 +1,80 @@
+package calculus
+
+import (
+	"math"
+	"testing"
+
+	"github.com/quizizz/cas/pkg/parser"
+)
+
+func TestIntegrate(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+	}{
+		{"constant", "5"},
+		{"variable", "x"},
+		{"power", "x^3"},
+		{"polynomial", "3*x^2+2*x+1"},
+		{"reciprocal", "1/x"},
+		{"reciprocal of linear", "1/(2*x+1)"},
+		{"negative power", "x^-2"},
+		{"sqrt", "sqrt(x)"},
+		{"exponential", "e^(2*x)"},
+		{"other base", "2^x"},
+		{"sine", "sin(3*x)"},
+		{"cosine", "cos(x)"},
+		{"tangent", "tan(x)"},
+		{"secant", "sec(2*x)"},
+		{"cosecant", "csc(x)"},
+		{"cotangent", "cot(x)"},
+		{"arctangent form", "1/(x^2+4)"},
+		{"product to expand", "x*(x+1)"},
+		{"powers to collect", "x*x^2"},
+		{"by parts exponential", "x*e^x"},
+		{"by parts repeated", "x^2*cos(x)"},
+		{"by parts sine", "x*sin(2*x)"},
+		{"other variable", "y*x"},
+	}
+
+	points := []float64{0.4, 0.9, 1.7}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := parser.Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+
+			integral, err := Integrate(expr, "x")
+			if err != nil {
+				t.Fatalf("Integrate(%s) error: %v", tt.expr, err)
+			}
+
+			// The derivative of the antiderivative must be the integrand
+			derivative, err := Derivative(integral, "x")
+			if err != nil {
+				t.Fatalf("Derivative(%s) error: %v", integral, err)
+			}
+			for _, x := range points {
+				point := map[string]float64{"x": x, "y": 2.5}
+				want := evalAt(t, expr, point)
+				if got := evalAt(t, derivative, point); math.Abs(got-want) > 1e-9 {
+					t.Errorf("d/dx(%s) = %v at x = %v, expected %v", integral, got, x, want)
+				}
+			}
+		})
+	}
+}
+
+func TestIntegrateUnsupported(t *testing.T) {
+	for _, input := range []string{"e^(x^2)", "sin(x^2)", "x^x"} {
+		expr, err := parser.Parse(input)
+		if err != nil {
+			t.Fatalf("Parse error: %v", err)
+		}
+		if result, err := Integrate(expr, "x"); err == nil {
+			t.Errorf("Integrate(%s) = %s, expected an error", input, result)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package calculus
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/quizizz/cas/pkg/ast"
+	"github.com/quizizz/cas/pkg/simplify"
+	"github.com/quizizz/cas/pkg/solve"
+)
+
+// CriticalPointKind classifies a critical point using the second-derivative test
+type CriticalPointKind int
+
+const (
+	// Inconclusive means the Hessian is singular and the test gives no answer
+	Inconclusive CriticalPointKind = iota
+	LocalMinimum
+	LocalMaximum
+	SaddlePoint
+)
+
+// String returns a readable name for the classification
+func (k CriticalPointKind) String() string {
+	switch k {
+	case LocalMinimum:
+		return "local minimum"
+	case LocalMaximum:
+		return "local maximum"
+	case SaddlePoint:
+		return "saddle point"
+	default:
+		return "inconclusive"
+	}
+}
+
+// CriticalPoint is a point where the gradient vanishes
+type CriticalPoint struct {
+	// Point maps each variable to its coordinate
+	Point map[string]ast.Expr
+	// Kind is the result of the second-derivative test
+	Kind CriticalPointKind
+	// Hessian is the Hessian matrix evaluated at the point
+	Hessian [][]ast.Expr
+}
+
+// Jacobian computes the matrix of first partial derivatives J[i][j] = d(exprs[i])/d(variables[j])
+func Jacobian(exprs []ast.Expr, variables []string) ([][]ast.Expr, error) {
+	jacobian := make([][]ast.Expr, len(exprs))
+
+	for i, expr := range exprs {
+		row := make([]ast.Expr, len(variables))
+		for j, variable := range variables {
+			partial, err := PartialDerivative(expr, variable)
+			if err != nil {
+				return nil, fmt.Errorf("error computing partial derivative of %s with respect to %s: %v", expr.String(), variable, err)
+			}
+			row[j] = partial
+		}
+		jacobian[i] = row
+	}
+
+	return jacobian, nil
+}
+
+// Hessian computes the matrix of second partial derivatives H[i][j] = d²f/(d(variables[i]) d(variables[j]))
+func Hessian(expr ast.Expr, variables []string) ([][]ast.Expr, error) {
+	gradient := make([]ast.Expr, len(variables))
+	for i, variable := range variables {
+		partial, err := PartialDerivative(expr, variable)
+		if err != nil {
+			return nil, fmt.Errorf("error computing partial derivative with respect to %s: %v", variable, err)
+		}
+		gradient[i] = partial
+	}
+
+	// The Hessian is the Jacobian of the gradient
+	return Jacobian(gradient, variables)
+}
+
+// CriticalPoints finds the points where every partial derivative of expr is
+// zero and classifies each one as a minimum, maximum or saddle point
+func CriticalPoints(expr ast.Expr, variables []string) ([]CriticalPoint, error) {
+	gradient := make([]ast.Expr, len(variables))
+	equations := make([]ast.Expr, len(variables))
+	for i, variable := range variables {
+		partial, err := PartialDerivative(expr, variable)
+		if err != nil {
+			return nil, fmt.Errorf("error computing partial derivative with respect to %s: %v", variable, err)
+		}
+		gradient[i] = simplify.Simplify(partial)
+		equations[i] = simplifyFactors(partial)
+	}
+
+	hessian, err := Jacobian(gradient, variables)
+	if err != nil {
+		return nil, err
+	}
+
+	points, err := solve.SolveSystem(equations, variables)
+	if err != nil {
+		return nil, fmt.Errorf("cannot find the critical points of %s: %v", expr, err)
+	}
+
+	result := make([]CriticalPoint, 0, len(points))
+	for _, point := range points {
+		atPoint, values, err := evaluateMatrix(hessian, point)
+		if err != nil {
+			return nil, fmt.Errorf("cannot evaluate Hessian at critical point: %v", err)
+		}
+		result = append(result, CriticalPoint{
+			Point:   point,
+			Kind:    classifyHessian(values),
+			Hessian: atPoint,
+		})
+	}
+
+	return result, nil
+}
+
+// simplifyFactors simplifies each factor of a product, nested products
+// included, on its own. Simplify would multiply the product out, and the
+// system solver splits only a product into one branch per factor.
+func simplifyFactors(expr ast.Expr) ast.Expr {
+	if _, ok := expr.(*ast.Mul); !ok {
+		return simplify.Simplify(expr)
+	}
+	factors := ast.Factors(expr)
+	for i, factor := range factors {
+		factors[i] = simplify.Simplify(factor)
+	}
+	return ast.NewMul(factors...)
+}
+
+// evaluateMatrix substitutes a point into every entry of a matrix, returning
+// both the simplified symbolic entries and their numeric values
+func evaluateMatrix(matrix [][]ast.Expr, point map[string]ast.Expr) ([][]ast.Expr, [][]float64, error) {
+	exprs := make([][]ast.Expr, len(matrix))
+	values := make([][]float64, len(matrix))
+
+	for i, row := range matrix {
+		exprs[i] = make([]ast.Expr, len(row))
+		values[i] = make([]float64, len(row))
+		for j, entry := range row {
+			substituted := simplify.Simplify(ast.SubstituteAll(entry, point))
+			val, err := substituted.Eval(make(map[string]*big.Float))
+			if err != nil {
+				return nil, nil, err
+			}
+			f, _ := val.Float64()
+			exprs[i][j] = numericEntry(substituted, f)
+			values[i][j] = f
+		}
+	}
+
+	return exprs, values, nil
+}
+
+// numericEntry prefers an integer node when an evaluated entry is integral
+func numericEntry(expr ast.Expr, value float64) ast.Expr {
+	if rounded := math.Round(value); math.Abs(value-rounded) < 1e-9 {
+		return ast.NewInt(int64(rounded))
+	}
+	return expr
+}
+
+// classifyHessian applies Sylvester's criterion to the leading principal minors
+func classifyHessian(h [][]float64) CriticalPointKind {
+	n := len(h)
+	if n == 0 {
+		return Inconclusive
+	}
+
+	const eps = 1e-9
+	positiveDefinite, negativeDefinite := true, true
+	var det float64
+
+	for k := 1; k <= n; k++ {
+		minor := make([][]float64, k)
+		for i := 0; i < k; i++ {
+			minor[i] = append([]float64(nil), h[i][:k]...)
+		}
+		det = determinant(minor)
+
+		if det <= eps {
+			positiveDefinite = false
+		}
+		// Negative definite minors alternate in sign, starting negative
+		if k%2 == 1 && det >= -eps || k%2 == 0 && det <= eps {
+			negativeDefinite = false
+		}
+	}
+
+	switch {
+	case positiveDefinite:
+		return LocalMinimum
+	case negativeDefinite:
+		return LocalMaximum
+	case math.Abs(det) > eps:
+		// Non-singular but neither definite means indefinite
+		return SaddlePoint
+	}
+
+	// A singular Hessian is still indefinite, and the point a saddle, when
+	// it has eigenvalues of both signs
+	positive, negative := false, false
+	for _, value := range symmetricEigenvalues(h) {
+		positive = positive || value > eps
+		negative = negative || value < -eps
+	}
+	if positive && negative {
+		return SaddlePoint
+	}
+	return Inconclusive
+}
+
+// symmetricEigenvalues computes the eigenvalues of a symmetric matrix with
+// the cyclic Jacobi method, rotating away each off-diagonal entry in turn
+func symmetricEigenvalues(h [][]float64) []float64 {
+	n := len(h)
+	a := make([][]float64, n)
+	for i := range h {
+		a[i] = append([]float64(nil), h[i]...)
+	}
+
+	for sweep := 0; sweep < 50; sweep++ {
+		off := 0.0
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				off += a[i][j] * a[i][j]
+			}
+		}
+		if off < 1e-24 {
+			break
+		}
+		for p := 0; p < n; p++ {
+			for q := p + 1; q < n; q++ {
+				if a[p][q] == 0 {
+					continue
+				}
+				theta := (a[q][q] - a[p][p]) / (2 * a[p][q])
+				t := math.Copysign(1, theta) / (math.Abs(theta) + math.Sqrt(theta*theta+1))
+				c := 1 / math.Sqrt(t*t+1)
+				sn := t * c
+				for k := 0; k < n; k++ {
+					akp, akq := a[k][p], a[k][q]
+					a[k][p] = c*akp - sn*akq
+					a[k][q] = sn*akp + c*akq
+				}
+				for k := 0; k < n; k++ {
+					apk, aqk := a[p][k], a[q][k]
+					a[p][k] = c*apk - sn*aqk
+					a[q][k] = sn*apk + c*aqk
+				}
+			}
+		}
+	}
+
+	values := make([]float64, n)
+	for i := range a {
+		values[i] = a[i][i]
+	}
+	return values
+}
+
+// determinant computes a determinant with Gaussian elimination
+func determinant(m [][]float64) float64 {
+	n := len(m)
+	det := 1.0
+	for col := 0; col < n; col++ {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(m[row][col]) > math.Abs(m[pivot][col]) {
+				pivot = row
+			}
+		}
+		if m[pivot][col] == 0 {
+			return 0
+		}
+		if pivot != col {
+			m[pivot], m[col] = m[col], m[pivot]
+			det = -det
+		}
+		det *= m[col][col]
+		for row := col + 1; row < n; row++ {
+			factor := m[row][col] / m[col][col]
+			for k := col; k < n; k++ {
+				m[row][k] -= factor * m[col][k]
+			}
+		}
+	}
+	return det
+}
@@ -0,0 +1,248 @@
+package calculus
+
+import (
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/quizizz/cas/pkg/ast"
+	"github.com/quizizz/cas/pkg/latex"
+	"github.com/quizizz/cas/pkg/parser"
+)
+
+func TestJacobian(t *testing.T) {
+	exprs := []ast.Expr{}
+	for _, s := range []string{"x^2*y", "5*x+sin(y)"} {
+		expr, err := parser.Parse(s)
+		if err != nil {
+			t.Fatalf("Parse error: %v", err)
+		}
+		exprs = append(exprs, expr)
+	}
+
+	jacobian, err := Jacobian(exprs, []string{"x", "y"})
+	if err != nil {
+		t.Fatalf("Jacobian error: %v", err)
+	}
+
+	if len(jacobian) != 2 || len(jacobian[0]) != 2 {
+		t.Fatalf("Jacobian has wrong shape: %v", jacobian)
+	}
+
+	// Check each entry numerically at x = 2, y = 0
+	point := map[string]float64{"x": 2, "y": 0}
+	expected := [][]float64{{0, 4}, {5, 1}}
+	for i := range expected {
+		for j := range expected[i] {
+			if got := evalAt(t, jacobian[i][j], point); !approxEqual(got, expected[i][j]) {
+				t.Errorf("J[%d][%d] = %s = %v at %v, expected %v", i, j, jacobian[i][j], got, point, expected[i][j])
+			}
+		}
+	}
+}
+
+func TestHessian(t *testing.T) {
+	expr, err := parser.Parse("x^3+x*y^2")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	hessian, err := Hessian(expr, []string{"x", "y"})
+	if err != nil {
+		t.Fatalf("Hessian error: %v", err)
+	}
+
+	// H = [[6x, 2y], [2y, 2x]]
+	point := map[string]float64{"x": 1, "y": 3}
+	expected := [][]float64{{6, 6}, {6, 2}}
+	for i := range expected {
+		for j := range expected[i] {
+			if got := evalAt(t, hessian[i][j], point); !approxEqual(got, expected[i][j]) {
+				t.Errorf("H[%d][%d] = %s = %v at %v, expected %v", i, j, hessian[i][j], got, point, expected[i][j])
+			}
+		}
+	}
+}
+
+func TestCriticalPoints(t *testing.T) {
+	tests := []struct {
+		name     string
+		expr     string
+		expected map[string]CriticalPointKind
+	}{
+		{"paraboloid", "x^2+y^2-2*x+4*y", map[string]CriticalPointKind{"1,-2": LocalMinimum}},
+		{"inverted paraboloid", "-x^2-y^2", map[string]CriticalPointKind{"0,0": LocalMaximum}},
+		{"saddle", "x^2-y^2", map[string]CriticalPointKind{"0,0": SaddlePoint}},
+		{"coupled linear gradient", "x^2+x*y+y^2-3*x", map[string]CriticalPointKind{"2,-1": LocalMinimum}},
+		{
+			"cubic",
+			"x^3+y^3-3*x-12*y",
+			map[string]CriticalPointKind{
+				"1,2":   LocalMinimum,
+				"1,-2":  SaddlePoint,
+				"-1,2":  SaddlePoint,
+				"-1,-2": LocalMaximum,
+			},
+		},
+		{"folium", "x^3+y^3-3*x*y", map[string]CriticalPointKind{"0,0": SaddlePoint, "1,1": LocalMinimum}},
+		{"quartic", "x^4+y^4", map[string]CriticalPointKind{"0,0": Inconclusive}},
+		{"exponential", "e^(x^2+y^2)", map[string]CriticalPointKind{"0,0": LocalMinimum}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := parser.Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+
+			points, err := CriticalPoints(expr, []string{"x", "y"})
+			if err != nil {
+				t.Fatalf("CriticalPoints error: %v", err)
+			}
+
+			if len(points) != len(tt.expected) {
+				t.Fatalf("CriticalPoints(%s) found %d points, expected %d", tt.expr, len(points), len(tt.expected))
+			}
+
+			for _, p := range points {
+				key := p.Point["x"].String() + "," + p.Point["y"].String()
+				kind, ok := tt.expected[key]
+				if !ok {
+					t.Errorf("unexpected critical point (%s)", key)
+					continue
+				}
+				if p.Kind != kind {
+					t.Errorf("point (%s) classified as %s, expected %s", key, p.Kind, kind)
+				}
+			}
+		})
+	}
+}
+
+func TestCriticalPointsOfProduct(t *testing.T) {
+	// The x-derivative 2xy vanishes on two branches, x = 0 and y = 0
+	expr, err := parser.Parse("x^2*y+y^3-3*y")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	points, err := CriticalPoints(expr, []string{"x", "y"})
+	if err != nil {
+		t.Fatalf("CriticalPoints error: %v", err)
+	}
+
+	root3 := math.Sqrt(3)
+	expected := []struct {
+		x, y float64
+		kind CriticalPointKind
+	}{
+		{0, 1, LocalMinimum},
+		{0, -1, LocalMaximum},
+		{root3, 0, SaddlePoint},
+		{-root3, 0, SaddlePoint},
+	}
+	if len(points) != len(expected) {
+		t.Fatalf("CriticalPoints(%s) found %d points, expected %d", expr, len(points), len(expected))
+	}
+	for _, want := range expected {
+		found := false
+		for _, p := range points {
+			x, y := evalAt(t, p.Point["x"], nil), evalAt(t, p.Point["y"], nil)
+			if approxEqual(x, want.x) && approxEqual(y, want.y) {
+				found = true
+				if p.Kind != want.kind {
+					t.Errorf("point (%v, %v) classified as %s, expected %s", want.x, want.y, p.Kind, want.kind)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("critical point (%v, %v) missing", want.x, want.y)
+		}
+	}
+}
+
+func TestCriticalPointsWithoutIsolatedPoints(t *testing.T) {
+	// e^x never vanishes, so there are no critical points
+	expr, err := parser.Parse("e^x + y^2")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	points, err := CriticalPoints(expr, []string{"x", "y"})
+	if err != nil {
+		t.Fatalf("CriticalPoints(%s) error: %v", expr, err)
+	}
+	if len(points) != 0 {
+		t.Errorf("CriticalPoints(%s) found %d points, expected none", expr, len(points))
+	}
+
+	// The gradient vanishes on the whole lines x = 1 and y = -2
+	expr, err = parser.Parse("(x-1)^2(y+2)^2")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if _, err := CriticalPoints(expr, []string{"x", "y"}); err == nil || !strings.Contains(err.Error(), "infinitely many") {
+		t.Errorf("CriticalPoints(%s) error = %v, expected infinitely many solutions", expr, err)
+	}
+}
+
+func TestClassifySingularHessian(t *testing.T) {
+	tests := []struct {
+		name     string
+		hessian  [][]float64
+		expected CriticalPointKind
+	}{
+		{"indefinite with a zero eigenvalue", [][]float64{{2, 0, 0}, {0, -2, 0}, {0, 0, 0}}, SaddlePoint},
+		{"off-diagonal indefinite", [][]float64{{0, 1, 0}, {1, 0, 0}, {0, 0, 0}}, SaddlePoint},
+		{"semidefinite", [][]float64{{2, 0}, {0, 0}}, Inconclusive},
+		{"zero", [][]float64{{0, 0}, {0, 0}}, Inconclusive},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyHessian(tt.hessian); got != tt.expected {
+				t.Errorf("classifyHessian(%v) = %s, expected %s", tt.hessian, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCriticalPointHessianRendersAsMatrix(t *testing.T) {
+	expr, err := parser.Parse("x^2+3*y^2")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	points, err := CriticalPoints(expr, []string{"x", "y"})
+	if err != nil || len(points) != 1 {
+		t.Fatalf("expected one critical point, got %v (err %v)", points, err)
+	}
+
+	formatted := latex.FormatMatrix(points[0].Hessian)
+	expected := "\\begin{pmatrix}\n2 & 0 \\\\\n0 & 6\n\\end{pmatrix}"
+	if formatted != expected {
+		t.Errorf("FormatMatrix(Hessian) = %q, expected %q", formatted, expected)
+	}
+	if !strings.Contains(points[0].Kind.String(), "minimum") {
+		t.Errorf("expected a minimum, got %s", points[0].Kind)
+	}
+}
+
+func evalAt(t *testing.T, expr ast.Expr, point map[string]float64) float64 {
+	t.Helper()
+	vars := make(map[string]*big.Float)
+	for name, value := range point {
+		vars[name] = big.NewFloat(value)
+	}
+	val, err := expr.Eval(vars)
+	if err != nil {
+		t.Fatalf("Eval(%s) error: %v", expr, err)
+	}
+	f, _ := val.Float64()
+	return f
+}
+
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
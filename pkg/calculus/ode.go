@@ -0,0 +1,870 @@
+package calculus
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+
+	"github.com/quizizz/cas/pkg/ast"
+	"github.com/quizizz/cas/pkg/expand"
+	"github.com/quizizz/cas/pkg/simplify"
+	"github.com/quizizz/cas/pkg/solve"
+)
+
+// IntegrationConstant is the name of the arbitrary constant in general solutions
+const IntegrationConstant = "C"
+
+// ODEType identifies the method used to solve a differential equation
+type ODEType int
+
+const (
+	// Separable equations have the form y' = g(x)h(y)
+	Separable ODEType = iota
+	// LinearODE equations have the form y' + P(x)y = Q(x)
+	LinearODE
+	// Exact equations have the form M(x,y) + N(x,y)y' = 0 with M_y = N_x
+	Exact
+)
+
+// String returns a readable name for the equation type
+func (t ODEType) String() string {
+	switch t {
+	case Separable:
+		return "separable"
+	case LinearODE:
+		return "linear"
+	case Exact:
+		return "exact"
+	default:
+		return "unknown"
+	}
+}
+
+// InitialCondition fixes the value of the unknown function at a point, y(X) = Y
+type InitialCondition struct {
+	X ast.Expr
+	Y ast.Expr
+}
+
+// ODEOptions controls differential equation solving
+type ODEOptions struct {
+	// Function is the name of the unknown function
+	Function string
+	// Variable is the independent variable; when empty it is taken from
+	// the derivative in the equation
+	Variable string
+	// InitialCondition selects a particular solution when set
+	InitialCondition *InitialCondition
+}
+
+// DefaultODEOptions returns default differential equation options
+func DefaultODEOptions() ODEOptions {
+	return ODEOptions{
+		Function: "y",
+	}
+}
+
+// ODESolution is the result of solving a first-order differential equation
+type ODESolution struct {
+	// Type is the kind of equation that was recognized
+	Type ODEType
+	// General is the general solution as an equation involving the constant C,
+	// explicit (y = ...) when it could be solved for y and implicit otherwise
+	General ast.Expr
+	// Particular is the solution satisfying the initial condition, or nil
+	Particular ast.Expr
+}
+
+// odeSolver holds the names shared by the solution methods
+type odeSolver struct {
+	x, y string
+}
+
+// sampleXs and sampleYs are the points used to recognize an equation's type
+var (
+	sampleXs = []float64{0.35, 0.8, 1.3, 1.85, 2.6}
+	sampleYs = []float64{0.45, 1.15, 1.7, 2.35, -0.65}
+)
+
+// SolveODE solves a first-order differential equation in y, y' and x. The
+// equation is recognized as separable, linear (solved with an integrating
+// factor) or exact, in that order.
+func SolveODE(eq ast.Expr, opts ...ODEOptions) (*ODESolution, error) {
+	options := DefaultODEOptions()
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+
+	expr := eq
+	if e, ok := eq.(*ast.Eq); ok {
+		if e.EqType() != ast.EqEqual {
+			return nil, fmt.Errorf("differential inequalities are not supported")
+		}
+		expr = ast.NewAdd(e.Left(), ast.NewMul(ast.NewInt(-1), e.Right()))
+	}
+
+	variable, err := derivativeVariable(expr, options.Function)
+	if err != nil {
+		return nil, err
+	}
+	if options.Variable != "" {
+		variable = options.Variable
+	}
+
+	// Replace y' with a plain variable so the equation can be rearranged
+	prime := options.Function + "'"
+	f := ast.Substitute(expr, prime, ast.NewVar(prime))
+
+	// The equation is a*y' + b = 0, which must be linear in y'
+	a, err := differentiate(f, prime)
+	if err != nil {
+		return nil, err
+	}
+	a = simplify.Simplify(a)
+	if containsVariable(a, prime) {
+		return nil, fmt.Errorf("equation is not linear in %s", prime)
+	}
+	b := simplify.Simplify(ast.Substitute(f, prime, ast.NewInt(0)))
+
+	solver := &odeSolver{x: variable, y: options.Function}
+	slope := quotient(ast.NewMul(ast.NewInt(-1), b), a)
+
+	solution := &ODESolution{}
+	switch {
+	case solver.isSeparable(slope):
+		solution.Type = Separable
+		solution.General, err = solver.solveSeparable(slope)
+	case solver.isLinear(slope):
+		solution.Type = LinearODE
+		solution.General, err = solver.solveLinear(slope)
+	case solver.isExact(b, a):
+		solution.Type = Exact
+		solution.General, err = solver.solveExact(b, a)
+	default:
+		return nil, fmt.Errorf("equation %s is not separable, linear or exact", eq.String())
+	}
+	if err != nil {
+		return nil, err
+	}
+	solution.General = tidyEquation(solution.General)
+
+	if options.InitialCondition != nil {
+		solution.Particular, err = solver.applyInitialCondition(solution.General, options.InitialCondition)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return solution, nil
+}
+
+// derivativeVariable checks that the only derivative in expr is the first
+// derivative of function and returns its variable of differentiation
+func derivativeVariable(expr ast.Expr, function string) (string, error) {
+	var derivatives []*ast.Derivative
+	collectDerivatives(expr, &derivatives)
+	if len(derivatives) == 0 {
+		return "", fmt.Errorf("%s is not a differential equation", expr.String())
+	}
+
+	for _, d := range derivatives {
+		operand, ok := d.Operand().(*ast.Var)
+		if !ok || operand.Name() != function {
+			return "", fmt.Errorf("unexpected derivative %s", d.String())
+		}
+		if d.Order() != 1 {
+			return "", fmt.Errorf("only first-order equations are supported, found %s", d.String())
+		}
+	}
+	return derivatives[0].Variable(), nil
+}
+
+// collectDerivatives gathers every derivative node in an expression
+func collectDerivatives(expr ast.Expr, found *[]*ast.Derivative) {
+	switch e := expr.(type) {
+	case *ast.Derivative:
+		*found = append(*found, e)
+	case *ast.Add:
+		for _, term := range e.Terms() {
+			collectDerivatives(term, found)
+		}
+	case *ast.Mul:
+		for _, factor := range e.Terms() {
+			collectDerivatives(factor, found)
+		}
+	case *ast.Pow:
+		collectDerivatives(e.Base(), found)
+		collectDerivatives(e.Exponent(), found)
+	case *ast.Func:
+		for _, arg := range e.Args() {
+			collectDerivatives(arg, found)
+		}
+	}
+}
+
+// isSeparable tests F(x,y)F(x0,y0) = F(x,y0)F(x0,y), which holds exactly
+// when F factors as g(x)h(y)
+func (s *odeSolver) isSeparable(slope ast.Expr) bool {
+	x0, y0, ok := s.basePoint(slope)
+	if !ok {
+		return false
+	}
+	base, _ := s.evalAt(slope, float64(x0), float64(y0))
+
+	checked := 0
+	for _, x := range sampleXs {
+		for _, y := range sampleYs {
+			value, ok1 := s.evalAt(slope, x, y)
+			alongX, ok2 := s.evalAt(slope, x, float64(y0))
+			alongY, ok3 := s.evalAt(slope, float64(x0), y)
+			if !ok1 || !ok2 || !ok3 {
+				continue
+			}
+			if !nearlyEqual(value*base, alongX*alongY) {
+				return false
+			}
+			checked++
+		}
+	}
+	return checked >= 3
+}
+
+// solveSeparable integrates dy/h(y) = g(x)dx
+func (s *odeSolver) solveSeparable(slope ast.Expr) (ast.Expr, error) {
+	c := ast.NewVar(IntegrationConstant)
+
+	// y' = g(x): y = ∫g dx + C
+	if !containsVariable(slope, s.y) {
+		integral, err := Integrate(slope, s.x)
+		if err != nil {
+			return nil, err
+		}
+		return ast.NewEq(ast.NewVar(s.y), ast.NewAdd(integral, c), ast.EqEqual), nil
+	}
+
+	x0, y0, _ := s.basePoint(slope)
+	atBase := exactConstant(ast.SubstituteAll(slope, s.point(x0, y0)))
+	g := fold(ast.Substitute(slope, s.y, ast.NewInt(y0)))
+	h := quotient(fold(ast.Substitute(slope, s.x, ast.NewInt(x0))), atBase)
+
+	G, err := integrate(g, s.x, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// y' = k*y*g(x): y = C*e^(k∫g dx)
+	if k, ok := s.proportionalToY(h); ok {
+		growth := exponential(scale(k, G))
+		return ast.NewEq(ast.NewVar(s.y), ast.NewMul(c, growth), ast.EqEqual), nil
+	}
+
+	H, err := Integrate(ast.NewPow(h, ast.NewInt(-1)), s.y)
+	if err != nil {
+		return nil, err
+	}
+	rhs := ast.NewAdd(fold(G), c)
+
+	// Prefer an explicit solution when H(y) = G(x) + C has a unique root
+	solved := solve.SolveEquation(H, rhs, solve.SolveOptions{Variable: s.y, AllowApproximate: true, MaxDegree: 2})
+	if len(solved.Solutions) == 1 {
+		return ast.NewEq(ast.NewVar(s.y), solved.Solutions[0].Value, ast.EqEqual), nil
+	}
+	return ast.NewEq(H, rhs, ast.EqEqual), nil
+}
+
+// proportionalToY reports whether h(y) = k*y and returns k
+func (s *odeSolver) proportionalToY(h ast.Expr) (ast.Expr, bool) {
+	k, ok := s.evalAt(h, 0, 1)
+	if !ok {
+		return nil, false
+	}
+	for _, y := range sampleYs {
+		value, ok := s.evalAt(h, 0, y)
+		if !ok || !nearlyEqual(value, k*y) {
+			return nil, false
+		}
+	}
+	return exactConstant(ast.Substitute(h, s.y, ast.NewInt(1))), true
+}
+
+// isLinear tests whether F(x,y) is an affine function of y
+func (s *odeSolver) isLinear(slope ast.Expr) bool {
+	checked := 0
+	for _, x := range sampleXs {
+		at0, ok0 := s.evalAt(slope, x, 0)
+		at1, ok1 := s.evalAt(slope, x, 1)
+		if !ok0 || !ok1 {
+			continue
+		}
+		for _, y := range sampleYs {
+			value, ok := s.evalAt(slope, x, y)
+			if !ok {
+				continue
+			}
+			if !nearlyEqual(value, at0+(at1-at0)*y) {
+				return false
+			}
+			checked++
+		}
+	}
+	return checked >= 3
+}
+
+// solveLinear writes y' = -P(x)y + Q(x) and multiplies through by the
+// integrating factor μ = e^(∫P dx), giving y = (∫μQ dx + C)/μ
+func (s *odeSolver) solveLinear(slope ast.Expr) (ast.Expr, error) {
+	q := fold(expand.Expand(ast.Substitute(slope, s.y, ast.NewInt(0))))
+	dy, err := differentiate(slope, s.y)
+	if err != nil {
+		return nil, err
+	}
+	p := fold(expand.Expand(ast.NewMul(ast.NewInt(-1), dy)))
+
+	integralP, err := integrate(p, s.x, 0)
+	if err != nil {
+		return nil, err
+	}
+	mu := exponential(integralP)
+
+	integralMuQ, err := Integrate(ast.NewMul(mu, q), s.x)
+	if err != nil {
+		return nil, err
+	}
+
+	numerator := ast.NewAdd(integralMuQ, ast.NewVar(IntegrationConstant))
+	return ast.NewEq(ast.NewVar(s.y), ast.NewMul(numerator, exponential(scale(ast.NewInt(-1), integralP))), ast.EqEqual), nil
+}
+
+// isExact tests M_y = N_x for the equation M + N*y' = 0
+func (s *odeSolver) isExact(m, n ast.Expr) bool {
+	my, err := differentiate(m, s.y)
+	if err != nil {
+		return false
+	}
+	nx, err := differentiate(n, s.x)
+	if err != nil {
+		return false
+	}
+
+	checked := 0
+	for _, x := range sampleXs {
+		for _, y := range sampleYs {
+			left, ok1 := s.evalAt(my, x, y)
+			right, ok2 := s.evalAt(nx, x, y)
+			if !ok1 || !ok2 {
+				continue
+			}
+			if !nearlyEqual(left, right) {
+				return false
+			}
+			checked++
+		}
+	}
+	return checked >= 3
+}
+
+// solveExact finds the potential Φ with Φ_x = M and Φ_y = N, giving Φ = C
+func (s *odeSolver) solveExact(m, n ast.Expr) (ast.Expr, error) {
+	fromM, err := integrate(m, s.x, 0)
+	if err != nil {
+		return nil, err
+	}
+	partialY, err := differentiate(fromM, s.y)
+	if err != nil {
+		return nil, err
+	}
+
+	// What remains of N depends on y alone
+	remainder := simplify.Simplify(ast.NewAdd(n, ast.NewMul(ast.NewInt(-1), partialY)))
+	var potential ast.Expr = fromM
+	if value, ok := ast.ConstantValue(remainder); !ok || value != 0 {
+		fromN, err := integrate(remainder, s.y, 0)
+		if err != nil {
+			return nil, err
+		}
+		potential = ast.NewAdd(fromM, fromN)
+	}
+
+	return ast.NewEq(simplify.Simplify(potential), ast.NewVar(IntegrationConstant), ast.EqEqual), nil
+}
+
+// applyInitialCondition solves for C and substitutes it into the general
+// solution. C is isolated by undoing the sums and products around it, so
+// that a value such as -2*e^-1 stays exact. Every candidate is substituted
+// back and checked against the condition, since solving through a singular
+// point, as 1 = C*0^-1, produces values that do not satisfy it.
+func (s *odeSolver) applyInitialCondition(general ast.Expr, ic *InitialCondition) (ast.Expr, error) {
+	eq := general.(*ast.Eq)
+	x0, okX := ast.ConstantValue(ic.X)
+	y0, okY := ast.ConstantValue(ic.Y)
+	if !okX || !okY {
+		return nil, fmt.Errorf("initial condition %s(%s) = %s is not numeric", s.y, ic.X.String(), ic.Y.String())
+	}
+	if !s.definedAt(eq, x0, y0) {
+		return nil, fmt.Errorf("initial condition %s(%s) = %s lies on a singularity of the general solution", s.y, ic.X.String(), ic.Y.String())
+	}
+
+	point := map[string]ast.Expr{s.x: ic.X, s.y: ic.Y}
+	left := tidy(ast.SubstituteAll(eq.Left(), point))
+	right := tidy(ast.SubstituteAll(eq.Right(), point))
+	if containsVariable(left, IntegrationConstant) {
+		left, right = right, left
+	}
+	if !containsVariable(right, IntegrationConstant) {
+		// Every solution takes the same value at the point, as y = C*x at x = 0
+		return nil, fmt.Errorf("no solution satisfies %s(%s) = %s", s.y, ic.X.String(), ic.Y.String())
+	}
+
+	var candidates []ast.Expr
+	if value, ok := isolateConstant(right, left); ok {
+		candidates = append(candidates, value)
+	} else {
+		// C is not linear, as in an explicit solution found by solving for y
+		solved := solve.SolveEquation(right, left, solve.SolveOptions{Variable: IntegrationConstant, MaxDegree: 2})
+		if len(solved.Solutions) == 0 {
+			return nil, fmt.Errorf("cannot solve for %s in %s = %s", IntegrationConstant, right.String(), left.String())
+		}
+		for _, solution := range solved.Solutions {
+			candidates = append(candidates, solution.Value)
+		}
+	}
+
+	var particulars []ast.Expr
+	for _, value := range candidates {
+		if _, ok := ast.ConstantValue(value); !ok {
+			continue
+		}
+		constant := map[string]ast.Expr{IntegrationConstant: value}
+		particular := tidyEquation(ast.NewEq(ast.SubstituteAll(eq.Left(), constant), ast.SubstituteAll(eq.Right(), constant), ast.EqEqual))
+		if s.satisfies(particular.(*ast.Eq), x0, y0) {
+			particulars = append(particulars, particular)
+		}
+	}
+
+	switch len(particulars) {
+	case 0:
+		return nil, fmt.Errorf("no solution satisfies %s(%s) = %s", s.y, ic.X.String(), ic.Y.String())
+	case 1:
+		return particulars[0], nil
+	}
+	branches := make([]string, len(particulars))
+	for i, particular := range particulars {
+		branches[i] = particular.String()
+	}
+	return nil, fmt.Errorf("%s(%s) = %s is satisfied by several solutions: %s", s.y, ic.X.String(), ic.Y.String(), strings.Join(branches, ", "))
+}
+
+// constantSamples are values of C used to test where the general solution
+// is defined
+var constantSamples = []float64{0.7, 1.3, -1.9}
+
+// definedAt reports whether both sides of the general solution can be
+// evaluated at (x, y) for some value of C
+func (s *odeSolver) definedAt(eq *ast.Eq, x, y float64) bool {
+	for _, c := range constantSamples {
+		vars := map[string]float64{s.x: x, s.y: y, IntegrationConstant: c}
+		_, okLeft := evalPoint(eq.Left(), vars)
+		_, okRight := evalPoint(eq.Right(), vars)
+		if okLeft && okRight {
+			return true
+		}
+	}
+	return false
+}
+
+// satisfies reports whether a particular solution holds at (x, y)
+func (s *odeSolver) satisfies(eq *ast.Eq, x, y float64) bool {
+	left, okLeft := s.evalAt(eq.Left(), x, y)
+	right, okRight := s.evalAt(eq.Right(), x, y)
+	return okLeft && okRight && nearlyEqual(left, right)
+}
+
+// isolateConstant solves side = other for C when C occurs once in side and
+// only inside sums and products
+func isolateConstant(side, other ast.Expr) (ast.Expr, bool) {
+	if v, ok := side.(*ast.Var); ok && v.Name() == IntegrationConstant {
+		return other, true
+	}
+
+	var parts []ast.Expr
+	switch e := side.(type) {
+	case *ast.Add:
+		parts = e.Terms()
+	case *ast.Mul:
+		parts = ast.Factors(e)
+	default:
+		return nil, false
+	}
+
+	inner := -1
+	var rest []ast.Expr
+	for i, part := range parts {
+		if !containsVariable(part, IntegrationConstant) {
+			rest = append(rest, part)
+			continue
+		}
+		if inner >= 0 {
+			return nil, false
+		}
+		inner = i
+	}
+	if inner < 0 {
+		return nil, false
+	}
+
+	// Move the other terms or factors across: u + r = v gives u = v - r,
+	// u*r = v gives u = v/r
+	if _, ok := side.(*ast.Add); ok {
+		other = tidy(ast.NewAdd(other, ast.NewMul(ast.NewInt(-1), ast.NewAdd(rest...))))
+	} else {
+		other = tidy(ast.NewMul(other, reciprocal(tidy(ast.NewMul(rest...)))))
+	}
+	return isolateConstant(parts[inner], other)
+}
+
+// reciprocal returns 1/expr, negating exponents and inverting numbers
+// rather than writing (2*e)^-1, which Simplify would fold into a float
+func reciprocal(expr ast.Expr) ast.Expr {
+	switch e := expr.(type) {
+	case *ast.Mul:
+		factors := ast.Factors(e)
+		for i, factor := range factors {
+			factors[i] = reciprocal(factor)
+		}
+		return tidy(ast.NewMul(factors...))
+	case *ast.Pow:
+		return tidy(ast.NewPow(e.Base(), ast.NewMul(ast.NewInt(-1), e.Exponent())))
+	}
+	return tidy(ast.NewPow(expr, ast.NewInt(-1)))
+}
+
+// basePoint finds a small integer point where F is defined and non-zero
+func (s *odeSolver) basePoint(slope ast.Expr) (int64, int64, bool) {
+	candidates := []int64{1, 2, 3, -1, -2}
+	for _, x0 := range candidates {
+		for _, y0 := range candidates {
+			if value, ok := s.evalAt(slope, float64(x0), float64(y0)); ok && math.Abs(value) > 1e-9 {
+				return x0, y0, true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// point builds a substitution for integer coordinates
+func (s *odeSolver) point(x, y int64) map[string]ast.Expr {
+	return map[string]ast.Expr{s.x: ast.NewInt(x), s.y: ast.NewInt(y)}
+}
+
+// evalAt evaluates expr at (x, y), reporting false outside its domain
+func (s *odeSolver) evalAt(expr ast.Expr, x, y float64) (float64, bool) {
+	return evalPoint(expr, map[string]float64{s.x: x, s.y: y})
+}
+
+// evalPoint evaluates expr at a point, reporting false outside its domain
+func evalPoint(expr ast.Expr, point map[string]float64) (float64, bool) {
+	vars := make(map[string]*big.Float, len(point))
+	for name, value := range point {
+		vars[name] = big.NewFloat(value)
+	}
+	val, err := expr.Eval(vars)
+	if err != nil {
+		return 0, false
+	}
+	f, _ := val.Float64()
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, false
+	}
+	return f, true
+}
+
+// exponential builds e^expr, rewriting e^(k ln|u|) as u^k. The absolute value
+// is dropped since its sign is absorbed into the integration constant.
+func exponential(expr ast.Expr) ast.Expr {
+	switch e := expr.(type) {
+	case *ast.Add:
+		terms := e.Terms()
+		factors := make([]ast.Expr, len(terms))
+		for i, term := range terms {
+			factors[i] = exponential(term)
+		}
+		return ast.NewMul(factors...)
+
+	case *ast.Func:
+		if arg, ok := logArgument(e); ok {
+			return arg
+		}
+
+	case *ast.Mul:
+		var logArg ast.Expr
+		var coefficients []ast.Expr
+		for _, factor := range ast.Factors(e) {
+			if fn, ok := factor.(*ast.Func); ok && logArg == nil {
+				if arg, ok := logArgument(fn); ok {
+					logArg = arg
+					continue
+				}
+			}
+			if len(factor.Variables()) > 0 {
+				logArg = nil
+				break
+			}
+			coefficients = append(coefficients, factor)
+		}
+		if logArg != nil {
+			exponent := exactConstant(ast.NewMul(coefficients...))
+			if value, ok := ast.ConstantValue(exponent); ok && value == 1 {
+				return logArg
+			}
+			return ast.NewPow(logArg, exponent)
+		}
+	}
+
+	return ast.NewPow(ast.E, simplify.Simplify(expr))
+}
+
+// logArgument returns u for ln(u) or ln|u|
+func logArgument(fn *ast.Func) (ast.Expr, bool) {
+	args := fn.Args()
+	if fn.Name() != "ln" || len(args) != 1 {
+		return nil, false
+	}
+	if abs, ok := args[0].(*ast.Func); ok && abs.Name() == "abs" && len(abs.Args()) == 1 {
+		return abs.Args()[0], true
+	}
+	return args[0], true
+}
+
+// scale multiplies by a constant, skipping a factor of one
+func scale(k, expr ast.Expr) ast.Expr {
+	if value, ok := ast.ConstantValue(k); ok && value == 1 {
+		return expr
+	}
+	return ast.NewMul(k, expr)
+}
+
+// fold simplifies an expression, reducing it to an exact number when it is constant
+func fold(expr ast.Expr) ast.Expr {
+	if _, ok := ast.ConstantValue(expr); ok {
+		return exactConstant(expr)
+	}
+	return simplify.Simplify(expr)
+}
+
+// tidyEquation tidies both sides of an equation
+func tidyEquation(eq ast.Expr) ast.Expr {
+	e := eq.(*ast.Eq)
+	return ast.NewEq(tidy(e.Left()), tidy(e.Right()), e.EqType())
+}
+
+// tidy cleans up the solution of an equation without the float arithmetic
+// of Simplify: numbers are combined exactly, like terms and powers of the
+// same base are collected, and e^0, 1^k, x^1 and sums with zero are
+// removed, so (0+C)*e^0 becomes C and 1/2*1^-1*x^2 becomes 1/2*x^2.
+func tidy(expr ast.Expr) ast.Expr {
+	switch e := expr.(type) {
+	case *ast.Add:
+		// Terms k*u with the same u are added up
+		var bases []ast.Expr
+		var coefficients []ast.Expr
+		index := map[string]int{}
+		var collect func(term ast.Expr)
+		collect = func(term ast.Expr) {
+			if inner, ok := term.(*ast.Add); ok {
+				for _, t := range inner.Terms() {
+					collect(t)
+				}
+				return
+			}
+			k, base := splitCoefficient(term)
+			key := base.String()
+			if i, ok := index[key]; ok {
+				coefficients[i] = foldNumber(ast.NewAdd(coefficients[i], k))
+				return
+			}
+			index[key] = len(bases)
+			bases = append(bases, base)
+			coefficients = append(coefficients, k)
+		}
+		for _, term := range e.Terms() {
+			collect(tidy(term))
+		}
+
+		var terms []ast.Expr
+		var number ast.Expr
+		for i, base := range bases {
+			switch {
+			case isZeroNumber(coefficients[i]):
+			case isOneNumber(base):
+				number = coefficients[i]
+			default:
+				terms = append(terms, tidy(ast.NewMul(coefficients[i], base)))
+			}
+		}
+		// A constant term goes last, as in x^2+1
+		if number != nil {
+			terms = append(terms, number)
+		}
+		switch len(terms) {
+		case 0:
+			return ast.NewInt(0)
+		case 1:
+			return terms[0]
+		}
+		return ast.NewAdd(terms...)
+
+	case *ast.Mul:
+		k, base := splitCoefficient(e)
+		if isZeroNumber(k) {
+			return ast.NewInt(0)
+		}
+		if isOneNumber(base) {
+			return k
+		}
+		if isOneNumber(k) {
+			return base
+		}
+		return ast.NewMul(k, base)
+
+	case *ast.Pow:
+		base, exponent := tidy(e.Base()), tidy(e.Exponent())
+		switch {
+		case isZeroNumber(exponent), isOneNumber(base):
+			return ast.NewInt(1)
+		case isOneNumber(exponent):
+			return base
+		case isExactNumber(base) && isExactNumber(exponent):
+			return foldNumber(ast.NewPow(base, exponent))
+		}
+		if inner, ok := base.(*ast.Pow); ok {
+			if _, ok := exponent.(*ast.Int); ok {
+				// (u^m)^n = u^(m*n) for an integer n
+				return tidy(ast.NewPow(inner.Base(), ast.NewMul(inner.Exponent(), exponent)))
+			}
+		}
+		return ast.NewPow(base, exponent)
+
+	case *ast.Func:
+		args := e.Args()
+		exact := true
+		for i, arg := range args {
+			args[i] = tidy(arg)
+			exact = exact && isExactNumber(args[i])
+		}
+		fn := ast.NewFunc(e.Name(), args...)
+		// A whole value such as arctan(0) or ln(1) is folded
+		if value, ok := ast.ConstantValue(fn); exact && ok && value == math.Trunc(value) {
+			return foldNumber(fn)
+		}
+		return fn
+
+	case *ast.Float:
+		// Simplify leaves whole numbers and simple fractions as floats
+		return foldNumber(e)
+	}
+	return expr
+}
+
+// splitCoefficient tidies a product and returns its exact numeric
+// coefficient and the product of its other factors, with powers of the
+// same base combined
+func splitCoefficient(expr ast.Expr) (ast.Expr, ast.Expr) {
+	var coefficient ast.Expr = ast.NewInt(1)
+	var bases, exponents []ast.Expr
+	index := map[string]int{}
+	var collect func(factor ast.Expr)
+	collect = func(factor ast.Expr) {
+		if mul, ok := factor.(*ast.Mul); ok {
+			for _, inner := range ast.Factors(mul) {
+				collect(inner)
+			}
+			return
+		}
+		factor = tidy(factor)
+		switch f := factor.(type) {
+		case *ast.Mul:
+			collect(f)
+			return
+		case *ast.Int, *ast.Rational:
+			coefficient = foldNumber(ast.NewMul(coefficient, f))
+			return
+		}
+
+		var base, exponent ast.Expr = factor, ast.NewInt(1)
+		if pow, ok := factor.(*ast.Pow); ok {
+			base, exponent = pow.Base(), pow.Exponent()
+		}
+		key := base.String()
+		if i, ok := index[key]; ok {
+			exponents[i] = ast.NewAdd(exponents[i], exponent)
+			return
+		}
+		index[key] = len(bases)
+		bases = append(bases, base)
+		exponents = append(exponents, exponent)
+	}
+	collect(expr)
+
+	var rest []ast.Expr
+	for i, base := range bases {
+		power := tidy(ast.NewPow(base, exponents[i]))
+		if isExactNumber(power) {
+			coefficient = foldNumber(ast.NewMul(coefficient, power))
+		} else if !isOneNumber(power) {
+			rest = append(rest, power)
+		}
+	}
+	switch len(rest) {
+	case 0:
+		return coefficient, ast.NewInt(1)
+	case 1:
+		return coefficient, rest[0]
+	}
+	return coefficient, ast.NewMul(rest...)
+}
+
+// foldNumber evaluates an expression of exact numbers to an Int or a
+// Rational, keeping it as it is when the value is not rational
+func foldNumber(expr ast.Expr) ast.Expr {
+	if folded := exactConstant(expr); isExactNumber(folded) {
+		return folded
+	}
+	return expr
+}
+
+func isExactNumber(expr ast.Expr) bool {
+	switch expr.(type) {
+	case *ast.Int, *ast.Rational:
+		return true
+	}
+	return false
+}
+
+func isZeroNumber(expr ast.Expr) bool {
+	value, ok := ast.ConstantValue(expr)
+	return isExactNumber(expr) && ok && value == 0
+}
+
+func isOneNumber(expr ast.Expr) bool {
+	value, ok := ast.ConstantValue(expr)
+	return isExactNumber(expr) && ok && value == 1
+}
+
+// quotient divides two expressions, folding a constant divisor into an
+// exact reciprocal
+func quotient(num, den ast.Expr) ast.Expr {
+	if value, ok := ast.ConstantValue(den); ok && value != 0 {
+		reciprocal := exactConstant(ast.NewPow(den, ast.NewInt(-1)))
+		if value == 1 {
+			return simplify.Simplify(num)
+		}
+		return simplify.Simplify(ast.NewMul(reciprocal, num))
+	}
+	return simplify.Simplify(ast.NewMul(num, ast.NewPow(den, ast.NewInt(-1))))
+}
+
+// nearlyEqual compares two samples with a relative tolerance
+func nearlyEqual(a, b float64) bool {
+	return math.Abs(a-b) <= 1e-7*(1+math.Max(math.Abs(a), math.Abs(b)))
+}
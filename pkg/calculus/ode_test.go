@@ -0,0 +1,265 @@
+package calculus
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/quizizz/cas/pkg/ast"
+	"github.com/quizizz/cas/pkg/parser"
+)
+
+func TestSolveODE(t *testing.T) {
+	tests := []struct {
+		name     string
+		equation string
+		slope    string // y' written in terms of x and y
+		odeType  ODEType
+	}{
+		{"direct integration", "y'=2*x", "2*x", Separable},
+		{"exponential growth", "y'=3*y", "3*y", Separable},
+		{"separable product", "y'=x*y", "x*y", Separable},
+		{"leibniz notation", "dy/dx=y/x", "y/x", Separable},
+		{"implicit separable", "y'=x/y", "x/y", Separable},
+		{"separable in y only", "y'=y^2", "y^2", Separable},
+		{"arctangent", "y'=1+y^2", "1+y^2", Separable},
+		{"trigonometric coefficient", "\\frac{dy}{dx}=\\cos{x}y", "cos(x)*y", Separable},
+		{"linear constant coefficient", "y'+y=x", "x-y", LinearODE},
+		{"linear variable coefficient", "y'+2*y/x=x", "x-2*y/x", LinearODE},
+		{"linear with leading coefficient", "x*y'-y=x^2", "(x^2+y)/x", LinearODE},
+		{"linear exponential forcing", "y'-2*y=e^(3*x)", "e^(3*x)+2*y", LinearODE},
+		{"exact", "2*x*y+3+(x^2+4*y)*y'=0", "-(2*x*y+3)/(x^2+4*y)", Exact},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eq, err := parser.Parse(tt.equation)
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+			slope, err := parser.Parse(tt.slope)
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+
+			solution, err := SolveODE(eq)
+			if err != nil {
+				t.Fatalf("SolveODE(%s) error: %v", tt.equation, err)
+			}
+			if solution.Type != tt.odeType {
+				t.Errorf("SolveODE(%s) type = %s, expected %s", tt.equation, solution.Type, tt.odeType)
+			}
+			if solution.Particular != nil {
+				t.Errorf("SolveODE(%s) returned a particular solution without an initial condition", tt.equation)
+			}
+
+			checkSolvesODE(t, solution.General, slope)
+		})
+	}
+}
+
+func TestSolveODEInitialCondition(t *testing.T) {
+	tests := []struct {
+		equation string
+		x, y     int64
+	}{
+		{"y'=2*x", 1, 2},
+		{"y'=3*y", 0, 5},
+		{"y'+y=x", 0, 1},
+		{"y'=x/y", 1, 2},
+		{"2*x*y+3+(x^2+4*y)*y'=0", 1, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.equation, func(t *testing.T) {
+			eq, err := parser.Parse(tt.equation)
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+
+			solution, err := SolveODE(eq, ODEOptions{
+				Function:         "y",
+				InitialCondition: &InitialCondition{X: ast.NewInt(tt.x), Y: ast.NewInt(tt.y)},
+			})
+			if err != nil {
+				t.Fatalf("SolveODE(%s) error: %v", tt.equation, err)
+			}
+			if solution.Particular == nil {
+				t.Fatalf("SolveODE(%s) returned no particular solution", tt.equation)
+			}
+			if containsVariable(solution.Particular, IntegrationConstant) {
+				t.Errorf("particular solution %s still contains C", solution.Particular)
+			}
+
+			particular := solution.Particular.(*ast.Eq)
+			point := map[string]float64{"x": float64(tt.x), "y": float64(tt.y)}
+			left, right := evalAt(t, particular.Left(), point), evalAt(t, particular.Right(), point)
+			if math.Abs(left-right) > 1e-9 {
+				t.Errorf("particular solution %s does not pass through (%d, %d)", solution.Particular, tt.x, tt.y)
+			}
+		})
+	}
+}
+
+func TestSolveODEExactSolutions(t *testing.T) {
+	tests := []struct {
+		equation   string
+		x, y       int64
+		general    string
+		particular string
+	}{
+		{"y'=0", 2, 3, "y=C", "y=3"},
+		{"y'=x", 1, 1, "y=1/2*x^2+C", "y=1/2*x^2+1/2"},
+		{"y'=y", 1, -2, "y=C*e^x", "y=-2*e^(x+-1)"},
+		{"y'=x/y", 1, 2, "1/2*y^2=1/2*x^2+C", "1/2*y^2=1/2*x^2+3/2"},
+		{"y'=1+y^2", 0, 0, "2*arctan(y)=2*x+C", "2*arctan(y)=2*x"},
+		{"y'-2*y=e^(3*x)", 0, 1, "y=(e^x+C)*e^(2*x)", "y=e^(3*x)"},
+		{"y'+2*y/x=x", 1, 1, "y=(1/4*x^4+C)*x^-2", "y=(1/4*x^4+3/4)*x^-2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.equation, func(t *testing.T) {
+			eq, err := parser.Parse(tt.equation)
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+
+			solution, err := SolveODE(eq, ODEOptions{
+				Function:         "y",
+				InitialCondition: &InitialCondition{X: ast.NewInt(tt.x), Y: ast.NewInt(tt.y)},
+			})
+			if err != nil {
+				t.Fatalf("SolveODE(%s) error: %v", tt.equation, err)
+			}
+			if got := solution.General.String(); got != tt.general {
+				t.Errorf("SolveODE(%s) general solution = %s, expected %s", tt.equation, got, tt.general)
+			}
+			if got := solution.Particular.String(); got != tt.particular {
+				t.Errorf("SolveODE(%s) particular solution = %s, expected %s", tt.equation, got, tt.particular)
+			}
+		})
+	}
+
+	// Every solution of y' = y/x passes through the origin
+	eq, _ := parser.Parse("y'=y/x")
+	_, err := SolveODE(eq, ODEOptions{Function: "y", InitialCondition: &InitialCondition{X: ast.NewInt(0), Y: ast.NewInt(3)}})
+	if err == nil {
+		t.Errorf("SolveODE(y'=y/x) with y(0) = 3 should fail")
+	}
+}
+
+func TestSolveODESingularInitialCondition(t *testing.T) {
+	// The general solutions (1/3*x^3+C)*x^-1 and (1/2*x^2+C)*x^-1 are not
+	// defined at x = 0, and no choice of C gives y(0) = 1
+	for _, equation := range []string{"x*y'+y=x^2", "y'+y/x=1"} {
+		t.Run(equation, func(t *testing.T) {
+			eq, err := parser.Parse(equation)
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+			solution, err := SolveODE(eq, ODEOptions{
+				Function:         "y",
+				InitialCondition: &InitialCondition{X: ast.NewInt(0), Y: ast.NewInt(1)},
+			})
+			if err == nil {
+				t.Errorf("SolveODE(%s) with y(0) = 1 = %s, expected an error", equation, solution.Particular)
+			}
+		})
+	}
+}
+
+func TestApplyInitialConditionBranches(t *testing.T) {
+	solver := &odeSolver{x: "x", y: "y"}
+	c := ast.NewVar(IntegrationConstant)
+
+	// y = (x+C)^2 passes through (0, 1) for C = 1 and C = -1
+	general := ast.NewEq(ast.NewVar("y"), ast.NewPow(ast.NewAdd(ast.NewVar("x"), c), ast.NewInt(2)), ast.EqEqual)
+	if particular, err := solver.applyInitialCondition(general, &InitialCondition{X: ast.NewInt(0), Y: ast.NewInt(1)}); err == nil {
+		t.Errorf("applyInitialCondition(%s) = %s, expected the branches to be reported as ambiguous", general, particular)
+	} else if !strings.Contains(err.Error(), "several solutions") {
+		t.Errorf("applyInitialCondition(%s) error = %v, expected the branches to be reported as ambiguous", general, err)
+	}
+}
+
+func TestSolveODEOtherVariable(t *testing.T) {
+	eq, err := parser.Parse("\\frac{dy}{dt}=-2*y")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	solution, err := SolveODE(eq)
+	if err != nil {
+		t.Fatalf("SolveODE error: %v", err)
+	}
+	if got := solution.General.String(); got != "y=C*e^(-2*t)" {
+		t.Errorf("SolveODE general solution = %s, expected y=C*e^(-2*t)", got)
+	}
+}
+
+func TestSolveODEErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		equation string
+	}{
+		{"no derivative", "x+y=1"},
+		{"second order", "y''=y"},
+		{"nonlinear in derivative", "y'^2=x"},
+		{"unrecognized", "y'=sin(x*y)"},
+		{"inequality", "y'>x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eq, err := parser.Parse(tt.equation)
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+			if solution, err := SolveODE(eq); err == nil {
+				t.Errorf("SolveODE(%s) = %s, expected an error", tt.equation, solution.General)
+			}
+		})
+	}
+}
+
+// checkSolvesODE verifies numerically that a general solution satisfies
+// y' = slope. Explicit solutions y = f(x, C) are differentiated directly;
+// implicit solutions Φ(x, y) = C use y' = -Φx/Φy.
+func checkSolvesODE(t *testing.T, general, slope ast.Expr) {
+	t.Helper()
+	eq, ok := general.(*ast.Eq)
+	if !ok {
+		t.Fatalf("general solution %s is not an equation", general)
+	}
+
+	if v, ok := eq.Left().(*ast.Var); ok && v.Name() == "y" {
+		derivative, err := Derivative(eq.Right(), "x")
+		if err != nil {
+			t.Fatalf("Derivative error: %v", err)
+		}
+		for _, x := range []float64{0.5, 1.2, 2.1} {
+			point := map[string]float64{"x": x, IntegrationConstant: 1.3}
+			point["y"] = evalAt(t, eq.Right(), point)
+			if got, want := evalAt(t, derivative, point), evalAt(t, slope, point); math.Abs(got-want) > 1e-7 {
+				t.Errorf("%s gives y' = %v at x = %v, expected %v", general, got, x, want)
+			}
+		}
+		return
+	}
+
+	potential := ast.NewAdd(eq.Left(), ast.NewMul(ast.NewInt(-1), eq.Right()))
+	phiX, err := Derivative(potential, "x")
+	if err != nil {
+		t.Fatalf("Derivative error: %v", err)
+	}
+	phiY, err := Derivative(potential, "y")
+	if err != nil {
+		t.Fatalf("Derivative error: %v", err)
+	}
+	for _, p := range [][2]float64{{0.5, 0.8}, {1.2, 1.6}, {2.1, 0.3}} {
+		point := map[string]float64{"x": p[0], "y": p[1], IntegrationConstant: 1.3}
+		got := -evalAt(t, phiX, point) / evalAt(t, phiY, point)
+		if want := evalAt(t, slope, point); math.Abs(got-want) > 1e-7 {
+			t.Errorf("%s gives y' = %v at %v, expected %v", general, got, p, want)
+		}
+	}
+}
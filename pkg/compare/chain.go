@@ -0,0 +1,86 @@
+package compare
+
+import (
+	"fmt"
+
+	"github.com/quizizz/cas/pkg/ast"
+	"github.com/quizizz/cas/pkg/solve"
+)
+
+// compareChains compares chained relations as the conjunction of their
+// links. Links are first matched in any order; failing that, relations in
+// a single variable are equivalent when they have the same solution set.
+func compareChains(expr1, expr2 ast.Expr, options Options) ComparisonResult {
+	links1, ok1 := chainLinks(expr1)
+	links2, ok2 := chainLinks(expr2)
+	if !ok1 || !ok2 {
+		return ComparisonResult{
+			Equal:   false,
+			Message: fmt.Sprintf("Cannot compare %s with %s", expr1.Type(), expr2.Type()),
+		}
+	}
+
+	_, missing1 := unmatched(links1, links2, options)
+	_, missing2 := unmatched(links2, links1, options)
+	if !missing1 && !missing2 {
+		return ComparisonResult{
+			Equal:   true,
+			Message: "Relations are equivalent",
+			Details: map[string]interface{}{
+				"comparison_type": "conjunction",
+			},
+		}
+	}
+
+	vars1, vars2 := expr1.Variables(), expr2.Variables()
+	if len(vars1) == 1 && len(vars2) == 1 && vars1[0] == vars2[0] {
+		solveOpts := solve.DefaultSolveOptions()
+		solveOpts.Variable = vars1[0]
+		set1, err1 := solve.SolveInequality(expr1, solveOpts)
+		set2, err2 := solve.SolveInequality(expr2, solveOpts)
+		for _, err := range []error{err1, err2} {
+			if err != nil {
+				return ComparisonResult{
+					Equal:   false,
+					Message: fmt.Sprintf("Cannot compare solution sets: %v", err),
+				}
+			}
+		}
+		if set1.Equal(set2) {
+			return ComparisonResult{
+				Equal:   true,
+				Message: "Relations have the same solution set",
+				Details: map[string]interface{}{
+					"comparison_type": "solution_set",
+					"solution":        set1.String(),
+				},
+			}
+		}
+		return ComparisonResult{
+			Equal:   false,
+			Message: fmt.Sprintf("Relations have different solution sets: %s vs %s", set1, set2),
+		}
+	}
+
+	return ComparisonResult{
+		Equal:   false,
+		Message: "Relations are not equivalent",
+	}
+}
+
+// chainLinks returns the comparisons making up a chain, or a single
+// comparison on its own
+func chainLinks(expr ast.Expr) ([]ast.Expr, bool) {
+	switch e := expr.(type) {
+	case *ast.Chain:
+		links := []ast.Expr{}
+		for _, link := range e.Links() {
+			links = append(links, link)
+		}
+		return links, true
+	case *ast.Eq:
+		return []ast.Expr{e}, true
+	default:
+		return nil, false
+	}
+}
@@ -39,6 +39,10 @@ type Options struct {
 	Tolerance float64
 	// Variables to check for consistency
 	RequireVariables []string
+	// Assumptions about the variables: expressions are simplified under
+	// them and sampled only where they hold. A variable with an assumption
+	// may appear in only one of the expressions.
+	Assumptions ast.Assumptions
 }
 
 // DefaultOptions returns the default comparison options
@@ -94,6 +98,33 @@ func CompareWithInputs(expr1, expr2 ast.Expr, input1, input2 string, opts ...Opt
 		}
 	}
 
+	// Measurements are compared in SI base units, after checking that
+	// their dimensions agree
+	if containsType(expr1, ast.TypeQuantity) || containsType(expr2, ast.TypeQuantity) {
+		return compareQuantities(expr1, expr2, options)
+	}
+
+	// Solution sets match in any order, and a ± answer stands for the set
+	// of its two branches
+	if expr1.Type() == ast.TypeSet || expr2.Type() == ast.TypeSet || hasPlusMinus(expr1) || hasPlusMinus(expr2) {
+		return compareSets(expr1, expr2, options)
+	}
+
+	// Chained relations are compared as the conjunction of their links
+	if expr1.Type() == ast.TypeChain || expr2.Type() == ast.TypeChain {
+		return compareChains(expr1, expr2, options)
+	}
+
+	// Tuples are compared component by component
+	if expr1.Type() == ast.TypeTuple || expr2.Type() == ast.TypeTuple {
+		return compareTuples(expr1, expr2, options)
+	}
+
+	// Matrices and vectors are compared entry by entry
+	if isArray(expr1) || isArray(expr2) {
+		return compareArrays(expr1, expr2, options)
+	}
+
 	// Handle equation comparison first (from Node.js KAS insight)
 	if expr1.Type() == ast.TypeEq && expr2.Type() == ast.TypeEq {
 		eq1 := expr1.(*ast.Eq)
@@ -196,11 +227,20 @@ func CompareWithInputs(expr1, expr2 ast.Expr, input1, input2 string, opts ...Opt
 		}
 	}
 
-	// 1. Check variables are consistent
+	// Functions defined by cases are sampled in every region and on both
+	// sides of each boundary
+	if containsType(expr1, ast.TypePiecewise) || containsType(expr2, ast.TypePiecewise) {
+		return comparePiecewise(expr1, expr2, options)
+	}
+
+	// 1. Check variables are consistent. A variable in only one expression
+	// may still drop out under the assumptions on it, as n does from
+	// cos(2*pi*n) = 1 for an integer n, so it is sampled with the others.
 	vars1 := expr1.Variables()
 	vars2 := expr2.Variables()
+	vars := unionVariables(vars1, vars2)
 
-	if !sameVariables(vars1, vars2) {
+	if !sameVariables(vars1, vars2) && !onlyAssumedDiffer(vars1, vars2, options.Assumptions) {
 		return ComparisonResult{
 			Equal: false,
 			Message: fmt.Sprintf("Different variables: expr1 has %v, expr2 has %v",
@@ -248,8 +288,10 @@ func CompareWithInputs(expr1, expr2 ast.Expr, input1, input2 string, opts ...Opt
 	}
 
 	// 4. Check semantic equivalence by simplifying both
-	simplified1 := simplify.Simplify(expr1)
-	simplified2 := simplify.Simplify(expr2)
+	simplifyOptions := simplify.DefaultOptions()
+	simplifyOptions.Assumptions = options.Assumptions
+	simplified1 := simplify.Simplify(expr1, simplifyOptions)
+	simplified2 := simplify.Simplify(expr2, simplifyOptions)
 
 	if simplified1.String() == simplified2.String() {
 		return ComparisonResult{
@@ -263,8 +305,8 @@ func CompareWithInputs(expr1, expr2 ast.Expr, input1, input2 string, opts ...Opt
 	}
 
 	// 5. Check numeric equivalence by evaluation
-	if len(vars1) > 0 {
-		result := checkNumericEquivalence(expr1, expr2, vars1, options.Tolerance)
+	if len(vars) > 0 {
+		result := checkNumericEquivalence(expr1, expr2, vars, options.Tolerance, options.Assumptions)
 		if result.Equal {
 			return result
 		}
@@ -379,7 +421,7 @@ func SemanticallyEqual(expr1, expr2 ast.Expr) bool {
 	}
 
 	// Test with multiple variable values (similar to Node.js compare method)
-	result := checkNumericEquivalence(expr1, expr2, vars1, math.Pow(10, -TOLERANCE_EXP))
+	result := checkNumericEquivalence(expr1, expr2, vars1, math.Pow(10, -TOLERANCE_EXP), nil)
 	return result.Equal
 }
 
@@ -407,12 +449,34 @@ func NumericallyEqual(expr1, expr2 ast.Expr, tolerance float64) bool {
 	}
 
 	// Test with multiple variable values
-	result := checkNumericEquivalence(expr1, expr2, vars1, tolerance)
+	result := checkNumericEquivalence(expr1, expr2, vars1, tolerance, nil)
 	return result.Equal
 }
 
 // Helper functions
 
+// onlyAssumedDiffer reports whether every variable found in only one of
+// the lists has an assumption on it
+func onlyAssumedDiffer(vars1, vars2 []string, assumptions ast.Assumptions) bool {
+	for _, v := range unionVariables(vars1, vars2) {
+		if containsVariable(vars1, v) != containsVariable(vars2, v) && assumptions[v] == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// unionVariables returns the variables in either list, each once
+func unionVariables(vars1, vars2 []string) []string {
+	var union []string
+	for _, v := range append(append([]string{}, vars1...), vars2...) {
+		if !containsVariable(union, v) {
+			union = append(union, v)
+		}
+	}
+	return union
+}
+
 func sameVariables(vars1, vars2 []string) bool {
 	if len(vars1) != len(vars2) {
 		return false
@@ -984,7 +1048,21 @@ func containsVariable(vars []string, target string) bool {
 	return false
 }
 
-func checkNumericEquivalence(expr1, expr2 ast.Expr, vars []string, tolerance float64) ComparisonResult {
+// containsType reports whether expr or any of its subexpressions has the
+// given type
+func containsType(expr ast.Expr, exprType ast.ExprType) bool {
+	if expr.Type() == exprType {
+		return true
+	}
+	for _, child := range ast.Children(expr) {
+		if containsType(child, exprType) {
+			return true
+		}
+	}
+	return false
+}
+
+func checkNumericEquivalence(expr1, expr2 ast.Expr, vars []string, tolerance float64, assumptions ast.Assumptions) ComparisonResult {
 	// Use a seeded random generator for reproducible results within a test run
 	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
 
@@ -1011,7 +1089,10 @@ func checkNumericEquivalence(expr1, expr2 ast.Expr, vars []string, tolerance flo
 				// Generate random integer in range [-valueRange, valueRange]
 				value = float64(rng.Intn(int(2*valueRange)+1) - int(valueRange))
 			}
-			varMap[varName] = big.NewFloat(value)
+			if assumptions.Has(varName, ast.Positive) {
+				value = positiveSample(rng, i)
+			}
+			varMap[varName] = big.NewFloat(assumedValue(value, varName, assumptions))
 		}
 
 		val1, err1 := expr1.Eval(varMap)
@@ -1101,6 +1182,31 @@ func checkNumericEquivalence(expr1, expr2 ast.Expr, vars []string, tolerance flo
 	}
 }
 
+// positiveSample draws a sample for a positive variable on a log scale.
+// The iterations are spread evenly over the exponents -3 to 3, so that
+// half of the points lie in (0, 1), where expressions such as sqrt((x-1)^2)
+// and x-1 differ.
+func positiveSample(rng *rand.Rand, i int) float64 {
+	exponent := -3 + 6*(float64(i)+rng.Float64())/float64(ITERATIONS)
+	return math.Pow(10, exponent)
+}
+
+// assumedValue moves a sample value into the domain the assumptions on the
+// named variable allow: integer variables are rounded, positive ones take
+// the absolute value, and a zero is replaced by one where it is excluded
+func assumedValue(value float64, name string, assumptions ast.Assumptions) float64 {
+	if assumptions.Has(name, ast.Integer) {
+		value = math.Round(value)
+	}
+	if assumptions.Has(name, ast.Positive) {
+		value = math.Abs(value)
+	}
+	if value == 0 && assumptions.Has(name, ast.Nonzero) {
+		value = 1
+	}
+	return value
+}
+
 func checkSameForm(expr1, expr2 ast.Expr) bool {
 	return getExpressionForm(expr1) == getExpressionForm(expr2)
 }
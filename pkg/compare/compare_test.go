@@ -1,6 +1,7 @@
 package compare
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/quizizz/cas/pkg/ast"
@@ -158,6 +159,62 @@ func TestCompare(t *testing.T) {
 			true,
 			"numerically equivalent",
 		},
+		{
+			"logarithm with a base",
+			"\\log_2 8",
+			"3",
+			DefaultOptions(),
+			true,
+			"numerically equivalent",
+		},
+		{
+			"change of base",
+			"\\log_b(x)",
+			"\\frac{\\ln x}{\\ln b}",
+			DefaultOptions(),
+			true,
+			"numerically equivalent",
+		},
+		{
+			"common logarithm",
+			"\\log_{10} x",
+			"\\log x",
+			DefaultOptions(),
+			true,
+			"numerically equivalent",
+		},
+		{
+			"different bases",
+			"\\log_2 x",
+			"\\log_3 x",
+			DefaultOptions(),
+			false,
+			"not equivalent",
+		},
+		{
+			"sine of degrees",
+			"\\sin(30°)",
+			"\\frac{1}{2}",
+			DefaultOptions(),
+			true,
+			"numerically equivalent",
+		},
+		{
+			"degrees and radians",
+			"12°30' + 180^\\circ",
+			"\\frac{5\\pi}{72} + \\pi",
+			DefaultOptions(),
+			true,
+			"numerically equivalent",
+		},
+		{
+			"degrees are not radians",
+			"\\sin 30^\\circ",
+			"\\sin(30)",
+			DefaultOptions(),
+			false,
+			"not equivalent",
+		},
 		{
 			"required variable missing",
 			"y+1",
@@ -335,3 +392,281 @@ func TestEdgeCases2(t *testing.T) {
 		})
 	}
 }
+
+func TestCompareMatrices(t *testing.T) {
+	tests := []struct {
+		name     string
+		expr1    string
+		expr2    string
+		expected bool
+	}{
+		{"identical", "[[1,2],[3,4]]", "[[1,2],[3,4]]", true},
+		{"equivalent entries", "[[x+x,1/2],[0,1]]", "[[2x,0.5],[0,1]]", true},
+		{"latex and brackets", "\\begin{pmatrix}x^2 & 1\\end{pmatrix}", "[[x*x,1]]", true},
+		{"different entry", "[[1,2],[3,4]]", "[[1,2],[3,5]]", false},
+		{"different shape", "[[1,2]]", "[[1],[2]]", false},
+		{"vectors", "[x+1,2]", "[1+x,2]", true},
+		{"vector and matrix", "[1,2]", "[[1],[2]]", false},
+		{"matrix and scalar", "[[1]]", "1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr1, err := parser.Parse(tt.expr1)
+			if err != nil {
+				t.Fatalf("Parse(%s) error: %v", tt.expr1, err)
+			}
+			expr2, err := parser.Parse(tt.expr2)
+			if err != nil {
+				t.Fatalf("Parse(%s) error: %v", tt.expr2, err)
+			}
+
+			result := Compare(expr1, expr2)
+			if result.Equal != tt.expected {
+				t.Errorf("Compare(%s, %s) = %v (%s), expected %v", tt.expr1, tt.expr2, result.Equal, result.Message, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCompareTuplesAndSets(t *testing.T) {
+	tests := []struct {
+		name     string
+		expr1    string
+		expr2    string
+		expected bool
+	}{
+		{"same point", "(2, -3)", "(2, -3)", true},
+		{"equivalent components", "(1/2, 2x)", "(0.5, x+x)", true},
+		{"swapped point", "(2, -3)", "(-3, 2)", false},
+		{"different lengths", "(1, 2)", "(1, 2, 3)", false},
+		{"point and scalar", "(1, 2)", "1", false},
+		{"solutions in order", "x = 2, x = -1", "x = 2, x = -1", true},
+		{"solutions reordered", "x = 2, x = -1", "x = -1, x = 2", true},
+		{"rearranged solution", "x = 2, x = -1", "x + 1 = 0, 2 = x", true},
+		{"missing solution", "x = 2, x = -1", "x = 2", false},
+		{"wrong solution", "x = 2, x = -1", "x = 2, x = 1", false},
+		{"single answer", "\\{4\\}", "4", true},
+		{"list of points", "(1, 2), (3, 4)", "(3, 4), (1, 2)", true},
+		{"points not swapped inside", "(1, 2), (3, 4)", "(2, 1), (4, 3)", false},
+		{"plus minus and its roots", "x = \\frac{-3 \\pm \\sqrt{5}}{2}", "x = \\frac{-3 - \\sqrt{5}}{2}, x = \\frac{-3 + \\sqrt{5}}{2}", true},
+		{"unary plus minus", "\\pm 2", "2, -2", true},
+		{"plus minus in both answers", "1 \\pm \\sqrt{2}", "\\sqrt{2} \\pm 1", false},
+		{"plus minus and one root", "\\pm 2", "2", false},
+		{"plus minus and wrong roots", "1 \\pm \\sqrt{2}", "1 + \\sqrt{2}, 1 - \\sqrt{3}", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr1, err := parser.Parse(tt.expr1)
+			if err != nil {
+				t.Fatalf("Parse(%s) error: %v", tt.expr1, err)
+			}
+			expr2, err := parser.Parse(tt.expr2)
+			if err != nil {
+				t.Fatalf("Parse(%s) error: %v", tt.expr2, err)
+			}
+
+			result := Compare(expr1, expr2)
+			if result.Equal != tt.expected {
+				t.Errorf("Compare(%s, %s) = %v (%s), expected %v", tt.expr1, tt.expr2, result.Equal, result.Message, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCompareChainedRelations(t *testing.T) {
+	tests := []struct {
+		name     string
+		expr1    string
+		expr2    string
+		expected bool
+	}{
+		{"identical", "1 < x <= 5", "1 < x <= 5", true},
+		{"reversed direction", "1 < x <= 5", "5 >= x > 1", true},
+		{"same solution set", "-2 < 3x + 1 <= 7", "-1 < x <= 2", true},
+		{"different endpoint", "1 < x <= 5", "1 <= x <= 5", false},
+		{"wider interval", "-2 < 3x + 1 <= 7", "-1 < x <= 3", false},
+		{"chain and single relation", "0 < x < 10", "x > 0", false},
+		{"redundant link", "0 <= x <= 1 < 2", "0 <= x <= 1", true},
+		{"chain and expression", "1 < x < 2", "x", false},
+		{"reciprocal chain", "1 < 1/x < 2", "1/2 < x < 1", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr1, err := parser.Parse(tt.expr1)
+			if err != nil {
+				t.Fatalf("Parse(%s) error: %v", tt.expr1, err)
+			}
+			expr2, err := parser.Parse(tt.expr2)
+			if err != nil {
+				t.Fatalf("Parse(%s) error: %v", tt.expr2, err)
+			}
+
+			result := Compare(expr1, expr2)
+			if result.Equal != tt.expected {
+				t.Errorf("Compare(%s, %s) = %v (%s), expected %v", tt.expr1, tt.expr2, result.Equal, result.Message, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCompareChainedRelationsUnsolved(t *testing.T) {
+	// The solver cannot yet handle 2^x, and must say so rather than report
+	// an empty solution set
+	expr1, _ := parser.Parse("1 < 2^x < 2")
+	expr2, _ := parser.Parse("0 < x < 1")
+	result := Compare(expr1, expr2)
+	if result.Equal || !strings.HasPrefix(result.Message, "Cannot compare solution sets") {
+		t.Errorf("Compare(%s, %s) = %v (%s)", expr1, expr2, result.Equal, result.Message)
+	}
+}
+
+func TestCompareQuantities(t *testing.T) {
+	tests := []struct {
+		name     string
+		expr1    string
+		expr2    string
+		expected bool
+		message  string
+	}{
+		{"same quantity", "9.8 m/s^2", "9.8\\,\\mathrm{m}/\\mathrm{s}^{2}", true, ""},
+		{"converted unit", "5 \\text{ km}", "5000 m", true, ""},
+		{"speed in other units", "72 km/h", "20 m s^{-1}", true, ""},
+		{"derived unit", "10 N", "10 kg \\cdot m/s^2", true, ""},
+		{"sum of lengths", "1 m + 50 cm", "150 cm", true, ""},
+		{"wrong value", "5 km", "500 m", false, ""},
+		{"wrong dimension", "5 m", "5 m/s", false, "Dimensions differ: m vs m*s^-1"},
+		{"missing unit", "5 m", "5", false, "Dimensions differ: m vs dimensionless"},
+		{"adding length and time", "1 m + 2 s", "3 m", false, "Inconsistent units: cannot add m and s"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr1, err := parser.Parse(tt.expr1, parser.Options{Units: true})
+			if err != nil {
+				t.Fatalf("Parse(%s) error: %v", tt.expr1, err)
+			}
+			expr2, err := parser.Parse(tt.expr2, parser.Options{Units: true})
+			if err != nil {
+				t.Fatalf("Parse(%s) error: %v", tt.expr2, err)
+			}
+
+			result := Compare(expr1, expr2)
+			if result.Equal != tt.expected {
+				t.Errorf("Compare(%s, %s) = %v (%s), expected %v", tt.expr1, tt.expr2, result.Equal, result.Message, tt.expected)
+			}
+			if tt.message != "" && result.Message != tt.message {
+				t.Errorf("Compare(%s, %s) message = %q, expected %q", tt.expr1, tt.expr2, result.Message, tt.message)
+			}
+		})
+	}
+}
+
+func TestCompareAssumptions(t *testing.T) {
+	positive := ast.Assume("x", ast.Positive)
+	integer := ast.Assume("n", ast.Integer)
+	tests := []struct {
+		name        string
+		expr1       string
+		expr2       string
+		assumptions ast.Assumptions
+		expected    bool
+	}{
+		{"square root of a square", "sqrt(x^2)", "x", nil, false},
+		{"square root of a positive square", "sqrt(x^2)", "x", positive, true},
+		{"absolute value", "abs(x)", "x", positive, true},
+		{"logarithm of a square", "\\ln(x^2)", "2\\ln x", positive, true},
+		{"power of a power", "(x^2)^{1/2}", "x", positive, true},
+		{"still different", "sqrt(x^2)", "-x", positive, false},
+		{"whole turns", "cos(2*pi*n)", "1", integer, true},
+		{"whole turns of a real angle", "cos(2*pi*n)", "1", nil, false},
+		{"half turns", "cos(pi*n)", "1", integer, false},
+		{"unassumed variable left over", "cos(2*pi*n) + x", "1", integer, false},
+		{"piecewise in whole turns", `\begin{cases} \cos(2\pi n) & n < 0 \\ 1 & \text{otherwise} \end{cases}`, "1", integer, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr1, err := parser.Parse(tt.expr1)
+			if err != nil {
+				t.Fatalf("Parse(%s) error: %v", tt.expr1, err)
+			}
+			expr2, err := parser.Parse(tt.expr2)
+			if err != nil {
+				t.Fatalf("Parse(%s) error: %v", tt.expr2, err)
+			}
+
+			opts := DefaultOptions()
+			opts.Assumptions = tt.assumptions
+			result := Compare(expr1, expr2, opts)
+			if result.Equal != tt.expected {
+				t.Errorf("Compare(%s, %s) = %v (%s), expected %v", tt.expr1, tt.expr2, result.Equal, result.Message, tt.expected)
+			}
+		})
+	}
+}
+
+func TestComparePositiveBelowOne(t *testing.T) {
+	// These pairs agree for x >= 1 and differ only in (0, 1), so every run
+	// must sample there
+	pairs := [][2]string{
+		{"\\sqrt{(x-1)^2}", "x-1"},
+		{"abs(x-0.5)", "x-0.5"},
+	}
+	opts := DefaultOptions()
+	opts.Assumptions = ast.Assume("x", ast.Positive)
+
+	for _, pair := range pairs {
+		expr1, err := parser.Parse(pair[0])
+		if err != nil {
+			t.Fatalf("Parse(%s) error: %v", pair[0], err)
+		}
+		expr2, err := parser.Parse(pair[1])
+		if err != nil {
+			t.Fatalf("Parse(%s) error: %v", pair[1], err)
+		}
+		for run := 0; run < 50; run++ {
+			if result := Compare(expr1, expr2, opts); result.Equal {
+				t.Fatalf("Compare(%s, %s) with x positive = true on run %d, expected false", pair[0], pair[1], run)
+			}
+		}
+	}
+}
+
+func TestComparePiecewise(t *testing.T) {
+	square := `\begin{cases} x^2 & x < 0 \\ 2x & \text{otherwise} \end{cases}`
+	tests := []struct {
+		name     string
+		expr1    string
+		expr2    string
+		expected bool
+	}{
+		{"explicit condition for otherwise", square, `\begin{cases} x^2 & x < 0 \\ 2x & x \ge 0 \end{cases}`, true},
+		{"cases reordered", square, `\begin{cases} 2x & x \ge 0 \\ x^2 & \text{otherwise} \end{cases}`, true},
+		{"boundary in either case of a continuous function", square, `\begin{cases} x^2 & x \le 0 \\ 2x & \text{otherwise} \end{cases}`, true},
+		{"boundary moved", square, `\begin{cases} x^2 & x < 1 \\ 2x & \text{otherwise} \end{cases}`, false},
+		{"step at the boundary", `\begin{cases} 1 & x < 0 \\ 2 & \text{otherwise} \end{cases}`, `\begin{cases} 1 & x \le 0 \\ 2 & \text{otherwise} \end{cases}`, false},
+		{"absolute value", `\begin{cases} -x & x < 0 \\ x & \text{otherwise} \end{cases}`, "abs(x)", true},
+		{"one case only", square, "2x", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr1, err := parser.Parse(tt.expr1)
+			if err != nil {
+				t.Fatalf("Parse(%s) error: %v", tt.expr1, err)
+			}
+			expr2, err := parser.Parse(tt.expr2)
+			if err != nil {
+				t.Fatalf("Parse(%s) error: %v", tt.expr2, err)
+			}
+
+			result := Compare(expr1, expr2)
+			if result.Equal != tt.expected {
+				t.Errorf("Compare(%s, %s) = %v (%s), expected %v", tt.expr1, tt.expr2, result.Equal, result.Message, tt.expected)
+			}
+		})
+	}
+}
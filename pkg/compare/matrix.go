@@ -0,0 +1,75 @@
+package compare
+
+import (
+	"fmt"
+
+	"github.com/quizizz/cas/pkg/ast"
+)
+
+// isArray reports whether expr is a matrix or vector
+func isArray(expr ast.Expr) bool {
+	return expr.Type() == ast.TypeMatrix || expr.Type() == ast.TypeVector
+}
+
+// compareArrays compares matrices or vectors entry by entry. Arrays of
+// different kinds or shapes are never equal.
+func compareArrays(expr1, expr2 ast.Expr, options Options) ComparisonResult {
+	if expr1.Type() != expr2.Type() {
+		return ComparisonResult{
+			Equal:   false,
+			Message: fmt.Sprintf("Cannot compare %s with %s", expr1.Type(), expr2.Type()),
+		}
+	}
+
+	rows1, rows2 := arrayRows(expr1), arrayRows(expr2)
+	r1, c1 := dimensions(rows1)
+	r2, c2 := dimensions(rows2)
+	if r1 != r2 || c1 != c2 {
+		return ComparisonResult{
+			Equal:   false,
+			Message: fmt.Sprintf("Different dimensions: %dx%d vs %dx%d", r1, c1, r2, c2),
+		}
+	}
+
+	for i := range rows1 {
+		for j := range rows1[i] {
+			result := Compare(rows1[i][j], rows2[i][j], options)
+			if !result.Equal {
+				return ComparisonResult{
+					Equal:   false,
+					Message: fmt.Sprintf("Entries at row %d, column %d differ: %s", i+1, j+1, result.Message),
+					Details: map[string]interface{}{
+						"row":    i + 1,
+						"column": j + 1,
+						"expr1":  rows1[i][j].String(),
+						"expr2":  rows2[i][j].String(),
+					},
+				}
+			}
+		}
+	}
+
+	return ComparisonResult{
+		Equal:   true,
+		Message: "All entries are equivalent",
+		Details: map[string]interface{}{
+			"comparison_type": "elementwise",
+		},
+	}
+}
+
+// arrayRows returns the entries of a matrix, or of a vector as a column
+func arrayRows(expr ast.Expr) [][]ast.Expr {
+	if v, ok := expr.(*ast.Vector); ok {
+		return v.AsColumn().Rows()
+	}
+	return expr.(*ast.Matrix).Rows()
+}
+
+// dimensions returns the row and column counts of an array's entries
+func dimensions(rows [][]ast.Expr) (int, int) {
+	if len(rows) == 0 {
+		return 0, 0
+	}
+	return len(rows), len(rows[0])
+}
@@ -0,0 +1,156 @@
+package compare
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+
+	"github.com/quizizz/cas/pkg/ast"
+	"github.com/quizizz/cas/pkg/solve"
+)
+
+// boundaryOffset is how far either side of a boundary, relative to its
+// size, a piecewise function is sampled
+const boundaryOffset = 1e-6
+
+// comparePiecewise compares expressions that contain functions defined by
+// cases. In one variable, both are evaluated inside every region between
+// the boundaries of the conditions, on each boundary and just either side
+// of it, so a case that ends at the wrong point is caught; random points
+// are then checked as for any expression.
+func comparePiecewise(expr1, expr2 ast.Expr, options Options) ComparisonResult {
+	vars1, vars2 := expr1.Variables(), expr2.Variables()
+	vars := unionVariables(vars1, vars2)
+	if !sameVariables(vars1, vars2) && !onlyAssumedDiffer(vars1, vars2, options.Assumptions) {
+		return ComparisonResult{
+			Equal:   false,
+			Message: fmt.Sprintf("Different variables: expr1 has %v, expr2 has %v", vars1, vars2),
+			Details: map[string]interface{}{
+				"expr1_vars": vars1,
+				"expr2_vars": vars2,
+			},
+		}
+	}
+
+	if len(vars) == 1 {
+		variable := vars[0]
+		boundaries := append(caseBoundaries(expr1, variable), caseBoundaries(expr2, variable)...)
+		for _, point := range regionSamples(boundaries) {
+			if !options.Assumptions.Allows(variable, point) {
+				continue
+			}
+			vars := map[string]*big.Float{variable: big.NewFloat(point)}
+			val1, err1 := expr1.Eval(vars)
+			val2, err2 := expr2.Eval(vars)
+			if err1 != nil && err2 != nil {
+				continue
+			}
+			if err1 != nil || err2 != nil || !withinTolerance(val1, val2, options.Tolerance) {
+				details := map[string]interface{}{"variables": map[string]float64{variable: point}}
+				if err1 == nil && err2 == nil {
+					details["expr1_result"] = val1.Text('g', -1)
+					details["expr2_result"] = val2.Text('g', -1)
+				}
+				return ComparisonResult{
+					Equal:   false,
+					Message: fmt.Sprintf("Piecewise expressions differ at %s = %g", variable, point),
+					Details: details,
+				}
+			}
+		}
+	}
+
+	result := checkNumericEquivalence(expr1, expr2, vars, options.Tolerance, options.Assumptions)
+	if result.Equal {
+		result.Message = "Piecewise expressions agree in every region"
+	}
+	return result
+}
+
+// caseBoundaries returns the values of variable at which the relations in
+// the conditions of the cases in expr change between true and false
+func caseBoundaries(expr ast.Expr, variable string) []float64 {
+	var boundaries []float64
+	if pw, ok := expr.(*ast.Piecewise); ok {
+		for _, condition := range pw.Conditions() {
+			for _, relation := range relationLinks(condition) {
+				boundaries = append(boundaries, relationRoots(relation, variable)...)
+			}
+		}
+	}
+	for _, child := range ast.Children(expr) {
+		boundaries = append(boundaries, caseBoundaries(child, variable)...)
+	}
+	return boundaries
+}
+
+// relationLinks returns the single relations a condition is made of
+func relationLinks(condition ast.Expr) []*ast.Eq {
+	switch c := condition.(type) {
+	case *ast.Eq:
+		return []*ast.Eq{c}
+	case *ast.Chain:
+		return c.Links()
+	}
+	return nil
+}
+
+// relationRoots returns the real values of variable where both sides of a
+// relation are equal
+func relationRoots(relation *ast.Eq, variable string) []float64 {
+	opts := solve.DefaultSolveOptions()
+	opts.Variable = variable
+	var roots []float64
+	for _, solution := range solve.SolveEquation(relation.Left(), relation.Right(), opts).Solutions {
+		if !solution.IsReal {
+			continue
+		}
+		value, err := solution.Value.Eval(nil)
+		if err != nil {
+			continue
+		}
+		if root, _ := value.Float64(); !math.IsNaN(root) && !math.IsInf(root, 0) {
+			roots = append(roots, root)
+		}
+	}
+	return roots
+}
+
+// regionSamples returns points to evaluate a piecewise function at: each
+// boundary and points just either side of it, a point inside each region
+// between boundaries, and points beyond the outermost ones
+func regionSamples(boundaries []float64) []float64 {
+	if len(boundaries) == 0 {
+		return nil
+	}
+	sort.Float64s(boundaries)
+
+	var points []float64
+	for i, b := range boundaries {
+		if i > 0 && b == boundaries[i-1] {
+			continue
+		}
+		offset := boundaryOffset * math.Max(1, math.Abs(b))
+		points = append(points, b-offset, b, b+offset)
+		if i > 0 {
+			points = append(points, (boundaries[i-1]+b)/2)
+		}
+	}
+	first, last := boundaries[0], boundaries[len(boundaries)-1]
+	return append(points, first-1-math.Abs(first), last+1+math.Abs(last))
+}
+
+// withinTolerance reports whether two values agree, absolutely for values
+// below 1 and relatively for larger ones
+func withinTolerance(val1, val2 *big.Float, tolerance float64) bool {
+	diff := new(big.Float).Sub(val1, val2)
+	diff.Abs(diff)
+	f1, _ := val1.Float64()
+	f2, _ := val2.Float64()
+	scale := math.Max(math.Abs(f1), math.Abs(f2))
+	if scale < 1 {
+		scale = 1
+	}
+	return diff.Cmp(big.NewFloat(scale*tolerance)) <= 0
+}
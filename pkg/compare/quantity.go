@@ -0,0 +1,53 @@
+package compare
+
+import (
+	"fmt"
+
+	"github.com/quizizz/cas/pkg/ast"
+	"github.com/quizizz/cas/pkg/units"
+)
+
+// compareQuantities compares measurements in SI base units, so 5 km and
+// 5000 m are equal. Answers of different dimensions are never equal, and
+// a bare number does not match a quantity.
+func compareQuantities(expr1, expr2 ast.Expr, options Options) ComparisonResult {
+	si1, dim1, err1 := units.ToSI(expr1)
+	si2, dim2, err2 := units.ToSI(expr2)
+	if err1 != nil || err2 != nil {
+		err := err1
+		if err == nil {
+			err = err2
+		}
+		return ComparisonResult{
+			Equal:   false,
+			Message: fmt.Sprintf("Inconsistent units: %v", err),
+		}
+	}
+
+	if dim1 != dim2 {
+		return ComparisonResult{
+			Equal:   false,
+			Message: fmt.Sprintf("Dimensions differ: %s vs %s", dim1, dim2),
+			Details: map[string]interface{}{
+				"expr1_dimension": dim1.String(),
+				"expr2_dimension": dim2.String(),
+			},
+		}
+	}
+
+	result := Compare(si1, si2, options)
+	message := "Quantities are not equal"
+	if result.Equal {
+		message = "Quantities are equal"
+	}
+	return ComparisonResult{
+		Equal:   result.Equal,
+		Message: message,
+		Details: map[string]interface{}{
+			"comparison_type": "units",
+			"dimension":       dim1.String(),
+			"expr1_si":        si1.String(),
+			"expr2_si":        si2.String(),
+		},
+	}
+}
@@ -0,0 +1,120 @@
+package compare
+
+import (
+	"fmt"
+
+	"github.com/quizizz/cas/pkg/ast"
+)
+
+// compareTuples compares tuples component by component, in order
+func compareTuples(expr1, expr2 ast.Expr, options Options) ComparisonResult {
+	tuple1, ok1 := expr1.(*ast.Tuple)
+	tuple2, ok2 := expr2.(*ast.Tuple)
+	if !ok1 || !ok2 {
+		return ComparisonResult{
+			Equal:   false,
+			Message: fmt.Sprintf("Cannot compare %s with %s", expr1.Type(), expr2.Type()),
+		}
+	}
+
+	if tuple1.Len() != tuple2.Len() {
+		return ComparisonResult{
+			Equal:   false,
+			Message: fmt.Sprintf("Tuples have different lengths: %d vs %d", tuple1.Len(), tuple2.Len()),
+		}
+	}
+
+	for i := 0; i < tuple1.Len(); i++ {
+		result := Compare(tuple1.At(i), tuple2.At(i), options)
+		if !result.Equal {
+			return ComparisonResult{
+				Equal:   false,
+				Message: fmt.Sprintf("Components at position %d differ: %s", i+1, result.Message),
+				Details: map[string]interface{}{
+					"position": i + 1,
+					"expr1":    tuple1.At(i).String(),
+					"expr2":    tuple2.At(i).String(),
+				},
+			}
+		}
+	}
+
+	return ComparisonResult{
+		Equal:   true,
+		Message: "All components are equivalent",
+		Details: map[string]interface{}{
+			"comparison_type": "ordered",
+		},
+	}
+}
+
+// compareSets matches members in any order. Every member of each side must
+// be equivalent to some member of the other; a single answer is treated as
+// a one-member set, and a member with a ± as its two branches.
+func compareSets(expr1, expr2 ast.Expr, options Options) ComparisonResult {
+	members1, members2 := setMembers(expr1), setMembers(expr2)
+
+	if missing, ok := unmatched(members1, members2, options); ok {
+		return ComparisonResult{
+			Equal:   false,
+			Message: fmt.Sprintf("%s has no equivalent in the second answer", missing),
+			Details: map[string]interface{}{
+				"unmatched": missing.String(),
+			},
+		}
+	}
+	if missing, ok := unmatched(members2, members1, options); ok {
+		return ComparisonResult{
+			Equal:   false,
+			Message: fmt.Sprintf("%s has no equivalent in the first answer", missing),
+			Details: map[string]interface{}{
+				"unmatched": missing.String(),
+			},
+		}
+	}
+
+	return ComparisonResult{
+		Equal:   true,
+		Message: "Sets contain equivalent members",
+		Details: map[string]interface{}{
+			"comparison_type": "unordered",
+		},
+	}
+}
+
+// unmatched returns the first member of a with no equivalent member in b
+func unmatched(a, b []ast.Expr, options Options) (ast.Expr, bool) {
+	for _, x := range a {
+		found := false
+		for _, y := range b {
+			if Compare(x, y, options).Equal {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return x, true
+		}
+	}
+	return nil, false
+}
+
+// setMembers returns the members of a set, or the expression itself, with
+// each ± expanded into its two branches
+func setMembers(expr ast.Expr) []ast.Expr {
+	members := []ast.Expr{expr}
+	if s, ok := expr.(*ast.Set); ok {
+		members = s.Elements()
+	}
+
+	var expanded []ast.Expr
+	for _, member := range members {
+		expanded = append(expanded, ast.ExpandPlusMinus(member)...)
+	}
+	return expanded
+}
+
+// hasPlusMinus reports whether expr contains a ±
+func hasPlusMinus(expr ast.Expr) bool {
+	return len(ast.ExpandPlusMinus(expr)) > 1
+}
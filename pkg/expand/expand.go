@@ -13,6 +13,8 @@ type Options struct {
 	MaxDegree int
 	// ExpandLogs enables logarithm expansion (ln(xy) = ln(x) + ln(y))
 	ExpandLogs bool
+	// ChangeLogBase rewrites logarithms in natural logs (log_b(x) = ln(x)/ln(b))
+	ChangeLogBase bool
 	// ExpandTrig enables trigonometric expansion
 	ExpandTrig bool
 }
@@ -137,6 +139,9 @@ func expandFunc(fn *ast.Func, opts Options) ast.Expr {
 	// Apply specific function expansion rules
 	switch fn.Name() {
 	case "ln", "log":
+		if opts.ChangeLogBase && fn.Name() == "log" {
+			return changeLogBase(expandedFunc, opts)
+		}
 		if opts.ExpandLogs {
 			return expandLogarithm(expandedFunc)
 		}
@@ -249,32 +254,80 @@ func expandByRepeatedMultiplication(add *ast.Add, n int, opts Options) ast.Expr
 // Logarithm expansion functions
 func expandLogarithm(fn *ast.Func) ast.Expr {
 	args := fn.Args()
-	if len(args) != 1 {
+	base := logBase(fn)
+	if base == nil {
 		return fn
 	}
 
 	arg := args[0]
 
+	// logOf takes the logarithm of x in the same base
+	logOf := func(x ast.Expr) ast.Expr {
+		return expandLogarithm(ast.NewFunc(fn.Name(), append([]ast.Expr{x}, args[1:]...)...))
+	}
+
+	// log_b(b) = 1 and log_b(1) = 0
+	if arg.Equal(base) {
+		return ast.NewInt(1)
+	}
+	if arg.Equal(ast.NewInt(1)) {
+		return ast.NewInt(0)
+	}
+	// log_2(8) = 3
+	if k, ok := ast.IntegerLog(arg, base); ok {
+		return ast.NewInt(k)
+	}
+
 	// ln(xy) = ln(x) + ln(y)
 	if mul, ok := arg.(*ast.Mul); ok {
 		factors := mul.Terms()
 		logTerms := make([]ast.Expr, len(factors))
 		for i, factor := range factors {
-			logTerms[i] = ast.NewFunc(fn.Name(), factor)
+			logTerms[i] = logOf(factor)
 		}
 		return ast.NewAdd(logTerms...)
 	}
 
-	// ln(x^y) = y * ln(x)
+	// ln(x^y) = y * ln(x), so log_b(b^y) = y
 	if pow, ok := arg.(*ast.Pow); ok {
-		base := pow.Base()
 		exp := pow.Exponent()
-		return ast.NewMul(exp, ast.NewFunc(fn.Name(), base))
+		inner := logOf(pow.Base())
+		if inner.Equal(ast.NewInt(1)) {
+			return exp
+		}
+		return ast.NewMul(exp, inner)
 	}
 
 	return fn
 }
 
+// logBase returns the base of a logarithm: e for ln, 10 for log and b for
+// the two-argument log(x, b). It returns nil for any other function.
+func logBase(fn *ast.Func) ast.Expr {
+	args := fn.Args()
+	switch {
+	case fn.Name() == "ln" && len(args) == 1:
+		return ast.E.Clone()
+	case fn.Name() == "log" && len(args) == 1:
+		return ast.NewInt(10)
+	case fn.Name() == "log" && len(args) == 2:
+		return args[1]
+	}
+	return nil
+}
+
+// changeLogBase rewrites a logarithm in natural logs: log_b(x) = ln(x)/ln(b)
+func changeLogBase(fn *ast.Func, opts Options) ast.Expr {
+	base := logBase(fn)
+	if base == nil {
+		return fn
+	}
+
+	numerator := expandFunc(ast.NewFunc("ln", fn.Args()[0]), opts)
+	denominator := expandFunc(ast.NewFunc("ln", base), opts)
+	return ast.NewMul(numerator, ast.NewPow(denominator, ast.NewInt(-1)))
+}
+
 // Trigonometric expansion functions
 func expandTangent(fn *ast.Func) ast.Expr {
 	args := fn.Args()
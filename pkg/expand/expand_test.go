@@ -372,3 +372,36 @@ func BenchmarkExpandComplex(b *testing.B) {
 		Expand(expr)
 	}
 }
+
+func TestExpandLogBases(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		options  Options
+		expected string
+	}{
+		{"product in base 2", "\\log_2(8x)", Options{ExpandLogs: true}, "3+log(x, 2)"},
+		{"power of the base", "\\log_2(2^k)", Options{ExpandLogs: true}, "k"},
+		{"log of the base", "\\log_b(b)", Options{ExpandLogs: true}, "1"},
+		{"log of one", "\\log_b(1)", Options{ExpandLogs: true}, "0"},
+		{"integer power", "\\log_3 81", Options{ExpandLogs: true}, "4"},
+		{"not an integer power", "\\log_3 10", Options{ExpandLogs: true}, "log(10, 3)"},
+		{"natural log of a power of e", "\\ln(e^3)", Options{ExpandLogs: true}, "3"},
+		{"change of base", "\\log_2 x", Options{ChangeLogBase: true}, "ln(x)*ln(2)^-1"},
+		{"change of common log", "\\log x", Options{ChangeLogBase: true}, "ln(x)*ln(10)^-1"},
+		{"change of base and expansion", "\\log_b(xy)", Options{ChangeLogBase: true, ExpandLogs: true}, "(ln(x)+ln(y))*ln(b)^-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := parser.Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+
+			if result := Expand(expr, tt.options).String(); result != tt.expected {
+				t.Errorf("Expand(%s) = %s, expected %s", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
@@ -23,6 +23,21 @@ type FormatOptions struct {
 	UseSymbols bool
 	// MaxDecimalPlaces limits decimal precision in output
 	MaxDecimalPlaces int
+	// UseFunctionPowers writes a power of a trigonometric or hyperbolic
+	// function on its name, as in \sin^{2}\left(x\right)
+	UseFunctionPowers bool
+	// UseInverseNotation writes inverse functions with a power of -1 on the
+	// name, as in \sin^{-1}\left(x\right) for arcsin
+	UseInverseNotation bool
+	// UseMixedNumbers writes an answer that is an improper fraction as a
+	// mixed number, as in 2\frac{1}{2}
+	UseMixedNumbers bool
+	// UsePercent writes an answer that is a number as a percentage, as in
+	// 35\%
+	UsePercent bool
+	// UseScientificNotation writes an answer that is a number in scientific
+	// notation, as in 3.2 \times 10^{4}
+	UseScientificNotation bool
 }
 
 // DefaultFormatOptions returns the default LaTeX formatting options
@@ -44,6 +59,11 @@ func Format(expr ast.Expr, opts ...FormatOptions) string {
 		options = opts[0]
 	}
 
+	// Number styles apply to a whole answer, not to the numbers inside
+	// an expression
+	if styled, ok := formatNumberStyle(expr, options); ok {
+		return styled
+	}
 	return formatExpression(expr, options, 0)
 }
 
@@ -62,25 +82,67 @@ func formatExpression(expr ast.Expr, opts FormatOptions, parentPrec int) string
 		return formatConstant(e, opts)
 	case *ast.Add:
 		return formatAddition(e, opts, parentPrec)
+	case *ast.PlusMinus:
+		return formatPlusMinus(e, opts, parentPrec)
+	case *ast.Angle:
+		return formatAngle(e, opts)
+	case *ast.Quantity:
+		return formatQuantity(e, opts, parentPrec)
+	case *ast.Piecewise:
+		return formatPiecewise(e, opts)
 	case *ast.Mul:
 		return formatMultiplication(e, opts, parentPrec)
 	case *ast.Pow:
 		return formatPower(e, opts, parentPrec)
 	case *ast.Func:
 		return formatFunction(e, opts)
+	case *ast.Derivative:
+		return formatDerivativeNode(e, opts)
+	case *ast.Matrix:
+		return FormatMatrix(e.Rows(), opts)
+	case *ast.Vector:
+		return FormatMatrix(e.AsColumn().Rows(), opts)
+	case *ast.Tuple:
+		return "\\left(" + formatList(e.Elements(), opts) + "\\right)"
+	case *ast.Set:
+		return "\\left\\{" + formatList(e.Elements(), opts) + "\\right\\}"
+	case *ast.Eq:
+		return formatRelation(e, opts)
+	case *ast.Chain:
+		return formatChain(e, opts)
 	default:
 		return expr.String()
 	}
 }
 
-func formatInteger(i *ast.Int, opts FormatOptions) string {
-	val, _ := i.Eval(make(map[string]*big.Float))
-	intVal, _ := val.Int64()
+// relationSymbols are the LaTeX spellings of the relations
+var relationSymbols = map[ast.EqType]string{
+	ast.EqEqual:        "=",
+	ast.EqLess:         "<",
+	ast.EqGreater:      ">",
+	ast.EqLessEqual:    "\\le",
+	ast.EqGreaterEqual: "\\ge",
+	ast.EqNotEqual:     "\\ne",
+}
 
-	if intVal < 0 {
-		return fmt.Sprintf("-%d", -intVal)
+// formatRelation writes an equation or inequality, as in x = \pm 2
+func formatRelation(eq *ast.Eq, opts FormatOptions) string {
+	return formatExpression(eq.Left(), opts, 0) + " " + relationSymbols[eq.EqType()] + " " + formatExpression(eq.Right(), opts, 0)
+}
+
+// formatChain writes a chained relation, as in -2 < 3x + 1 \le 7
+func formatChain(chain *ast.Chain, opts FormatOptions) string {
+	operands := chain.Operands()
+	parts := []string{formatExpression(operands[0], opts, 0)}
+	for i, relation := range chain.Relations() {
+		parts = append(parts, relationSymbols[relation], formatExpression(operands[i+1], opts, 0))
 	}
-	return fmt.Sprintf("%d", intVal)
+	return strings.Join(parts, " ")
+}
+
+func formatInteger(i *ast.Int, opts FormatOptions) string {
+	// Integers of any size, such as 6.02e23 read exactly, print in full
+	return i.IntValue().String()
 }
 
 func formatFloat(f *ast.Float, opts FormatOptions) string {
@@ -139,34 +201,9 @@ func formatVariable(v *ast.Var, opts FormatOptions) string {
 		}
 	}
 
-	// Handle Greek letters and special symbols
-	if opts.UseSymbols {
-		switch name {
-		case "alpha":
-			return "\\alpha"
-		case "beta":
-			return "\\beta"
-		case "gamma":
-			return "\\gamma"
-		case "delta":
-			return "\\delta"
-		case "epsilon":
-			return "\\epsilon"
-		case "theta":
-			return "\\theta"
-		case "lambda":
-			return "\\lambda"
-		case "mu":
-			return "\\mu"
-		case "pi":
-			return "\\pi"
-		case "sigma":
-			return "\\sigma"
-		case "phi":
-			return "\\phi"
-		case "omega":
-			return "\\omega"
-		}
+	// Handle Greek letters
+	if _, ok := ast.GreekChar(name); ok && opts.UseSymbols {
+		return "\\" + name
 	}
 
 	// Multi-character variables get proper formatting
@@ -224,11 +261,70 @@ func formatAddition(add *ast.Add, opts FormatOptions, parentPrec int) string {
 	return result
 }
 
+func formatPlusMinus(pm *ast.PlusMinus, opts FormatOptions, parentPrec int) string {
+	// The operand that is added and subtracted binds like a subtrahend
+	result := "\\pm " + formatExpression(pm.Right(), opts, 2)
+	if left := pm.Left(); left != nil {
+		result = formatExpression(left, opts, 1) + " " + result
+	}
+
+	if parentPrec > 1 && opts.UseParentheses {
+		return fmt.Sprintf("\\left(%s\\right)", result)
+	}
+	return result
+}
+
+// formatAngle writes an angle in the notation it was given in, as in
+// 45^{\circ} or 12^{\circ}30'
+func formatAngle(angle *ast.Angle, opts FormatOptions) string {
+	result := formatExpression(angle.Degrees(), opts, 3) + "^{\\circ}"
+	if minutes := angle.Minutes(); minutes != nil {
+		result += formatExpression(minutes, opts, 3) + "'"
+	}
+	if seconds := angle.Seconds(); seconds != nil {
+		result += formatExpression(seconds, opts, 3) + "''"
+	}
+	return result
+}
+
+// formatQuantity writes a value and its unit in upright type, with a thin
+// space between them, as in 9.8\,\mathrm{m}/\mathrm{s}^{2}
+func formatQuantity(q *ast.Quantity, opts FormatOptions, parentPrec int) string {
+	result := formatExpression(q.Value(), opts, 2) + "\\," + q.UnitLaTeX()
+	if parentPrec > 2 && opts.UseParentheses {
+		return fmt.Sprintf("\\left(%s\\right)", result)
+	}
+	return result
+}
+
+// formatPiecewise writes the cases of a piecewise expression in a cases
+// environment, one row per case
+func formatPiecewise(pw *ast.Piecewise, opts FormatOptions) string {
+	conditions := pw.Conditions()
+	rows := make([]string, pw.Len())
+	for i, value := range pw.Values() {
+		condition := "\\text{otherwise}"
+		if conditions[i] != nil {
+			condition = formatExpression(conditions[i], opts, 0)
+		}
+		rows[i] = formatExpression(value, opts, 0) + " & " + condition
+	}
+	return "\\begin{cases} " + strings.Join(rows, " \\\\ ") + " \\end{cases}"
+}
+
 func formatMultiplication(mul *ast.Mul, opts FormatOptions, parentPrec int) string {
 	factors := mul.Terms()
 	if len(factors) == 0 {
 		return "1"
 	}
+	if len(factors) == 1 {
+		return formatExpression(factors[0], opts, parentPrec)
+	}
+	if opts.UseFractions {
+		if numerator, denominator, ok := splitReciprocal(factors); ok {
+			return fmt.Sprintf("\\frac{%s}{%s}", formatExpression(numerator, opts, 0), formatExpression(denominator, opts, 0))
+		}
+	}
 
 	var parts []string
 	var hasNegative bool
@@ -246,9 +342,15 @@ func formatMultiplication(mul *ast.Mul, opts FormatOptions, parentPrec int) stri
 			}
 		}
 
-		// Special formatting for common patterns
-		if i > 0 && needsMultiplicationSpace(factors[i-1], factor) {
+		// Special formatting for common patterns. A digit is never written
+		// straight before a number or a fraction, as 2\frac{1}{3} would
+		// read as a mixed number, and an angle is set off so that 2 and
+		// 30^{\circ} do not run together into 230^{\circ}.
+		if len(parts) > 0 && (needsMultiplicationSpace(factors[i-1], factor) || endsWithDigit(parts) && startsWithNumber(formatted) || isAngle(factor) && startsWithNumber(formatted)) {
 			parts = append(parts, " \\cdot "+formatted)
+		} else if i > 0 && endsWithControlWord(parts) && startsWithLetter(formatted) {
+			// \alpha x, not the undefined command \alphax
+			parts = append(parts, " "+formatted)
 		} else if i > 0 {
 			parts = append(parts, formatted)
 		} else {
@@ -269,6 +371,79 @@ func formatMultiplication(mul *ast.Mul, opts FormatOptions, parentPrec int) stri
 	return result
 }
 
+// splitReciprocal splits a product with a single reciprocal factor, the
+// parsed form of a fraction such as \frac{2x}{3}, into its numerator and
+// denominator
+func splitReciprocal(factors []ast.Expr) (ast.Expr, ast.Expr, bool) {
+	var numerator []ast.Expr
+	var denominator ast.Expr
+	for _, factor := range factors {
+		if pow, ok := factor.(*ast.Pow); ok && ast.IsMinusOne(pow.Exponent()) {
+			if denominator != nil {
+				return nil, nil, false
+			}
+			denominator = pow.Base()
+			continue
+		}
+		numerator = append(numerator, factor)
+	}
+	switch {
+	case denominator == nil || len(numerator) == 0:
+		return nil, nil, false
+	case len(numerator) == 1:
+		return numerator[0], denominator, true
+	}
+	return ast.NewMul(numerator...), denominator, true
+}
+
+// isAngle reports whether expr is an angle in degrees
+func isAngle(expr ast.Expr) bool {
+	_, ok := expr.(*ast.Angle)
+	return ok
+}
+
+// isNumber reports whether expr is an Int, a Rational or a Float
+func isNumber(expr ast.Expr) bool {
+	_, ok := expr.(ast.Numeric)
+	return ok
+}
+
+// endsWithDigit reports whether the formatted factors so far end in a digit
+func endsWithDigit(parts []string) bool {
+	if len(parts) == 0 {
+		return false
+	}
+	last := parts[len(parts)-1]
+	return last != "" && last[len(last)-1] >= '0' && last[len(last)-1] <= '9'
+}
+
+// startsWithNumber reports whether a formatted factor begins with a digit
+// or a fraction
+func startsWithNumber(formatted string) bool {
+	return formatted != "" && formatted[0] >= '0' && formatted[0] <= '9' || strings.HasPrefix(formatted, "\\frac")
+}
+
+// endsWithControlWord reports whether the last formatted factor ends with
+// a LaTeX command made of letters, such as \alpha
+func endsWithControlWord(parts []string) bool {
+	if len(parts) == 0 {
+		return false
+	}
+	last := parts[len(parts)-1]
+	word := strings.TrimRightFunc(last, isLetter)
+	return len(word) < len(last) && strings.HasSuffix(word, "\\")
+}
+
+// startsWithLetter reports whether a formatted factor begins with a letter
+func startsWithLetter(formatted string) bool {
+	return formatted != "" && isLetter(rune(formatted[0]))
+}
+
+// isLetter reports whether r is an ASCII letter
+func isLetter(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+}
+
 func needsMultiplicationSpace(left, right ast.Expr) bool {
 	// Add space between numbers
 	if _, ok := left.(ast.Numeric); ok {
@@ -288,6 +463,12 @@ func needsMultiplicationSpace(left, right ast.Expr) bool {
 }
 
 func formatPower(pow *ast.Pow, opts FormatOptions, parentPrec int) string {
+	if opts.UseFunctionPowers {
+		if result, ok := formatFunctionPower(pow, opts); ok {
+			return result
+		}
+	}
+
 	base := formatExpression(pow.Base(), opts, 4)
 	exp := formatExpression(pow.Exponent(), opts, 0)
 
@@ -338,7 +519,8 @@ func formatPower(pow *ast.Pow, opts FormatOptions, parentPrec int) string {
 
 func needsBaseBraces(base ast.Expr) bool {
 	switch base.(type) {
-	case *ast.Add:
+	case *ast.Add, *ast.Angle:
+		// An angle already carries a superscript
 		return true
 	case *ast.Mul:
 		// Only if it has more than one factor
@@ -363,6 +545,12 @@ func formatFunction(fn *ast.Func, opts FormatOptions) string {
 		argStrs = append(argStrs, formatExpression(arg, opts, 0))
 	}
 
+	if opts.UseInverseNotation && len(args) == 1 {
+		if function, ok := inverseOf[name]; ok {
+			return fmt.Sprintf("\\%s^{-1}\\left(%s\\right)", function, argStrs[0])
+		}
+	}
+
 	// Special LaTeX functions
 	switch name {
 	case "sqrt":
@@ -371,13 +559,11 @@ func formatFunction(fn *ast.Func, opts FormatOptions) string {
 		}
 	case "sin", "cos", "tan", "sec", "csc", "cot":
 		return fmt.Sprintf("\\%s\\left(%s\\right)", name, strings.Join(argStrs, ", "))
-	case "arcsin", "arccos", "arctan":
-		latexName := strings.Replace(name, "arc", "\\arcsin", 1)
-		latexName = strings.Replace(latexName, "arccos", "\\arccos", 1)
-		latexName = strings.Replace(latexName, "arctan", "\\arctan", 1)
-		return fmt.Sprintf("%s\\left(%s\\right)", latexName, strings.Join(argStrs, ", "))
-	case "sinh", "cosh", "tanh":
+	case "arcsin", "arccos", "arctan", "sinh", "cosh", "tanh", "coth":
 		return fmt.Sprintf("\\%s\\left(%s\\right)", name, strings.Join(argStrs, ", "))
+	case "arcsec", "arccsc", "arccot", "arcsinh", "arccosh", "arctanh", "sech", "csch":
+		// LaTeX has no command for these names
+		return fmt.Sprintf("\\operatorname{%s}\\left(%s\\right)", name, strings.Join(argStrs, ", "))
 	case "ln":
 		return fmt.Sprintf("\\ln\\left(%s\\right)", strings.Join(argStrs, ", "))
 	case "log":
@@ -391,12 +577,61 @@ func formatFunction(fn *ast.Func, opts FormatOptions) string {
 	case "exp":
 		// Use e^x notation for exponential
 		return fmt.Sprintf("e^{%s}", strings.Join(argStrs, ", "))
+	case "sum":
+		if len(args) == 4 {
+			return fmt.Sprintf("\\sum_{%s=%s}^{%s} %s", argStrs[1], argStrs[2], argStrs[3], formatExpression(args[0], opts, 2))
+		}
+	case "int":
+		if len(args) == 2 {
+			return FormatIntegral(args[0], argStrs[1], false, nil, nil, opts)
+		}
+		if len(args) == 4 {
+			return FormatIntegral(args[0], argStrs[1], true, args[2], args[3], opts)
+		}
 	}
 
 	// Generic function formatting
 	return fmt.Sprintf("\\mathrm{%s}\\left(%s\\right)", name, strings.Join(argStrs, ", "))
 }
 
+// poweredFunctions are the functions whose powers can be written on the
+// function name
+var poweredFunctions = map[string]bool{
+	"sin": true, "cos": true, "tan": true, "sec": true, "csc": true, "cot": true,
+	"sinh": true, "cosh": true, "tanh": true, "sech": true, "csch": true, "coth": true,
+}
+
+// inverseOf maps an inverse function to the function it inverts
+var inverseOf = map[string]string{
+	"arcsin": "sin", "arccos": "cos", "arctan": "tan",
+	"arcsec": "sec", "arccsc": "csc", "arccot": "cot",
+	"arcsinh": "sinh", "arccosh": "cosh", "arctanh": "tanh",
+}
+
+// formatFunctionPower writes a positive integer power of a trigonometric
+// or hyperbolic function on its name, as in \sin^{2}\left(x\right)
+func formatFunctionPower(pow *ast.Pow, opts FormatOptions) (string, bool) {
+	fn, ok := pow.Base().(*ast.Func)
+	if !ok || !poweredFunctions[fn.Name()] || len(fn.Args()) != 1 {
+		return "", false
+	}
+	exp, ok := pow.Exponent().(*ast.Int)
+	if !ok || exp.IntValue().Sign() <= 0 {
+		return "", false
+	}
+	arg := formatExpression(fn.Args()[0], opts, 0)
+	return fmt.Sprintf("\\%s^{%s}\\left(%s\\right)", fn.Name(), exp.String(), arg), true
+}
+
+// formatDerivativeNode uses prime notation for an unknown function such as
+// y' and Leibniz notation otherwise
+func formatDerivativeNode(d *ast.Derivative, opts FormatOptions) string {
+	if v, ok := d.Operand().(*ast.Var); ok {
+		return formatVariable(v, opts) + strings.Repeat("'", d.Order())
+	}
+	return FormatDerivative(d.Operand(), d.Variable(), d.Order(), opts)
+}
+
 // FormatEquation formats an equation with proper LaTeX styling
 func FormatEquation(lhs, rhs ast.Expr, opts ...FormatOptions) string {
 	options := DefaultFormatOptions()
@@ -446,7 +681,16 @@ func FormatIntegral(expr ast.Expr, variable string, definite bool, lower, upper
 	return fmt.Sprintf("\\int %s \\, d%s", exprFormatted, variable)
 }
 
-// FormatMatrix formats matrix expressions (placeholder for future implementation)
+// formatList formats comma-separated elements
+func formatList(elements []ast.Expr, opts FormatOptions) string {
+	parts := make([]string, len(elements))
+	for i, elem := range elements {
+		parts[i] = formatExpression(elem, opts, 0)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// FormatMatrix formats a matrix, given row by row, as a pmatrix environment
 func FormatMatrix(matrix [][]ast.Expr, opts ...FormatOptions) string {
 	options := DefaultFormatOptions()
 	if len(opts) > 0 {
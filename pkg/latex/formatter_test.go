@@ -1,11 +1,13 @@
 package latex
 
 import (
+	"math"
 	"math/big"
 	"testing"
 
 	"github.com/quizizz/cas/pkg/ast"
 	"github.com/quizizz/cas/pkg/parser"
+	"github.com/quizizz/cas/pkg/solve"
 )
 
 func TestFormatNumbers(t *testing.T) {
@@ -146,6 +148,51 @@ func TestFormatMultiplication(t *testing.T) {
 	}
 }
 
+func TestFormatProductsRoundTrip(t *testing.T) {
+	// Products are written as they were typed, never multiplied out
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"2*3", "2 \\cdot 3"},
+		{"2^{10}x", "2^{10}x"},
+		{"2^{64}x", "2^{64}x"},
+		{"2*3/2", "\\frac{2 \\cdot 3}{2}"},
+		{"3*3^{-1}*x", "\\frac{3}{3}x"},
+		{"x/y", "\\frac{x}{y}"},
+		{"2x/3", "\\frac{2x}{3}"},
+		{"2/(x+1)", "\\frac{2}{x + 1}"},
+		{"a/b/c", "\\frac{\\frac{a}{b}}{c}"},
+		// A command such as \alpha is set off from a letter after it
+		{"\\alpha x", "\\alpha x"},
+		{"\\pi r", "\\pi r"},
+		{"τΔ", "\\tau\\Delta"},
+		{"x\\alpha", "x\\alpha"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			expr, err := parser.Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+
+			result := Format(expr)
+			if result != tt.expected {
+				t.Errorf("Format(%s) = %s, want %s", tt.input, result, tt.expected)
+			}
+
+			back, err := parser.Parse(result)
+			if err != nil {
+				t.Fatalf("Parse(%s) error: %v", result, err)
+			}
+			if back.String() != expr.String() {
+				t.Errorf("Parse(%s) = %s, want %s", result, back, expr)
+			}
+		})
+	}
+}
+
 func TestFormatPowers(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -245,6 +292,204 @@ func TestFormatFunctions(t *testing.T) {
 	}
 }
 
+func TestFormatTrigonometricFamilies(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"\\sec x", "\\sec\\left(x\\right)"},
+		{"\\csc x", "\\csc\\left(x\\right)"},
+		{"\\cot x", "\\cot\\left(x\\right)"},
+		{"\\arcsin x", "\\arcsin\\left(x\\right)"},
+		{"\\arccos x", "\\arccos\\left(x\\right)"},
+		{"\\arctan x", "\\arctan\\left(x\\right)"},
+		{"\\sinh x", "\\sinh\\left(x\\right)"},
+		{"\\arcsec x", "\\operatorname{arcsec}\\left(x\\right)"},
+		{"\\arccot x", "\\operatorname{arccot}\\left(x\\right)"},
+		{"\\arcsinh x", "\\operatorname{arcsinh}\\left(x\\right)"},
+		{"\\arctanh x", "\\operatorname{arctanh}\\left(x\\right)"},
+		{"\\log_2 x", "\\log_{2}\\left(x\\right)"},
+		{"\\log_{b}(x+1)", "\\log_{b}\\left(x + 1\\right)"},
+		{"log_x_0 y", "\\log_{x_{0}}\\left(y\\right)"},
+		{"\\sin 30^\\circ", "\\sin\\left(30^{\\circ}\\right)"},
+		{"\\cos(12°30')", "\\cos\\left(12^{\\circ}30'\\right)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			expr, err := parser.Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+
+			result := Format(expr)
+			if result != tt.expected {
+				t.Errorf("Format(%s) = %s, want %s", tt.input, result, tt.expected)
+			}
+
+			// The output reads back as the same expression
+			back, err := parser.Parse(result)
+			if err != nil {
+				t.Fatalf("Parse(%s) error: %v", result, err)
+			}
+			if back.String() != expr.String() {
+				t.Errorf("Parse(%s) = %s, want %s", result, back, expr)
+			}
+		})
+	}
+}
+
+func TestFormatFunctionsRoundTrip(t *testing.T) {
+	names := []string{
+		"sec", "csc", "cot", "arcsec", "arccsc", "arccot",
+		"sinh", "cosh", "tanh", "arcsinh", "arccosh", "arctanh",
+		"sech", "csch", "coth",
+	}
+	powers := DefaultFormatOptions()
+	powers.UseFunctionPowers = true
+
+	for _, name := range names {
+		for _, input := range []string{name + "(x)", name + "(x)^2", "2*" + name + "(x+1)"} {
+			expr, err := parser.Parse(input)
+			if err != nil {
+				t.Fatalf("Parse(%s) error: %v", input, err)
+			}
+			for _, opts := range []FormatOptions{DefaultFormatOptions(), powers} {
+				formatted := Format(expr, opts)
+				back, err := parser.Parse(formatted)
+				if err != nil {
+					t.Errorf("Parse(%s) error: %v", formatted, err)
+					continue
+				}
+				if back.String() != expr.String() {
+					t.Errorf("Parse(%s) = %s, want %s", formatted, back, expr)
+				}
+			}
+		}
+	}
+}
+
+func TestFormatRelations(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x = \\pm 2", "x = \\pm 2"},
+		{"2x + 1 = 7", "2x + 1 = 7"},
+		{"x^2 \\ne 4", "x^{2} \\ne 4"},
+		{"3x >= 1/2", "3x \\ge \\frac{1}{2}"},
+		{"-2<3x+1<=7", "-2 < 3x + 1 \\le 7"},
+		{"0 \\le x < 1", "0 \\le x < 1"},
+		{"5 >= x > 1", "5 \\ge x > 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			expr, err := parser.Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+
+			result := Format(expr)
+			if result != tt.expected {
+				t.Errorf("Format(%s) = %s, want %s", tt.input, result, tt.expected)
+			}
+
+			back, err := parser.Parse(result)
+			if err != nil {
+				t.Fatalf("Parse(%s) error: %v", result, err)
+			}
+			if back.String() != expr.String() {
+				t.Errorf("Parse(%s) = %s, want %s", result, back, expr)
+			}
+		})
+	}
+}
+
+func TestFormatAngles(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"30°", "30^{\\circ}"},
+		{"2\\cdot 30°", "2 \\cdot 30^{\\circ}"},
+		{"x\\cdot 30°", "x \\cdot 30^{\\circ}"},
+		{"(30°)^2", "\\left(30^{\\circ}\\right)^{2}"},
+		{"(12°30')^2", "\\left(12^{\\circ}30'\\right)^{2}"},
+		{"-(30°)", "-30^{\\circ}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			expr, err := parser.Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+
+			result := Format(expr)
+			if result != tt.expected {
+				t.Errorf("Format(%s) = %s, want %s", tt.input, result, tt.expected)
+			}
+
+			back, err := parser.Parse(result)
+			if err != nil {
+				t.Fatalf("Parse(%s) error: %v", result, err)
+			}
+			if back.String() != expr.String() {
+				t.Errorf("Parse(%s) = %s, want %s", result, back, expr)
+			}
+		})
+	}
+}
+
+func TestFormatFunctionNotation(t *testing.T) {
+	options := DefaultFormatOptions()
+	options.UseFunctionPowers = true
+	options.UseInverseNotation = true
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"\\sin^2 x", "\\sin^{2}\\left(x\\right)"},
+		{"\\cos(x)^3", "\\cos^{3}\\left(x\\right)"},
+		{"\\sec^2 x", "\\sec^{2}\\left(x\\right)"},
+		{"\\sin^2 x + \\cos^2 x", "\\sin^{2}\\left(x\\right) + \\cos^{2}\\left(x\\right)"},
+		{"\\arcsin x", "\\sin^{-1}\\left(x\\right)"},
+		{"\\sin^{-1}(2x)", "\\sin^{-1}\\left(2x\\right)"},
+		{"\\arctanh x", "\\tanh^{-1}\\left(x\\right)"},
+		{"\\ln(x)^2", "\\ln\\left(x\\right)^{2}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			expr, err := parser.Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+
+			result := Format(expr, options)
+			if result != tt.expected {
+				t.Errorf("Format(%s) = %s, want %s", tt.input, result, tt.expected)
+			}
+
+			back, err := parser.Parse(result)
+			if err != nil {
+				t.Fatalf("Parse(%s) error: %v", result, err)
+			}
+			if back.String() != expr.String() {
+				t.Errorf("Parse(%s) = %s, want %s", result, back, expr)
+			}
+		})
+	}
+
+	// Both notations are off by default
+	expr, _ := parser.Parse("\\arcsin(x) + \\sin^2 x")
+	if result := Format(expr); containsSubstring(result, "^{-1}") || containsSubstring(result, "\\sin^") {
+		t.Errorf("Format(%s) = %s, want the default notation", expr, result)
+	}
+}
+
 func TestFormatComplexExpressions(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -371,6 +616,36 @@ func TestSpecialFormatting(t *testing.T) {
 				return containsSubstring(result, "\\frac{d}{dx}") && containsSubstring(result, "x^{2}")
 			},
 		},
+		{
+			"derivative node formatting",
+			func() string {
+				expr, _ := parser.Parse("y'' + \\frac{dy}{dx}")
+				return Format(expr)
+			},
+			func(result string) bool {
+				return containsSubstring(result, "y''") && containsSubstring(result, "y'")
+			},
+		},
+		{
+			"matrix node formatting",
+			func() string {
+				expr, _ := parser.Parse("[[1,x],[0,2]]")
+				return Format(expr)
+			},
+			func(result string) bool {
+				return containsSubstring(result, "\\begin{pmatrix}") && containsSubstring(result, "1 & x")
+			},
+		},
+		{
+			"tuple and set formatting",
+			func() string {
+				expr, _ := parser.Parse("(1, x), (2, y)")
+				return Format(expr)
+			},
+			func(result string) bool {
+				return containsSubstring(result, "\\left\\{") && containsSubstring(result, "\\left(1, x\\right)")
+			},
+		},
 		{
 			"integral formatting",
 			func() string {
@@ -424,3 +699,206 @@ func BenchmarkFormatComplex(b *testing.B) {
 		Format(expr)
 	}
 }
+
+func TestFormatNumberStyles(t *testing.T) {
+	mixed := FormatOptions{UseMixedNumbers: true, UseFractions: true, MaxDecimalPlaces: 6}
+	percent := FormatOptions{UsePercent: true, MaxDecimalPlaces: 6}
+	scientific := FormatOptions{UseScientificNotation: true, MaxDecimalPlaces: 6}
+
+	tests := []struct {
+		name     string
+		expr     ast.Expr
+		options  FormatOptions
+		expected string
+	}{
+		{"mixed number", ast.NewRational(5, 2), mixed, "2\\frac{1}{2}"},
+		{"negative mixed number", ast.NewRational(-7, 3), mixed, "-2\\frac{1}{3}"},
+		{"proper fraction", ast.NewRational(1, 3), mixed, "\\frac{1}{3}"},
+		{"percent", ast.NewRational(7, 20), percent, "35\\%"},
+		{"decimal percent", ast.NewFloat(0.125), percent, "12.5\\%"},
+		{"whole percent", ast.NewInt(2), percent, "200\\%"},
+		{"scientific", ast.NewInt(32000), scientific, "3.2 \\times 10^{4}"},
+		{"small scientific", ast.NewRational(-21, 50000), scientific, "-4.2 \\times 10^{-4}"},
+		{"scientific without a power", ast.NewRational(5, 2), scientific, "2.5"},
+		{"scientific rounding up", ast.NewFloat(9.9999999), scientific, "1 \\times 10^{1}"},
+		{"expressions keep their numbers", ast.NewAdd(ast.NewVar("x"), ast.NewInt(32000)), scientific, "x + 32000"},
+	}
+
+	parseOptions := parser.Options{MixedNumbers: true, Percent: true, ScientificNotation: true}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Format(tt.expr, tt.options)
+			if result != tt.expected {
+				t.Errorf("Format(%s) = %s, want %s", tt.expr, result, tt.expected)
+			}
+
+			// The output reads back as the same value, up to rounding
+			back, err := parser.ParseWith(result, parseOptions)
+			if err != nil {
+				t.Fatalf("ParseWith(%s) error: %v", result, err)
+			}
+			vars := map[string]*big.Float{"x": big.NewFloat(1)}
+			want, err1 := tt.expr.Eval(vars)
+			got, err2 := back.Eval(vars)
+			if err1 != nil || err2 != nil {
+				t.Fatalf("Eval errors: %v, %v", err1, err2)
+			}
+			w, _ := want.Float64()
+			g, _ := got.Float64()
+			if math.Abs(w-g) > 1e-6*math.Abs(w) {
+				t.Errorf("ParseWith(%s) = %s, want %s", result, back, tt.expr)
+			}
+		})
+	}
+
+	// Scientific input is exact, so large integers print in full
+	expr, _ := parser.ParseWith("6.02e23", parseOptions)
+	if result := Format(expr); result != "602000000000000000000000" {
+		t.Errorf("Format(%s) = %s, want 602000000000000000000000", expr, result)
+	}
+}
+
+func TestFormatParsedFractionsRoundTrip(t *testing.T) {
+	parseOptions := parser.Options{MixedNumbers: true}
+	tests := []struct {
+		input    string
+		mixed    string
+		fraction string
+	}{
+		{"5/2", "2\\frac{1}{2}", "\\frac{5}{2}"},
+		{"\\frac{5}{2}", "2\\frac{1}{2}", "\\frac{5}{2}"},
+		{"1/2", "\\frac{1}{2}", "\\frac{1}{2}"},
+		{"-7/3", "-2\\frac{1}{3}", "\\frac{-7}{3}"},
+		{"2/3", "\\frac{2}{3}", "\\frac{2}{3}"},
+		{"6/3", "\\frac{6}{3}", "\\frac{6}{3}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			expr, err := parser.Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+			want, err := expr.Eval(nil)
+			if err != nil {
+				t.Fatalf("Eval error: %v", err)
+			}
+
+			for _, c := range []struct {
+				options  FormatOptions
+				expected string
+			}{
+				{FormatOptions{UseMixedNumbers: true, UseFractions: true, MaxDecimalPlaces: 6}, tt.mixed},
+				{DefaultFormatOptions(), tt.fraction},
+			} {
+				result := Format(expr, c.options)
+				if result != c.expected {
+					t.Errorf("Format(%s) = %s, want %s", tt.input, result, c.expected)
+				}
+
+				back, err := parser.ParseWith(result, parseOptions)
+				if err != nil {
+					t.Fatalf("ParseWith(%s) error: %v", result, err)
+				}
+				got, err := back.Eval(nil)
+				if err != nil {
+					t.Fatalf("Eval error: %v", err)
+				}
+				w, _ := want.Float64()
+				g, _ := got.Float64()
+				if math.Abs(w-g) > 1e-12 {
+					t.Errorf("ParseWith(%s) = %s, want the value of %s", result, got.Text('g', 10), tt.input)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatPlusMinus(t *testing.T) {
+	roots := solve.QuadraticRoots(big.NewRat(1, 1), big.NewRat(-2, 1), big.NewRat(-1, 1))
+	x := ast.NewVar("x")
+	// collapsed returns the ± answer of the roots solve.Solve finds
+	collapsed := func(input string) ast.Expr {
+		expr, err := parser.Parse(input)
+		if err != nil {
+			t.Fatalf("Parse error: %v", err)
+		}
+		solutions := solve.Solve(expr, solve.DefaultSolveOptions()).Solutions
+		if len(solutions) != 2 {
+			t.Fatalf("Solve(%s) found %d solutions, want 2", input, len(solutions))
+		}
+		result, ok := solve.CollapseRoots(solutions[0].Value, solutions[1].Value)
+		if !ok {
+			t.Fatalf("CollapseRoots failed for the roots of %s", input)
+		}
+		return result
+	}
+	solutions, _ := solve.CollapseRoots(ast.NewEq(x, roots[0], ast.EqEqual), ast.NewEq(x, roots[1], ast.EqEqual))
+	tests := []struct {
+		name     string
+		expr     ast.Expr
+		expected string
+	}{
+		{"solutions", solutions, "x = 1 \\pm \\sqrt{2}"},
+		{"opposite numbers", ast.NewPlusMinus(nil, ast.NewInt(2)), "\\pm 2"},
+		{"plus minus in a product", ast.NewMul(ast.NewPlusMinus(ast.NewInt(-3), x), ast.NewVar("y")), "\\left(-3 \\pm x\\right)y"},
+		{"solved roots", collapsed("x^2+3x+1"), "\\frac{-3 \\pm \\sqrt{5}}{2}"},
+		{"solved roots with a square factor", collapsed("x^2-4x+1"), "2 \\pm \\sqrt{3}"},
+		{"solved roots over a denominator", collapsed("2x^2-2x-1"), "\\frac{1 \\pm \\sqrt{3}}{2}"},
+		{"solved opposite roots", collapsed("x^2-4"), "\\pm 2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := Format(tt.expr); result != tt.expected {
+				t.Errorf("Format(%s) = %s, want %s", tt.expr, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFormatQuantities(t *testing.T) {
+	perSecondSquared := ast.NewMul(ast.NewUnit("m"), ast.NewPow(ast.NewUnit("s"), ast.NewInt(-2)))
+	tests := []struct {
+		name     string
+		expr     ast.Expr
+		expected string
+	}{
+		{"single unit", ast.NewQuantity(ast.NewInt(5), ast.NewUnit("km")), "5\\,\\mathrm{km}"},
+		{"unit with a denominator", ast.NewQuantity(ast.NewFloat(9.8), perSecondSquared), "9.8\\,\\mathrm{m}/\\mathrm{s}^{2}"},
+		{"sum as the value", ast.NewQuantity(ast.NewAdd(ast.NewInt(2), ast.NewVar("x")), ast.NewUnit("m")), "\\left(2 + x\\right)\\,\\mathrm{m}"},
+		{"quantity in a product", ast.NewMul(ast.NewInt(2), ast.NewQuantity(ast.NewInt(3), ast.NewUnit("s"))), "2\\left(3\\,\\mathrm{s}\\right)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := Format(tt.expr); result != tt.expected {
+				t.Errorf("Format(%s) = %s, want %s", tt.expr, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFormatPiecewise(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"otherwise", "\\begin{cases} x^2 & x < 0 \\\\ 2x & \\text{otherwise} \\end{cases}", "\\begin{cases} x^{2} & x < 0 \\\\ 2x & \\text{otherwise} \\end{cases}"},
+		{"chain condition", "\\begin{cases} \\sqrt{x} & 0 \\le x < 1 \\\\ 1 & x \\ge 1 \\end{cases}", "\\begin{cases} \\sqrt{x} & 0 \\le x < 1 \\\\ 1 & x \\ge 1 \\end{cases}"},
+		{"fraction in a condition", "\\begin{cases} 1 & \\frac{x}{2} > 1 \\\\ 0 & \\text{otherwise} \\end{cases}", "\\begin{cases} 1 & \\frac{x}{2} > 1 \\\\ 0 & \\text{otherwise} \\end{cases}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := parser.Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse(%s) error: %v", tt.input, err)
+			}
+			if result := Format(expr); result != tt.expected {
+				t.Errorf("Format(%s) = %s, want %s", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package latex
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/quizizz/cas/pkg/ast"
+)
+
+// formatNumberStyle writes a number as a percentage, in scientific notation
+// or as a mixed number, as the options ask. It reports false when expr is
+// not a number or no style applies to it.
+func formatNumberStyle(expr ast.Expr, opts FormatOptions) (string, bool) {
+	if !opts.UsePercent && !opts.UseScientificNotation && !opts.UseMixedNumbers {
+		return "", false
+	}
+	value, ok := fractionValue(expr)
+	if !ok {
+		return "", false
+	}
+
+	switch {
+	case opts.UsePercent:
+		percent := new(big.Rat).Mul(value, big.NewRat(100, 1))
+		return decimalString(percent, opts.MaxDecimalPlaces) + "\\%", true
+	case opts.UseScientificNotation:
+		return formatScientific(value, opts), true
+	case opts.UseMixedNumbers:
+		return formatMixedNumber(value)
+	}
+	return "", false
+}
+
+// fractionValue returns the value of a number, or of a fraction of two
+// integers in its parsed form n*d^-1, as a rational
+func fractionValue(expr ast.Expr) (*big.Rat, bool) {
+	switch expr.(type) {
+	case *ast.Int, *ast.Rational, *ast.Float:
+		return ast.ExactValue(expr)
+	}
+	mul, ok := expr.(*ast.Mul)
+	if !ok {
+		return nil, false
+	}
+	factors := mul.Terms()
+	if len(factors) != 2 {
+		return nil, false
+	}
+	numerator, ok := factors[0].(*ast.Int)
+	reciprocal, isPow := factors[1].(*ast.Pow)
+	if !ok || !isPow || !ast.IsMinusOne(reciprocal.Exponent()) {
+		return nil, false
+	}
+	denominator, ok := reciprocal.Base().(*ast.Int)
+	if !ok || denominator.IntValue().Sign() == 0 {
+		return nil, false
+	}
+	return new(big.Rat).SetFrac(numerator.IntValue(), denominator.IntValue()), true
+}
+
+// formatScientific writes a nonzero value as m \times 10^{n} with
+// 1 <= |m| < 10, leaving out the power when n is 0
+func formatScientific(value *big.Rat, opts FormatOptions) string {
+	if value.Sign() == 0 {
+		return "0"
+	}
+
+	abs := new(big.Rat).Abs(value)
+	exponent := len(abs.Num().String()) - len(abs.Denom().String())
+	mantissa := new(big.Rat).Quo(value, ast.PowerOfTen(exponent))
+	if new(big.Rat).Abs(mantissa).Cmp(big.NewRat(1, 1)) < 0 {
+		exponent--
+		mantissa.Mul(mantissa, big.NewRat(10, 1))
+	}
+
+	digits := decimalString(mantissa, opts.MaxDecimalPlaces)
+	// Rounding can carry the mantissa up to 10
+	if strings.TrimPrefix(digits, "-") == "10" {
+		exponent++
+		digits = strings.TrimSuffix(digits, "0")
+	}
+
+	if exponent == 0 {
+		return digits
+	}
+	return fmt.Sprintf("%s \\times 10^{%d}", digits, exponent)
+}
+
+// formatMixedNumber writes an improper fraction as a whole number and a
+// proper fraction. It reports false for whole numbers and proper fractions.
+func formatMixedNumber(value *big.Rat) (string, bool) {
+	if value.IsInt() {
+		return "", false
+	}
+
+	num := new(big.Int).Abs(value.Num())
+	whole, remainder := new(big.Int).QuoRem(num, value.Denom(), new(big.Int))
+	if whole.Sign() == 0 {
+		return "", false
+	}
+
+	sign := ""
+	if value.Sign() < 0 {
+		sign = "-"
+	}
+	return fmt.Sprintf("%s%s\\frac{%s}{%s}", sign, whole, remainder, value.Denom()), true
+}
+
+// decimalString writes a value as a decimal rounded to at most places
+// digits after the point
+func decimalString(value *big.Rat, places int) string {
+	text := value.FloatString(places)
+	if strings.Contains(text, ".") {
+		text = strings.TrimRight(text, "0")
+		text = strings.TrimSuffix(text, ".")
+	}
+	if text == "-0" {
+		return "0"
+	}
+	return text
+}
@@ -0,0 +1,159 @@
+package linalg
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/quizizz/cas/pkg/ast"
+	"github.com/quizizz/cas/pkg/solve"
+)
+
+// CharacteristicPolynomial returns det(λI - A) of a numeric square matrix as
+// a polynomial in the named variable
+func CharacteristicPolynomial(m *ast.Matrix, variable string) (ast.Expr, error) {
+	coeffs, err := characteristicCoefficients(m)
+	if err != nil {
+		return nil, err
+	}
+
+	terms := []ast.Expr{}
+	for k := len(coeffs) - 1; k >= 0; k-- {
+		if coeffs[k].Sign() == 0 {
+			continue
+		}
+		var power ast.Expr = ast.NewVar(variable)
+		if k == 0 {
+			terms = append(terms, ast.NewNumber(coeffs[k]))
+			continue
+		}
+		if k > 1 {
+			power = ast.NewPow(power, ast.NewInt(int64(k)))
+		}
+		if coeffs[k].Cmp(big.NewRat(1, 1)) == 0 {
+			terms = append(terms, power)
+		} else {
+			terms = append(terms, ast.NewMul(ast.NewNumber(coeffs[k]), power))
+		}
+	}
+
+	if len(terms) == 1 {
+		return terms[0], nil
+	}
+	return ast.NewAdd(terms...), nil
+}
+
+// Eigenvalues returns the real eigenvalues of a numeric square matrix in
+// ascending order, repeated according to their algebraic multiplicity.
+// Rational eigenvalues are exact; irrational ones from a remaining quadratic
+// factor are returned in surd form.
+func Eigenvalues(m *ast.Matrix) ([]ast.Expr, error) {
+	coeffs, err := characteristicCoefficients(m)
+	if err != nil {
+		return nil, err
+	}
+
+	var roots []*big.Rat
+	for len(coeffs) > 3 {
+		root, ok := solve.RationalRoot(coeffs)
+		if !ok {
+			return nil, fmt.Errorf("characteristic polynomial has a factor of degree %d without rational roots", len(coeffs)-1)
+		}
+		roots = append(roots, root)
+		coeffs = solve.Deflate(coeffs, root)
+	}
+
+	type eigenvalue struct {
+		expr  ast.Expr
+		value float64
+	}
+	values := make([]eigenvalue, 0, len(roots)+2)
+	for _, root := range roots {
+		f, _ := root.Float64()
+		values = append(values, eigenvalue{ast.NewNumber(root), f})
+	}
+
+	switch len(coeffs) {
+	case 2:
+		root := new(big.Rat).Quo(new(big.Rat).Neg(coeffs[0]), coeffs[1])
+		f, _ := root.Float64()
+		values = append(values, eigenvalue{ast.NewNumber(root), f})
+	case 3:
+		quadratic := solve.QuadraticRoots(coeffs[2], coeffs[1], coeffs[0])
+		if quadratic == nil {
+			return nil, fmt.Errorf("matrix has complex eigenvalues")
+		}
+		for _, root := range quadratic {
+			f, _ := root.Eval(nil)
+			value, _ := f.Float64()
+			values = append(values, eigenvalue{root, value})
+		}
+	}
+
+	sort.SliceStable(values, func(i, j int) bool { return values[i].value < values[j].value })
+	result := make([]ast.Expr, len(values))
+	for i, v := range values {
+		result[i] = v.expr
+	}
+	return result, nil
+}
+
+// characteristicCoefficients computes the coefficients c[0..n] of det(λI - A),
+// lowest degree first, using the Faddeev-LeVerrier recurrence
+func characteristicCoefficients(m *ast.Matrix) ([]*big.Rat, error) {
+	n := m.RowCount()
+	if n != m.ColCount() {
+		return nil, fmt.Errorf("characteristic polynomial requires a square matrix, got %s", shape(m))
+	}
+	a, ok := ratRows(m)
+	if !ok {
+		return nil, fmt.Errorf("characteristic polynomial requires numeric entries")
+	}
+
+	coeffs := make([]*big.Rat, n+1)
+	coeffs[n] = big.NewRat(1, 1)
+
+	// M_0 = 0; M_k = A·M_{k-1} + c_{n-k+1}·I; c_{n-k} = -tr(A·M_k)/k
+	current := ratZero(n)
+	for k := 1; k <= n; k++ {
+		next := ratProduct(a, current)
+		for i := 0; i < n; i++ {
+			next[i][i].Add(next[i][i], coeffs[n-k+1])
+		}
+		current = next
+
+		trace := new(big.Rat)
+		product := ratProduct(a, current)
+		for i := 0; i < n; i++ {
+			trace.Add(trace, product[i][i])
+		}
+		coeffs[n-k] = trace.Quo(trace, big.NewRat(int64(-k), 1))
+	}
+	return coeffs, nil
+}
+
+// ratZero returns an n×n matrix of zeros
+func ratZero(n int) [][]*big.Rat {
+	rows := make([][]*big.Rat, n)
+	for i := range rows {
+		rows[i] = make([]*big.Rat, n)
+		for j := range rows[i] {
+			rows[i][j] = new(big.Rat)
+		}
+	}
+	return rows
+}
+
+// ratProduct multiplies two square rational matrices
+func ratProduct(a, b [][]*big.Rat) [][]*big.Rat {
+	n := len(a)
+	result := ratZero(n)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			for k := 0; k < n; k++ {
+				result[i][j].Add(result[i][j], new(big.Rat).Mul(a[i][k], b[k][j]))
+			}
+		}
+	}
+	return result
+}
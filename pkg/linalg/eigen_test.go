@@ -0,0 +1,79 @@
+package linalg
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEigenvalues(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"[[2,1],[1,2]]", []string{"1", "3"}},
+		{"[[1,1],[0,1]]", []string{"1", "1"}},
+		{"[[2,0,0],[0,3,4],[0,4,9]]", []string{"1", "2", "11"}},
+		{"[[1,1],[1,0]]", []string{"1/2+-1/2*sqrt(5)", "1/2+1/2*sqrt(5)"}},
+		{"[[0,2],[2,0]]", []string{"-2", "2"}},
+		{"[[0,1],[2,0]]", []string{"-1*sqrt(2)", "sqrt(2)"}},
+		{"[[1/2,0],[0,1/3]]", []string{"1/3", "1/2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			values, err := Eigenvalues(parseMatrix(t, tt.input))
+			if err != nil {
+				t.Fatalf("Eigenvalues error: %v", err)
+			}
+			got := make([]string, len(values))
+			for i, v := range values {
+				got[i] = v.String()
+			}
+			if strings.Join(got, "; ") != strings.Join(tt.expected, "; ") {
+				t.Errorf("Eigenvalues(%s) = %v, expected %v", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEigenvaluesErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"complex", "[[0,-1],[1,0]]"},
+		{"not square", "[[1,2,3],[4,5,6]]"},
+		{"symbolic", "[[x,1],[1,x]]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Eigenvalues(parseMatrix(t, tt.input)); err == nil {
+				t.Errorf("Eigenvalues(%s) should fail", tt.input)
+			}
+		})
+	}
+}
+
+func TestCharacteristicPolynomial(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[[1,2],[3,4]]", "t^2+-5*t+-2"},
+		{"[[2,0,1],[1,3,2],[1,1,1]]", "t^3+-6*t^2+8*t"},
+		{"[[5]]", "t+-5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			poly, err := CharacteristicPolynomial(parseMatrix(t, tt.input), "t")
+			if err != nil {
+				t.Fatalf("CharacteristicPolynomial error: %v", err)
+			}
+			if poly.String() != tt.expected {
+				t.Errorf("CharacteristicPolynomial(%s) = %s, expected %s", tt.input, poly, tt.expected)
+			}
+		})
+	}
+}
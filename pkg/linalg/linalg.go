@@ -0,0 +1,130 @@
+// Package linalg implements linear algebra on matrix and vector expressions.
+// Numeric entries are handled exactly as rationals; symbolic entries are
+// combined and simplified as expressions.
+package linalg
+
+import (
+	"fmt"
+
+	"github.com/quizizz/cas/pkg/ast"
+	"github.com/quizizz/cas/pkg/simplify"
+)
+
+// Add returns the element-wise sum a + b
+func Add(a, b *ast.Matrix) (*ast.Matrix, error) {
+	if a.RowCount() != b.RowCount() || a.ColCount() != b.ColCount() {
+		return nil, fmt.Errorf("cannot add %s matrix and %s matrix", shape(a), shape(b))
+	}
+
+	rows := make([][]ast.Expr, a.RowCount())
+	for i := range rows {
+		rows[i] = make([]ast.Expr, a.ColCount())
+		for j := range rows[i] {
+			rows[i][j] = combine(ast.NewAdd(a.At(i, j), b.At(i, j)))
+		}
+	}
+	return ast.NewMatrix(rows)
+}
+
+// Multiply returns the matrix product a * b
+func Multiply(a, b *ast.Matrix) (*ast.Matrix, error) {
+	if a.ColCount() != b.RowCount() {
+		return nil, fmt.Errorf("cannot multiply %s matrix by %s matrix", shape(a), shape(b))
+	}
+
+	rows := make([][]ast.Expr, a.RowCount())
+	for i := range rows {
+		rows[i] = make([]ast.Expr, b.ColCount())
+		for j := range rows[i] {
+			terms := make([]ast.Expr, a.ColCount())
+			for k := range terms {
+				terms[k] = ast.NewMul(a.At(i, k), b.At(k, j))
+			}
+			rows[i][j] = combine(ast.NewAdd(terms...))
+		}
+	}
+	return ast.NewMatrix(rows)
+}
+
+// MultiplyVector returns the product of a matrix and a column vector
+func MultiplyVector(m *ast.Matrix, v *ast.Vector) (*ast.Vector, error) {
+	product, err := Multiply(m, v.AsColumn())
+	if err != nil {
+		return nil, err
+	}
+
+	elements := make([]ast.Expr, product.RowCount())
+	for i := range elements {
+		elements[i] = product.At(i, 0)
+	}
+	return ast.NewVector(elements...), nil
+}
+
+// Dot returns the dot product of two vectors of the same length
+func Dot(a, b *ast.Vector) (ast.Expr, error) {
+	if a.Len() != b.Len() {
+		return nil, fmt.Errorf("cannot take dot product of vectors of length %d and %d", a.Len(), b.Len())
+	}
+
+	terms := make([]ast.Expr, a.Len())
+	for i := range terms {
+		terms[i] = ast.NewMul(a.At(i), b.At(i))
+	}
+	return combine(ast.NewAdd(terms...)), nil
+}
+
+// Scale multiplies every entry of m by k
+func Scale(k ast.Expr, m *ast.Matrix) *ast.Matrix {
+	rows := m.Rows()
+	for i := range rows {
+		for j := range rows[i] {
+			rows[i][j] = combine(ast.NewMul(k.Clone(), rows[i][j]))
+		}
+	}
+	result, _ := ast.NewMatrix(rows)
+	return result
+}
+
+// Transpose swaps the rows and columns of m
+func Transpose(m *ast.Matrix) *ast.Matrix {
+	rows := make([][]ast.Expr, m.ColCount())
+	for i := range rows {
+		rows[i] = make([]ast.Expr, m.RowCount())
+		for j := range rows[i] {
+			rows[i][j] = m.At(j, i)
+		}
+	}
+	result, _ := ast.NewMatrix(rows)
+	return result
+}
+
+// Identity returns the n×n identity matrix
+func Identity(n int) *ast.Matrix {
+	rows := make([][]ast.Expr, n)
+	for i := range rows {
+		rows[i] = make([]ast.Expr, n)
+		for j := range rows[i] {
+			if i == j {
+				rows[i][j] = ast.NewInt(1)
+			} else {
+				rows[i][j] = ast.NewInt(0)
+			}
+		}
+	}
+	result, _ := ast.NewMatrix(rows)
+	return result
+}
+
+// combine reduces a numeric entry to an exact Int or Rational and
+// simplifies a symbolic one
+func combine(expr ast.Expr) ast.Expr {
+	if r, ok := ast.ExactValue(expr); ok {
+		return ast.NewNumber(r)
+	}
+	return simplify.Simplify(expr)
+}
+
+// shape describes a matrix's dimensions for error messages
+func shape(m *ast.Matrix) string {
+	return fmt.Sprintf("%dx%d", m.RowCount(), m.ColCount())
+}
@@ -0,0 +1,115 @@
+package linalg
+
+import (
+	"testing"
+
+	"github.com/quizizz/cas/pkg/ast"
+	"github.com/quizizz/cas/pkg/parser"
+)
+
+// parseMatrix parses input and fails the test unless it is a matrix
+func parseMatrix(t *testing.T, input string) *ast.Matrix {
+	t.Helper()
+	expr, err := parser.Parse(input)
+	if err != nil {
+		t.Fatalf("Parse(%q) error: %v", input, err)
+	}
+	m, ok := expr.(*ast.Matrix)
+	if !ok {
+		t.Fatalf("Parse(%q) = %s, expected a matrix", input, expr)
+	}
+	return m
+}
+
+func TestAdd(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected string
+	}{
+		{"[[1,2],[3,4]]", "[[5,6],[7,8]]", "[[6, 8], [10, 12]]"},
+		{"[[1/2,0]]", "[[1/3,0.25]]", "[[5/6, 1/4]]"},
+		{"[[x,1]]", "[[x,y]]", "[[2*x, 1+y]]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"+"+tt.b, func(t *testing.T) {
+			result, err := Add(parseMatrix(t, tt.a), parseMatrix(t, tt.b))
+			if err != nil {
+				t.Fatalf("Add error: %v", err)
+			}
+			if result.String() != tt.expected {
+				t.Errorf("Add(%s, %s) = %s, expected %s", tt.a, tt.b, result, tt.expected)
+			}
+		})
+	}
+
+	if _, err := Add(parseMatrix(t, "[[1,2]]"), parseMatrix(t, "[[1],[2]]")); err == nil {
+		t.Errorf("Add should reject mismatched shapes")
+	}
+}
+
+func TestMultiply(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected string
+	}{
+		{"[[1,2],[3,4]]", "[[5,6],[7,8]]", "[[19, 22], [43, 50]]"},
+		{"[[1,2,3]]", "[[1],[2],[3]]", "[[14]]"},
+		{"[[1/2,0],[0,2]]", "[[2,0],[0,1/2]]", "[[1, 0], [0, 1]]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"*"+tt.b, func(t *testing.T) {
+			result, err := Multiply(parseMatrix(t, tt.a), parseMatrix(t, tt.b))
+			if err != nil {
+				t.Fatalf("Multiply error: %v", err)
+			}
+			if result.String() != tt.expected {
+				t.Errorf("Multiply(%s, %s) = %s, expected %s", tt.a, tt.b, result, tt.expected)
+			}
+		})
+	}
+
+	if _, err := Multiply(parseMatrix(t, "[[1,2]]"), parseMatrix(t, "[[1,2]]")); err == nil {
+		t.Errorf("Multiply should reject incompatible shapes")
+	}
+}
+
+func TestVectorOperations(t *testing.T) {
+	m := parseMatrix(t, "[[1,2],[3,4]]")
+	v := ast.NewVector(ast.NewInt(1), ast.NewInt(-1))
+
+	product, err := MultiplyVector(m, v)
+	if err != nil {
+		t.Fatalf("MultiplyVector error: %v", err)
+	}
+	if got, want := product.String(), "[-1, -1]"; got != want {
+		t.Errorf("MultiplyVector() = %s, want %s", got, want)
+	}
+
+	dot, err := Dot(ast.NewVector(ast.NewInt(1), ast.NewInt(2), ast.NewInt(3)), ast.NewVector(ast.NewInt(4), ast.NewInt(5), ast.NewInt(6)))
+	if err != nil {
+		t.Fatalf("Dot error: %v", err)
+	}
+	if got, want := dot.String(), "32"; got != want {
+		t.Errorf("Dot() = %s, want %s", got, want)
+	}
+
+	if _, err := Dot(v, ast.NewVector(ast.NewInt(1))); err == nil {
+		t.Errorf("Dot should reject vectors of different lengths")
+	}
+}
+
+func TestTransposeAndScale(t *testing.T) {
+	m := parseMatrix(t, "[[1,2,3],[4,5,6]]")
+
+	if got, want := Transpose(m).String(), "[[1, 4], [2, 5], [3, 6]]"; got != want {
+		t.Errorf("Transpose() = %s, want %s", got, want)
+	}
+	if got, want := Scale(ast.NewRational(1, 2), m).String(), "[[1/2, 1, 3/2], [2, 5/2, 3]]"; got != want {
+		t.Errorf("Scale() = %s, want %s", got, want)
+	}
+	if got, want := Identity(2).String(), "[[1, 0], [0, 1]]"; got != want {
+		t.Errorf("Identity() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,260 @@
+package linalg
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/quizizz/cas/pkg/ast"
+	"github.com/quizizz/cas/pkg/expand"
+	"github.com/quizizz/cas/pkg/simplify"
+)
+
+// maxSymbolicSize bounds cofactor expansion, which grows factorially
+const maxSymbolicSize = 5
+
+// Determinant returns the determinant of a square matrix. Numeric matrices
+// are reduced exactly by elimination; symbolic ones by cofactor expansion.
+func Determinant(m *ast.Matrix) (ast.Expr, error) {
+	if m.RowCount() != m.ColCount() {
+		return nil, fmt.Errorf("determinant requires a square matrix, got %s", shape(m))
+	}
+
+	if rats, ok := ratRows(m); ok {
+		return ast.NewNumber(ratDeterminant(rats)), nil
+	}
+
+	if m.RowCount() > maxSymbolicSize {
+		return nil, fmt.Errorf("symbolic determinant is limited to %dx%d matrices", maxSymbolicSize, maxSymbolicSize)
+	}
+	return simplify.Simplify(expand.Expand(cofactorDeterminant(m.Rows()))), nil
+}
+
+// Inverse returns the inverse of a square matrix, or an error if it is singular
+func Inverse(m *ast.Matrix) (*ast.Matrix, error) {
+	n := m.RowCount()
+	if n != m.ColCount() {
+		return nil, fmt.Errorf("inverse requires a square matrix, got %s", shape(m))
+	}
+
+	if rats, ok := ratRows(m); ok {
+		// Gauss-Jordan on [A | I]
+		augmented := make([][]*big.Rat, n)
+		for i := range augmented {
+			augmented[i] = make([]*big.Rat, 2*n)
+			copy(augmented[i], rats[i])
+			for j := 0; j < n; j++ {
+				augmented[i][n+j] = new(big.Rat)
+				if i == j {
+					augmented[i][n+j].SetInt64(1)
+				}
+			}
+		}
+		if ratReduce(augmented, n) < n {
+			return nil, fmt.Errorf("matrix is singular")
+		}
+		rows := make([][]*big.Rat, n)
+		for i := range rows {
+			rows[i] = augmented[i][n:]
+		}
+		return fromRatRows(rows), nil
+	}
+
+	// Symbolic inverse via the adjugate: inv(A)[i][j] = C[j][i] / det(A)
+	det, err := Determinant(m)
+	if err != nil {
+		return nil, err
+	}
+	if r, ok := ast.ExactValue(det); ok && r.Sign() == 0 {
+		return nil, fmt.Errorf("matrix is singular")
+	}
+
+	entries := m.Rows()
+	rows := make([][]ast.Expr, n)
+	for i := range rows {
+		rows[i] = make([]ast.Expr, n)
+		for j := range rows[i] {
+			cofactor := cofactorDeterminant(minor(entries, j, i))
+			if (i+j)%2 == 1 {
+				cofactor = ast.NewMul(ast.NewInt(-1), cofactor)
+			}
+			entry := ast.NewMul(cofactor, ast.NewPow(det.Clone(), ast.NewInt(-1)))
+			rows[i][j] = simplify.Simplify(entry)
+		}
+	}
+	return ast.NewMatrix(rows)
+}
+
+// RREF returns the reduced row echelon form of a numeric matrix
+func RREF(m *ast.Matrix) (*ast.Matrix, error) {
+	rats, ok := ratRows(m)
+	if !ok {
+		return nil, fmt.Errorf("row reduction requires numeric entries")
+	}
+	ratReduce(rats, m.ColCount())
+	return fromRatRows(rats), nil
+}
+
+// Rank returns the number of linearly independent rows of a numeric matrix
+func Rank(m *ast.Matrix) (int, error) {
+	rats, ok := ratRows(m)
+	if !ok {
+		return 0, fmt.Errorf("rank requires numeric entries")
+	}
+	return ratReduce(rats, m.ColCount()), nil
+}
+
+// ratRows converts every entry of m to an exact rational, reporting false
+// if any entry is symbolic
+func ratRows(m *ast.Matrix) ([][]*big.Rat, bool) {
+	rows := make([][]*big.Rat, m.RowCount())
+	for i := range rows {
+		rows[i] = make([]*big.Rat, m.ColCount())
+		for j := range rows[i] {
+			r, ok := ast.ExactValue(m.At(i, j))
+			if !ok {
+				return nil, false
+			}
+			rows[i][j] = r
+		}
+	}
+	return rows, true
+}
+
+// fromRatRows builds a matrix of exact entries from rational rows
+func fromRatRows(rats [][]*big.Rat) *ast.Matrix {
+	rows := make([][]ast.Expr, len(rats))
+	for i := range rows {
+		rows[i] = make([]ast.Expr, len(rats[i]))
+		for j, r := range rats[i] {
+			rows[i][j] = ast.NewNumber(r)
+		}
+	}
+	result, _ := ast.NewMatrix(rows)
+	return result
+}
+
+// ratReduce brings rows to reduced row echelon form in place, pivoting only
+// within the first cols columns. It returns the number of pivots found.
+func ratReduce(rows [][]*big.Rat, cols int) int {
+	pivotRow := 0
+	for col := 0; col < cols && pivotRow < len(rows); col++ {
+		pivot := -1
+		for i := pivotRow; i < len(rows); i++ {
+			if rows[i][col].Sign() != 0 {
+				pivot = i
+				break
+			}
+		}
+		if pivot < 0 {
+			continue
+		}
+		rows[pivotRow], rows[pivot] = rows[pivot], rows[pivotRow]
+
+		inv := new(big.Rat).Inv(rows[pivotRow][col])
+		for j := range rows[pivotRow] {
+			rows[pivotRow][j].Mul(rows[pivotRow][j], inv)
+		}
+
+		for i := range rows {
+			if i == pivotRow || rows[i][col].Sign() == 0 {
+				continue
+			}
+			factor := new(big.Rat).Set(rows[i][col])
+			for j := range rows[i] {
+				rows[i][j].Sub(rows[i][j], new(big.Rat).Mul(factor, rows[pivotRow][j]))
+			}
+		}
+		pivotRow++
+	}
+	return pivotRow
+}
+
+// ratDeterminant computes a determinant by forward elimination, tracking
+// row swaps for the sign
+func ratDeterminant(rows [][]*big.Rat) *big.Rat {
+	n := len(rows)
+	work := make([][]*big.Rat, n)
+	for i := range work {
+		work[i] = make([]*big.Rat, n)
+		for j := range work[i] {
+			work[i][j] = new(big.Rat).Set(rows[i][j])
+		}
+	}
+
+	det := big.NewRat(1, 1)
+	for col := 0; col < n; col++ {
+		pivot := -1
+		for i := col; i < n; i++ {
+			if work[i][col].Sign() != 0 {
+				pivot = i
+				break
+			}
+		}
+		if pivot < 0 {
+			return new(big.Rat)
+		}
+		if pivot != col {
+			work[col], work[pivot] = work[pivot], work[col]
+			det.Neg(det)
+		}
+		det.Mul(det, work[col][col])
+
+		for i := col + 1; i < n; i++ {
+			factor := new(big.Rat).Quo(work[i][col], work[col][col])
+			for j := col; j < n; j++ {
+				work[i][j].Sub(work[i][j], new(big.Rat).Mul(factor, work[col][j]))
+			}
+		}
+	}
+	return det
+}
+
+// cofactorDeterminant expands the determinant along the first row
+func cofactorDeterminant(rows [][]ast.Expr) ast.Expr {
+	n := len(rows)
+	if n == 0 {
+		return ast.NewInt(1)
+	}
+	if n == 1 {
+		return rows[0][0].Clone()
+	}
+
+	terms := make([]ast.Expr, 0, n)
+	for j := 0; j < n; j++ {
+		if r, ok := ast.ExactValue(rows[0][j]); ok && r.Sign() == 0 {
+			continue
+		}
+		term := ast.NewMul(rows[0][j].Clone(), cofactorDeterminant(minor(rows, 0, j)))
+		if j%2 == 1 {
+			term = ast.NewMul(ast.NewInt(-1), term)
+		}
+		terms = append(terms, term)
+	}
+
+	switch len(terms) {
+	case 0:
+		return ast.NewInt(0)
+	case 1:
+		return terms[0]
+	default:
+		return ast.NewAdd(terms...)
+	}
+}
+
+// minor returns rows with row i and column j removed
+func minor(rows [][]ast.Expr, i, j int) [][]ast.Expr {
+	result := make([][]ast.Expr, 0, len(rows)-1)
+	for r, row := range rows {
+		if r == i {
+			continue
+		}
+		entries := make([]ast.Expr, 0, len(row)-1)
+		for c, entry := range row {
+			if c != j {
+				entries = append(entries, entry)
+			}
+		}
+		result = append(result, entries)
+	}
+	return result
+}
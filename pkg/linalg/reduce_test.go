@@ -0,0 +1,139 @@
+package linalg
+
+import (
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestDeterminant(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[[1,2],[3,4]]", "-2"},
+		{"[[2,0,1],[1,3,2],[1,1,1]]", "0"},
+		{"[[1/2,1/3],[1/4,1]]", "5/12"},
+		{"[[0,1],[1,0]]", "-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			det, err := Determinant(parseMatrix(t, tt.input))
+			if err != nil {
+				t.Fatalf("Determinant error: %v", err)
+			}
+			if det.String() != tt.expected {
+				t.Errorf("Determinant(%s) = %s, expected %s", tt.input, det, tt.expected)
+			}
+		})
+	}
+
+	// The order of symbolic terms is not fixed, so check ad - bc by value
+	det, err := Determinant(parseMatrix(t, "[[a,b],[c,d]]"))
+	if err != nil {
+		t.Fatalf("Determinant error: %v", err)
+	}
+	vars := map[string]*big.Float{"a": big.NewFloat(2), "b": big.NewFloat(3), "c": big.NewFloat(5), "d": big.NewFloat(7)}
+	if value, err := det.Eval(vars); err != nil || value.Cmp(big.NewFloat(-1)) != 0 {
+		t.Errorf("Determinant([[a,b],[c,d]]) = %s, expected a*d-b*c", det)
+	}
+
+	if _, err := Determinant(parseMatrix(t, "[[1,2,3]]")); err == nil {
+		t.Errorf("Determinant should reject non-square matrices")
+	}
+}
+
+func TestInverse(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[[1,2],[3,4]]", "[[-2, 1], [3/2, -1/2]]"},
+		{"[[2,0],[0,4]]", "[[1/2, 0], [0, 1/4]]"},
+		{"[[1,0,2],[0,1,0],[0,0,1]]", "[[1, 0, -2], [0, 1, 0], [0, 0, 1]]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			inv, err := Inverse(parseMatrix(t, tt.input))
+			if err != nil {
+				t.Fatalf("Inverse error: %v", err)
+			}
+			if inv.String() != tt.expected {
+				t.Errorf("Inverse(%s) = %s, expected %s", tt.input, inv, tt.expected)
+			}
+		})
+	}
+
+	if _, err := Inverse(parseMatrix(t, "[[1,2],[2,4]]")); err == nil {
+		t.Errorf("Inverse should reject singular matrices")
+	}
+}
+
+func TestSymbolicInverse(t *testing.T) {
+	m := parseMatrix(t, "[[x,1],[1,x]]")
+	inv, err := Inverse(m)
+	if err != nil {
+		t.Fatalf("Inverse error: %v", err)
+	}
+
+	// A·A⁻¹ should be the identity at any point where A is invertible
+	product, err := Multiply(m, inv)
+	if err != nil {
+		t.Fatalf("Multiply error: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		for j := 0; j < 2; j++ {
+			value, err := product.At(i, j).Eval(map[string]*big.Float{"x": big.NewFloat(3)})
+			if err != nil {
+				t.Fatalf("Eval error: %v", err)
+			}
+			want := 0.0
+			if i == j {
+				want = 1
+			}
+			if got, _ := value.Float64(); math.Abs(got-want) > 1e-9 {
+				t.Errorf("(A·A⁻¹)[%d][%d] at x=3 = %v, want %v", i, j, got, want)
+			}
+		}
+	}
+}
+
+func TestRREFAndRank(t *testing.T) {
+	tests := []struct {
+		input string
+		rref  string
+		rank  int
+	}{
+		{"[[1,2],[3,4]]", "[[1, 0], [0, 1]]", 2},
+		{"[[1,2],[2,4]]", "[[1, 2], [0, 0]]", 1},
+		{"[[2,0,1],[1,3,2],[1,1,1]]", "[[1, 0, 1/2], [0, 1, 1/2], [0, 0, 0]]", 2},
+		{"[[0,0],[0,0]]", "[[0, 0], [0, 0]]", 0},
+		{"[[1,2,3],[2,4,7]]", "[[1, 2, 0], [0, 0, 1]]", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			m := parseMatrix(t, tt.input)
+			reduced, err := RREF(m)
+			if err != nil {
+				t.Fatalf("RREF error: %v", err)
+			}
+			if reduced.String() != tt.rref {
+				t.Errorf("RREF(%s) = %s, expected %s", tt.input, reduced, tt.rref)
+			}
+			rank, err := Rank(m)
+			if err != nil {
+				t.Fatalf("Rank error: %v", err)
+			}
+			if rank != tt.rank {
+				t.Errorf("Rank(%s) = %d, expected %d", tt.input, rank, tt.rank)
+			}
+		})
+	}
+
+	if _, err := Rank(parseMatrix(t, "[[x,1],[1,x]]")); err == nil {
+		t.Errorf("Rank should reject symbolic entries")
+	}
+}
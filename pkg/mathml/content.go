@@ -0,0 +1,213 @@
+package mathml
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/quizizz/cas/pkg/ast"
+)
+
+// relations maps the content relation elements to equation types
+var relations = map[string]ast.EqType{
+	"eq":  ast.EqEqual,
+	"neq": ast.EqNotEqual,
+	"lt":  ast.EqLess,
+	"gt":  ast.EqGreater,
+	"leq": ast.EqLessEqual,
+	"geq": ast.EqGreaterEqual,
+}
+
+// parseContent builds the expression a content element denotes
+func parseContent(e *element) (ast.Expr, error) {
+	switch e.name {
+	case "semantics":
+		if len(e.children) == 0 {
+			return nil, fmt.Errorf("mathml: empty <semantics>")
+		}
+		return parseContent(e.children[0])
+	case "cn":
+		return parseNumber(e)
+	case "ci":
+		name := e.Text()
+		if name == "" {
+			return nil, fmt.Errorf("mathml: empty <ci>")
+		}
+		if greek, ok := ast.GreekName(name); ok {
+			name = greek
+		}
+		return ast.NewVar(name), nil
+	case "pi":
+		return ast.Pi.Clone(), nil
+	case "exponentiale":
+		return ast.E.Clone(), nil
+	case "csymbol":
+		switch e.Text() {
+		case "pi":
+			return ast.Pi.Clone(), nil
+		case "e", "exponentiale":
+			return ast.E.Clone(), nil
+		}
+		return nil, fmt.Errorf("mathml: unsupported symbol %q", e.Text())
+	case "apply":
+		return parseApply(e)
+	}
+	return nil, fmt.Errorf("mathml: unsupported element <%s>", e.name)
+}
+
+// parseNumber reads a <cn>, which is an integer, a decimal or, with
+// type="rational", a numerator and denominator separated by <sep/>
+func parseNumber(e *element) (ast.Expr, error) {
+	text := e.Text()
+	if e.attrs["type"] == "rational" || e.attrs["type"] == "e-notation" {
+		if len(e.segments) != 2 || len(e.children) != 1 || e.children[0].name != "sep" {
+			return nil, fmt.Errorf("mathml: malformed <cn type=%q>", e.attrs["type"])
+		}
+		parts := []string{strings.TrimSpace(e.segments[0]), strings.TrimSpace(e.segments[1])}
+		if e.attrs["type"] == "e-notation" {
+			text = parts[0] + "e" + parts[1]
+		} else {
+			num, ok1 := new(big.Int).SetString(parts[0], 10)
+			den, ok2 := new(big.Int).SetString(parts[1], 10)
+			if !ok1 || !ok2 || den.Sign() == 0 {
+				return nil, fmt.Errorf("mathml: invalid rational %s/%s", parts[0], parts[1])
+			}
+			return ast.NewRationalFromInts(num, den), nil
+		}
+	}
+
+	if n, err := ast.NewIntFromString(text); err == nil {
+		return n, nil
+	}
+	if f, err := ast.NewFloatFromString(text); err == nil {
+		return f, nil
+	}
+	return nil, fmt.Errorf("mathml: invalid number %q", text)
+}
+
+// parseApply builds the expression an <apply> denotes: its first child is
+// the operator and the rest are its arguments, except for qualifiers such as
+// <degree> and <logbase>
+func parseApply(e *element) (ast.Expr, error) {
+	if len(e.children) == 0 {
+		return nil, fmt.Errorf("mathml: empty <apply>")
+	}
+	op := e.children[0]
+
+	var args []ast.Expr
+	qualifiers := map[string]ast.Expr{}
+	for _, child := range e.children[1:] {
+		switch child.name {
+		case "degree", "logbase":
+			if len(child.children) != 1 {
+				return nil, fmt.Errorf("mathml: <%s> expects 1 child, got %d", child.name, len(child.children))
+			}
+			q, err := parseContent(child.children[0])
+			if err != nil {
+				return nil, err
+			}
+			qualifiers[child.name] = q
+		default:
+			arg, err := parseContent(child)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+		}
+	}
+
+	arity := func(n int) error {
+		if len(args) != n {
+			return fmt.Errorf("mathml: <%s> expects %d arguments, got %d", op.name, n, len(args))
+		}
+		return nil
+	}
+
+	if eqType, ok := relations[op.name]; ok {
+		if len(args) < 2 {
+			return nil, fmt.Errorf("mathml: <%s> expects at least 2 arguments, got %d", op.name, len(args))
+		}
+		if len(args) == 2 {
+			return ast.NewEq(args[0], args[1], eqType), nil
+		}
+		types := make([]ast.EqType, len(args)-1)
+		for i := range types {
+			types[i] = eqType
+		}
+		return ast.NewChain(args, types)
+	}
+
+	if functionNames[op.name] && op.name != "log" {
+		if err := arity(1); err != nil {
+			return nil, err
+		}
+		return ast.NewFunc(op.name, args[0]), nil
+	}
+
+	switch op.name {
+	case "plus":
+		if len(args) == 0 {
+			return nil, fmt.Errorf("mathml: <plus> expects arguments")
+		}
+		if len(args) == 1 {
+			return args[0], nil
+		}
+		return ast.NewAdd(args...), nil
+	case "minus":
+		switch len(args) {
+		case 1:
+			return ast.NewMul(ast.NewInt(-1), args[0]), nil
+		case 2:
+			return ast.NewAdd(args[0], ast.NewMul(ast.NewInt(-1), args[1])), nil
+		}
+		return nil, fmt.Errorf("mathml: <minus> expects 1 or 2 arguments, got %d", len(args))
+	case "times":
+		if len(args) == 0 {
+			return nil, fmt.Errorf("mathml: <times> expects arguments")
+		}
+		if len(args) == 1 {
+			return args[0], nil
+		}
+		return ast.NewMul(args...), nil
+	case "divide":
+		if err := arity(2); err != nil {
+			return nil, err
+		}
+		return ast.NewMul(args[0], ast.NewPow(args[1], ast.NewInt(-1))), nil
+	case "power":
+		if err := arity(2); err != nil {
+			return nil, err
+		}
+		return ast.NewPow(args[0], args[1]), nil
+	case "root":
+		if err := arity(1); err != nil {
+			return nil, err
+		}
+		if degree, ok := qualifiers["degree"]; ok {
+			return ast.NewPow(args[0], ast.NewPow(degree, ast.NewInt(-1))), nil
+		}
+		return ast.NewFunc("sqrt", args[0]), nil
+	case "abs":
+		if err := arity(1); err != nil {
+			return nil, err
+		}
+		return ast.NewFunc("abs", args[0]), nil
+	case "exp":
+		if err := arity(1); err != nil {
+			return nil, err
+		}
+		return ast.NewPow(ast.E.Clone(), args[0]), nil
+	case "log":
+		if err := arity(1); err != nil {
+			return nil, err
+		}
+		if base, ok := qualifiers["logbase"]; ok {
+			return ast.NewFunc("log", args[0], base), nil
+		}
+		return ast.NewFunc("log", args[0]), nil
+	case "ci":
+		// An application of a named function such as f(x)
+		return ast.NewFunc(op.Text(), args...), nil
+	}
+	return nil, fmt.Errorf("mathml: unsupported operator <%s>", op.name)
+}
@@ -0,0 +1,463 @@
+package mathml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"math/big"
+	"strings"
+
+	"github.com/quizizz/cas/pkg/ast"
+)
+
+// Namespace is the XML namespace of MathML
+const Namespace = "http://www.w3.org/1998/Math/MathML"
+
+const (
+	invisibleTimes = "&#x2062;"
+	applyFunction  = "&#x2061;"
+)
+
+// relationSymbols are the operators shown for each equation type
+var relationSymbols = map[ast.EqType]string{
+	ast.EqEqual:        "=",
+	ast.EqNotEqual:     "≠",
+	ast.EqLess:         "&lt;",
+	ast.EqGreater:      "&gt;",
+	ast.EqLessEqual:    "≤",
+	ast.EqGreaterEqual: "≥",
+}
+
+// Format converts an expression to presentation MathML. Identifiers are
+// written as <mi>, numbers as <mn> and operators, including the invisible
+// times and function application, as <mo>, so that screen readers and
+// renderers treat each part correctly.
+func Format(expr ast.Expr) string {
+	return `<math xmlns="` + Namespace + `">` + formatExpression(expr, 0) + `</math>`
+}
+
+// formatExpression formats an expression, adding parentheses when its
+// precedence is below parentPrec: 1 for terms, 2 for factors and 4 for
+// the base of a power, as in pkg/latex
+func formatExpression(expr ast.Expr, parentPrec int) string {
+	switch e := expr.(type) {
+	case *ast.Int:
+		value := e.IntValue()
+		if value.Sign() < 0 {
+			return negative(mn(new(big.Int).Neg(value).String()), parentPrec)
+		}
+		return mn(value.String())
+	case *ast.Float:
+		text := e.String()
+		if strings.HasPrefix(text, "-") {
+			return negative(mn(text[1:]), parentPrec)
+		}
+		return mn(text)
+	case *ast.Rational:
+		num, den := e.Numerator(), e.Denominator()
+		if den.Cmp(big.NewInt(1)) == 0 {
+			return formatExpression(newInt(num), parentPrec)
+		}
+		frac := "<mfrac>" + mn(new(big.Int).Abs(num).String()) + mn(den.String()) + "</mfrac>"
+		if num.Sign() < 0 {
+			return negative(frac, parentPrec)
+		}
+		return frac
+	case *ast.Var:
+		return formatVariable(e.Name())
+	case *ast.Const:
+		if e.Name() == "pi" {
+			return mi("π")
+		}
+		return mi(e.Name())
+	case *ast.Add:
+		return formatAddition(e, parentPrec)
+	case *ast.PlusMinus:
+		return formatPlusMinus(e, parentPrec)
+	case *ast.Angle:
+		return formatAngle(e)
+	case *ast.Quantity:
+		return formatQuantity(e, parentPrec)
+	case *ast.Unit:
+		return `<mi mathvariant="normal">` + escape(e.Name()) + "</mi>"
+	case *ast.Piecewise:
+		return formatPiecewise(e)
+	case *ast.Mul:
+		return formatMultiplication(e, parentPrec)
+	case *ast.Pow:
+		return formatPower(e, parentPrec)
+	case *ast.Func:
+		return formatFunction(e)
+	case *ast.Eq:
+		return "<mrow>" + formatExpression(e.Left(), 0) + mo(relationSymbols[e.EqType()]) +
+			formatExpression(e.Right(), 0) + "</mrow>"
+	case *ast.Chain:
+		var b strings.Builder
+		b.WriteString("<mrow>")
+		for i, operand := range e.Operands() {
+			if i > 0 {
+				b.WriteString(mo(relationSymbols[e.Relations()[i-1]]))
+			}
+			b.WriteString(formatExpression(operand, 0))
+		}
+		b.WriteString("</mrow>")
+		return b.String()
+	case *ast.Derivative:
+		return formatDerivative(e)
+	case *ast.Matrix:
+		return formatMatrix(e.Rows())
+	case *ast.Vector:
+		return formatMatrix(e.AsColumn().Rows())
+	case *ast.Tuple:
+		return fenced("(", ")", e.Elements())
+	case *ast.Set:
+		return fenced("{", "}", e.Elements())
+	default:
+		return "<mtext>" + escape(expr.String()) + "</mtext>"
+	}
+}
+
+func mi(name string) string {
+	return "<mi>" + escape(name) + "</mi>"
+}
+
+func mn(number string) string {
+	return "<mn>" + number + "</mn>"
+}
+
+// mo writes an operator, which is given already escaped
+func mo(op string) string {
+	return "<mo>" + op + "</mo>"
+}
+
+func escape(s string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// parenthesize wraps formatted content in stretchy parentheses
+func parenthesize(content string) string {
+	return "<mrow>" + mo("(") + content + mo(")") + "</mrow>"
+}
+
+// negative prefixes formatted content with a minus sign, parenthesized
+// inside a product or power
+func negative(content string, parentPrec int) string {
+	result := "<mrow>" + mo("-") + content + "</mrow>"
+	if parentPrec > 1 {
+		return parenthesize(result)
+	}
+	return result
+}
+
+// fenced writes elements separated by commas between the given brackets
+func fenced(open, close string, elements []ast.Expr) string {
+	var b strings.Builder
+	b.WriteString("<mrow>" + mo(open))
+	for i, element := range elements {
+		if i > 0 {
+			b.WriteString(mo(","))
+		}
+		b.WriteString(formatExpression(element, 0))
+	}
+	b.WriteString(mo(close) + "</mrow>")
+	return b.String()
+}
+
+func formatVariable(name string) string {
+	// Handle subscripts
+	if parts := strings.SplitN(name, "_", 2); len(parts) == 2 {
+		sub := mi(parts[1])
+		if _, err := ast.NewIntFromString(parts[1]); err == nil {
+			sub = mn(parts[1])
+		}
+		return "<msub>" + formatVariable(parts[0]) + sub + "</msub>"
+	}
+	if letter, ok := ast.GreekChar(name); ok {
+		return mi(letter)
+	}
+	return mi(name)
+}
+
+// negated returns the term without its minus sign, if it has one
+func negated(term ast.Expr) (ast.Expr, bool) {
+	switch t := term.(type) {
+	case *ast.Int:
+		if t.IntValue().Sign() < 0 {
+			return newInt(new(big.Int).Neg(t.IntValue())), true
+		}
+	case *ast.Rational:
+		if t.Numerator().Sign() < 0 {
+			return ast.NewRationalFromInts(new(big.Int).Neg(t.Numerator()), t.Denominator()), true
+		}
+	case *ast.Mul:
+		factors := t.Terms()
+		if len(factors) == 0 {
+			return nil, false
+		}
+		if first, ok := factors[0].(*ast.Int); ok && first.IntValue().Cmp(big.NewInt(-1)) == 0 {
+			if len(factors) == 2 {
+				return factors[1], true
+			}
+			return ast.NewMul(factors[1:]...), true
+		}
+		if first, ok := negated(factors[0]); ok {
+			if len(factors) == 1 {
+				return first, true
+			}
+			return ast.NewMul(append([]ast.Expr{first}, factors[1:]...)...), true
+		}
+	}
+	return nil, false
+}
+
+func formatAddition(add *ast.Add, parentPrec int) string {
+	terms := add.Terms()
+	if len(terms) == 0 {
+		return mn("0")
+	}
+
+	var b strings.Builder
+	b.WriteString("<mrow>")
+	for i, term := range terms {
+		if positive, ok := negated(term); ok {
+			b.WriteString(mo("-") + formatExpression(positive, 2))
+			continue
+		}
+		if i > 0 {
+			b.WriteString(mo("+"))
+		}
+		b.WriteString(formatExpression(term, 1))
+	}
+	b.WriteString("</mrow>")
+
+	if parentPrec > 1 {
+		return parenthesize(b.String())
+	}
+	return b.String()
+}
+
+func formatPlusMinus(pm *ast.PlusMinus, parentPrec int) string {
+	result := mo("±") + formatExpression(pm.Right(), 2)
+	if left := pm.Left(); left != nil {
+		result = formatExpression(left, 1) + result
+	}
+	result = "<mrow>" + result + "</mrow>"
+
+	if parentPrec > 1 {
+		return parenthesize(result)
+	}
+	return result
+}
+
+// formatAngle writes an angle with its degree sign and the primes of its
+// minutes and seconds
+func formatAngle(angle *ast.Angle) string {
+	result := formatExpression(angle.Degrees(), 3) + mo("°")
+	if minutes := angle.Minutes(); minutes != nil {
+		result += formatExpression(minutes, 3) + mo("′")
+	}
+	if seconds := angle.Seconds(); seconds != nil {
+		result += formatExpression(seconds, 3) + mo("″")
+	}
+	return "<mrow>" + result + "</mrow>"
+}
+
+// formatQuantity writes a value and its unit with a thin space between
+// them, parenthesized inside a product or power
+func formatQuantity(q *ast.Quantity, parentPrec int) string {
+	result := "<mrow>" + formatExpression(q.Value(), 2) + `<mspace width="0.167em"/>` + formatExpression(q.Unit(), 2) + "</mrow>"
+	if parentPrec > 2 {
+		return parenthesize(result)
+	}
+	return result
+}
+
+// formatPiecewise writes the cases of a piecewise expression as a table
+// after an opening brace, one row per case
+func formatPiecewise(pw *ast.Piecewise) string {
+	var b strings.Builder
+	b.WriteString("<mrow>" + mo("{") + "<mtable>")
+	conditions := pw.Conditions()
+	for i, value := range pw.Values() {
+		condition := "<mtext>otherwise</mtext>"
+		if conditions[i] != nil {
+			condition = formatExpression(conditions[i], 0)
+		}
+		b.WriteString("<mtr><mtd>" + formatExpression(value, 0) + "</mtd><mtd>" + condition + "</mtd></mtr>")
+	}
+	b.WriteString("</mtable></mrow>")
+	return b.String()
+}
+
+// reciprocal returns the denominator of a factor written as a power with a
+// negative integer exponent
+func reciprocal(factor ast.Expr) (ast.Expr, bool) {
+	pow, ok := factor.(*ast.Pow)
+	if !ok {
+		return nil, false
+	}
+	exp, ok := pow.Exponent().(*ast.Int)
+	if !ok || exp.IntValue().Sign() >= 0 {
+		return nil, false
+	}
+	if exp.IntValue().Cmp(big.NewInt(-1)) == 0 {
+		return pow.Base(), true
+	}
+	return ast.NewPow(pow.Base(), newInt(new(big.Int).Neg(exp.IntValue()))), true
+}
+
+// formatProduct writes factors side by side, with a dot between numbers
+// and an invisible times otherwise. A lone factor is formatted at
+// parentPrec, so that it is not bracketed inside a fraction.
+func formatProduct(factors []ast.Expr, parentPrec int) string {
+	if len(factors) == 0 {
+		return mn("1")
+	}
+	if len(factors) == 1 {
+		return formatExpression(factors[0], parentPrec)
+	}
+
+	var b strings.Builder
+	b.WriteString("<mrow>")
+	for i, factor := range factors {
+		if i > 0 {
+			_, leftNumeric := factors[i-1].(ast.Numeric)
+			_, rightNumeric := factor.(ast.Numeric)
+			if leftNumeric && rightNumeric {
+				b.WriteString(mo("⋅"))
+			} else {
+				b.WriteString(mo(invisibleTimes))
+			}
+		}
+		b.WriteString(formatExpression(factor, 3))
+	}
+	b.WriteString("</mrow>")
+	return b.String()
+}
+
+func formatMultiplication(mul *ast.Mul, parentPrec int) string {
+	factors := mul.Terms()
+	if len(factors) == 0 {
+		return mn("1")
+	}
+
+	if positive, ok := negated(mul); ok {
+		return negative(formatExpression(positive, 2), parentPrec)
+	}
+
+	// Factors with a negative exponent go below a fraction bar
+	var numerator, denominator []ast.Expr
+	for _, factor := range factors {
+		if den, ok := reciprocal(factor); ok {
+			denominator = append(denominator, den)
+		} else {
+			numerator = append(numerator, factor)
+		}
+	}
+
+	if len(denominator) > 0 {
+		return "<mfrac>" + formatProduct(numerator, 0) + formatProduct(denominator, 0) + "</mfrac>"
+	}
+
+	result := formatProduct(factors, 2)
+	if parentPrec > 2 && len(factors) > 1 {
+		return parenthesize(result)
+	}
+	return result
+}
+
+// rootIndex returns n when the exponent is 1/n
+func rootIndex(exp ast.Expr) (ast.Expr, bool) {
+	switch e := exp.(type) {
+	case *ast.Rational:
+		if e.Numerator().Cmp(big.NewInt(1)) == 0 && e.Denominator().Cmp(big.NewInt(1)) > 0 {
+			return newInt(e.Denominator()), true
+		}
+	case *ast.Pow:
+		if n, ok := e.Exponent().(*ast.Int); ok && n.IntValue().Cmp(big.NewInt(-1)) == 0 {
+			return e.Base(), true
+		}
+	}
+	return nil, false
+}
+
+func formatPower(pow *ast.Pow, parentPrec int) string {
+	if index, ok := rootIndex(pow.Exponent()); ok {
+		base := formatExpression(pow.Base(), 0)
+		if n, ok := index.(*ast.Int); ok && n.IntValue().Cmp(big.NewInt(2)) == 0 {
+			return "<msqrt>" + base + "</msqrt>"
+		}
+		return "<mroot>" + base + formatExpression(index, 0) + "</mroot>"
+	}
+
+	if den, ok := reciprocal(pow); ok {
+		return "<mfrac>" + mn("1") + formatExpression(den, 0) + "</mfrac>"
+	}
+
+	base := formatExpression(pow.Base(), 4)
+	if _, ok := pow.Base().(*ast.Func); ok {
+		// sin(x)^2 rather than sin of x^2
+		base = parenthesize(base)
+	}
+	return "<msup>" + base + formatExpression(pow.Exponent(), 0) + "</msup>"
+}
+
+// applied writes a named function applied to its parenthesized arguments
+func applied(name string, args []ast.Expr) string {
+	return "<mrow>" + name + mo(applyFunction) + fenced("(", ")", args) + "</mrow>"
+}
+
+func formatFunction(f *ast.Func) string {
+	args := f.Args()
+	switch {
+	case f.Name() == "sqrt" && len(args) == 1:
+		return "<msqrt>" + formatExpression(args[0], 0) + "</msqrt>"
+	case f.Name() == "abs" && len(args) == 1:
+		return "<mrow>" + mo("|") + formatExpression(args[0], 0) + mo("|") + "</mrow>"
+	case f.Name() == "log" && len(args) == 2:
+		return applied("<msub>"+mi("log")+formatExpression(args[1], 0)+"</msub>", args[:1])
+	case f.Name() == "sum" && len(args) == 4:
+		return "<mrow><munderover>" + mo("∑") +
+			"<mrow>" + formatExpression(args[1], 0) + mo("=") + formatExpression(args[2], 0) + "</mrow>" +
+			formatExpression(args[3], 0) + "</munderover>" + formatExpression(args[0], 2) + "</mrow>"
+	case f.Name() == "int" && (len(args) == 2 || len(args) == 4):
+		sign := mo("∫")
+		if len(args) == 4 {
+			sign = "<msubsup>" + sign + formatExpression(args[2], 0) + formatExpression(args[3], 0) + "</msubsup>"
+		}
+		return "<mrow>" + sign + formatExpression(args[0], 1) + mi("d") + formatExpression(args[1], 0) + "</mrow>"
+	}
+	return applied(mi(f.Name()), args)
+}
+
+func formatDerivative(d *ast.Derivative) string {
+	top, bottom := mi("d"), formatVariable(d.Variable())
+	if d.Order() > 1 {
+		order := mn(big.NewInt(int64(d.Order())).String())
+		top = "<msup>" + top + order + "</msup>"
+		bottom = "<msup>" + bottom + order + "</msup>"
+	}
+	return "<mrow><mfrac>" + top + "<mrow>" + mi("d") + bottom + "</mrow></mfrac>" +
+		formatExpression(d.Operand(), 3) + "</mrow>"
+}
+
+func formatMatrix(rows [][]ast.Expr) string {
+	var b strings.Builder
+	b.WriteString("<mrow>" + mo("(") + "<mtable>")
+	for _, row := range rows {
+		b.WriteString("<mtr>")
+		for _, cell := range row {
+			b.WriteString("<mtd>" + formatExpression(cell, 0) + "</mtd>")
+		}
+		b.WriteString("</mtr>")
+	}
+	b.WriteString("</mtable>" + mo(")") + "</mrow>")
+	return b.String()
+}
+
+// newInt creates an integer expression from a big integer
+func newInt(value *big.Int) *ast.Int {
+	n, _ := ast.NewIntFromString(value.String())
+	return n
+}
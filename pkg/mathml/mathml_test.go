@@ -0,0 +1,304 @@
+package mathml
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/quizizz/cas/pkg/parser"
+)
+
+func TestParsePresentation(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		latex string
+	}{
+		{"number", "<math><mn>42</mn></math>", "42"},
+		{"sum", "<math><mrow><mi>x</mi><mo>+</mo><mn>1</mn></mrow></math>", "x+1"},
+		{"implicit product", "<math><mrow><mn>2</mn><mo>&InvisibleTimes;</mo><mi>x</mi></mrow></math>", "2x"},
+		{"fraction", "<math><mfrac><mrow><mi>x</mi><mo>+</mo><mn>1</mn></mrow><mn>2</mn></mfrac></math>", "\\frac{x+1}{2}"},
+		{"power", "<math><msup><mi>x</mi><mn>2</mn></msup></math>", "x^2"},
+		{"power of a sum", "<math><msup><mrow><mo>(</mo><mi>x</mi><mo>+</mo><mn>1</mn><mo>)</mo></mrow><mn>2</mn></msup></math>", "(x+1)^2"},
+		{"square root", "<math><msqrt><mi>x</mi><mo>+</mo><mn>1</mn></msqrt></math>", "\\sqrt{x+1}"},
+		{"cube root", "<math><mroot><mi>x</mi><mn>3</mn></mroot></math>", "\\sqrt[3]{x}"},
+		{"subscript", "<math><msub><mi>x</mi><mn>1</mn></msub></math>", "x_1"},
+		{"function", "<math><mi>sin</mi><mo>&ApplyFunction;</mo><mi>x</mi></math>", "\\sin x"},
+		{"function power", "<math><msup><mi>sin</mi><mn>2</mn></msup><mi>x</mi></math>", "\\sin^2 x"},
+		{"inverse function", "<math><msup><mi>sin</mi><mrow><mo>-</mo><mn>1</mn></mrow></msup><mi>x</mi></math>", "\\arcsin x"},
+		{"absolute value", "<math><mrow><mo>|</mo><mi>x</mi><mo>|</mo></mrow></math>", "abs(x)"},
+		{"fenced", "<math><mn>2</mn><mfenced><mi>x</mi></mfenced></math>", "2(x)"},
+		{"relation", "<math><mi>x</mi><mo>&le;</mo><mn>3</mn></math>", "x \\le 3"},
+		{"greek", "<math><mn>2</mn><mi>&pi;</mi><mi>r</mi></math>", "2\\pi r"},
+		{"greek alphabet", "<math><mi>τ</mi><mo>+</mo><mi>Δ</mi></math>", "\\tau+\\Delta"},
+		{"without root element", "<mrow><mi>a</mi><mo>-</mo><mi>b</mi></mrow>", "a-b"},
+		{"semantics", "<math><semantics><mrow><mi>x</mi></mrow><annotation encoding=\"TeX\">x</annotation></semantics></math>", "x"},
+		{"sum", "<math><munderover><mo>&sum;</mo><mrow><mi>i</mi><mo>=</mo><mn>1</mn></mrow><mi>n</mi></munderover><mi>i</mi></math>", "\\sum_{i=1}^{n} i"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := Parse([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("Parse(%q) error: %v", tt.input, err)
+			}
+			expected, err := parser.Parse(tt.latex)
+			if err != nil {
+				t.Fatalf("parser.Parse(%q) error: %v", tt.latex, err)
+			}
+			if expr.String() != expected.String() {
+				t.Errorf("Parse(%q) = %s, want %s", tt.input, expr, expected)
+			}
+		})
+	}
+}
+
+func TestParseContent(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		latex string
+	}{
+		{"variable", "<math><ci>x</ci></math>", "x"},
+		{"plus", "<math><apply><plus/><ci>x</ci><cn>1</cn></apply></math>", "x+1"},
+		{"minus", "<math><apply><minus/><ci>x</ci><cn>1</cn></apply></math>", "x-1"},
+		{"negation", "<math><apply><minus/><ci>x</ci></apply></math>", "-x"},
+		{"times", "<math><apply><times/><cn>2</cn><ci>x</ci></apply></math>", "2x"},
+		{"divide", "<math><apply><divide/><ci>x</ci><cn>2</cn></apply></math>", "\\frac{x}{2}"},
+		{"power", "<math><apply><power/><ci>x</ci><cn>2</cn></apply></math>", "x^2"},
+		{"square root", "<math><apply><root/><ci>x</ci></apply></math>", "\\sqrt{x}"},
+		{"cube root", "<math><apply><root/><degree><cn>3</cn></degree><ci>x</ci></apply></math>", "\\sqrt[3]{x}"},
+		{"function", "<math><apply><sin/><ci>x</ci></apply></math>", "\\sin x"},
+		{"reciprocal function", "<math><apply><sec/><ci>x</ci></apply></math>", "\\sec x"},
+		{"inverse hyperbolic function", "<math><apply><arcsinh/><ci>x</ci></apply></math>", "\\arcsinh x"},
+		{"pi", "<math><apply><times/><cn>2</cn><pi/></apply></math>", "2\\pi"},
+		{"equation", "<math><apply><eq/><ci>y</ci><apply><plus/><ci>x</ci><cn>1</cn></apply></apply></math>", "y=x+1"},
+		{"chain", "<math><apply><lt/><cn>0</cn><ci>x</ci><cn>1</cn></apply></math>", "0<x<1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := Parse([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("Parse(%q) error: %v", tt.input, err)
+			}
+			expected, err := parser.Parse(tt.latex)
+			if err != nil {
+				t.Fatalf("parser.Parse(%q) error: %v", tt.latex, err)
+			}
+			if expr.String() != expected.String() {
+				t.Errorf("Parse(%q) = %s, want %s", tt.input, expr, expected)
+			}
+		})
+	}
+}
+
+func TestParseContentNumbers(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"<cn>42</cn>", "42"},
+		{"<cn>2.5</cn>", "2.5"},
+		{"<cn type=\"rational\">3<sep/>4</cn>", "3/4"},
+		{"<cn type=\"e-notation\">1.5<sep/>3</cn>", "1500"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			expr, err := Parse([]byte("<math>" + tt.input + "</math>"))
+			if err != nil {
+				t.Fatalf("Parse(%q) error: %v", tt.input, err)
+			}
+			if expr.String() != tt.expected {
+				t.Errorf("Parse(%q) = %s, want %s", tt.input, expr, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"malformed xml", "<math><mi>x</math>"},
+		{"empty", ""},
+		{"unsupported element", "<math><mglyph/></math>"},
+		{"unmatched bar", "<math><mrow><mo>|</mo><mi>x</mi></mrow></math>"},
+		{"wrong arity", "<math><mfrac><mn>1</mn></mfrac></math>"},
+		{"unknown operator", "<math><apply><curl/><ci>x</ci></apply></math>"},
+		{"divide arity", "<math><apply><divide/><ci>x</ci></apply></math>"},
+		{"latex in an identifier", "<math><mi>\\frac{1}{0}</mi></math>"},
+		{"latex in a number", "<math><mn>1}+{2</mn></math>"},
+		{"latex in an operator", "<math><mo>\\sqrt</mo><mi>x</mi></math>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse([]byte(tt.input)); err == nil {
+				t.Errorf("Parse(%q) should fail", tt.input)
+			}
+		})
+	}
+}
+
+func TestParseIdentifiers(t *testing.T) {
+	expr, err := Parse([]byte("<math><mrow><mi>xy</mi><mo>+</mo><mi>x</mi><mi>y</mi></mrow></math>"))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if got, want := expr.String(), "xy+x*y"; got != want {
+		t.Errorf("Parse = %s, want %s", got, want)
+	}
+}
+
+func TestParseErrorOffsets(t *testing.T) {
+	input := "<math><mrow><mi>x</mi><mo>+</mo><mo>+</mo><mn>1</mn></mrow></math>"
+	_, err := Parse([]byte(input))
+	parseErr, ok := err.(*parser.ParseError)
+	if !ok {
+		t.Fatalf("Parse(%q) error = %v, want a *parser.ParseError", input, err)
+	}
+	if got, want := input[parseErr.Start:parseErr.End], "<mo>+</mo>"; got != want || parseErr.Start != 32 {
+		t.Errorf("error covers %q at %d, want %q at 32", got, parseErr.Start, want)
+	}
+	if !strings.Contains(parseErr.Message, "offset 32") {
+		t.Errorf("error message %q should give the offset in the document", parseErr.Message)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"integer", "42", "<mn>42</mn>"},
+		{"variable", "x", "<mi>x</mi>"},
+		{"greek", "\\theta", "<mi>θ</mi>"},
+		{"greek alphabet", "\\tau\\Delta", "<mrow><mi>τ</mi><mo>&#x2062;</mo><mi>Δ</mi></mrow>"},
+		{"pi", "\\pi", "<mi>π</mi>"},
+		{"sum", "x+1", "<mrow><mi>x</mi><mo>+</mo><mn>1</mn></mrow>"},
+		{"difference", "x-1", "<mrow><mi>x</mi><mo>-</mo><mn>1</mn></mrow>"},
+		{"implicit product", "2x", "<mrow><mn>2</mn><mo>&#x2062;</mo><mi>x</mi></mrow>"},
+		{"product of numbers", "2 \\cdot 3", "<mrow><mn>2</mn><mo>⋅</mo><mn>3</mn></mrow>"},
+		{"fraction", "\\frac{x}{2}", "<mfrac><mi>x</mi><mn>2</mn></mfrac>"},
+		{"fraction of a sum", "(x+1)/2", "<mfrac><mrow><mi>x</mi><mo>+</mo><mn>1</mn></mrow><mn>2</mn></mfrac>"},
+		{"fraction over a sum", "1/(x+1)", "<mfrac><mn>1</mn><mrow><mi>x</mi><mo>+</mo><mn>1</mn></mrow></mfrac>"},
+		{"power", "x^2", "<msup><mi>x</mi><mn>2</mn></msup>"},
+		{"power of a sum", "(x+1)^2", "<msup><mrow><mo>(</mo><mrow><mi>x</mi><mo>+</mo><mn>1</mn></mrow><mo>)</mo></mrow><mn>2</mn></msup>"},
+		{"square root", "\\sqrt{x}", "<msqrt><mi>x</mi></msqrt>"},
+		{"cube root", "\\sqrt[3]{x}", "<mroot><mi>x</mi><mn>3</mn></mroot>"},
+		{"function", "\\sin x", "<mrow><mi>sin</mi><mo>&#x2061;</mo><mrow><mo>(</mo><mi>x</mi><mo>)</mo></mrow></mrow>"},
+		{"subscript", "x_1", "<msub><mi>x</mi><mn>1</mn></msub>"},
+		{"relation", "x \\le 3", "<mrow><mi>x</mi><mo>≤</mo><mn>3</mn></mrow>"},
+		{"less than", "x < 3", "<mrow><mi>x</mi><mo>&lt;</mo><mn>3</mn></mrow>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := parser.Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+			expected := `<math xmlns="http://www.w3.org/1998/Math/MathML">` + tt.expected + `</math>`
+			if result := Format(expr); result != expected {
+				t.Errorf("Format(%s) = %s, want %s", tt.input, result, expected)
+			}
+		})
+	}
+}
+
+func TestFormatRoundTrip(t *testing.T) {
+	inputs := []string{
+		"x^2+2x+1",
+		"\\frac{x+1}{x-1}",
+		"-3x",
+		"\\sqrt{x}+\\sqrt[3]{y}",
+		"\\sin(x)^2",
+		"\\ln x - \\cos x",
+		"\\log_2 x + \\log_{10}(x+1)",
+		"x = \\frac{-b \\pm \\sqrt{d}}{2a}",
+		"\\sin 30^\\circ + \\cos(12°30')",
+		"2\\pi r",
+		"x^{-2}",
+		"e^{x+1}",
+		"y = 2x - 5",
+		"x-(y-z)",
+		"1 < x \\le 2",
+		"\\sum_{i=1}^{n} i^2",
+		"\\int_0^1 x^2 dx",
+		"\\begin{pmatrix}1&2\\\\3&4\\end{pmatrix}",
+		"\\begin{cases} x^2 & x < 0 \\\\ 2x & 0 \\le x \\le 1 \\\\ 1 & \\text{otherwise} \\end{cases}",
+	}
+
+	vars := map[string]*big.Float{"x": big.NewFloat(0.7), "y": big.NewFloat(1.3), "z": big.NewFloat(0.4), "r": big.NewFloat(2), "n": big.NewFloat(5)}
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			expr, err := parser.Parse(input)
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+			formatted := Format(expr)
+			back, err := Parse([]byte(formatted))
+			if err != nil {
+				t.Fatalf("Parse(Format(%s)) error: %v\n%s", input, err, formatted)
+			}
+			if back.String() == expr.String() {
+				return
+			}
+
+			// Fractions may come back in an equivalent shape
+			want, err1 := expr.Eval(vars)
+			got, err2 := back.Eval(vars)
+			if err1 != nil || err2 != nil || !closeTo(want, got) {
+				t.Errorf("Parse(Format(%s)) = %s, want %s\n%s", input, back, expr, formatted)
+			}
+		})
+	}
+}
+
+func closeTo(a, b *big.Float) bool {
+	diff := new(big.Float).Sub(a, b)
+	return diff.Abs(diff).Cmp(big.NewFloat(1e-9)) < 0
+}
+
+func TestFormatIsWellFormed(t *testing.T) {
+	expr, err := parser.Parse("\\frac{1}{x} < \\sqrt{x}")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	formatted := Format(expr)
+	if !strings.Contains(formatted, "&lt;") {
+		t.Errorf("Format should escape <: %s", formatted)
+	}
+	if _, err := decode([]byte(formatted)); err != nil {
+		t.Errorf("Format produced malformed XML: %v\n%s", err, formatted)
+	}
+}
+
+func TestFormatQuantity(t *testing.T) {
+	expr, err := parser.Parse("9.8 m/s^2", parser.Options{Units: true})
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	expected := `<math xmlns="http://www.w3.org/1998/Math/MathML"><mrow><mn>9.8</mn><mspace width="0.167em"/>` +
+		`<mfrac><mi mathvariant="normal">m</mi><msup><mi mathvariant="normal">s</mi><mn>2</mn></msup></mfrac></mrow></math>`
+	if result := Format(expr); result != expected {
+		t.Errorf("Format(9.8 m/s^2) = %s, want %s", result, expected)
+	}
+}
+
+func TestFormatPiecewise(t *testing.T) {
+	expr, err := parser.Parse(`\begin{cases} 1 & x > 0 \\ 0 & \text{otherwise} \end{cases}`)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	expected := `<math xmlns="http://www.w3.org/1998/Math/MathML"><mrow><mo>{</mo><mtable>` +
+		`<mtr><mtd><mn>1</mn></mtd><mtd><mrow><mi>x</mi><mo>&gt;</mo><mn>0</mn></mrow></mtd></mtr>` +
+		`<mtr><mtd><mn>0</mn></mtd><mtd><mtext>otherwise</mtext></mtd></mtr></mtable></mrow></math>`
+	if result := Format(expr); result != expected {
+		t.Errorf("Format(piecewise) = %s, want %s", result, expected)
+	}
+}
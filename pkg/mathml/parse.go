@@ -0,0 +1,483 @@
+// Package mathml imports and exports MathML, the XML notation used by LMS
+// systems and equation editors.
+package mathml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/quizizz/cas/pkg/ast"
+	"github.com/quizizz/cas/pkg/parser"
+)
+
+// element is a MathML element with its attributes, children and text
+type element struct {
+	name     string
+	attrs    map[string]string
+	children []*element
+	// segments holds the character data between successive children
+	segments []string
+	// offset and end are the byte offsets of the start tag and just past
+	// the end tag in the document
+	offset int
+	end    int
+}
+
+// Text returns the trimmed character data of the element
+func (e *element) Text() string {
+	return strings.TrimSpace(strings.Join(e.segments, ""))
+}
+
+// entities are the named character references MathML documents commonly
+// use beyond those of XML itself
+var entities = func() map[string]string {
+	m := map[string]string{
+		"InvisibleTimes": "⁢",
+		"it":             "⁢",
+		"ApplyFunction":  "⁡",
+		"af":             "⁡",
+		"ExponentialE":   "ⅇ",
+		"ee":             "ⅇ",
+		"PlusMinus":      "±",
+		"LessEqual":      "≤",
+		"GreaterEqual":   "≥",
+		"NotEqual":       "≠",
+		"Sqrt":           "√",
+	}
+	for name, value := range xml.HTMLEntity {
+		m[name] = value
+	}
+	return m
+}()
+
+// Parse reads a MathML document in presentation or content markup. The
+// root <math> element is optional. Presentation markup (mrow, mfrac, msup,
+// msqrt, ...) is read through the same grammar as parser.Parse, and content
+// markup (apply, ci, cn, ...) is built into the same trees, so the result
+// compares and formats like the LaTeX spelling of the input. A syntax
+// error in presentation markup is a *parser.ParseError whose offsets cover
+// the element of the document it was found in.
+func Parse(data []byte) (ast.Expr, error) {
+	root, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	children := root.children
+	if root.name != "math" {
+		children = []*element{root}
+	}
+	children = unwrapSemantics(children)
+
+	if isContent(children) {
+		if len(children) != 1 {
+			return nil, fmt.Errorf("mathml: expected one content element, got %d", len(children))
+		}
+		return parseContent(children[0])
+	}
+
+	var t translator
+	if err := t.writeAll(children); err != nil {
+		return nil, err
+	}
+	expr, err := parser.Parse(t.String(), parser.Options{Variables: t.names})
+	if err != nil {
+		return nil, t.locate(err)
+	}
+	return expr, nil
+}
+
+// decode reads the element tree of a MathML document
+func decode(data []byte) (*element, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Entity = entities
+
+	var stack []*element
+	var root *element
+	for {
+		offset := int(decoder.InputOffset())
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("mathml: %v", err)
+		}
+
+		switch tok := token.(type) {
+		case xml.StartElement:
+			e := &element{name: tok.Name.Local, attrs: map[string]string{}, segments: []string{""}, offset: offset}
+			for _, attr := range tok.Attr {
+				e.attrs[attr.Name.Local] = attr.Value
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, e)
+				parent.segments = append(parent.segments, "")
+			} else if root == nil {
+				root = e
+			}
+			stack = append(stack, e)
+		case xml.EndElement:
+			stack[len(stack)-1].end = int(decoder.InputOffset())
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				current := stack[len(stack)-1]
+				current.segments[len(current.segments)-1] += string(tok)
+			}
+		}
+	}
+
+	if root == nil {
+		return nil, fmt.Errorf("mathml: empty document")
+	}
+	return root, nil
+}
+
+// unwrapSemantics replaces a <semantics> element by its first child, the
+// primary notation, dropping its annotations
+func unwrapSemantics(children []*element) []*element {
+	if len(children) == 1 && children[0].name == "semantics" && len(children[0].children) > 0 {
+		return unwrapSemantics(children[0].children[:1])
+	}
+	return children
+}
+
+// isContent reports whether the elements are content rather than
+// presentation markup
+func isContent(children []*element) bool {
+	for _, child := range children {
+		switch child.name {
+		case "apply", "ci", "cn", "csymbol", "pi", "exponentiale":
+			return true
+		}
+	}
+	return false
+}
+
+// functionNames are the functions written as a named <mi> in presentation
+// markup and as an empty element in content markup
+var functionNames = map[string]bool{
+	"sin": true, "cos": true, "tan": true, "sec": true, "csc": true, "cot": true,
+	"arcsin": true, "arccos": true, "arctan": true, "arcsec": true, "arccsc": true, "arccot": true,
+	"sinh": true, "cosh": true, "tanh": true, "arcsinh": true, "arccosh": true, "arctanh": true,
+	"sech": true, "csch": true, "coth": true,
+	"ln": true, "log": true,
+}
+
+// numberText is the text of an <mn>: digits with an optional decimal point
+var numberText = regexp.MustCompile(`^([0-9]+(\.[0-9]*)?|\.[0-9]+)$`)
+
+// identifierText is the text of an <mi> read as one variable
+var identifierText = regexp.MustCompile(`^[A-Za-z]+$`)
+
+// operators are the <mo> operators the parser reads as they are written
+var operators = map[string]bool{
+	"+": true, "-": true, "−": true, "±": true, "*": true, "×": true, "⋅": true, "·": true,
+	"∙": true, "∗": true, "/": true, "÷": true, "∕": true, "⁄": true, "^": true,
+	"=": true, "<": true, ">": true, "≤": true, "≥": true, "⩽": true, "⩾": true, "≠": true,
+	"(": true, ")": true, "[": true, "]": true, ",": true, "!": true, "'": true,
+	"″": true, "°": true, "√": true,
+}
+
+// translator writes presentation markup in the LaTeX grammar of
+// parser.Parse
+type translator struct {
+	strings.Builder
+	// bars counts the | operators seen in the current row, so that each
+	// pair becomes an absolute value
+	bars int
+	// names lists the identifiers of several letters, which the parser is
+	// told to read as one variable
+	names []string
+	// marks map offsets in the LaTeX back to the elements written there
+	marks []mark
+}
+
+// mark records that the LaTeX from offset latex on was written for el
+type mark struct {
+	latex int
+	el    *element
+}
+
+// errorf builds an error at the offset of e in the document
+func errorf(e *element, format string, args ...interface{}) error {
+	return fmt.Errorf("mathml: %s at offset %d", fmt.Sprintf(format, args...), e.offset)
+}
+
+// positionSuffix is the LaTeX position the parser adds to its messages
+var positionSuffix = regexp.MustCompile(` at position [0-9]+$`)
+
+// locate moves a parse error of the translated LaTeX onto the element of
+// the document it was written for
+func (t *translator) locate(err error) error {
+	var parseErr *parser.ParseError
+	if !errors.As(err, &parseErr) || len(t.marks) == 0 {
+		return fmt.Errorf("mathml: %v", err)
+	}
+	i := sort.Search(len(t.marks), func(i int) bool { return t.marks[i].latex > parseErr.Start })
+	el := t.marks[max(i-1, 0)].el
+	located := *parseErr
+	located.Message = fmt.Sprintf("mathml: %s in <%s> at offset %d", positionSuffix.ReplaceAllString(parseErr.Message, ""), el.name, el.offset)
+	located.Start, located.End = el.offset, el.end
+	return &located
+}
+
+// write translates a presentation element
+func (t *translator) write(e *element) error {
+	t.marks = append(t.marks, mark{latex: t.Len(), el: e})
+	switch e.name {
+	case "math", "mrow", "mstyle", "mpadded", "merror":
+		saved := t.bars
+		t.bars = 0
+		if err := t.writeAll(e.children); err != nil {
+			return err
+		}
+		if t.bars%2 == 1 {
+			return errorf(e, "unmatched | in <%s>", e.name)
+		}
+		t.bars = saved
+	case "semantics":
+		if len(e.children) > 0 {
+			return t.write(e.children[0])
+		}
+	case "mn":
+		if !numberText.MatchString(e.Text()) {
+			return errorf(e, "invalid number %q", e.Text())
+		}
+		t.WriteString(" " + e.Text() + " ")
+	case "mi":
+		return t.writeIdentifier(e)
+	case "mo":
+		return t.writeOperator(e, e.Text())
+	case "mfrac":
+		return t.writeTemplate(e, 2, "\\frac{", "}{", "}")
+	case "msup":
+		if len(e.children) == 2 && e.children[0].name == "mi" && functionNames[e.children[0].Text()] {
+			// A power on a function name, as in sin² x or sin⁻¹ x
+			return t.writeTemplate(e, 2, "", "^{", "}")
+		}
+		return t.writeTemplate(e, 2, "{", "}^{", "}")
+	case "msub":
+		return t.writeTemplate(e, 2, "", "_{", "}")
+	case "msubsup", "munderover":
+		return t.writeTemplate(e, 3, "", "_{", "}^{", "}")
+	case "msqrt":
+		t.WriteString("\\sqrt{")
+		if err := t.writeAll(e.children); err != nil {
+			return err
+		}
+		t.WriteString("}")
+	case "mroot":
+		if len(e.children) != 2 {
+			return errorf(e, "<mroot> expects 2 children, got %d", len(e.children))
+		}
+		// The index comes second in MathML but first in LaTeX
+		swapped := *e
+		swapped.children = []*element{e.children[1], e.children[0]}
+		return t.writeTemplate(&swapped, 2, "\\sqrt[", "]{", "}")
+	case "mfenced":
+		return t.writeFenced(e)
+	case "mtable":
+		return t.writeTable(e)
+	case "mtext", "mspace", "mphantom", "annotation", "annotation-xml", "none":
+		// Layout and annotations carry no mathematical content
+	default:
+		return errorf(e, "unsupported element <%s>", e.name)
+	}
+	return nil
+}
+
+// writeAll translates elements in order. A brace followed by a table is
+// a piecewise expression, as Format writes it.
+func (t *translator) writeAll(children []*element) error {
+	for i := 0; i < len(children); i++ {
+		child := children[i]
+		if child.name == "mo" && child.Text() == "{" && i+1 < len(children) && children[i+1].name == "mtable" {
+			if err := t.writeCases(children[i+1]); err != nil {
+				return err
+			}
+			i++
+			continue
+		}
+		if err := t.write(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeTemplate translates the children of e between the given pieces of
+// LaTeX, which surround and separate them
+func (t *translator) writeTemplate(e *element, arity int, pieces ...string) error {
+	if len(e.children) != arity {
+		return errorf(e, "<%s> expects %d children, got %d", e.name, arity, len(e.children))
+	}
+	t.WriteString(pieces[0])
+	for i, child := range e.children {
+		if err := t.write(child); err != nil {
+			return err
+		}
+		t.WriteString(pieces[i+1])
+	}
+	return nil
+}
+
+// writeIdentifier translates an <mi>. An identifier of several letters
+// is one variable, as <mi>xy</mi> is xy and not x times y.
+func (t *translator) writeIdentifier(e *element) error {
+	name := e.Text()
+	switch {
+	case functionNames[name]:
+		t.WriteString(" \\" + name + " ")
+	case name == "ⅇ" || name == "ⅈ":
+		t.WriteString(" e ")
+	case len([]rune(name)) == 1 && unicode.IsLetter([]rune(name)[0]):
+		t.WriteString(" " + name + " ")
+	case identifierText.MatchString(name):
+		t.names = append(t.names, name)
+		t.WriteString(" " + name + " ")
+	default:
+		return errorf(e, "invalid identifier %q", name)
+	}
+	return nil
+}
+
+// writeOperator translates an <mo>
+func (t *translator) writeOperator(e *element, op string) error {
+	switch op {
+	case "⁢":
+		// Invisible times
+		t.WriteString("*")
+	case "⁡", "":
+		// Function application is implied by juxtaposition
+		t.WriteString(" ")
+	case "|":
+		if t.bars%2 == 0 {
+			t.WriteString(" abs(")
+		} else {
+			t.WriteString(")")
+		}
+		t.bars++
+	case "{":
+		t.WriteString("\\{")
+	case "}":
+		t.WriteString("\\}")
+	case "′":
+		t.WriteString("'")
+	case "∑":
+		t.WriteString("\\sum ")
+	case "∫":
+		t.WriteString("\\int ")
+	default:
+		if !operators[op] {
+			return errorf(e, "unsupported operator %q", op)
+		}
+		t.WriteString(" " + op + " ")
+	}
+	return nil
+}
+
+// writeFenced translates an <mfenced>, whose children are separated by
+// commas (or its separators attribute) and surrounded by parentheses
+func (t *translator) writeFenced(e *element) error {
+	open, close := "(", ")"
+	if value, ok := e.attrs["open"]; ok {
+		open = value
+	}
+	if value, ok := e.attrs["close"]; ok {
+		close = value
+	}
+	separators := ","
+	if value, ok := e.attrs["separators"]; ok {
+		separators = strings.Join(strings.Fields(value), "")
+	}
+
+	if open == "|" && close == "|" {
+		t.WriteString(" abs(")
+		if err := t.writeAll(e.children); err != nil {
+			return err
+		}
+		t.WriteString(")")
+		return nil
+	}
+	if err := t.writeOperator(e, open); err != nil {
+		return err
+	}
+	for i, child := range e.children {
+		if i > 0 && separators != "" {
+			// The last separator repeats for the remaining children
+			sep := []rune(separators)
+			if err := t.writeOperator(e, string(sep[min(i-1, len(sep)-1)])); err != nil {
+				return err
+			}
+		}
+		if err := t.write(child); err != nil {
+			return err
+		}
+	}
+	return t.writeOperator(e, close)
+}
+
+// writeTable translates an <mtable> into a matrix
+func (t *translator) writeTable(e *element) error {
+	t.WriteString("\\begin{matrix}")
+	for i, row := range e.children {
+		if i > 0 {
+			t.WriteString("\\\\")
+		}
+		for j, cell := range row.children {
+			if j > 0 {
+				t.WriteString("&")
+			}
+			// Cells (<mtd>) hold an inferred row
+			if err := t.writeAll(cell.children); err != nil {
+				return err
+			}
+		}
+	}
+	t.WriteString("\\end{matrix}")
+	return nil
+}
+
+// writeCases translates the table after an opening brace into a cases
+// environment. Each row holds a value and its condition, and a condition
+// in text, such as otherwise, is kept as text.
+func (t *translator) writeCases(table *element) error {
+	t.marks = append(t.marks, mark{latex: t.Len(), el: table})
+	t.WriteString("\\begin{cases}")
+	for i, row := range table.children {
+		if len(row.children) != 2 {
+			return errorf(row, "case expects a value and a condition, got %d cells", len(row.children))
+		}
+		if i > 0 {
+			t.WriteString("\\\\")
+		}
+		if err := t.writeAll(row.children[0].children); err != nil {
+			return err
+		}
+		t.WriteString("&")
+		for _, child := range row.children[1].children {
+			if child.name == "mtext" {
+				if !identifierText.MatchString(child.Text()) {
+					return errorf(child, "unsupported condition %q", child.Text())
+				}
+				t.WriteString("\\text{" + child.Text() + "}")
+				continue
+			}
+			if err := t.write(child); err != nil {
+				return err
+			}
+		}
+	}
+	t.WriteString("\\end{cases}")
+	return nil
+}
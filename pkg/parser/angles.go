@@ -0,0 +1,98 @@
+package parser
+
+import (
+	"math/big"
+
+	"github.com/quizizz/cas/pkg/ast"
+)
+
+// tryParseAngle reads a degree sign after an operand, as in 45°, 30^\circ
+// or 30^{\circ}, followed by minutes and seconds of arc, as in 12°30′15″.
+// The parser state is left as it was when there is no degree sign.
+func (p *Parser) tryParseAngle(degrees ast.Expr) (ast.Expr, bool) {
+	if !p.matchDegreeSign() {
+		return nil, false
+	}
+
+	var minutes, seconds ast.Expr
+	if value, ok := p.tryParseArcPart(TokenPrime); ok {
+		minutes = value
+	}
+	if value, ok := p.tryParseArcPart(TokenDoublePrime); ok {
+		seconds = value
+	}
+	return ast.NewAngleDMS(degrees, minutes, seconds), true
+}
+
+// matchDegreeSign consumes °, ^\circ or ^{\circ}
+func (p *Parser) matchDegreeSign() bool {
+	if p.current.Type == TokenDegree {
+		p.advance()
+		return true
+	}
+	if p.current.Type != TokenPower {
+		return false
+	}
+
+	savedPos, savedCurrent, savedEnd := p.lexer.Position(), p.current, p.end
+	p.advance()
+	braced := p.current.Type == TokenLeftBrace
+	if braced {
+		p.advance()
+	}
+	if p.current.Type == TokenDegree {
+		p.advance()
+		if !braced || p.current.Type == TokenRightBrace {
+			if braced {
+				p.advance()
+			}
+			return true
+		}
+	}
+
+	p.lexer.SetPosition(savedPos)
+	p.current, p.end = savedCurrent, savedEnd
+	return false
+}
+
+// tryParseArcPart reads a number of minutes, marked by a prime, or of
+// seconds, marked by a double prime or two primes, restoring the parser
+// state when there is none
+func (p *Parser) tryParseArcPart(mark TokenType) (ast.Expr, bool) {
+	if p.current.Type != TokenInt && p.current.Type != TokenFloat {
+		return nil, false
+	}
+	savedPos, savedCurrent, savedEnd := p.lexer.Position(), p.current, p.end
+
+	value, _ := new(big.Rat).SetString(p.current.Value)
+	p.advance()
+	if p.matchArcMark(mark) {
+		return ast.NewNumber(value), true
+	}
+
+	p.lexer.SetPosition(savedPos)
+	p.current, p.end = savedCurrent, savedEnd
+	return nil, false
+}
+
+// matchArcMark consumes a prime for minutes, or a double prime or two
+// adjacent primes for seconds. A prime followed by another is not minutes.
+func (p *Parser) matchArcMark(mark TokenType) bool {
+	if mark == TokenDoublePrime && p.current.Type == TokenDoublePrime {
+		p.advance()
+		return true
+	}
+	if p.current.Type != TokenPrime {
+		return false
+	}
+	end := p.current.End
+	p.advance()
+	double := p.current.Type == TokenPrime && p.current.Pos == end
+	if mark == TokenPrime {
+		return !double
+	}
+	if double {
+		p.advance()
+	}
+	return double
+}
@@ -0,0 +1,123 @@
+package parser
+
+import (
+	"github.com/quizizz/cas/pkg/ast"
+)
+
+// asciiMathRules is the scanner table for AsciiMath input. Unlike the LaTeX
+// table, longer names come before their prefixes (sinh before sin, leq
+// before le), as AsciiMath reads the longest symbol it knows.
+var asciiMathRules = func() []rule {
+	rules := []rule{
+		// Numbers
+		{match: matchFloat, starts: digits, tokenType: TokenFloat},
+		{match: matchLeadingDotFloat, starts: ".", tokenType: TokenFloat},
+		{match: matchDigits, starts: digits, tokenType: TokenInt},
+
+		// Operators
+		{literal: "**", tokenType: TokenMultiply},
+		{literal: "*", tokenType: TokenMultiply},
+		{literal: "xx", tokenType: TokenMultiply},
+		{literal: "cdot", tokenType: TokenMultiply},
+		{literal: "//", tokenType: TokenDivide},
+		{literal: "/", tokenType: TokenDivide},
+		{literal: "-:", tokenType: TokenDivide},
+		{literal: "div", tokenType: TokenDivide},
+		{literal: "-", tokenType: TokenMinus},
+		{literal: "\u2212", tokenType: TokenMinus}, // Unicode minus
+		{literal: "+-", tokenType: TokenPlusMinus},
+		{literal: "\u00b1", tokenType: TokenPlusMinus},
+		{literal: "+", tokenType: TokenPlus},
+		{literal: "^", tokenType: TokenPower},
+
+		// Brackets; {: and :} group without being shown
+		{literal: "{:", tokenType: TokenLeftParen},
+		{literal: ":}", tokenType: TokenRightParen},
+		{literal: "(", tokenType: TokenLeftParen},
+		{literal: ")", tokenType: TokenRightParen},
+		{literal: "[", tokenType: TokenLeftBracket},
+		{literal: "]", tokenType: TokenRightBracket},
+		{literal: "{", tokenType: TokenLeftBrace},
+		{literal: "}", tokenType: TokenRightBrace},
+
+		// Relations
+		{literal: "<=", tokenType: TokenLessEqual},
+		{literal: ">=", tokenType: TokenGreaterEqual},
+		{literal: "!=", tokenType: TokenNotEqual, value: "<>"},
+		{literal: "<", tokenType: TokenLess},
+		{literal: ">", tokenType: TokenGreater},
+		{literal: "=", tokenType: TokenEquals},
+		{literal: "leq", tokenType: TokenLessEqual, value: "<="},
+		{literal: "le", tokenType: TokenLessEqual, value: "<="},
+		{literal: "lt", tokenType: TokenLess, value: "<"},
+		{literal: "geq", tokenType: TokenGreaterEqual, value: ">="},
+		{literal: "ge", tokenType: TokenGreaterEqual, value: ">="},
+		{literal: "gt", tokenType: TokenGreater, value: ">"},
+		{literal: "ne", tokenType: TokenNotEqual, value: "<>"},
+
+		// Big operators and layout
+		{literal: "sum", tokenType: TokenSum},
+		{literal: "int", tokenType: TokenIntegral},
+		{literal: "root", tokenType: TokenRoot},
+		{literal: "frac", tokenType: TokenFrac},
+	}
+
+	for _, f := range functions {
+		rules = append(rules, rule{literal: f.name, tokenType: f.tokenType})
+	}
+	for _, g := range ast.GreekLetters {
+		if g.Name != "pi" {
+			rules = append(rules, rule{literal: g.Name, tokenType: TokenVar})
+		}
+	}
+	for _, v := range ast.GreekVariants {
+		rules = append(rules, rule{literal: v.Name, tokenType: greekToken(v.Letter), value: v.Letter})
+	}
+	for _, c := range constants {
+		rules = append(rules, rule{literal: c.name, tokenType: c.tokenType})
+	}
+
+	return append(rules,
+		rule{literal: "_", tokenType: TokenSubscript},
+		rule{literal: "|", tokenType: TokenPipe},
+		rule{literal: ",", tokenType: TokenComma},
+		rule{literal: "!", tokenType: TokenExclamation},
+		rule{literal: "'", tokenType: TokenPrime},
+		rule{literal: "\u00b0", tokenType: TokenDegree},
+		rule{literal: "deg", tokenType: TokenDegree},
+		rule{match: matchLetter, starts: letters, tokenType: TokenVar},
+	)
+}()
+
+var asciiMathRulesByFirstByte = indexRules(asciiMathRules)
+
+// newAsciiMathLexer creates a lexer for AsciiMath input
+func newAsciiMathLexer(input string) *Lexer {
+	return &Lexer{input: input, table: asciiMathRules, rules: &asciiMathRulesByFirstByte}
+}
+
+// ParseAsciiMath parses AsciiMath input such as sum_(i=1)^n i^3, sqrt(x)/2
+// or int_0^1 f(x) dx. It builds the same trees as Parse does for the LaTeX
+// spelling of the input, so the result compares and formats identically.
+func ParseAsciiMath(input string, opts ...Options) (ast.Expr, error) {
+	parser := newParser(newAsciiMathLexer(input), opts...)
+	parser.asciiMath = true
+	return parser.parse()
+}
+
+// parseRoot parses the AsciiMath root(n)(x) into the same tree as
+// \sqrt[n]{x}
+func (p *Parser) parseRoot() (ast.Expr, error) {
+	p.advance() // consume root
+
+	index, err := p.parseGroup("root")
+	if err != nil {
+		return nil, err
+	}
+	operand, err := p.parseGroup("root")
+	if err != nil {
+		return nil, err
+	}
+
+	return ast.NewPow(operand, ast.NewPow(index, ast.NewInt(-1))), nil
+}
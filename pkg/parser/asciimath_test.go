@@ -0,0 +1,91 @@
+package parser
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestParseAsciiMath(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		latex string // LaTeX spelling that must give the same tree
+	}{
+		{"sum", "sum_(i=1)^n i^3", "\\sum_{i=1}^{n} i^3"},
+		{"sum plus term", "sum_(i=1)^n i + 1", "\\sum_{i=1}^{n} i + 1"},
+		{"sqrt over number", "sqrt(x)/2", "\\frac{\\sqrt{x}}{2}"},
+		{"definite integral", "int_0^1 f(x) dx", "\\int_0^1 f(x) dx"},
+		{"indefinite integral", "int x^2 + 1 dx", "\\int x^2 + 1 dx"},
+		{"fraction", "frac(a+1)(b)", "\\frac{a+1}{b}"},
+		{"braced fraction", "frac{a}{b}", "\\frac{a}{b}"},
+		{"root", "root(3)(x)", "\\sqrt[3]{x}"},
+		{"grouped power", "x^(n+1)", "x^{n+1}"},
+		{"invisible brackets", "{:x+1:}^2", "(x+1)^2"},
+		{"times", "2 xx 3", "2 \\times 3"},
+		{"divide", "a -: b", "a \\div b"},
+		{"degrees", "sin(30 deg)", "\\sin(30°)"},
+		{"plus minus", "x = (-b +- sqrt(d))/2", "x = \\frac{-b \\pm \\sqrt{d}}{2}"},
+		{"relations", "x != 2", "x \\ne 2"},
+		{"spelled relation", "x le 3", "x \\le 3"},
+		{"trig", "sin(theta) + cos x", "\\sin(\\theta) + \\cos x"},
+		{"absolute value", "abs(x-1)", "abs(x-1)"},
+		{"greek", "2 pi r + phi", "2\\pi r + \\phi"},
+		{"greek alphabet", "tau + Delta + varphi", "\\tau + \\Delta + \\phi"},
+		{"exponential", "e^(2x)", "e^{2x}"},
+		{"leibniz", "dy/dx = 3y", "\\frac{dy}{dx} = 3y"},
+		{"point", "(1, -2)", "\\left(1, -2\\right)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := ParseAsciiMath(tt.input)
+			if err != nil {
+				t.Fatalf("ParseAsciiMath(%s) returned error: %v", tt.input, err)
+			}
+			want, err := Parse(tt.latex)
+			if err != nil {
+				t.Fatalf("Parse(%s) returned error: %v", tt.latex, err)
+			}
+
+			if expr.String() != want.String() {
+				t.Errorf("ParseAsciiMath(%s) = %s, want %s", tt.input, expr, want)
+			}
+		})
+	}
+
+	for _, input := range []string{"sum_i^n i", "int x", "frac(a)", "root(3)"} {
+		if _, err := ParseAsciiMath(input); err == nil {
+			t.Errorf("ParseAsciiMath(%s) should return an error", input)
+		}
+	}
+}
+
+func TestParseSumsAndIntegralsEvaluate(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"sum_(i=1)^n i^3", 100}, // n = 4
+		{"sum_(k=0)^3 2^k", 15},
+		{"int_0^1 x^2 dx", 1.0 / 3},
+		{"int_0^n 2x dx", 16},
+	}
+
+	vars := map[string]*big.Float{"n": big.NewFloat(4)}
+	for _, tt := range tests {
+		expr, err := ParseAsciiMath(tt.input)
+		if err != nil {
+			t.Fatalf("ParseAsciiMath(%s) returned error: %v", tt.input, err)
+		}
+		value, err := expr.Eval(vars)
+		if err != nil {
+			t.Fatalf("Eval(%s) returned error: %v", tt.input, err)
+		}
+		if got, _ := value.Float64(); got-tt.expected > 1e-9 || tt.expected-got > 1e-9 {
+			t.Errorf("Eval(%s) = %v, want %v", tt.input, got, tt.expected)
+		}
+		if vars := expr.Variables(); len(vars) > 1 || (len(vars) == 1 && vars[0] != "n") {
+			t.Errorf("%s.Variables() = %v, want only the free variable n", tt.input, vars)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/quizizz/cas/pkg/ast"
+)
+
+// conditionWords may introduce the condition of a case, as in
+// \text{if } x < 0
+var conditionWords = map[string]bool{"": true, "if": true, "for": true, "when": true, "where": true}
+
+// otherwiseWords stand for a case that applies everywhere else
+var otherwiseWords = map[string]bool{"otherwise": true, "else": true, "elsewhere": true}
+
+// parseCases parses a cases environment into a piecewise expression:
+//
+//	\begin{cases} x^2 & x < 0 \\ 2x & \text{otherwise} \end{cases}
+//
+// Each row holds a value, an optional comma, then & and its condition, a
+// relation or chain of relations. A row without a condition applies
+// everywhere, like otherwise.
+func (p *Parser) parseCases() (ast.Expr, error) {
+	start := p.current.Pos
+	if err := p.expect(TokenBeginCases); err != nil {
+		return nil, err
+	}
+
+	var values, conditions []ast.Expr
+	for p.current.Type != TokenEndCases {
+		value, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		if p.current.Type == TokenComma {
+			p.advance()
+		}
+
+		var condition ast.Expr
+		if p.current.Type == TokenAmpersand {
+			p.advance()
+			if condition, err = p.parseCaseCondition(); err != nil {
+				return nil, err
+			}
+		}
+		values = append(values, value)
+		conditions = append(conditions, condition)
+
+		switch p.current.Type {
+		case TokenRowSeparator:
+			p.advance()
+		case TokenEndCases:
+		default:
+			if p.current.Type == TokenError {
+				return nil, p.invalidCharacter()
+			}
+			err := errorAt(p.current, ErrUnexpectedToken, "expected & or \\\\ in cases, got %s at position %d", p.current.Type, p.current.Pos)
+			err.Expected = []TokenType{TokenAmpersand, TokenRowSeparator, TokenEndCases}
+			return nil, err
+		}
+	}
+	p.advance()
+
+	if len(values) == 0 {
+		return nil, p.errorSpan(start, ErrInvalidStructure, fmt.Errorf("cases environment has no cases"))
+	}
+	return ast.NewPiecewise(values, conditions), nil
+}
+
+// parseCaseCondition parses the condition after the & of a case, which is
+// nil for otherwise
+func (p *Parser) parseCaseCondition() (ast.Expr, error) {
+	if p.current.Type == TokenText {
+		word := p.current.Value
+		switch {
+		case otherwiseWords[word]:
+			p.advance()
+			return nil, nil
+		case conditionWords[word]:
+			p.advance()
+		default:
+			return nil, errorAt(p.current, ErrUnexpectedToken, "unexpected text %q in cases at position %d", word, p.current.Pos)
+		}
+	}
+
+	start := p.current.Pos
+	condition, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	if condition.Type() != ast.TypeEq && condition.Type() != ast.TypeChain {
+		err := fmt.Errorf("condition %s is not a relation", condition.String())
+		return nil, p.errorSpan(start, ErrInvalidStructure, err)
+	}
+	return condition, nil
+}
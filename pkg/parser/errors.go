@@ -0,0 +1,339 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/quizizz/cas/pkg/ast"
+)
+
+// ErrorCode identifies the kind of a ParseError. Codes are stable and can be
+// used to look up a localized message instead of showing Message directly.
+type ErrorCode string
+
+const (
+	// ErrInvalidCharacter is a character the lexer does not recognize
+	ErrInvalidCharacter ErrorCode = "invalid_character"
+	// ErrUnexpectedToken is a token that cannot appear where it was found
+	ErrUnexpectedToken ErrorCode = "unexpected_token"
+	// ErrUnexpectedEnd is input that ends before the expression is complete
+	ErrUnexpectedEnd ErrorCode = "unexpected_end"
+	// ErrMissingClosing is a group opened without its closing delimiter
+	ErrMissingClosing ErrorCode = "missing_closing"
+	// ErrInvalidNumber is a numeric literal that cannot be represented
+	ErrInvalidNumber ErrorCode = "invalid_number"
+	// ErrEmptyCall is a function call without arguments, such as f()
+	ErrEmptyCall ErrorCode = "empty_call"
+	// ErrInvalidStructure is a well-formed construct with invalid contents,
+	// such as a matrix with rows of different lengths
+	ErrInvalidStructure ErrorCode = "invalid_structure"
+)
+
+// ParseError describes a syntax error and the part of the input it covers
+type ParseError struct {
+	Code    ErrorCode
+	Message string
+	// Start and End are byte offsets into the input; End is exclusive
+	Start int
+	End   int
+	// Expected lists the tokens that would have been accepted, when known
+	Expected []TokenType
+	// Suggestion is an optional hint for fixing the input
+	Suggestion string
+}
+
+// Error returns the error message
+func (e *ParseError) Error() string {
+	return e.Message
+}
+
+// ErrorList is returned in recovery mode when the input has several errors.
+// The errors are in input order.
+type ErrorList []*ParseError
+
+// Error returns the first message and the number of further errors
+func (l ErrorList) Error() string {
+	switch len(l) {
+	case 0:
+		return "no errors"
+	case 1:
+		return l[0].Error()
+	default:
+		return fmt.Sprintf("%s (and %d more errors)", l[0].Error(), len(l)-1)
+	}
+}
+
+// primaryTokens are the tokens that can start an operand
+var primaryTokens = []TokenType{
+	TokenInt, TokenFloat, TokenVulgarFraction, TokenVar, TokenPi, TokenE, TokenLeftParen, TokenLeftBrace,
+	TokenLeftBracket, TokenLeftPipe, TokenPipe, TokenSqrt, TokenFrac, TokenDfrac, TokenLn, TokenLog,
+	TokenSin, TokenCos, TokenTan, TokenSec, TokenCsc, TokenCot,
+	TokenArcsin, TokenArccos, TokenArctan, TokenArcsec, TokenArccsc, TokenArccot,
+	TokenSinh, TokenCosh, TokenTanh, TokenArcsinh, TokenArccosh, TokenArctanh, TokenSech, TokenCsch, TokenCoth, TokenAbs, TokenBeginMatrix, TokenSum, TokenIntegral,
+	TokenRoot, TokenMinus, TokenBeginCases,
+}
+
+// errorAt builds an error covering the given token
+func errorAt(token Token, code ErrorCode, format string, args ...interface{}) *ParseError {
+	return &ParseError{
+		Code:    code,
+		Message: fmt.Sprintf(format, args...),
+		Start:   token.Pos,
+		End:     token.End,
+	}
+}
+
+// errorSpan builds an error covering the input from start up to the
+// current token
+func (p *Parser) errorSpan(start int, code ErrorCode, err error) *ParseError {
+	return &ParseError{
+		Code:    code,
+		Message: err.Error(),
+		Start:   start,
+		End:     p.current.Pos,
+	}
+}
+
+// unexpected reports the current token where one of expected should be
+func (p *Parser) unexpected(expected ...TokenType) *ParseError {
+	if p.current.Type == TokenError {
+		return p.invalidCharacter()
+	}
+
+	var err *ParseError
+	if len(expected) == 1 {
+		err = errorAt(p.current, ErrUnexpectedToken, "expected %s, got %s at position %d", expected[0], p.current.Type, p.current.Pos)
+	} else {
+		err = errorAt(p.current, ErrUnexpectedToken, "unexpected token %s at position %d", p.current.Type, p.current.Pos)
+	}
+	if p.current.Type == TokenEOF {
+		err.Code = ErrUnexpectedEnd
+	}
+	err.Expected = expected
+	return err
+}
+
+// invalidCharacter reports the current error token
+func (p *Parser) invalidCharacter() *ParseError {
+	// Quote the whole character rather than its first byte
+	char := p.lexer.input[p.current.Pos:p.current.End]
+	if char == "," && p.options.Locale.decimal() == ',' {
+		return errorAt(p.current, ErrInvalidCharacter, "invalid character ',' at position %d: the comma is the decimal separator, so separate with ';'", p.current.Pos)
+	}
+	return errorAt(p.current, ErrInvalidCharacter, "invalid character '%s' at position %d", char, p.current.Pos)
+}
+
+// expectClosing consumes the closing delimiter of a group. When it is
+// missing, the error suggests which delimiter to add and, if construct is
+// not empty, what it closes.
+func (p *Parser) expectClosing(closing TokenType, construct string) error {
+	if p.current.Type == closing {
+		p.advance()
+		return nil
+	}
+
+	err := p.unexpected(closing)
+	if err.Code == ErrInvalidCharacter {
+		return err
+	}
+	err.Code = ErrMissingClosing
+	err.Suggestion = "missing closing " + delimiterName(closing)
+	if construct != "" {
+		err.Suggestion += " for " + construct
+	}
+	return err
+}
+
+// delimiterName names a closing delimiter for suggestions
+func delimiterName(closing TokenType) string {
+	switch closing {
+	case TokenRightParen:
+		return "parenthesis"
+	case TokenRightBracket:
+		return "bracket"
+	case TokenRightBrace:
+		return "brace"
+	case TokenRightPipe, TokenPipe:
+		return "bar"
+	case TokenEndMatrix, TokenEndCases:
+		return "\\end"
+	default:
+		return strings.ToLower(closing.String())
+	}
+}
+
+// synchronize skips to the next comma or relation outside any group, or
+// to the end of input, so parsing can resume after an error
+func (p *Parser) synchronize() {
+	depth := 0
+	for {
+		switch p.current.Type {
+		case TokenEOF:
+			return
+		case TokenError:
+			// The lexer does not move past a character it cannot read
+			p.lexer.SetPosition(p.current.End)
+		case TokenLeftParen, TokenLeftBracket, TokenLeftBrace, TokenLeftPipe, TokenBeginMatrix, TokenBeginCases:
+			depth++
+		case TokenRightParen, TokenRightBracket, TokenRightBrace, TokenRightPipe, TokenEndMatrix, TokenEndCases:
+			if depth > 0 {
+				depth--
+			}
+		case TokenComma:
+			if depth == 0 {
+				return
+			}
+		default:
+			if _, ok := relationType(p.current.Type); ok && depth == 0 {
+				return
+			}
+		}
+		p.advance()
+	}
+}
+
+// parseRecovering parses a top-level answer like parseList, but records
+// each error and resumes at the next comma or relation so that a single
+// pass reports every error it can find. Errors inside an expression are
+// recovered from closer to where they occur; see recover.
+func (p *Parser) parseRecovering() (ast.Expr, error) {
+	start := p.current.Pos
+	var elements []ast.Expr
+	for {
+		errors := len(p.errs)
+		elem, err := p.parseExpression()
+		// A stray closing delimiter was already reported where the
+		// expression recovered, so it is skipped rather than reported
+		// again as trailing input
+		skip := len(p.errs) > errors && p.errs[len(p.errs)-1].Start == p.current.Pos
+		if err == nil && !skip && p.current.Type != TokenComma && p.current.Type != TokenEOF {
+			err = p.trailing(false)
+		}
+		failed := err != nil
+		for err != nil || skip {
+			if err != nil {
+				p.errs = append(p.errs, asParseError(err, p.current))
+			}
+			skip = false
+			p.synchronize()
+			err = nil
+			if _, ok := relationType(p.current.Type); ok {
+				// Resume after the relation so the other side is checked too
+				p.advance()
+				_, err = p.parseExpression()
+			}
+		}
+		if !failed {
+			elements = append(elements, elem)
+		}
+
+		if p.current.Type != TokenComma {
+			break
+		}
+		p.advance()
+	}
+
+	if len(p.errs) > 0 {
+		return nil, p.errs
+	}
+	if len(elements) > 1 {
+		return p.mark(ast.NewSet(elements...), start), nil
+	}
+	return elements[0], nil
+}
+
+// recover records err in recovery mode and skips to where the caller can
+// carry on: a token for which stop holds, a closing delimiter that ends an
+// enclosing group, or the end of input. It reports false, skipping
+// nothing, outside recovery mode.
+func (p *Parser) recover(err error, stop func(TokenType) bool) bool {
+	if !p.options.Recover {
+		return false
+	}
+	p.errs = append(p.errs, asParseError(err, p.current))
+
+	depth := 0
+	for {
+		switch t := p.current.Type; {
+		case t == TokenEOF:
+			return true
+		case t == TokenError:
+			// The lexer does not move past a character it cannot read
+			p.lexer.SetPosition(p.current.End)
+		case isOpening(t):
+			depth++
+		case isClosing(t):
+			if depth == 0 {
+				return true
+			}
+			depth--
+		case depth == 0 && stop(t):
+			return true
+		}
+		p.advance()
+	}
+}
+
+// isTermBoundary reports whether a token ends a term of a sum, so that
+// parsing can resume there
+func isTermBoundary(t TokenType) bool {
+	if _, ok := relationType(t); ok {
+		return true
+	}
+	return t == TokenPlus || t == TokenMinus || t == TokenPlusMinus || t == TokenComma
+}
+
+func isOpening(t TokenType) bool {
+	switch t {
+	case TokenLeftParen, TokenLeftBracket, TokenLeftBrace, TokenLeftPipe, TokenBeginMatrix, TokenBeginCases:
+		return true
+	}
+	return false
+}
+
+func isClosing(t TokenType) bool {
+	switch t {
+	case TokenRightParen, TokenRightBracket, TokenRightBrace, TokenRightPipe, TokenEndMatrix, TokenEndCases:
+		return true
+	}
+	return false
+}
+
+// placeholder stands in for an operand that failed to parse in recovery
+// mode; the expression it ends up in is never returned
+func placeholder() ast.Expr {
+	return ast.NewInt(0)
+}
+
+// trailing reports the input left over after a complete expression, as in
+// 1+2) or x y z 3. The error spans the leftover tokens up to the end of
+// input, or with toEnd false up to the next comma or relation outside any
+// group.
+func (p *Parser) trailing(toEnd bool) *ParseError {
+	if p.current.Type == TokenError {
+		return p.invalidCharacter()
+	}
+
+	err := errorAt(p.current, ErrUnexpectedToken, "unexpected %s at position %d after the end of the expression", p.current.Type, p.current.Pos)
+	if isClosing(p.current.Type) {
+		err.Suggestion = "remove the unmatched closing " + delimiterName(p.current.Type)
+	}
+	for {
+		p.synchronize()
+		if !toEnd || p.current.Type == TokenEOF {
+			break
+		}
+		p.advance()
+	}
+	err.End = p.end
+	return err
+}
+
+// asParseError returns err as a ParseError, attributing an untyped error
+// to the given token
+func asParseError(err error, at Token) *ParseError {
+	if parseErr, ok := err.(*ParseError); ok {
+		return parseErr
+	}
+	return errorAt(at, ErrInvalidStructure, "%s", err.Error())
+}
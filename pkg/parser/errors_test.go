@@ -0,0 +1,152 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestParseErrorSpans(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		code       ErrorCode
+		start      int
+		end        int
+		expected   []TokenType
+		suggestion string
+	}{
+		{"unclosed frac", "\\frac{1}{2", ErrMissingClosing, 10, 10, []TokenType{TokenRightBrace}, "missing closing brace for \\frac"},
+		{"unclosed sqrt", "\\sqrt{x", ErrMissingClosing, 7, 7, []TokenType{TokenRightBrace}, "missing closing brace for \\sqrt"},
+		{"unclosed parenthesis", "(1+2", ErrMissingClosing, 4, 4, []TokenType{TokenRightParen}, "missing closing parenthesis"},
+		{"wrong closing", "(1+2]", ErrMissingClosing, 4, 5, []TokenType{TokenRightParen}, "missing closing parenthesis"},
+		{"unclosed call", "sin(x", ErrMissingClosing, 5, 5, []TokenType{TokenRightParen}, "missing closing parenthesis for sin"},
+		{"unclosed bar", "|2x - 3", ErrMissingClosing, 7, 7, []TokenType{TokenPipe}, "missing closing bar for absolute value"},
+		{"trailing operator", "x + ", ErrUnexpectedEnd, 4, 4, primaryTokens, ""},
+		{"misplaced operator", "x * = 2", ErrUnexpectedToken, 4, 5, primaryTokens, ""},
+		{"invalid character", "2 + @", ErrInvalidCharacter, 4, 5, nil, ""},
+		{"invalid multibyte character", "é", ErrInvalidCharacter, 0, 2, nil, ""},
+		{"unary plus", "+3", ErrUnexpectedToken, 0, 1, nil, "remove the leading +"},
+		{"empty call", "f()", ErrEmptyCall, 2, 3, nil, "add an argument between the parentheses"},
+		{"ragged matrix", "[[1, 2], [3]]", ErrInvalidStructure, 0, 13, nil, ""},
+		{"unmatched parenthesis", "1+2)", ErrUnexpectedToken, 3, 4, nil, "remove the unmatched closing parenthesis"},
+		{"extra parenthesis", "(1+2))", ErrUnexpectedToken, 5, 6, nil, "remove the unmatched closing parenthesis"},
+		{"extra brace", "\\frac{1}{2}}", ErrUnexpectedToken, 11, 12, nil, "remove the unmatched closing brace"},
+		{"trailing number", "x y z 3", ErrUnexpectedToken, 6, 7, nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.input)
+			if err == nil {
+				t.Fatalf("Parse(%s) should return an error", tt.input)
+			}
+			parseErr, ok := err.(*ParseError)
+			if !ok {
+				t.Fatalf("Parse(%s) returned %T, want *ParseError", tt.input, err)
+			}
+
+			if parseErr.Code != tt.code {
+				t.Errorf("Parse(%s) code = %s, want %s", tt.input, parseErr.Code, tt.code)
+			}
+			if parseErr.Start != tt.start || parseErr.End != tt.end {
+				t.Errorf("Parse(%s) span = [%d, %d), want [%d, %d)", tt.input, parseErr.Start, parseErr.End, tt.start, tt.end)
+			}
+			if !sameTokenTypes(parseErr.Expected, tt.expected) {
+				t.Errorf("Parse(%s) expected = %v, want %v", tt.input, parseErr.Expected, tt.expected)
+			}
+			if parseErr.Suggestion != tt.suggestion {
+				t.Errorf("Parse(%s) suggestion = %q, want %q", tt.input, parseErr.Suggestion, tt.suggestion)
+			}
+		})
+	}
+}
+
+func TestParseErrorMessages(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(1+2", "expected ), got EOF at position 4"},
+		{"x + ", "unexpected token EOF at position 4"},
+		{"2 + @", "invalid character '@' at position 4"},
+		{"é", "invalid character 'é' at position 0"},
+	}
+
+	for _, tt := range tests {
+		_, err := Parse(tt.input)
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("Parse(%s) error = %v, want %s", tt.input, err, tt.expected)
+		}
+	}
+}
+
+func TestParseRecovery(t *testing.T) {
+	type span struct {
+		code       ErrorCode
+		start, end int
+	}
+	tests := []struct {
+		name     string
+		input    string
+		expected []span
+	}{
+		{"single error", "x + ", []span{{ErrUnexpectedEnd, 4, 4}}},
+		{"both sides of an equation", "1 + * 2 = )3", []span{{ErrUnexpectedToken, 4, 5}, {ErrUnexpectedToken, 10, 11}}},
+		{"several answers", "x ++ 1, y = 2, \\frac{1}{", []span{{ErrUnexpectedToken, 3, 4}, {ErrUnexpectedEnd, 24, 24}}},
+		{"invalid characters", "@ = 2 + #", []span{{ErrInvalidCharacter, 0, 1}, {ErrInvalidCharacter, 8, 9}}},
+		{"error inside a group", "(x = 1 +) = *", []span{{ErrUnexpectedToken, 8, 9}, {ErrUnexpectedToken, 12, 13}}},
+		{"errors between terms", "1 + @ + 2 + #", []span{{ErrInvalidCharacter, 4, 5}, {ErrInvalidCharacter, 12, 13}}},
+		{"errors in two groups", "(1 + ) * (2 + )", []span{{ErrUnexpectedToken, 5, 6}, {ErrUnexpectedToken, 14, 15}}},
+		{"trailing input", "1 + 2) = 3", []span{{ErrUnexpectedToken, 5, 6}}},
+		{"stray closing parenthesis", "1 + * 2 + ) 3", []span{{ErrUnexpectedToken, 4, 5}, {ErrUnexpectedToken, 10, 11}}},
+		{"stray closing parenthesis in a list", "1 + ), 2 + *", []span{{ErrUnexpectedToken, 4, 5}, {ErrUnexpectedToken, 11, 12}}},
+		{"stray closing parenthesis before a relation", "1 + ) = *", []span{{ErrUnexpectedToken, 4, 5}, {ErrUnexpectedToken, 8, 9}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.input, Options{Recover: true})
+			errs, ok := err.(ErrorList)
+			if !ok {
+				t.Fatalf("Parse(%s) returned %T (%v), want ErrorList", tt.input, err, err)
+			}
+			if len(errs) != len(tt.expected) {
+				t.Fatalf("Parse(%s) reported %d errors (%v), want %d", tt.input, len(errs), errs, len(tt.expected))
+			}
+			for i, want := range tt.expected {
+				if errs[i].Code != want.code || errs[i].Start != want.start || errs[i].End != want.end {
+					t.Errorf("Parse(%s) error %d = %s [%d, %d), want %s [%d, %d)", tt.input, i,
+						errs[i].Code, errs[i].Start, errs[i].End, want.code, want.start, want.end)
+				}
+			}
+		})
+	}
+
+	// Valid input parses as usual
+	expr, err := Parse("x = 2, x = -1", Options{Recover: true})
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	if got := expr.String(); got != "{x=2, x=-1}" {
+		t.Errorf("Parse(x = 2, x = -1) = %s, want {x=2, x=-1}", got)
+	}
+}
+
+func TestErrorListMessage(t *testing.T) {
+	_, err := Parse("1 + * 2 = )3", Options{Recover: true})
+	expected := "unexpected token * at position 4 (and 1 more errors)"
+	if err == nil || err.Error() != expected {
+		t.Errorf("error = %v, want %s", err, expected)
+	}
+}
+
+func sameTokenTypes(a, b []TokenType) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,81 @@
+package parser
+
+// functions lists the named functions every input syntax understands, with
+// the token the lexer emits for each and the ast.Func name the parser
+// builds from it. Sharing one table keeps the trees of all syntaxes alike,
+// so comparison and formatting do not depend on how an answer was typed.
+var functions = []struct {
+	name      string
+	tokenType TokenType
+}{
+	{"sqrt", TokenSqrt},
+	{"abs", TokenAbs},
+	{"ln", TokenLn},
+	{"log", TokenLog},
+	{"arcsinh", TokenArcsinh},
+	{"arccosh", TokenArccosh},
+	{"arctanh", TokenArctanh},
+	{"arcsin", TokenArcsin},
+	{"arccos", TokenArccos},
+	{"arctan", TokenArctan},
+	{"arcsec", TokenArcsec},
+	{"arccsc", TokenArccsc},
+	{"arccot", TokenArccot},
+	{"sinh", TokenSinh},
+	{"cosh", TokenCosh},
+	{"tanh", TokenTanh},
+	{"sin", TokenSin},
+	{"cos", TokenCos},
+	{"tan", TokenTan},
+	{"sech", TokenSech},
+	{"csch", TokenCsch},
+	{"coth", TokenCoth},
+	{"sec", TokenSec},
+	{"csc", TokenCsc},
+	{"cot", TokenCot},
+}
+
+// inverses maps each function with a named inverse to that inverse, which
+// a power of -1 on the function name denotes, as in \sin^{-1} x
+var inverses = map[string]string{
+	"sin":  "arcsin",
+	"cos":  "arccos",
+	"tan":  "arctan",
+	"sec":  "arcsec",
+	"csc":  "arccsc",
+	"cot":  "arccot",
+	"sinh": "arcsinh",
+	"cosh": "arccosh",
+	"tanh": "arctanh",
+}
+
+// areaNames maps the ISO names of the inverse hyperbolic functions, as in
+// \operatorname{arsinh}, to the names the parser builds
+var areaNames = map[string]string{
+	"arsinh": "arcsinh",
+	"arcosh": "arccosh",
+	"artanh": "arctanh",
+}
+
+// constants lists the named constants every input syntax understands
+var constants = []struct {
+	name      string
+	tokenType TokenType
+}{
+	{"pi", TokenPi},
+	{"e", TokenE},
+}
+
+// functionNames maps a function token to the name of the ast.Func it builds
+var functionNames = func() map[TokenType]string {
+	names := make(map[TokenType]string, len(functions))
+	for _, f := range functions {
+		names[f.tokenType] = f.name
+	}
+	return names
+}()
+
+// functionName returns the ast.Func name for a function token
+func functionName(tokenType TokenType) string {
+	return functionNames[tokenType]
+}
@@ -0,0 +1,375 @@
+package parser
+
+import (
+	"math/rand"
+	"regexp"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// lexerCorpus holds inputs whose token streams must not change, including
+// the cases where a short rule shadows a longer one (\le before \leq,
+// = before =/=)
+var lexerCorpus = []string{
+	"",
+	"x^2 + 3x - 5",
+	"2.5*x + .5 - 1.",
+	"\\frac{1}{2} + \\dfrac{x}{y}",
+	"\\sqrt[3]{x} + sqrt(4)",
+	"\\sin(x) + \\cos x + \\tan{x} + \\sinh(x) + \\cosh x + \\tanh x",
+	"\\arcsin x + \\arccos x + \\arctan x + \\sec x + \\csc x + \\cot x",
+	"sin(x)cos(x)tan(x) + sinh(x) + ln(x) + log(x) + abs(x)",
+	"\\ln x + \\log_{2} 8",
+	"e^{i\\pi} + pi + \\pi + \\theta + \\phi + theta + phi + psi + omega",
+	"alpha + beta + gamma + delta + epsilon",
+	"x <= 5, x >= 2, x <> 3, x < 1, x > 0, x = 4, x =/= 2",
+	"x \\le 5 \\ge 2 \\leq 3 \\geq 4 \\ne 1 \\neq 0",
+	"\\left(x\\right) + \\left[x\\right] + \\left\\{x\\right\\} + \\left{x\\right} + \\{1, 2\\}",
+	"\\left|x\\right| + |x|",
+	"a \\cdot b \\times c \\ast d \\div e ** 2",
+	"3 \u2212 2",
+	"\\begin{pmatrix}1 & 2 \\\\ 3 & 4\\end{pmatrix}",
+	"\\begin{bmatrix}a\\end{bmatrix}\\begin{matrix}b\\end{matrix}\\begin{Vmatrix}c\\end{Vmatrix}",
+	"\\begin{xmatrix}",
+	"[[1,2],[3,4]]",
+	"y'' + y' = f'(t)",
+	"x_1 + x_{12} + 5!",
+	"x\\space+\\ y\t\n\r\f z",
+	"x # y",
+	"x @ 2",
+	"\\unknown",
+	"caf\u00e9",
+}
+
+// lexerFragments are glued together at random to explore rule interactions
+var lexerFragments = []string{
+	"\\", "\\l", "\\le", "\\left", "\\left(", "\\left|", "\\right", "\\right)", "\\sin", "\\sinh",
+	"\\begin{", "pmatrix}", "matrix}", "\\end{", "{", "}", "(", ")", "[", "]", "|", "&",
+	"1", "23", ".", ".5", "4.", "e", "pi", "p", "i", "s", "sin", "sqrt", "ln", "log", "abs", "theta", "phi",
+	"=", "/", "=/=", "<", ">", "<>", "*", "**", "^", "-", "+", "\u2212", "_", ",", "!", "'", " ", "\t",
+	"\\space", "\\ ", "\\ne", "\\neq", "\\ge", "\\geq", "\\frac", "\\cdot", "\\pi", "\\theta",
+	"x", "y", "Z", "#", "\u00e9",
+}
+
+// collectTokens lexes input until EOF or the first error
+func collectTokens(next func() Token) []Token {
+	var tokens []Token
+	for {
+		token := next()
+		tokens = append(tokens, token)
+		if token.Type == TokenEOF || token.Type == TokenError {
+			return tokens
+		}
+	}
+}
+
+func assertSameTokens(t *testing.T, input string) {
+	t.Helper()
+	want := collectTokens(newLegacyLexer(input).NextToken)
+	got := collectTokens(NewLexer(input).NextToken)
+	if len(got) != len(want) {
+		t.Fatalf("lexing %q: got %v, want %v", input, got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("lexing %q: token %d = %+v, want %+v", input, i, got[i], want[i])
+		}
+	}
+}
+
+func TestLexerMatchesRegexRules(t *testing.T) {
+	for _, input := range lexerCorpus {
+		assertSameTokens(t, input)
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		var sb strings.Builder
+		for n := rng.Intn(12); n >= 0; n-- {
+			sb.WriteString(lexerFragments[rng.Intn(len(lexerFragments))])
+		}
+		assertSameTokens(t, sb.String())
+	}
+}
+
+func TestLexerPeek(t *testing.T) {
+	lexer := NewLexer("\\frac{x}")
+	peeked := lexer.Peek()
+	if next := lexer.NextToken(); next != peeked {
+		t.Errorf("Peek() = %+v, NextToken() = %+v", peeked, next)
+	}
+	if lexer.Position() != len("\\frac") {
+		t.Errorf("Position() = %d, want %d", lexer.Position(), len("\\frac"))
+	}
+}
+
+// benchmarkInput is a typical graded answer
+const benchmarkInput = "\\frac{-b + \\sqrt{b^{2} - 4ac}}{2a} + \\sin(\\theta)^{2} \\cdot 3.14x_{1} \\le 10"
+
+func BenchmarkLexer(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		lexer := NewLexer(benchmarkInput)
+		for lexer.NextToken().Type != TokenEOF {
+		}
+	}
+}
+
+func BenchmarkLegacyRegexLexer(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		lexer := newLegacyLexer(benchmarkInput)
+		for lexer.NextToken().Type != TokenEOF {
+		}
+	}
+}
+
+func BenchmarkParse(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := Parse(benchmarkInput); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// legacyRule represents a regex tokenization rule
+type legacyRule struct {
+	Pattern   *regexp.Regexp
+	TokenType TokenType
+	Transform func(string) string // Optional transformation function
+}
+
+// legacyLexer is the original regex-driven lexer, kept as the reference
+// the table-driven scanner must reproduce token for token
+type legacyLexer struct {
+	input string
+	pos   int
+	rules []legacyRule
+}
+
+// newLegacyLexer creates a regex lexer, compiling every rule
+func newLegacyLexer(input string) *legacyLexer {
+	lexer := &legacyLexer{
+		input: input,
+		pos:   0,
+	}
+	lexer.initRules()
+	return lexer
+}
+
+// initRules initializes the tokenization rules
+func (l *legacyLexer) initRules() {
+	l.rules = []legacyRule{
+		// Skip whitespace and LaTeX spacing
+		{regexp.MustCompile(`^\s+`), TokenEOF, nil}, // Will be skipped
+		{regexp.MustCompile(`^\\space`), TokenEOF, nil},
+		{regexp.MustCompile(`^\\ `), TokenEOF, nil},
+
+		// Numbers - float first to match decimals properly
+		{regexp.MustCompile(`^[0-9]+\.[0-9]*`), TokenFloat, nil}, // Handle "1." and "1.23"
+		{regexp.MustCompile(`^\.[0-9]+`), TokenFloat, nil},       // Handle ".5"
+		{regexp.MustCompile(`^[0-9]+`), TokenInt, nil},
+
+		// Operators
+		{regexp.MustCompile(`^\*\*`), TokenPower, nil},
+		{regexp.MustCompile(`^\*`), TokenMultiply, nil},
+		{regexp.MustCompile(`^\\cdot`), TokenMultiply, nil},
+		{regexp.MustCompile(`^\\times`), TokenMultiply, nil},
+		{regexp.MustCompile(`^\\ast`), TokenMultiply, nil},
+		{regexp.MustCompile(`^/`), TokenDivide, nil},
+		{regexp.MustCompile(`^\\div`), TokenDivide, nil},
+		{regexp.MustCompile(`^-`), TokenMinus, nil},
+		{regexp.MustCompile("^\u2212"), TokenMinus, nil}, // Unicode minus
+		{regexp.MustCompile(`^\+`), TokenPlus, nil},
+		{regexp.MustCompile(`^\^`), TokenPower, nil},
+
+		// Parentheses and brackets
+		{regexp.MustCompile(`^\(`), TokenLeftParen, nil},
+		{regexp.MustCompile(`^\)`), TokenRightParen, nil},
+		{regexp.MustCompile(`^\\left\(`), TokenLeftParen, nil},
+		{regexp.MustCompile(`^\\right\)`), TokenRightParen, nil},
+		{regexp.MustCompile(`^\[`), TokenLeftBracket, nil},
+		{regexp.MustCompile(`^\]`), TokenRightBracket, nil},
+		{regexp.MustCompile(`^\{`), TokenLeftBrace, nil},
+		{regexp.MustCompile(`^\}`), TokenRightBrace, nil},
+		{regexp.MustCompile(`^\\left\{`), TokenLeftBrace, nil},
+		{regexp.MustCompile(`^\\right\}`), TokenRightBrace, nil},
+		{regexp.MustCompile(`^\\left\\\{`), TokenLeftBrace, nil},
+		{regexp.MustCompile(`^\\right\\\}`), TokenRightBrace, nil},
+		{regexp.MustCompile(`^\\\{`), TokenLeftBrace, nil},
+		{regexp.MustCompile(`^\\\}`), TokenRightBrace, nil},
+		{regexp.MustCompile(`^\\left\|`), TokenLeftPipe, nil},
+		{regexp.MustCompile(`^\\right\|`), TokenRightPipe, nil},
+
+		// Matrix environments
+		{regexp.MustCompile(`^\\begin\{[pbBvV]?matrix\}`), TokenBeginMatrix, nil},
+		{regexp.MustCompile(`^\\end\{[pbBvV]?matrix\}`), TokenEndMatrix, nil},
+		{regexp.MustCompile(`^&`), TokenAmpersand, nil},
+		{regexp.MustCompile(`^\\\\`), TokenRowSeparator, nil},
+
+		// Comparison operators
+		{regexp.MustCompile(`^<=`), TokenLessEqual, nil},
+		{regexp.MustCompile(`^>=`), TokenGreaterEqual, nil},
+		{regexp.MustCompile(`^<>`), TokenNotEqual, nil},
+		{regexp.MustCompile(`^<`), TokenLess, nil},
+		{regexp.MustCompile(`^>`), TokenGreater, nil},
+		{regexp.MustCompile(`^=`), TokenEquals, nil},
+		{regexp.MustCompile(`^\\le`), TokenLessEqual, func(s string) string { return "<=" }},
+		{regexp.MustCompile(`^\\ge`), TokenGreaterEqual, func(s string) string { return ">=" }},
+		{regexp.MustCompile(`^\\leq`), TokenLessEqual, func(s string) string { return "<=" }},
+		{regexp.MustCompile(`^\\geq`), TokenGreaterEqual, func(s string) string { return ">=" }},
+		{regexp.MustCompile(`^=/=`), TokenNotEqual, func(s string) string { return "<>" }},
+		{regexp.MustCompile(`^\\ne`), TokenNotEqual, func(s string) string { return "<>" }},
+
+		// Functions and special symbols
+		{regexp.MustCompile(`^\\sqrt`), TokenSqrt, nil},
+		{regexp.MustCompile(`^\\frac`), TokenFrac, nil},
+		{regexp.MustCompile(`^\\dfrac`), TokenDfrac, nil},
+		{regexp.MustCompile(`^\\ln`), TokenLn, nil},
+		{regexp.MustCompile(`^\\log`), TokenLog, nil},
+
+		// Inverse, hyperbolic and trigonometric functions, longest first
+		{regexp.MustCompile(`^\\arcsinh`), TokenArcsinh, nil},
+		{regexp.MustCompile(`^\\arccosh`), TokenArccosh, nil},
+		{regexp.MustCompile(`^\\arctanh`), TokenArctanh, nil},
+		{regexp.MustCompile(`^\\arcsin`), TokenArcsin, nil},
+		{regexp.MustCompile(`^\\arccos`), TokenArccos, nil},
+		{regexp.MustCompile(`^\\arctan`), TokenArctan, nil},
+		{regexp.MustCompile(`^\\arcsec`), TokenArcsec, nil},
+		{regexp.MustCompile(`^\\arccsc`), TokenArccsc, nil},
+		{regexp.MustCompile(`^\\arccot`), TokenArccot, nil},
+		{regexp.MustCompile(`^\\sinh`), TokenSinh, nil},
+		{regexp.MustCompile(`^\\cosh`), TokenCosh, nil},
+		{regexp.MustCompile(`^\\tanh`), TokenTanh, nil},
+		{regexp.MustCompile(`^\\sin`), TokenSin, nil},
+		{regexp.MustCompile(`^\\cos`), TokenCos, nil},
+		{regexp.MustCompile(`^\\tan`), TokenTan, nil},
+		{regexp.MustCompile(`^\\sec`), TokenSec, nil},
+		{regexp.MustCompile(`^\\csc`), TokenCsc, nil},
+		{regexp.MustCompile(`^\\cot`), TokenCot, nil},
+
+		// Constants (must be before single char variables)
+		{regexp.MustCompile(`^pi`), TokenPi, func(s string) string { return "pi" }},
+		{regexp.MustCompile(`^\\pi`), TokenPi, func(s string) string { return "pi" }},
+		{regexp.MustCompile(`^\\theta`), TokenVar, func(s string) string { return "theta" }},
+		{regexp.MustCompile(`^\\phi`), TokenVar, func(s string) string { return "phi" }},
+
+		// Functions (must be before single char variables)
+		{regexp.MustCompile(`^sqrt`), TokenSqrt, nil},
+		{regexp.MustCompile(`^abs`), TokenAbs, nil},
+		{regexp.MustCompile(`^ln`), TokenLn, nil},
+		{regexp.MustCompile(`^log`), TokenLog, nil},
+		{regexp.MustCompile(`^arcsinh`), TokenArcsinh, nil},
+		{regexp.MustCompile(`^arccosh`), TokenArccosh, nil},
+		{regexp.MustCompile(`^arctanh`), TokenArctanh, nil},
+		{regexp.MustCompile(`^arcsin`), TokenArcsin, nil},
+		{regexp.MustCompile(`^arccos`), TokenArccos, nil},
+		{regexp.MustCompile(`^arctan`), TokenArctan, nil},
+		{regexp.MustCompile(`^arcsec`), TokenArcsec, nil},
+		{regexp.MustCompile(`^arccsc`), TokenArccsc, nil},
+		{regexp.MustCompile(`^arccot`), TokenArccot, nil},
+		{regexp.MustCompile(`^sinh`), TokenSinh, nil},
+		{regexp.MustCompile(`^cosh`), TokenCosh, nil},
+		{regexp.MustCompile(`^tanh`), TokenTanh, nil},
+		{regexp.MustCompile(`^sin`), TokenSin, nil},
+		{regexp.MustCompile(`^cos`), TokenCos, nil},
+		{regexp.MustCompile(`^tan`), TokenTan, nil},
+		{regexp.MustCompile(`^sec`), TokenSec, nil},
+		{regexp.MustCompile(`^csc`), TokenCsc, nil},
+		{regexp.MustCompile(`^cot`), TokenCot, nil},
+
+		// Known multi-character variables and constants (must be before single char variables)
+		{regexp.MustCompile(`^theta`), TokenVar, func(s string) string { return "theta" }},
+		{regexp.MustCompile(`^alpha`), TokenVar, func(s string) string { return "alpha" }},
+		{regexp.MustCompile(`^beta`), TokenVar, func(s string) string { return "beta" }},
+		{regexp.MustCompile(`^gamma`), TokenVar, func(s string) string { return "gamma" }},
+		{regexp.MustCompile(`^delta`), TokenVar, func(s string) string { return "delta" }},
+		{regexp.MustCompile(`^epsilon`), TokenVar, func(s string) string { return "epsilon" }},
+		{regexp.MustCompile(`^phi`), TokenVar, func(s string) string { return "phi" }},
+		{regexp.MustCompile(`^psi`), TokenVar, func(s string) string { return "psi" }},
+		{regexp.MustCompile(`^omega`), TokenVar, func(s string) string { return "omega" }},
+
+		// Other symbols
+		{regexp.MustCompile(`^_`), TokenSubscript, nil},
+		{regexp.MustCompile(`^\|`), TokenPipe, nil},
+		{regexp.MustCompile(`^,`), TokenComma, nil},
+		{regexp.MustCompile(`^!`), TokenExclamation, nil},
+		{regexp.MustCompile(`^'`), TokenPrime, nil},
+
+		// Single character variables (everything else should be parsed as individual chars for implicit multiplication)
+		{regexp.MustCompile(`^[a-zA-Z]`), TokenVar, nil},
+	}
+}
+
+// NextToken returns the next token from the input
+func (l *legacyLexer) NextToken() Token {
+	for l.pos < len(l.input) {
+		// Skip whitespace and LaTeX spacing
+		if match := regexp.MustCompile(`^\s+`).FindString(l.input[l.pos:]); match != "" {
+			l.pos += len(match)
+			continue
+		}
+		if match := regexp.MustCompile(`^\\space`).FindString(l.input[l.pos:]); match != "" {
+			l.pos += len(match)
+			continue
+		}
+		if match := regexp.MustCompile(`^\\ `).FindString(l.input[l.pos:]); match != "" {
+			l.pos += len(match)
+			continue
+		}
+
+		// Try to match each rule
+		for _, rule := range l.rules {
+			if match := rule.Pattern.FindString(l.input[l.pos:]); match != "" {
+				token := Token{
+					Type:  rule.TokenType,
+					Value: match,
+					Pos:   l.pos,
+				}
+				if rule.Transform != nil {
+					token.Value = rule.Transform(match)
+				}
+				l.pos += len(match)
+				token.End = l.pos
+
+				// Handle special variable cases
+				switch token.Value {
+				case "pi":
+					token.Type = TokenPi
+				case "e":
+					token.Type = TokenE
+				case "ln":
+					token.Type = TokenLn
+				case "log":
+					token.Type = TokenLog
+				case "sin":
+					token.Type = TokenSin
+				case "cos":
+					token.Type = TokenCos
+				case "tan":
+					token.Type = TokenTan
+				case "sqrt":
+					token.Type = TokenSqrt
+				case "abs":
+					token.Type = TokenAbs
+				}
+
+				return token
+			}
+		}
+
+		// If no rule matched, it's an invalid character
+		char := string(l.input[l.pos])
+		_, size := utf8.DecodeRuneInString(l.input[l.pos:])
+		return Token{
+			Type:  TokenError,
+			Value: char,
+			Pos:   l.pos,
+			End:   l.pos + size,
+		}
+	}
+
+	return Token{Type: TokenEOF, Pos: l.pos, End: l.pos}
+}
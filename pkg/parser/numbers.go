@@ -0,0 +1,489 @@
+package parser
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/quizizz/cas/pkg/ast"
+)
+
+// maxScientificExponent bounds the power of ten read as an exact number;
+// larger powers are left as products such as 2*10^5000
+const maxScientificExponent = 1000
+
+// parseNumberForm reads the rest of a number written in one of the forms
+// the options enable: a repeating decimal such as 0.\overline{3},
+// scientific notation such as 6.02e23 or 3.2 \times 10^{4}, or a mixed
+// number such as 2 1/2. Otherwise value, the literal just parsed from
+// token, is returned as it is.
+func (p *Parser) parseNumberForm(value ast.Expr, token Token) (ast.Expr, error) {
+	if err := p.checkGrouping(token); err != nil {
+		return nil, err
+	}
+
+	if p.options.RepeatingDecimals && token.Type == TokenFloat {
+		r, ok, err := p.tryParseRepetend(token)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return ast.NewNumber(r), nil
+		}
+	}
+
+	if p.options.ScientificNotation {
+		if exponent, ok := p.tryParseExponent(token); ok {
+			mantissa, _ := new(big.Rat).SetString(token.Value)
+			return ast.NewNumber(mantissa.Mul(mantissa, ast.PowerOfTen(exponent))), nil
+		}
+	}
+
+	if p.options.MixedNumbers && token.Type == TokenInt {
+		fraction, ok, err := p.tryParseProperFraction(token)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			whole, _ := new(big.Rat).SetString(token.Value)
+			return ast.NewNumber(whole.Add(whole, fraction)), nil
+		}
+	}
+
+	return value, nil
+}
+
+// checkGrouping rejects a number followed by a thousands separator and
+// digits that do not make a group of three, as in 12.34 with a decimal
+// comma, which would otherwise be read as 12 and an invalid character.
+// Separators that also separate numbers, the comma of 1,23 and the space
+// of 1 23, are only checked after a whole number that is itself grouped,
+// so that 1,000,00 is an error.
+func (p *Parser) checkGrouping(number Token) error {
+	separator := p.options.Locale.Thousands
+	if separator == 0 {
+		return nil
+	}
+
+	sep := string(separator)
+	input := p.lexer.input
+	if listSeparator(separator) && (number.Type != TokenInt || !strings.Contains(input[number.Pos:number.End], sep)) {
+		return nil
+	}
+	end := number.End
+	for strings.HasPrefix(input[end:], sep) && matchDigits(input[end+len(sep):]) > 0 {
+		end += len(sep) + matchDigits(input[end+len(sep):])
+	}
+	if end == number.End {
+		return nil
+	}
+
+	text := input[number.Pos:end]
+	return &ParseError{
+		Code:       ErrInvalidNumber,
+		Message:    fmt.Sprintf("invalid digit grouping in %s at position %d", text, number.Pos),
+		Start:      number.Pos,
+		End:        end,
+		Suggestion: fmt.Sprintf("group the digits of the whole part in threes, as in 1%s234", sep),
+	}
+}
+
+// tryParseExponent reads the power of ten after a number in scientific
+// notation, restoring the parser state when there is none
+func (p *Parser) tryParseExponent(number Token) (int, bool) {
+	savedPos, savedCurrent, savedEnd := p.lexer.Position(), p.current, p.end
+
+	if exponent, ok := p.matchExponent(number); ok {
+		return exponent, true
+	}
+
+	p.lexer.SetPosition(savedPos)
+	p.current, p.end = savedCurrent, savedEnd
+	return 0, false
+}
+
+// matchExponent matches e23 or E-3 written against the number, or a
+// product with a power of ten such as \times 10^{-4}, \cdot 10^3 or ×10⁴
+func (p *Parser) matchExponent(number Token) (int, bool) {
+	if p.current.Type == TokenE || (p.current.Type == TokenVar && p.current.Value == "E") {
+		end := p.current.End
+		if p.current.Pos != number.End {
+			return 0, false
+		}
+		p.advance()
+
+		negative := false
+		if (p.current.Type == TokenMinus || p.current.Type == TokenPlus) && p.current.Pos == end {
+			negative = p.current.Type == TokenMinus
+			end = p.current.End
+			p.advance()
+		}
+		if p.current.Type != TokenInt || p.current.Pos != end {
+			return 0, false
+		}
+		return p.matchExponentDigits(negative)
+	}
+
+	if p.current.Type != TokenMultiply {
+		return 0, false
+	}
+	p.advance()
+	if p.current.Type != TokenInt || p.current.Value != "10" {
+		return 0, false
+	}
+	p.advance()
+
+	if p.current.Type == TokenSuperscript {
+		exponent, err := strconv.Atoi(p.current.Value)
+		if err != nil || exponent > maxScientificExponent || exponent < -maxScientificExponent {
+			return 0, false
+		}
+		p.advance()
+		return exponent, true
+	}
+
+	if p.current.Type != TokenPower {
+		return 0, false
+	}
+	p.advance()
+
+	braced := p.current.Type == TokenLeftBrace
+	if braced {
+		p.advance()
+	}
+	negative := p.current.Type == TokenMinus
+	if negative {
+		p.advance()
+	}
+	if p.current.Type != TokenInt {
+		return 0, false
+	}
+	exponent, ok := p.matchExponentDigits(negative)
+	if !ok {
+		return 0, false
+	}
+	if braced {
+		if p.current.Type != TokenRightBrace {
+			return 0, false
+		}
+		p.advance()
+	}
+	return exponent, true
+}
+
+// matchExponentDigits consumes the integer of an exponent
+func (p *Parser) matchExponentDigits(negative bool) (int, bool) {
+	exponent, err := strconv.Atoi(p.current.Value)
+	if err != nil || exponent > maxScientificExponent {
+		return 0, false
+	}
+	p.advance()
+	if negative {
+		exponent = -exponent
+	}
+	return exponent, true
+}
+
+// tryParseProperFraction reads the fraction of a mixed number after its
+// whole part, restoring the parser state when there is none. The fraction
+// is written 1/2, \frac{1}{2} or ½, and must be proper: 2 3/2 is an error
+// rather than a product no one means to write.
+func (p *Parser) tryParseProperFraction(whole Token) (*big.Rat, bool, error) {
+	savedPos, savedCurrent, savedEnd := p.lexer.Position(), p.current, p.end
+
+	if numerator, denominator, ok := p.matchFraction(whole); ok {
+		if numerator.Sign() > 0 && numerator.Cmp(denominator) < 0 {
+			return new(big.Rat).SetFrac(numerator, denominator), true, nil
+		}
+		text := p.lexer.input[whole.Pos:p.end]
+		return nil, false, &ParseError{
+			Code:       ErrInvalidNumber,
+			Message:    fmt.Sprintf("improper mixed number %s at position %d", text, whole.Pos),
+			Start:      whole.Pos,
+			End:        p.end,
+			Suggestion: "write the fraction of a mixed number as less than one, as in 2 1/2",
+		}
+	}
+
+	p.lexer.SetPosition(savedPos)
+	p.current, p.end = savedCurrent, savedEnd
+	return nil, false, nil
+}
+
+// matchFraction matches the integer numerator and denominator of a
+// fraction
+func (p *Parser) matchFraction(whole Token) (*big.Int, *big.Int, bool) {
+	var numerator, denominator string
+
+	switch p.current.Type {
+	case TokenVulgarFraction:
+		r, _ := new(big.Rat).SetString(p.current.Value)
+		p.advance()
+		return r.Num(), r.Denom(), true
+	case TokenInt:
+		// 2 1/2 needs the space that tells it from 21/2
+		if p.current.Pos == whole.End {
+			return nil, nil, false
+		}
+		numerator = p.current.Value
+		p.advance()
+		if p.current.Type != TokenDivide {
+			return nil, nil, false
+		}
+		p.advance()
+		if p.current.Type != TokenInt {
+			return nil, nil, false
+		}
+		denominator = p.current.Value
+		p.advance()
+	case TokenFrac, TokenDfrac:
+		p.advance()
+		for _, part := range []*string{&numerator, &denominator} {
+			if p.current.Type != TokenLeftBrace {
+				return nil, nil, false
+			}
+			p.advance()
+			if p.current.Type != TokenInt {
+				return nil, nil, false
+			}
+			*part = p.current.Value
+			p.advance()
+			if p.current.Type != TokenRightBrace {
+				return nil, nil, false
+			}
+			p.advance()
+		}
+	default:
+		return nil, nil, false
+	}
+
+	num, _ := new(big.Int).SetString(numerator, 10)
+	den, _ := new(big.Int).SetString(denominator, 10)
+	return num, den, true
+}
+
+// tryParseRepetend reads the repeating block after a decimal, restoring
+// the parser state when there is none, and returns the exact value of the
+// repeating decimal
+func (p *Parser) tryParseRepetend(decimal Token) (*big.Rat, bool, error) {
+	savedPos, savedCurrent, savedEnd := p.lexer.Position(), p.current, p.end
+
+	repetend, ok, err := p.matchRepetend(decimal)
+	if err != nil {
+		return nil, false, err
+	}
+	if ok {
+		return repeatingDecimal(decimal.Value, repetend), true, nil
+	}
+
+	p.lexer.SetPosition(savedPos)
+	p.current, p.end = savedCurrent, savedEnd
+	return nil, false, nil
+}
+
+// matchRepetend matches the digits of \overline{3} or of (3) written
+// against the decimal, as in 0.1(6); with a space, 0.5 (3) stays a product.
+// An \overline without digits, as in 0.\overline{}, is an error.
+func (p *Parser) matchRepetend(decimal Token) (string, bool, error) {
+	var closing TokenType
+	switch p.current.Type {
+	case TokenOverline:
+		overline := p.current
+		p.advance()
+		if p.current.Type != TokenLeftBrace {
+			return "", false, nil
+		}
+		if p.peek().Type != TokenInt {
+			p.advance()
+			err := errorAt(overline, ErrInvalidNumber, "the repeating block at position %d has no digits", overline.Pos)
+			err.End = p.current.End
+			err.Suggestion = "write the repeating digits inside \\overline{}"
+			return "", false, err
+		}
+		closing = TokenRightBrace
+	case TokenLeftParen:
+		if p.current.Pos != decimal.End {
+			return "", false, nil
+		}
+		closing = TokenRightParen
+	default:
+		return "", false, nil
+	}
+	p.advance()
+
+	// The digits are read as written, keeping the leading zeros of (03)
+	if p.current.Type != TokenInt {
+		return "", false, nil
+	}
+	digits := p.current.Value
+	p.advance()
+	if p.current.Type != closing {
+		return "", false, nil
+	}
+	end := p.current.End
+	p.advance()
+
+	// The block repeats forever, so no digits can follow it
+	if (p.current.Type == TokenInt || p.current.Type == TokenFloat) && (closing == TokenRightBrace || p.current.Pos == end) {
+		err := errorAt(p.current, ErrInvalidNumber, "digits %s after the repeating block at position %d", p.current.Value, p.current.Pos)
+		err.Start = decimal.Pos
+		err.Suggestion = "write the repeating block last, as in 0.1\\overline{6}"
+		return "", false, err
+	}
+	return digits, true, nil
+}
+
+// repeatingDecimal returns the value of decimal, such as 0.1, followed by
+// repetend repeated forever: 0.1 + 6/90 for 0.1(6)
+func repeatingDecimal(decimal, repetend string) *big.Rat {
+	value, _ := new(big.Rat).SetString(decimal)
+	places := 0
+	if point := strings.IndexByte(decimal, '.'); point >= 0 {
+		places = len(decimal) - point - 1
+	}
+
+	// The block is worth repetend / (10^k - 1), shifted past the places
+	digits, _ := new(big.Rat).SetString(repetend)
+	block := ast.PowerOfTen(len(repetend))
+	block.Sub(block, big.NewRat(1, 1))
+	digits.Quo(digits, block)
+	digits.Quo(digits, ast.PowerOfTen(places))
+	return value.Add(value, digits)
+}
+
+// percentOf divides an operand followed by a percent sign by 100, exactly
+// when the operand is a number
+func percentOf(operand ast.Expr) ast.Expr {
+	hundredth := big.NewRat(1, 100)
+	switch n := operand.(type) {
+	case *ast.Int:
+		return ast.NewNumber(new(big.Rat).Mul(new(big.Rat).SetInt(n.IntValue()), hundredth))
+	case *ast.Rational:
+		return ast.NewNumber(new(big.Rat).Mul(new(big.Rat).SetFrac(n.Numerator(), n.Denominator()), hundredth))
+	case *ast.Float:
+		if r, ok := new(big.Rat).SetString(n.Value().Text('g', -1)); ok {
+			return ast.NewNumber(r.Mul(r, hundredth))
+		}
+	}
+	return ast.NewMul(operand, ast.NewRational(1, 100))
+}
+
+// Locale gives the separators a locale writes numbers with
+type Locale struct {
+	// Decimal separates the whole part of a number from its fraction, '.'
+	// or ','; zero means '.'
+	Decimal rune
+	// Thousands groups the digits of the whole part in threes, as in
+	// 1,234,567; zero means the digits are not grouped
+	Thousands rune
+}
+
+// Locales in common use
+var (
+	// LocaleUS writes 1,234.5
+	LocaleUS = Locale{Decimal: '.', Thousands: ','}
+	// LocaleEuropean writes 1.234,5
+	LocaleEuropean = Locale{Decimal: ',', Thousands: '.'}
+	// LocaleSI writes 1 234,5, grouping with a space
+	LocaleSI = Locale{Decimal: ',', Thousands: ' '}
+)
+
+// decimal returns the decimal separator of the locale
+func (l Locale) decimal() rune {
+	if l.Decimal == 0 {
+		return '.'
+	}
+	return l.Decimal
+}
+
+// listSeparator reports whether a thousands separator also separates the
+// elements of a list, as the comma of 1,234 and the space of 1 234 do
+func listSeparator(separator rune) bool {
+	return separator == ',' || unicode.IsSpace(separator)
+}
+
+// ungroup splits a number grouped with a comma inside parentheses or
+// brackets, where the comma separates arguments and elements: f(1,234)
+// has two arguments and (1,234) is a pair. The token is cut at its first
+// separator and the lexer resumes there.
+func (p *Parser) ungroup() {
+	if p.current.Type != TokenInt && p.current.Type != TokenFloat || p.options.Locale.Thousands != ',' {
+		return
+	}
+	text := p.lexer.input[p.current.Pos:p.current.End]
+	cut := strings.IndexByte(text, ',')
+	if cut < 0 || !enclosed(p.lexer.input[:p.current.Pos]) {
+		return
+	}
+	p.current = Token{Type: TokenInt, Value: text[:cut], Pos: p.current.Pos, End: p.current.Pos + cut}
+	p.lexer.SetPosition(p.current.End)
+}
+
+// enclosed reports whether the end of input lies inside parentheses or
+// brackets
+func enclosed(input string) bool {
+	depth := 0
+	for _, r := range input {
+		switch r {
+		case '(', '[':
+			depth++
+		case ')', ']':
+			depth--
+		}
+	}
+	return depth > 0
+}
+
+// matchNumber returns a matcher for the numbers of the locale: integers
+// when float is false, and numbers with a fraction when it is set.
+// Thousands are only grouped in threes, so 1,234 is one number where 1,23
+// and 1, 234 are two. A decimal comma must be followed by a digit, so that
+// f(1, 2) still has two arguments, except before a repeating block, as in
+// 0,(3) or 0,\overline{3}.
+func (l Locale) matchNumber(float bool) func(s string) int {
+	decimal := string(l.decimal())
+	thousands := ""
+	if l.Thousands != 0 {
+		thousands = string(l.Thousands)
+	}
+
+	return func(s string) int {
+		n := matchDigits(s)
+		if n == 0 {
+			return 0
+		}
+		if thousands != "" && n <= 3 {
+			for strings.HasPrefix(s[n:], thousands) {
+				group := s[n+len(thousands):]
+				if matchDigits(group) != 3 {
+					break
+				}
+				n += len(thousands) + 3
+			}
+		}
+		if !float {
+			return n
+		}
+
+		if !strings.HasPrefix(s[n:], decimal) {
+			return 0
+		}
+		n += len(decimal)
+		fraction := matchDigits(s[n:])
+		if fraction == 0 && decimal == "," && !strings.HasPrefix(s[n:], "(") && !strings.HasPrefix(s[n:], "\\overline") {
+			return 0
+		}
+		return n + fraction
+	}
+}
+
+// normalize rewrites a number of the locale with a decimal point and no
+// thousands separators, as the number parsers read it
+func (l Locale) normalize(s string) string {
+	if l.Thousands != 0 {
+		s = strings.ReplaceAll(s, string(l.Thousands), "")
+	}
+	return strings.Replace(s, string(l.decimal()), ".", 1)
+}
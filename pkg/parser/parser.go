@@ -2,44 +2,166 @@ package parser
 
 import (
 	"fmt"
+	"math/big"
 	"strconv"
+	"strings"
 
 	"github.com/quizizz/cas/pkg/ast"
 )
 
+// Options controls how literals are parsed
+type Options struct {
+	// ExactDecimals parses decimal literals as exact rationals (0.125 -> 1/8)
+	// instead of floating-point numbers
+	ExactDecimals bool
+	// Recover keeps parsing after a syntax error so that one call reports
+	// every error it finds, returned together as an ErrorList
+	Recover bool
+
+	// Lenient drops the quirks kept for KAS compatibility: a unary + is
+	// accepted and -0 parses as 0 rather than -1*0
+	Lenient bool
+	// UnaryPlus accepts a unary +, as in +5 or 2*+3, while keeping the
+	// other KAS quirks
+	UnaryPlus bool
+	// MultiLetterVariables reads a run of letters as one variable, so xy is
+	// the variable xy rather than x*y. Function names and constants are
+	// still recognised, and d followed by a single letter is still a
+	// differential.
+	MultiLetterVariables bool
+	// Variables lists multi-letter variable names, such as rate or mass,
+	// that are read as one variable even when letters are otherwise split
+	Variables []string
+	// Functions lists the names that are function calls when followed by
+	// parentheses, such as f or gcd. When set, any other variable followed
+	// by parentheses multiplies, as in a(b+c); when nil, every variable
+	// followed by parentheses is a call, as KAS reads it.
+	Functions []string
+	// VariableE reads e as an ordinary variable instead of Euler's number
+	VariableE bool
+
+	// MixedNumbers reads a whole number followed by a proper fraction, as in
+	// 2 1/2, 2\frac{1}{2} or 2½, as one number (5/2)
+	MixedNumbers bool
+	// Percent reads a trailing % or \% as hundredths, so 35% is 7/20
+	Percent bool
+	// ScientificNotation reads 3.2 \times 10^{4} and 6.02e23 as one exact
+	// number rather than a product
+	ScientificNotation bool
+	// RepeatingDecimals reads a repeating block after the decimal point,
+	// written with an overline as in 0.1\overline{6} or in parentheses as
+	// in 0.1(6), as an exact fraction (1/6)
+	RepeatingDecimals bool
+	// Locale gives the decimal and thousands separators numbers are written
+	// with. The zero Locale reads a decimal point and no thousands
+	// separators. Inside parentheses and brackets a comma separates
+	// arguments and elements rather than grouping digits.
+	Locale Locale
+	// Units reads units of measure after a value, as in 9.8 m/s^2,
+	// 5\,\mathrm{km} or 5 \text{ km}, giving an ast.Quantity. A unit
+	// symbol with no value before it is still read as variables.
+	Units bool
+}
+
+// DefaultOptions returns the default parser options
+func DefaultOptions() Options {
+	return Options{
+		ExactDecimals: false,
+		Recover:       false,
+	}
+}
+
 // Parser implements a recursive descent parser for mathematical expressions
 type Parser struct {
 	lexer   *Lexer
 	current Token
+	options Options
+	// end is the offset just past the last consumed token
+	end int
+	// asciiMath is set when parsing AsciiMath rather than LaTeX input
+	asciiMath bool
+	// integrals counts the integrals whose body is being parsed, where a
+	// differential such as dx ends the body instead of multiplying it
+	integrals int
+	// pipes counts the bars opened by |x| that are not yet closed, where
+	// a bar ends the absolute value instead of starting a factor
+	pipes int
+	// errs collects the errors found in recovery mode
+	errs ErrorList
 }
 
 // New creates a new parser instance
-func New(input string) *Parser {
-	lexer := NewLexer(input)
+func New(input string, opts ...Options) *Parser {
+	return newParser(NewLexer(input), opts...)
+}
+
+// newParser creates a parser reading tokens from lexer
+func newParser(lexer *Lexer, opts ...Options) *Parser {
+	options := DefaultOptions()
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+
+	if options.MultiLetterVariables || len(options.Variables) > 0 || len(options.Functions) > 0 ||
+		options.Percent || options.RepeatingDecimals || options.Locale != (Locale{}) || options.Units {
+		lexer.configure(options)
+	}
+
 	parser := &Parser{
-		lexer: lexer,
+		lexer:   lexer,
+		options: options,
 	}
 	parser.advance()
 	return parser
 }
 
-// Parse parses the input expression and returns an AST node
-func Parse(input string) (ast.Expr, error) {
-	parser := New(input)
-	// Check for lexical errors first
-	if parser.current.Type == TokenError {
-		return nil, fmt.Errorf("invalid character '%s' at position %d", parser.current.Value, parser.current.Pos)
+// Parse parses the input expression and returns an AST node. Every node
+// records the span of input it was parsed from; see ast.SpanOf. Syntax
+// errors are returned as a *ParseError, or as an ErrorList in recovery mode.
+func Parse(input string, opts ...Options) (ast.Expr, error) {
+	return New(input, opts...).parse()
+}
+
+// ParseWith parses the input expression in the dialect the options
+// describe, such as strict KAS compatibility or lenient input with
+// multi-letter variables
+func ParseWith(input string, options Options) (ast.Expr, error) {
+	return New(input, options).parse()
+}
+
+// parse parses the whole input and records the spans of the result
+func (p *Parser) parse() (ast.Expr, error) {
+	var expr ast.Expr
+	var err error
+	if p.options.Recover {
+		expr, err = p.parseRecovering()
+	} else if p.current.Type == TokenError {
+		// Check for lexical errors first
+		return nil, p.invalidCharacter()
+	} else {
+		expr, err = p.parseList()
+		if err == nil && p.current.Type != TokenEOF {
+			err = p.trailing(true)
+		}
+	}
+	if err != nil {
+		return nil, err
 	}
-	return parser.parseExpression()
+
+	span, _ := ast.SpanOf(expr)
+	fillSpans(expr, span)
+	return expr, nil
 }
 
 // advance moves to the next token
 func (p *Parser) advance() {
+	p.end = p.current.End
 	p.current = p.lexer.NextToken()
 	// Check for lexical errors during parsing
 	if p.current.Type == TokenError {
 		return
 	}
+	p.ungroup()
 }
 
 // peek returns the next token without advancing
@@ -49,82 +171,122 @@ func (p *Parser) peek() Token {
 
 // expect consumes a token of the given type or returns an error
 func (p *Parser) expect(tokenType TokenType) error {
-	if p.current.Type == TokenError {
-		return fmt.Errorf("invalid character '%s' at position %d", p.current.Value, p.current.Pos)
-	}
 	if p.current.Type != tokenType {
-		return fmt.Errorf("expected %s, got %s at position %d", tokenType, p.current.Type, p.current.Pos)
+		return p.unexpected(tokenType)
 	}
 	p.advance()
 	return nil
 }
 
+// parseList parses a top-level answer. Comma-separated answers such as
+// x = 2, x = -1 form an unordered set.
+func (p *Parser) parseList() (ast.Expr, error) {
+	start := p.current.Pos
+	elements, err := p.parseElements()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(elements) > 1 {
+		return p.mark(ast.NewSet(elements...), start), nil
+	}
+	return elements[0], nil
+}
+
 // parseExpression parses a full expression (handles equations)
 func (p *Parser) parseExpression() (ast.Expr, error) {
 	// Check for lexical errors
 	if p.current.Type == TokenError {
-		return nil, fmt.Errorf("invalid character '%s' at position %d", p.current.Value, p.current.Pos)
+		return nil, p.invalidCharacter()
 	}
 
+	start := p.current.Pos
 	left, err := p.parseArithmeticExpression()
 	if err != nil {
 		return nil, err
 	}
 
-	// Check for comparison operators (equations/inequalities)
-	switch p.current.Type {
-	case TokenEquals, TokenLess, TokenGreater, TokenLessEqual, TokenGreaterEqual, TokenNotEqual:
-		operator := p.current.Type
+	// Check for comparison operators (equations/inequalities). Several in a
+	// row, as in -2 < 3x + 1 <= 7, form a chained relation.
+	operands := []ast.Expr{left}
+	var relations []ast.EqType
+	for {
+		eqType, ok := relationType(p.current.Type)
+		if !ok {
+			break
+		}
 		p.advance()
 		right, err := p.parseArithmeticExpression()
 		if err != nil {
 			return nil, err
 		}
+		operands = append(operands, right)
+		relations = append(relations, eqType)
+	}
 
-		// Map token types to equation types
-		var eqType ast.EqType
-		switch operator {
-		case TokenEquals:
-			eqType = ast.EqEqual
-		case TokenLess:
-			eqType = ast.EqLess
-		case TokenGreater:
-			eqType = ast.EqGreater
-		case TokenLessEqual:
-			eqType = ast.EqLessEqual
-		case TokenGreaterEqual:
-			eqType = ast.EqGreaterEqual
-		case TokenNotEqual:
-			eqType = ast.EqNotEqual
+	switch len(relations) {
+	case 0:
+		return left, nil
+	case 1:
+		return p.mark(ast.NewEq(operands[0], operands[1], relations[0]), start), nil
+	default:
+		chain, err := ast.NewChain(operands, relations)
+		if err != nil {
+			return nil, p.errorSpan(start, ErrInvalidStructure, err)
 		}
-
-		return ast.NewEq(left, right, eqType), nil
+		return p.mark(chain, start), nil
 	}
+}
 
-	return left, nil
+// relationType maps a comparison token to its equation type
+func relationType(tokenType TokenType) (ast.EqType, bool) {
+	switch tokenType {
+	case TokenEquals:
+		return ast.EqEqual, true
+	case TokenLess:
+		return ast.EqLess, true
+	case TokenGreater:
+		return ast.EqGreater, true
+	case TokenLessEqual:
+		return ast.EqLessEqual, true
+	case TokenGreaterEqual:
+		return ast.EqGreaterEqual, true
+	case TokenNotEqual:
+		return ast.EqNotEqual, true
+	default:
+		return 0, false
+	}
 }
 
 // parseArithmeticExpression parses addition and subtraction (lowest precedence)
 func (p *Parser) parseArithmeticExpression() (ast.Expr, error) {
+	start := p.current.Pos
 	left, err := p.parseMultiplicativeExpression()
 	if err != nil {
 		return nil, err
 	}
 
-	for p.current.Type == TokenPlus || p.current.Type == TokenMinus {
-		op := p.current.Type
+	for p.current.Type == TokenPlus || p.current.Type == TokenMinus || p.current.Type == TokenPlusMinus {
+		op := p.current
 		p.advance()
 		right, err := p.parseMultiplicativeExpression()
 		if err != nil {
-			return nil, err
+			// Resume at the next term, as in 1 + @ + 2
+			if !p.recover(err, isTermBoundary) {
+				return nil, err
+			}
+			right = placeholder()
 		}
 
-		if op == TokenPlus {
-			left = ast.NewAdd(left, right)
-		} else {
+		switch op.Type {
+		case TokenPlus:
+			left = p.mark(ast.NewAdd(left, right), start)
+		case TokenPlusMinus:
+			left = p.mark(ast.NewPlusMinus(left, right), start)
+		default:
 			// Handle subtraction as addition of negative
-			negatedRight := ast.NewMul(ast.NewInt(-1), right)
-			left = ast.NewAdd(left, negatedRight)
+			negatedRight := ast.NewMul(markToken(ast.NewInt(-1), op), right)
+			left = p.mark(ast.NewAdd(left, p.mark(negatedRight, op.Pos)), start)
 		}
 	}
 
@@ -133,32 +295,39 @@ func (p *Parser) parseArithmeticExpression() (ast.Expr, error) {
 
 // parseMultiplicativeExpression parses multiplication and division
 func (p *Parser) parseMultiplicativeExpression() (ast.Expr, error) {
+	start := p.current.Pos
 	left, err := p.parseUnaryExpression()
 	if err != nil {
 		return nil, err
 	}
 
-	for p.current.Type == TokenMultiply || p.current.Type == TokenDivide || p.isImplicitMultiplication() {
-		var op TokenType
+	for p.current.Type == TokenMultiply || p.current.Type == TokenDivide || p.isImplicitMultiplication() || p.current.Type == TokenUnit {
+		// A unit after a value measures it, as in 9.8 m/s^2
+		if p.current.Type == TokenUnit {
+			if left, err = p.parseQuantity(left, start); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		op := Token{Type: TokenMultiply, Pos: p.current.Pos}
 		if p.current.Type == TokenMultiply || p.current.Type == TokenDivide {
-			op = p.current.Type
+			op = p.current
 			p.advance()
-		} else {
-			// Implicit multiplication
-			op = TokenMultiply
 		}
+		// Implicit multiplication has no operator token
 
 		right, err := p.parseUnaryExpression()
 		if err != nil {
 			return nil, err
 		}
 
-		if op == TokenMultiply {
-			left = ast.NewMul(left, right)
+		if op.Type == TokenMultiply {
+			left = p.mark(ast.NewMul(left, right), start)
 		} else {
 			// Handle division - convert to multiplication by reciprocal for KAS compatibility
-			reciprocal := ast.NewPow(right, ast.NewInt(-1))
-			left = ast.NewMul(left, reciprocal)
+			reciprocal := ast.NewPow(right, markToken(ast.NewInt(-1), op))
+			left = p.mark(ast.NewMul(left, p.mark(reciprocal, op.Pos)), start)
 		}
 	}
 
@@ -167,9 +336,29 @@ func (p *Parser) parseMultiplicativeExpression() (ast.Expr, error) {
 
 // isImplicitMultiplication checks if the current position indicates implicit multiplication
 func (p *Parser) isImplicitMultiplication() bool {
+	// The differential ends the body of an integral
+	if p.integrals > 0 && p.atDifferential() {
+		return false
+	}
+
 	switch p.current.Type {
-	case TokenVar, TokenLeftParen, TokenLeftBrace, TokenSqrt, TokenFrac, TokenDfrac, TokenLn, TokenLog, TokenSin, TokenCos, TokenTan, TokenAbs, TokenPi, TokenE:
+	case TokenVar, TokenLeftParen, TokenLeftBrace, TokenSqrt, TokenFrac, TokenDfrac, TokenLn, TokenLog, TokenSin, TokenCos, TokenTan, TokenAbs, TokenLeftPipe, TokenPi, TokenE, TokenBeginMatrix, TokenBeginCases,
+		TokenSum, TokenIntegral, TokenRoot, TokenSec, TokenCsc, TokenCot, TokenArcsin, TokenArccos, TokenArctan,
+		TokenArcsec, TokenArccsc, TokenArccot, TokenSinh, TokenCosh, TokenTanh, TokenArcsinh, TokenArccosh, TokenArctanh,
+		TokenSech, TokenCsch, TokenCoth:
 		return true
+	case TokenPipe:
+		// A bar starts a factor, as in 2|x|, unless it closes one
+		return p.pipes == 0
+	case TokenInt, TokenFloat:
+		// A number multiplies when it touches the operand before it, as in
+		// x4 or (x+1)2, or follows a number or closing delimiter, as in
+		// \frac{1}{2} 2; after a spaced variable, as in x y z 3, it is
+		// trailing input
+		if p.current.Pos == p.end {
+			return true
+		}
+		return p.end > 0 && strings.ContainsRune(digits+")]}|", rune(p.lexer.input[p.end-1]))
 	default:
 		return false
 	}
@@ -177,11 +366,31 @@ func (p *Parser) isImplicitMultiplication() bool {
 
 // parseExponentialExpression parses exponentiation (right-associative)
 func (p *Parser) parseExponentialExpression() (ast.Expr, error) {
+	start := p.current.Pos
 	left, err := p.parsePrimaryExpression()
 	if err != nil {
 		return nil, err
 	}
 
+	// A percent sign applies to the operand before it, as in 35%
+	if p.current.Type == TokenPercent {
+		p.advance()
+		left = p.mark(percentOf(left), start)
+	}
+
+	// A degree sign makes the operand an angle, as in 45° or 30^\circ
+	if angle, ok := p.tryParseAngle(left); ok {
+		left = p.mark(angle, start)
+	}
+
+	// A superscript such as x² is an integer exponent
+	if p.current.Type == TokenSuperscript {
+		token := p.current
+		p.advance()
+		exponent, _ := ast.NewIntFromString(token.Value)
+		return p.mark(ast.NewPow(left, markToken(exponent, token)), start), nil
+	}
+
 	if p.current.Type == TokenPower {
 		p.advance()
 		// Right-associative: a^b^c = a^(b^c)
@@ -190,7 +399,7 @@ func (p *Parser) parseExponentialExpression() (ast.Expr, error) {
 		if err != nil {
 			return nil, err
 		}
-		return ast.NewPow(left, right), nil
+		return p.mark(ast.NewPow(left, right), start), nil
 	}
 
 	return left, nil
@@ -198,63 +407,105 @@ func (p *Parser) parseExponentialExpression() (ast.Expr, error) {
 
 // parseUnaryExpression parses unary operators (negation and unary plus)
 func (p *Parser) parseUnaryExpression() (ast.Expr, error) {
-	if p.current.Type == TokenPlus {
+	if p.current.Type == TokenPlus && !p.options.Lenient && !p.options.UnaryPlus {
 		// For KAS compatibility, reject unary plus - KAS doesn't parse "+49"
-		return nil, fmt.Errorf("unexpected token '+' at position %d", p.current.Pos)
+		err := errorAt(p.current, ErrUnexpectedToken, "unexpected token '+' at position %d", p.current.Pos)
+		err.Suggestion = "remove the leading +"
+		return nil, err
 	}
 	if p.current.Type == TokenMinus {
+		minus := p.current
 		p.advance()
 		// Recursively handle multiple minuses like "--x"
 		operand, err := p.parseUnaryExpression()
 		if err != nil {
 			return nil, err
 		}
+		return p.mark(p.negate(operand, minus), minus.Pos), nil
+	}
 
-		// Special case: if operand is a positive integer, create negative integer directly
-		// Exception: for zero, KAS expects -1*0 representation
-		if operand.Type() == ast.TypeInt {
-			intVal := operand.(*ast.Int)
-			floatVal := intVal.Value()
-			if floatVal.IsInt() {
-				intValue, _ := floatVal.Int64()
-				if intValue == 0 {
-					// For KAS compatibility: -0 should be -1*0
-					return ast.NewMul(ast.NewInt(-1), operand), nil
-				}
-				if intVal.Value().Sign() > 0 {
-					return ast.NewInt(-intValue), nil
-				}
-			}
+	if p.current.Type == TokenPlus {
+		p.advance()
+		return p.parseUnaryExpression()
+	}
+
+	if p.current.Type == TokenPlusMinus {
+		// A leading ±, as in x = \pm 2
+		start := p.current.Pos
+		p.advance()
+		operand, err := p.parseMultiplicativeExpression()
+		if err != nil {
+			return nil, err
 		}
+		return p.mark(ast.NewPlusMinus(nil, operand), start), nil
+	}
 
-		// Special case: if operand is a positive float, create negative float directly
-		if operand.Type() == ast.TypeFloat {
-			if floatVal := operand.(*ast.Float); floatVal.Value().Sign() > 0 {
-				positiveValue, _ := floatVal.Value().Float64()
-				return ast.NewFloat(-positiveValue), nil
+	return p.parseExponentialExpression()
+}
+
+// negate applies a unary minus to its operand. Positive literals are folded
+// into a negative literal; anything else becomes -1 times the operand.
+func (p *Parser) negate(operand ast.Expr, minus Token) ast.Expr {
+	// Special case: if operand is a positive integer, create negative integer directly
+	// Exception: for zero, KAS expects -1*0 representation
+	if operand.Type() == ast.TypeInt {
+		intValue := operand.(*ast.Int).IntValue()
+		if intValue.Sign() == 0 {
+			if p.options.Lenient {
+				return operand
 			}
+			// For KAS compatibility: -0 should be -1*0
+			return ast.NewMul(markToken(ast.NewInt(-1), minus), operand)
+		}
+		if intValue.Sign() > 0 {
+			negated, _ := ast.NewIntFromString(new(big.Int).Neg(intValue).String())
+			return negated
 		}
+	}
 
-		return ast.NewMul(ast.NewInt(-1), operand), nil
+	// Special case: an exact decimal is negated in place
+	if operand.Type() == ast.TypeRational {
+		if r := operand.(*ast.Rational); r.Numerator().Sign() > 0 {
+			return ast.NewRationalFromInts(new(big.Int).Neg(r.Numerator()), r.Denominator())
+		}
 	}
 
-	if p.current.Type == TokenPlus {
-		p.advance()
-		return p.parseExponentialExpression()
+	// Special case: if operand is a positive float, create negative float directly
+	if operand.Type() == ast.TypeFloat {
+		if floatVal := operand.(*ast.Float); floatVal.Value().Sign() > 0 {
+			positiveValue, _ := floatVal.Value().Float64()
+			return ast.NewFloat(-positiveValue)
+		}
 	}
 
-	return p.parseExponentialExpression()
+	return ast.NewMul(markToken(ast.NewInt(-1), minus), operand)
 }
 
-// parsePrimaryExpression parses primary expressions (atoms)
+// parsePrimaryExpression parses primary expressions (atoms) and records
+// their source span
 func (p *Parser) parsePrimaryExpression() (ast.Expr, error) {
+	start := p.current.Pos
+	expr, err := p.parseAtom()
+	if err != nil {
+		return nil, err
+	}
+	return p.mark(expr, start), nil
+}
+
+// parseAtom parses a single operand, such as a number, variable, group or
+// function application
+func (p *Parser) parseAtom() (ast.Expr, error) {
 	switch p.current.Type {
 	case TokenInt:
 		return p.parseInteger()
 	case TokenFloat:
 		return p.parseFloat()
+	case TokenVulgarFraction:
+		return p.parseVulgarFraction()
 	case TokenVar:
 		return p.parseVariable()
+	case TokenUnit:
+		return p.parseUnitVariable()
 	case TokenPi:
 		return p.parseConstant()
 	case TokenE:
@@ -265,24 +516,37 @@ func (p *Parser) parsePrimaryExpression() (ast.Expr, error) {
 		return p.parseBraces()
 	case TokenSqrt:
 		return p.parseSqrt()
+	case TokenRoot:
+		return p.parseRoot()
 	case TokenFrac, TokenDfrac:
 		return p.parseFrac()
+	case TokenSum:
+		return p.parseSum()
+	case TokenIntegral:
+		return p.parseIntegral()
 	case TokenLn, TokenLog:
 		return p.parseLogFunction()
-	case TokenSin, TokenCos, TokenTan, TokenArcsin, TokenArccos, TokenArctan:
-		return p.parseTrigFunction()
-	case TokenSinh, TokenCosh, TokenTanh:
-		return p.parseHyperbolicFunction()
-	case TokenAbs, TokenLeftPipe:
+	case TokenSin, TokenCos, TokenTan, TokenSec, TokenCsc, TokenCot,
+		TokenArcsin, TokenArccos, TokenArctan, TokenArcsec, TokenArccsc, TokenArccot,
+		TokenSinh, TokenCosh, TokenTanh, TokenArcsinh, TokenArccosh, TokenArctanh, TokenSech, TokenCsch, TokenCoth:
+		return p.parseFunctionApplication()
+	case TokenAbs, TokenLeftPipe, TokenPipe:
 		return p.parseAbsoluteValue()
+	case TokenBeginMatrix:
+		return p.parseMatrixEnvironment()
+	case TokenBeginCases:
+		return p.parseCases()
+	case TokenLeftBracket:
+		return p.parseBracketList()
 	default:
-		return nil, fmt.Errorf("unexpected token %s at position %d", p.current.Type, p.current.Pos)
+		return nil, p.unexpected(primaryTokens...)
 	}
 }
 
 // parseInteger parses integer literals
 func (p *Parser) parseInteger() (ast.Expr, error) {
-	value := p.current.Value
+	token := p.current
+	value := token.Value
 	p.advance()
 
 	// Handle subscripts
@@ -290,30 +554,61 @@ func (p *Parser) parseInteger() (ast.Expr, error) {
 		return p.parseSubscriptedVariable(value)
 	}
 
-	intValue, err := strconv.ParseInt(value, 10, 64)
+	intValue, err := ast.NewIntFromString(value)
 	if err != nil {
-		return nil, fmt.Errorf("invalid integer: %s", value)
+		return nil, errorAt(token, ErrInvalidNumber, "invalid integer: %s", value)
 	}
 
-	return ast.NewInt(intValue), nil
+	return p.parseNumberForm(intValue, token)
 }
 
 // parseFloat parses floating-point literals
 func (p *Parser) parseFloat() (ast.Expr, error) {
-	value := p.current.Value
+	token := p.current
+	value := token.Value
 	p.advance()
 
+	if p.options.ExactDecimals {
+		r, ok := new(big.Rat).SetString(value)
+		if !ok {
+			return nil, errorAt(token, ErrInvalidNumber, "invalid float: %s", value)
+		}
+		return p.parseNumberForm(ast.NewNumber(r), token)
+	}
+
 	floatValue, err := strconv.ParseFloat(value, 64)
 	if err != nil {
-		return nil, fmt.Errorf("invalid float: %s", value)
+		return nil, errorAt(token, ErrInvalidNumber, "invalid float: %s", value)
 	}
 
-	return ast.NewFloat(floatValue), nil
+	return p.parseNumberForm(ast.NewFloat(floatValue), token)
+}
+
+// parseVulgarFraction parses a single-character fraction such as ½ into the
+// same tree as 1/2
+func (p *Parser) parseVulgarFraction() (ast.Expr, error) {
+	token := p.current
+	p.advance()
+
+	slash := strings.IndexByte(token.Value, '/')
+	numerator, _ := ast.NewIntFromString(token.Value[:slash])
+	denominator, _ := ast.NewIntFromString(token.Value[slash+1:])
+	return ast.NewMul(numerator, ast.NewPow(denominator, ast.NewInt(-1))), nil
 }
 
-// parseVariable parses variables and function calls
+// parseVariable parses variables, function calls and derivatives in prime
+// or Leibniz notation
 func (p *Parser) parseVariable() (ast.Expr, error) {
 	name := p.current.Value
+	start := p.current.Pos
+
+	// Leibniz notation dy/dx
+	if name == "d" {
+		if deriv, ok := p.tryParseLeibniz(); ok {
+			return deriv, nil
+		}
+	}
+
 	p.advance()
 
 	// Handle subscripts
@@ -321,14 +616,148 @@ func (p *Parser) parseVariable() (ast.Expr, error) {
 		return p.parseSubscriptedVariable(name)
 	}
 
-	// Handle function calls - any variable followed by parentheses is a function call
-	if p.current.Type == TokenLeftParen {
-		return p.parseFunctionCall(name)
+	// Prime notation: y', y'' and f'(x)
+	order := 0
+	for p.current.Type == TokenPrime {
+		order++
+		p.advance()
+	}
+
+	// Handle function calls - a variable followed by parentheses is a
+	// function call unless the options name the functions
+	if p.current.Type == TokenLeftParen && p.isFunction(name) {
+		call, err := p.parseFunctionCall(name)
+		if err != nil || order == 0 {
+			return call, err
+		}
+		// f'(x) is differentiated with respect to its argument
+		args := call.(*ast.Func).Args()
+		if len(args) != 1 || args[0].Type() != ast.TypeVar {
+			err := fmt.Errorf("prime notation requires a single variable argument in %s", call.String())
+			return nil, p.errorSpan(start, ErrInvalidStructure, err)
+		}
+		return ast.NewDerivative(call, args[0].(*ast.Var).Name(), order), nil
+	}
+
+	if order > 0 {
+		// A bare y' is taken with respect to x
+		return ast.NewDerivative(ast.NewVar(name), "x", order), nil
 	}
 
 	return ast.NewVar(name), nil
 }
 
+// isFunction reports whether a variable followed by parentheses is a call
+// of the named function rather than a product
+func (p *Parser) isFunction(name string) bool {
+	if p.options.Functions == nil {
+		return true
+	}
+	for _, function := range p.options.Functions {
+		if function == name {
+			return true
+		}
+	}
+	return false
+}
+
+// tryParseLeibniz parses dy/dx and d^2y/dx^2, restoring the parser state
+// when the input turns out to be an ordinary product such as d*y
+func (p *Parser) tryParseLeibniz() (ast.Expr, bool) {
+	savedPos, savedCurrent, savedEnd := p.lexer.Position(), p.current, p.end
+
+	name, order, ok := p.matchDifferential(false)
+	if ok && p.current.Type == TokenDivide {
+		p.advance()
+		variable, denominatorOrder, ok := p.matchDifferential(true)
+		if ok && order == denominatorOrder {
+			return ast.NewDerivative(ast.NewVar(name), variable, order), true
+		}
+	}
+
+	p.lexer.SetPosition(savedPos)
+	p.current, p.end = savedCurrent, savedEnd
+	return nil, false
+}
+
+// tryParseFracDerivative parses \frac{dy}{dx} after the \frac token has been
+// consumed, restoring the parser state when the fraction is not a derivative
+func (p *Parser) tryParseFracDerivative() (ast.Expr, bool) {
+	savedPos, savedCurrent, savedEnd := p.lexer.Position(), p.current, p.end
+
+	if p.current.Type == TokenLeftBrace {
+		p.advance()
+		name, order, ok := p.matchDifferential(false)
+		if ok && p.current.Type == TokenRightBrace && p.peek().Type == TokenLeftBrace {
+			p.advance()
+			p.advance()
+			variable, denominatorOrder, ok := p.matchDifferential(true)
+			if ok && order == denominatorOrder && p.current.Type == TokenRightBrace {
+				p.advance()
+				return ast.NewDerivative(ast.NewVar(name), variable, order), true
+			}
+		}
+	}
+
+	p.lexer.SetPosition(savedPos)
+	p.current, p.end = savedCurrent, savedEnd
+	return nil, false
+}
+
+// matchDifferential matches a differential such as dy or d^2y (numerator)
+// or dx and dx^2 (denominator), returning the variable name and order
+func (p *Parser) matchDifferential(denominator bool) (string, int, bool) {
+	if p.current.Type != TokenVar || p.current.Value != "d" {
+		return "", 0, false
+	}
+	p.advance()
+
+	order := 1
+	if !denominator {
+		order = p.matchDifferentialOrder()
+	}
+
+	if p.current.Type != TokenVar {
+		return "", 0, false
+	}
+	name := p.current.Value
+	p.advance()
+
+	if denominator {
+		order = p.matchDifferentialOrder()
+	}
+	return name, order, order > 0
+}
+
+// matchDifferentialOrder matches an optional ^n or ^{n}, returning 1 when it
+// is absent and 0 when the exponent is not a positive integer
+func (p *Parser) matchDifferentialOrder() int {
+	if p.current.Type != TokenPower {
+		return 1
+	}
+	p.advance()
+
+	braced := p.current.Type == TokenLeftBrace
+	if braced {
+		p.advance()
+	}
+	if p.current.Type != TokenInt {
+		return 0
+	}
+	order, err := strconv.Atoi(p.current.Value)
+	if err != nil || order < 1 {
+		return 0
+	}
+	p.advance()
+	if braced {
+		if p.current.Type != TokenRightBrace {
+			return 0
+		}
+		p.advance()
+	}
+	return order
+}
+
 // parseSubscriptedVariable parses variables with subscripts (e.g., x_1, x_n)
 func (p *Parser) parseSubscriptedVariable(baseName string) (ast.Expr, error) {
 	if err := p.expect(TokenSubscript); err != nil {
@@ -336,13 +765,14 @@ func (p *Parser) parseSubscriptedVariable(baseName string) (ast.Expr, error) {
 	}
 
 	subscript := ""
-	if p.current.Type == TokenLeftBrace {
+	if closing, ok := map[TokenType]TokenType{TokenLeftBrace: TokenRightBrace, TokenLeftParen: TokenRightParen}[p.current.Type]; ok {
+		// A grouped subscript, as in a_{n+1} or a_(n+1)
 		p.advance()
-		for p.current.Type != TokenRightBrace && p.current.Type != TokenEOF {
+		for p.current.Type != closing && p.current.Type != TokenEOF {
 			subscript += p.current.Value
 			p.advance()
 		}
-		if err := p.expect(TokenRightBrace); err != nil {
+		if err := p.expectClosing(closing, "subscript"); err != nil {
 			return nil, err
 		}
 	} else {
@@ -359,67 +789,200 @@ func (p *Parser) parseConstant() (ast.Expr, error) {
 	switch p.current.Type {
 	case TokenPi:
 		p.advance()
-		return ast.Pi, nil
+		// Copy the shared constant so its span can be recorded
+		return ast.Pi.Clone(), nil
 	case TokenE:
 		p.advance()
-		return ast.E, nil
+		if p.options.VariableE {
+			return ast.NewVar("e"), nil
+		}
+		return ast.E.Clone(), nil
 	default:
-		return nil, fmt.Errorf("unknown constant: %s", p.current.Value)
+		return nil, errorAt(p.current, ErrUnexpectedToken, "unknown constant: %s", p.current.Value)
 	}
 }
 
-// parseParentheses parses parenthesized expressions
+// parseParentheses parses parenthesized expressions. A comma-separated
+// list such as (2, -3) becomes a tuple.
 func (p *Parser) parseParentheses() (ast.Expr, error) {
 	if err := p.expect(TokenLeftParen); err != nil {
 		return nil, err
 	}
 
-	expr, err := p.parseExpression()
+	elements, err := p.parseCommaList(TokenRightParen)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := p.expect(TokenRightParen); err != nil {
-		return nil, err
+	if len(elements) > 1 {
+		return ast.NewTuple(elements...), nil
 	}
-
-	return expr, nil
+	return elements[0], nil
 }
 
-// parseBraces parses braced expressions (similar to parentheses in LaTeX)
+// parseBraces parses braced expressions (similar to parentheses in LaTeX).
+// A comma-separated list such as \{1, 2\} becomes a set.
 func (p *Parser) parseBraces() (ast.Expr, error) {
 	if err := p.expect(TokenLeftBrace); err != nil {
 		return nil, err
 	}
 
-	expr, err := p.parseExpression()
+	elements, err := p.parseCommaList(TokenRightBrace)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := p.expect(TokenRightBrace); err != nil {
+	if len(elements) > 1 {
+		return ast.NewSet(elements...), nil
+	}
+	return elements[0], nil
+}
+
+// parseMatrixEnvironment parses \begin{pmatrix} a & b \\ c & d \end{pmatrix}
+func (p *Parser) parseMatrixEnvironment() (ast.Expr, error) {
+	start := p.current.Pos
+	if err := p.expect(TokenBeginMatrix); err != nil {
 		return nil, err
 	}
 
-	return expr, nil
+	rows := [][]ast.Expr{{}}
+	for p.current.Type != TokenEndMatrix {
+		entry, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		last := len(rows) - 1
+		rows[last] = append(rows[last], entry)
+
+		switch p.current.Type {
+		case TokenAmpersand:
+			p.advance()
+		case TokenRowSeparator:
+			p.advance()
+			// Allow a trailing \\ before \end{pmatrix}
+			if p.current.Type != TokenEndMatrix {
+				rows = append(rows, []ast.Expr{})
+			}
+		case TokenEndMatrix:
+		default:
+			if p.current.Type == TokenError {
+				return nil, p.invalidCharacter()
+			}
+			err := errorAt(p.current, ErrUnexpectedToken, "expected & or \\\\ in matrix, got %s at position %d", p.current.Type, p.current.Pos)
+			err.Expected = []TokenType{TokenAmpersand, TokenRowSeparator, TokenEndMatrix}
+			return nil, err
+		}
+	}
+	p.advance()
+
+	matrix, err := ast.NewMatrix(rows)
+	if err != nil {
+		return nil, p.errorSpan(start, ErrInvalidStructure, err)
+	}
+	return matrix, nil
+}
+
+// parseBracketList parses [a, b, c] as a vector and [[a, b], [c, d]] as a
+// matrix given row by row
+func (p *Parser) parseBracketList() (ast.Expr, error) {
+	start := p.current.Pos
+	if err := p.expect(TokenLeftBracket); err != nil {
+		return nil, err
+	}
+
+	if p.current.Type != TokenLeftBracket {
+		elements, err := p.parseCommaList(TokenRightBracket)
+		if err != nil {
+			return nil, err
+		}
+		return ast.NewVector(elements...), nil
+	}
+
+	var rows [][]ast.Expr
+	for {
+		if err := p.expect(TokenLeftBracket); err != nil {
+			return nil, err
+		}
+		row, err := p.parseCommaList(TokenRightBracket)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+
+		if p.current.Type != TokenComma {
+			break
+		}
+		p.advance()
+	}
+	if err := p.expectClosing(TokenRightBracket, ""); err != nil {
+		return nil, err
+	}
+
+	matrix, err := ast.NewMatrix(rows)
+	if err != nil {
+		return nil, p.errorSpan(start, ErrInvalidStructure, err)
+	}
+	return matrix, nil
+}
+
+// parseCommaList parses comma-separated expressions up to and including
+// the closing token
+func (p *Parser) parseCommaList(closing TokenType) ([]ast.Expr, error) {
+	elements, err := p.parseElements()
+	if err != nil {
+		// Resume at the closing delimiter, as in (1 + ) * (2 + )
+		if !p.recover(err, func(t TokenType) bool { return t == closing }) {
+			return nil, err
+		}
+		elements = []ast.Expr{placeholder()}
+	}
+	if err := p.expectClosing(closing, ""); err != nil {
+		return nil, err
+	}
+	return elements, nil
+}
+
+// parseElements parses one or more comma-separated expressions
+func (p *Parser) parseElements() ([]ast.Expr, error) {
+	var elements []ast.Expr
+	for {
+		elem, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, elem)
+
+		if p.current.Type != TokenComma {
+			return elements, nil
+		}
+		p.advance()
+	}
 }
 
 // parseSqrt parses square root expressions, including \sqrt[n]{x} syntax
+// and the cube and fourth root signs ∛ and ∜
 func (p *Parser) parseSqrt() (ast.Expr, error) {
+	sign := p.current.Value
 	if err := p.expect(TokenSqrt); err != nil {
 		return nil, err
 	}
 
 	// Handle optional root index [n] in \sqrt[n]{x}
 	var rootIndex ast.Expr
-	if p.current.Type == TokenLeftBracket {
+	switch sign {
+	case "\u221b":
+		rootIndex = ast.NewInt(3)
+	case "\u221c":
+		rootIndex = ast.NewInt(4)
+	}
+	if rootIndex == nil && p.current.Type == TokenLeftBracket {
 		p.advance() // consume [
 		var err error
 		rootIndex, err = p.parseExpression()
 		if err != nil {
 			return nil, err
 		}
-		if err := p.expect(TokenRightBracket); err != nil {
+		if err := p.expectClosing(TokenRightBracket, "the index of \\sqrt"); err != nil {
 			return nil, err
 		}
 	}
@@ -433,7 +996,7 @@ func (p *Parser) parseSqrt() (ast.Expr, error) {
 		if err != nil {
 			return nil, err
 		}
-		if err := p.expect(TokenRightBrace); err != nil {
+		if err := p.expectClosing(TokenRightBrace, "\\sqrt"); err != nil {
 			return nil, err
 		}
 	} else {
@@ -458,105 +1021,170 @@ func (p *Parser) parseSqrt() (ast.Expr, error) {
 func (p *Parser) parseFrac() (ast.Expr, error) {
 	p.advance() // consume \frac or \dfrac
 
-	if err := p.expect(TokenLeftBrace); err != nil {
-		return nil, err
+	// Leibniz notation \frac{dy}{dx}
+	if deriv, ok := p.tryParseFracDerivative(); ok {
+		return deriv, nil
 	}
 
-	numerator, err := p.parseExpression()
+	numerator, err := p.parseGroup("\\frac")
 	if err != nil {
 		return nil, err
 	}
 
-	if err := p.expect(TokenRightBrace); err != nil {
+	denominator, err := p.parseGroup("\\frac")
+	if err != nil {
 		return nil, err
 	}
 
-	if err := p.expect(TokenLeftBrace); err != nil {
+	// Create division as multiplication by reciprocal
+	reciprocal := ast.NewPow(denominator, ast.NewInt(-1))
+	return ast.NewMul(numerator, reciprocal), nil
+}
+
+// parseGroup parses a braced argument such as each part of \frac{a}{b}.
+// AsciiMath also allows parentheses, as in frac(a)(b).
+func (p *Parser) parseGroup(construct string) (ast.Expr, error) {
+	closing := TokenRightBrace
+	if p.asciiMath && p.current.Type == TokenLeftParen {
+		closing = TokenRightParen
+	} else if err := p.expect(TokenLeftBrace); err != nil {
 		return nil, err
 	}
+	if closing == TokenRightParen {
+		p.advance()
+	}
 
-	denominator, err := p.parseExpression()
+	expr, err := p.parseExpression()
 	if err != nil {
 		return nil, err
 	}
+	if err := p.expectClosing(closing, construct); err != nil {
+		return nil, err
+	}
+	return expr, nil
+}
+
+// parseSum parses \sum_{i=1}^{n} body into sum(body, i, 1, n). The body is
+// a single term, so \sum_{i=1}^{n} i + 1 adds 1 to the sum.
+func (p *Parser) parseSum() (ast.Expr, error) {
+	p.advance() // consume \sum
 
-	if err := p.expect(TokenRightBrace); err != nil {
+	start := p.current.Pos
+	if err := p.expect(TokenSubscript); err != nil {
+		return nil, err
+	}
+	lower, err := p.parsePrimaryExpression()
+	if err != nil {
+		return nil, err
+	}
+	index, ok := lower.(*ast.Eq)
+	if !ok || index.EqType() != ast.EqEqual || index.Left().Type() != ast.TypeVar {
+		err := p.errorSpan(start, ErrInvalidStructure, fmt.Errorf("the lower limit of a sum must name its index, as in i=1"))
+		err.Suggestion = "write the lower limit as _{i=1}"
 		return nil, err
 	}
 
-	// Create division as multiplication by reciprocal
-	reciprocal := ast.NewPow(denominator, ast.NewInt(-1))
-	return ast.NewMul(numerator, reciprocal), nil
-}
+	if err := p.expect(TokenPower); err != nil {
+		return nil, err
+	}
+	upper, err := p.parsePrimaryExpression()
+	if err != nil {
+		return nil, err
+	}
 
-// parseLogFunction parses logarithm functions
-func (p *Parser) parseLogFunction() (ast.Expr, error) {
-	var funcName string
-	switch p.current.Type {
-	case TokenLn:
-		funcName = "ln"
-	case TokenLog:
-		funcName = "log"
+	body, err := p.parseMultiplicativeExpression()
+	if err != nil {
+		return nil, err
 	}
-	p.advance()
 
-	var operand ast.Expr
-	var err error
+	return ast.NewSum(body, index.Left().(*ast.Var).Name(), index.Right(), upper), nil
+}
 
-	if p.current.Type == TokenLeftBrace {
+// parseIntegral parses \int f(x) dx and \int_a^b f(x) dx into int(f(x), x)
+// and int(f(x), x, a, b)
+func (p *Parser) parseIntegral() (ast.Expr, error) {
+	p.advance() // consume \int
+
+	var limits []ast.Expr
+	if p.current.Type == TokenSubscript {
 		p.advance()
-		operand, err = p.parseExpression()
+		lower, err := p.parsePrimaryExpression()
 		if err != nil {
 			return nil, err
 		}
-		if err := p.expect(TokenRightBrace); err != nil {
+		if err := p.expect(TokenPower); err != nil {
 			return nil, err
 		}
-	} else if p.current.Type == TokenLeftParen {
-		return p.parseFunctionCall(funcName)
-	} else {
-		operand, err = p.parsePrimaryExpression()
+		upper, err := p.parsePrimaryExpression()
 		if err != nil {
 			return nil, err
 		}
+		limits = []ast.Expr{lower, upper}
 	}
 
-	return ast.NewFunc(funcName, operand), nil
-}
+	p.integrals++
+	body, err := p.parseArithmeticExpression()
+	p.integrals--
+	if err != nil {
+		return nil, err
+	}
 
-// parseTrigFunction parses trigonometric functions
-func (p *Parser) parseTrigFunction() (ast.Expr, error) {
-	var funcName string
-	switch p.current.Type {
-	case TokenSin:
-		funcName = "sin"
-	case TokenCos:
-		funcName = "cos"
-	case TokenTan:
-		funcName = "tan"
-	case TokenArcsin:
-		funcName = "arcsin"
-	case TokenArccos:
-		funcName = "arccos"
-	case TokenArctan:
-		funcName = "arctan"
+	if !p.atDifferential() {
+		err := p.unexpected(TokenVar)
+		err.Message = fmt.Sprintf("expected a differential such as dx, got %s at position %d", p.current.Type, p.current.Pos)
+		err.Suggestion = "end the integral with its differential, such as dx"
+		return nil, err
 	}
 	p.advance()
+	variable := p.current.Value
+	p.advance()
 
-	var operand ast.Expr
+	return ast.NewIntegral(body, variable, limits...), nil
+}
+
+// atDifferential reports whether the current tokens are a differential
+// such as dx
+func (p *Parser) atDifferential() bool {
+	return p.current.Type == TokenVar && p.current.Value == "d" && p.peek().Type == TokenVar
+}
+
+// parseLogFunction parses logarithm functions. A subscript on log gives the
+// base, as in \log_2 x or log_b(x), which becomes a second argument.
+func (p *Parser) parseLogFunction() (ast.Expr, error) {
+	start := p.current.Pos
+	funcName := functionName(p.current.Type)
+	p.advance()
+
+	var operand, base ast.Expr
 	var err error
 
+	if funcName == "log" && p.current.Type == TokenSubscript {
+		base, err = p.parseLogBase()
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	if p.current.Type == TokenLeftBrace {
 		p.advance()
 		operand, err = p.parseExpression()
 		if err != nil {
 			return nil, err
 		}
-		if err := p.expect(TokenRightBrace); err != nil {
+		if err := p.expectClosing(TokenRightBrace, "\\"+funcName); err != nil {
 			return nil, err
 		}
 	} else if p.current.Type == TokenLeftParen {
-		return p.parseFunctionCall(funcName)
+		call, err := p.parseFunctionCall(funcName)
+		if err != nil || base == nil {
+			return call, err
+		}
+		args := call.(*ast.Func).Args()
+		if len(args) != 1 {
+			err := fmt.Errorf("a logarithm with a base takes one argument, got %d", len(args))
+			return nil, p.errorSpan(start, ErrInvalidStructure, err)
+		}
+		operand = args[0]
 	} else {
 		operand, err = p.parsePrimaryExpression()
 		if err != nil {
@@ -564,63 +1192,140 @@ func (p *Parser) parseTrigFunction() (ast.Expr, error) {
 		}
 	}
 
+	if base != nil {
+		return ast.NewFunc(funcName, operand, base), nil
+	}
 	return ast.NewFunc(funcName, operand), nil
 }
 
-// parseHyperbolicFunction parses hyperbolic functions
-func (p *Parser) parseHyperbolicFunction() (ast.Expr, error) {
-	var funcName string
+// parseLogBase parses the subscript of \log_b: a braced or parenthesized
+// group, or a single number, constant or variable, which may carry its own
+// subscript as in \log_{x_0}
+func (p *Parser) parseLogBase() (ast.Expr, error) {
+	if err := p.expect(TokenSubscript); err != nil {
+		return nil, err
+	}
+
 	switch p.current.Type {
-	case TokenSinh:
-		funcName = "sinh"
-	case TokenCosh:
-		funcName = "cosh"
-	case TokenTanh:
-		funcName = "tanh"
+	case TokenLeftBrace:
+		return p.parseBraces()
+	case TokenLeftParen:
+		return p.parseParentheses()
+	case TokenVar:
+		// Read the variable alone, so that log_b(x) is not the call b(x)
+		name := p.current.Value
+		p.advance()
+		if p.current.Type == TokenSubscript {
+			return p.parseSubscriptedVariable(name)
+		}
+		return ast.NewVar(name), nil
 	}
+	return p.parsePrimaryExpression()
+}
+
+// parseFunctionApplication parses a named function and its argument, which
+// is braced, parenthesized or a single primary expression. A power written
+// on the name, as in \sec^2 x, applies to the value of the function, except
+// that a power of -1 names the inverse function: \sin^{-1} x is arcsin(x).
+func (p *Parser) parseFunctionApplication() (ast.Expr, error) {
+	start := p.current.Pos
+	funcName := functionName(p.current.Type)
 	p.advance()
 
-	var operand ast.Expr
-	var err error
+	power, err := p.parseFunctionPower()
+	if err != nil {
+		return nil, err
+	}
+	if inverse, ok := inverses[funcName]; ok && ast.IsMinusOne(power) {
+		funcName, power = inverse, nil
+	}
 
+	var fn ast.Expr
 	if p.current.Type == TokenLeftBrace {
 		p.advance()
-		operand, err = p.parseExpression()
+		operand, err := p.parseExpression()
 		if err != nil {
 			return nil, err
 		}
-		if err := p.expect(TokenRightBrace); err != nil {
+		if err := p.expectClosing(TokenRightBrace, "\\"+funcName); err != nil {
 			return nil, err
 		}
+		fn = ast.NewFunc(funcName, operand)
 	} else if p.current.Type == TokenLeftParen {
-		return p.parseFunctionCall(funcName)
+		fn, err = p.parseFunctionCall(funcName)
+		if err != nil {
+			return nil, err
+		}
 	} else {
-		operand, err = p.parsePrimaryExpression()
+		operandStart := p.current.Pos
+		operand, err := p.parsePrimaryExpression()
 		if err != nil {
 			return nil, err
 		}
+		// The degree sign belongs to the argument, as in \sin 30^\circ
+		if angle, ok := p.tryParseAngle(operand); ok {
+			operand = p.mark(angle, operandStart)
+		}
+		fn = ast.NewFunc(funcName, operand)
 	}
 
-	return ast.NewFunc(funcName, operand), nil
+	if power == nil {
+		return fn, nil
+	}
+	return p.mark(ast.NewPow(fn, power), start), nil
+}
+
+// parseFunctionPower parses the exponent written on a function name, such
+// as the 2 in \sec^2 x or \cos² x, and returns nil when there is none
+func (p *Parser) parseFunctionPower() (ast.Expr, error) {
+	switch p.current.Type {
+	case TokenSuperscript:
+		token := p.current
+		p.advance()
+		exponent, _ := ast.NewIntFromString(token.Value)
+		return markToken(exponent, token), nil
+	case TokenPower:
+		p.advance()
+		if p.current.Type == TokenMinus {
+			minus := p.current
+			p.advance()
+			operand, err := p.parsePrimaryExpression()
+			if err != nil {
+				return nil, err
+			}
+			return p.mark(p.negate(operand, minus), minus.Pos), nil
+		}
+		return p.parsePrimaryExpression()
+	}
+	return nil, nil
 }
 
 // parseAbsoluteValue parses absolute value expressions
 func (p *Parser) parseAbsoluteValue() (ast.Expr, error) {
 	if p.current.Type == TokenAbs {
-		return p.parseTrigFunction() // Reuse trig function parsing logic
+		return p.parseFunctionApplication()
 	}
 
-	// Handle |expression| syntax
-	if err := p.expect(TokenLeftPipe); err != nil {
-		return nil, err
+	// Handle \left|expression\right| and |expression| syntax, where a bar
+	// closes the innermost one still open, so ||x|| is abs(abs(x))
+	closing := TokenRightPipe
+	if p.current.Type == TokenPipe {
+		closing = TokenPipe
 	}
+	p.advance()
 
+	if closing == TokenPipe {
+		p.pipes++
+	}
 	operand, err := p.parseExpression()
+	if closing == TokenPipe {
+		p.pipes--
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	if err := p.expect(TokenRightPipe); err != nil {
+	if err := p.expectClosing(closing, "absolute value"); err != nil {
 		return nil, err
 	}
 
@@ -637,7 +1342,9 @@ func (p *Parser) parseFunctionCall(funcName string) (ast.Expr, error) {
 
 	// Empty function calls are not allowed
 	if p.current.Type == TokenRightParen {
-		return nil, fmt.Errorf("empty function call not allowed: %s()", funcName)
+		err := errorAt(p.current, ErrEmptyCall, "empty function call not allowed: %s()", funcName)
+		err.Suggestion = "add an argument between the parentheses"
+		return nil, err
 	}
 
 	// Parse first argument
@@ -657,7 +1364,7 @@ func (p *Parser) parseFunctionCall(funcName string) (ast.Expr, error) {
 		args = append(args, arg)
 	}
 
-	if err := p.expect(TokenRightParen); err != nil {
+	if err := p.expectClosing(TokenRightParen, funcName); err != nil {
 		return nil, err
 	}
 
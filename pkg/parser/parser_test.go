@@ -3,6 +3,8 @@ package parser
 import (
 	"math/big"
 	"testing"
+
+	"github.com/quizizz/cas/pkg/ast"
 )
 
 func TestLexer(t *testing.T) {
@@ -51,6 +53,11 @@ func TestLexer(t *testing.T) {
 			"\\sqrt{x}",
 			[]TokenType{TokenSqrt, TokenLeftBrace, TokenVar, TokenRightBrace, TokenEOF},
 		},
+		{
+			"prime",
+			"y''",
+			[]TokenType{TokenVar, TokenPrime, TokenPrime, TokenEOF},
+		},
 	}
 
 	for _, tt := range tests {
@@ -191,6 +198,47 @@ func TestParseFunctions(t *testing.T) {
 		{"sin", "\\sin{x}", "sin(x)"},
 		{"cos", "\\cos{x}", "cos(x)"},
 		{"tan", "\\tan{x}", "tan(x)"},
+		{"sec", "\\sec x", "sec(x)"},
+		{"csc", "\\csc{x}", "csc(x)"},
+		{"cot", "\\cot(x)", "cot(x)"},
+		{"bare sec", "secx", "sec(x)"},
+		{"arcsec", "\\arcsec x", "arcsec(x)"},
+		{"arccsc", "arccsc(x)", "arccsc(x)"},
+		{"arccot", "\\operatorname{arccot} x", "arccot(x)"},
+		{"sinh", "\\sinh x", "sinh(x)"},
+		{"bare cosh", "cosh(x)", "cosh(x)"},
+		{"arcsinh", "\\arcsinh x", "arcsinh(x)"},
+		{"arccosh", "arccosh(x)", "arccosh(x)"},
+		{"arctanh", "\\operatorname{arctanh}{x}", "arctanh(x)"},
+		{"spaced operator name", "\\operatorname { arcsec }(x)", "arcsec(x)"},
+		{"other operator name", "\\operatorname{sgn}(x)", "sgn(x)"},
+		{"coth", "\\coth x", "coth(x)"},
+		{"sech", "\\sech{x}", "sech(x)"},
+		{"csch operator name", "\\operatorname{csch} x", "csch(x)"},
+		{"bare coth", "cothx", "coth(x)"},
+		{"arc sec not hyperbolic", "\\arcsecx", "arcsec(x)"},
+		{"area sine", "\\operatorname{arsinh} x", "arcsinh(x)"},
+		{"area cosine", "\\operatorname{arcosh}(x)", "arccosh(x)"},
+		{"sec squared", "\\sec^2 x", "sec(x)^2"},
+		{"csc squared braced", "\\csc^{2}(x)", "csc(x)^2"},
+		{"cot unicode square", "\\cot² x", "cot(x)^2"},
+		{"cosh squared", "\\cosh^2 x - \\sinh^2 x", "cosh(x)^2+-1*sinh(x)^2"},
+		{"implicit product", "2\\sec x", "2*sec(x)"},
+		{"sin squared parens", "\\sin^2(x)", "sin(x)^2"},
+		{"sin squared no space", "sin^2x", "sin(x)^2"},
+		{"sin cubed braced", "\\sin^{3}{x}", "sin(x)^3"},
+		{"sin inverse", "\\sin^{-1} x", "arcsin(x)"},
+		{"sin inverse unbraced", "sin^-1(x)", "arcsin(x)"},
+		{"cos inverse superscript", "\\cos⁻¹ x", "arccos(x)"},
+		{"sec inverse", "\\sec^{-1}(x)", "arcsec(x)"},
+		{"tanh inverse", "\\tanh^{-1} x", "arctanh(x)"},
+		{"sin to minus two", "\\sin^{-2} x", "sin(x)^-2"},
+		{"log base 2", "\\log_2 x", "log(x, 2)"},
+		{"log braced base", "\\log_{2}(8)", "log(8, 2)"},
+		{"log variable base", "log_b(x)", "log(x, b)"},
+		{"log parenthesized base", "log_(3)(9)", "log(9, 3)"},
+		{"log subscripted base", "\\log_{x_0} y", "log(y, x_0)"},
+		{"log base binds to its argument", "\\log_2 x^3", "log(x, 2)^3"},
 		{"function call", "f(x)", "f(x)"},
 		{"function with multiple args", "f(x, y)", "f(x, y)"},
 	}
@@ -219,7 +267,7 @@ func TestParseComplexExpressions(t *testing.T) {
 	}{
 		{"quadratic", "x^2 + 2*x + 1", "x^2+2*x+1"},
 		{"fraction with variables", "x/y", "x*y^-1"},
-		{"nested power", "(x^2)^3", "x^2^3"},
+		{"nested power", "(x^2)^3", "(x^2)^3"},
 		{"implicit multiplication", "2x", "2*x"},
 		{"multiple variables", "a*x + b", "a*x+b"},
 		{"complex fraction", "(x+1)/(x-1)", "(x+1)*(x+-1)^-1"},
@@ -251,6 +299,13 @@ func TestParseErrors(t *testing.T) {
 		{"invalid token", "x + @"},
 		{"empty function", "sin()"},
 		{"incomplete frac", "\\frac{1}"},
+		{"log base without argument", "\\log_2"},
+		{"log base with two arguments", "\\log_2(x, y)"},
+		{"unknown inverse hyperbolic cotangent", "\\arccoth x"},
+		{"unknown inverse hyperbolic secant", "\\arcsech x"},
+		{"unknown inverse hyperbolic cosecant", "\\arccsch x"},
+		{"chain in both directions", "1 < x > 2"},
+		{"chain in both directions with equality", "1 \\le x \\ge 2"},
 	}
 
 	for _, tt := range tests {
@@ -334,4 +389,657 @@ func TestParseAndEvaluate(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+func TestParseDerivatives(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"prime", "y'", "y'"},
+		{"double prime", "y''", "y''"},
+		{"prime in equation", "y'+2*y=x", "y'+2*y=x"},
+		{"function prime", "f'(t)", "d/dt(f(t))"},
+		{"leibniz", "dy/dx", "y'"},
+		{"leibniz second order", "d^2y/dx^2", "y''"},
+		{"leibniz frac", "\\frac{dy}{dt}", "y'"},
+		{"leibniz frac second order", "\\frac{d^{2}y}{dx^{2}}", "y''"},
+		{"product with d", "d*y", "d*y"},
+		{"quotient with d", "dy/x", "d*y*x^-1"},
+		{"ordinary frac", "\\frac{d}{x}", "d*x^-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := Parse(tt.input)
+			if err != nil {
+				t.Errorf("Parse(%s) returned error: %v", tt.input, err)
+				return
+			}
+
+			result := expr.String()
+			if result != tt.expected {
+				t.Errorf("Parse(%s).String() = %s, want %s", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseMatrices(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"bracket matrix", "[[1,2],[3,4]]", "[[1, 2], [3, 4]]"},
+		{"bracket vector", "[1,2,x]", "[1, 2, x]"},
+		{"pmatrix", "\\begin{pmatrix}1 & 2 \\\\ 3 & 4\\end{pmatrix}", "[[1, 2], [3, 4]]"},
+		{"bmatrix trailing row separator", "\\begin{bmatrix}a & b \\\\ c & d \\\\\\end{bmatrix}", "[[a, b], [c, d]]"},
+		{"fraction entries", "[[\\frac{1}{2},0],[0,x^2]]", "[[1*2^-1, 0], [0, x^2]]"},
+		{"scaled matrix", "2\\begin{pmatrix}1 & 0\\end{pmatrix}", "2*[[1, 0]]"},
+		{"nth root still parses", "\\sqrt[3]{x}", "x^3^-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := Parse(tt.input)
+			if err != nil {
+				t.Errorf("Parse(%s) returned error: %v", tt.input, err)
+				return
+			}
+
+			result := expr.String()
+			if result != tt.expected {
+				t.Errorf("Parse(%s).String() = %s, want %s", tt.input, result, tt.expected)
+			}
+		})
+	}
+
+	for _, input := range []string{"[[1,2],[3]]", "\\begin{pmatrix}1 & 2 \\\\ 3\\end{pmatrix}", "[1,2"} {
+		if _, err := Parse(input); err == nil {
+			t.Errorf("Parse(%s) should return an error", input)
+		}
+	}
+}
+
+func TestParseTuplesAndSets(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		expected     string
+		expectedType ast.ExprType
+	}{
+		{"point", "(2, -3)", "(2, -3)", ast.TypeTuple},
+		{"latex point", "\\left(\\frac{1}{2}, x\\right)", "(1*2^-1, x)", ast.TypeTuple},
+		{"triple", "(1,2,3)", "(1, 2, 3)", ast.TypeTuple},
+		{"parenthesized expression", "(x+1)", "x+1", ast.TypeAdd},
+		{"solution list", "x = 2, x = -1", "{x=2, x=-1}", ast.TypeSet},
+		{"value list", "2, -1", "{2, -1}", ast.TypeSet},
+		{"set braces", "\\{1, 2\\}", "{1, 2}", ast.TypeSet},
+		{"left right braces", "\\left\\{1, 2\\right\\}", "{1, 2}", ast.TypeSet},
+		{"list of points", "(1, 2), (3, 4)", "{(1, 2), (3, 4)}", ast.TypeSet},
+		{"function call args", "f(x, y)", "f(x, y)", ast.TypeFunc},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := Parse(tt.input)
+			if err != nil {
+				t.Errorf("Parse(%s) returned error: %v", tt.input, err)
+				return
+			}
+
+			if result := expr.String(); result != tt.expected {
+				t.Errorf("Parse(%s).String() = %s, want %s", tt.input, result, tt.expected)
+			}
+			if expr.Type() != tt.expectedType {
+				t.Errorf("Parse(%s).Type() = %s, want %s", tt.input, expr.Type(), tt.expectedType)
+			}
+		})
+	}
+
+	for _, input := range []string{"(1, 2", "(1,)", "1,"} {
+		if _, err := Parse(input); err == nil {
+			t.Errorf("Parse(%s) should return an error", input)
+		}
+	}
+}
+
+func TestParseChainedRelations(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		expected     string
+		expectedType ast.ExprType
+	}{
+		{"two relations", "1 < x <= 5", "1<x<=5", ast.TypeChain},
+		{"linear middle", "-2 < 3x + 1 <= 7", "-2<3*x+1<=7", ast.TypeChain},
+		{"latex chain", "0 \\le x \\le 1", "0<=x<=1", ast.TypeChain},
+		{"descending", "5 >= x > 1", "5>=x>1", ast.TypeChain},
+		{"single relation", "x < 3", "x<3", ast.TypeEq},
+		{"chain in list", "0 < x < 1, x = 3", "{0<x<1, x=3}", ast.TypeSet},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := Parse(tt.input)
+			if err != nil {
+				t.Errorf("Parse(%s) returned error: %v", tt.input, err)
+				return
+			}
+
+			if result := expr.String(); result != tt.expected {
+				t.Errorf("Parse(%s).String() = %s, want %s", tt.input, result, tt.expected)
+			}
+			if expr.Type() != tt.expectedType {
+				t.Errorf("Parse(%s).Type() = %s, want %s", tt.input, expr.Type(), tt.expectedType)
+			}
+		})
+	}
+}
+
+func TestParsePlusMinus(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		expected     string
+		expectedType ast.ExprType
+	}{
+		{"quadratic formula", "x = \\frac{-3 \\pm \\sqrt{5}}{2}", "x=(-3±sqrt(5))*2^-1", ast.TypeEq},
+		{"unary", "\\pm 2", "±2", ast.TypePlusMinus},
+		{"unicode", "1 ± x", "1±x", ast.TypePlusMinus},
+		{"binds like plus", "1 + 2 \\pm 3x", "1+2±3*x", ast.TypePlusMinus},
+		{"followed by a term", "1 \\pm 2 + x", "1±2+x", ast.TypeAdd},
+		{"in a list", "x = \\pm 3, y = 1", "{x=±3, y=1}", ast.TypeSet},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := Parse(tt.input)
+			if err != nil {
+				t.Errorf("Parse(%s) returned error: %v", tt.input, err)
+				return
+			}
+
+			if result := expr.String(); result != tt.expected {
+				t.Errorf("Parse(%s).String() = %s, want %s", tt.input, result, tt.expected)
+			}
+			if expr.Type() != tt.expectedType {
+				t.Errorf("Parse(%s).Type() = %s, want %s", tt.input, expr.Type(), tt.expectedType)
+			}
+		})
+	}
+}
+
+func TestParseAngles(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"degree sign", "45°", "45°"},
+		{"circ", "30^\\circ", "30°"},
+		{"braced circ", "30^{\\circ}", "30°"},
+		{"degree command", "90\\degree", "90°"},
+		{"minutes", "12°30'", "12°30'"},
+		{"minutes and seconds", "12°30'15''", "12°30'15''"},
+		{"unicode primes", "12°30′15″", "12°30'15''"},
+		{"function argument", "\\sin 30^\\circ", "sin(30°)"},
+		{"parenthesized argument", "\\cos(60°)", "cos(60°)"},
+		{"variable", "x° + 10°", "x°+10°"},
+		{"derivative prime is unchanged", "y' + 2", "y'+2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := Parse(tt.input)
+			if err != nil {
+				t.Errorf("Parse(%s) returned error: %v", tt.input, err)
+				return
+			}
+
+			if result := expr.String(); result != tt.expected {
+				t.Errorf("Parse(%s).String() = %s, want %s", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseUnits(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"acceleration", "9.8 m/s^2", "9.8 m/s^2"},
+		{"text unit", "5 \\text{ km}", "5 km"},
+		{"mathrm unit with thin space", "5\\,\\mathrm{km}", "5 km"},
+		{"product of units", "3 kg \\cdot m/s^{2}", "3 kg*m/s^2"},
+		{"negative power", "20 m s^{-1}", "20 m/s"},
+		{"superscript power", "10 s⁻¹", "10 s^-1"},
+		{"sum of quantities", "2m + 50cm", "2 m+50 cm"},
+		{"longest symbol", "5 min", "5 min"},
+		{"division by a number", "6 m / 2", "(6 m)*2^-1"},
+		{"equation", "d = 3 ft", "d=3 ft"},
+		{"unit symbol alone", "m", "m"},
+		{"unit symbols as letters", "ms", "m*s"},
+		{"longer word", "max", "m*a*x"},
+		{"function name wins", "\\sec x", "sec(x)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := Parse(tt.input, Options{Units: true})
+			if err != nil {
+				t.Errorf("Parse(%s) returned error: %v", tt.input, err)
+				return
+			}
+
+			if result := expr.String(); result != tt.expected {
+				t.Errorf("Parse(%s).String() = %s, want %s", tt.input, result, tt.expected)
+			}
+		})
+	}
+
+	if expr, err := Parse("5 m"); err != nil || expr.String() != "5*m" {
+		t.Errorf("Parse(5 m) without units = %v, %v, want 5*m", expr, err)
+	}
+}
+
+func TestParsePiecewise(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"otherwise", "\\begin{cases} x^2 & x < 0 \\\\ 2x & \\text{otherwise} \\end{cases}", "{x^2 if x<0; 2*x otherwise}"},
+		{"comma and if", "\\begin{cases} 1, & \\text{if } x \\ge 0 \\\\ -1, & \\text{if } x < 0 \\end{cases}", "{1 if x>=0; -1 if x<0}"},
+		{"chain condition", "\\begin{cases} x & 0 \\le x < 1 \\\\ 1 & x \\ge 1 \\end{cases}", "{x if 0<=x<1; 1 if x>=1}"},
+		{"trailing row separator", "\\begin{cases} 0 & x < 0 \\\\ x & \\text{else} \\\\ \\end{cases}", "{0 if x<0; x otherwise}"},
+		{"quad before condition", "\\begin{cases} -x & \\quad x < 0 \\\\ x & \\quad x \\ge 0 \\end{cases}", "{-1*x if x<0; x if x>=0}"},
+		{"in an equation", "f(x) = \\begin{cases} 1 & x > 0 \\\\ 0 & \\text{otherwise} \\end{cases}", "f(x)={1 if x>0; 0 otherwise}"},
+		{"after a coefficient", "2\\begin{cases} 1 & x > 0 \\\\ 0 & \\text{otherwise} \\end{cases}", "2*{1 if x>0; 0 otherwise}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := Parse(tt.input)
+			if err != nil {
+				t.Errorf("Parse(%s) returned error: %v", tt.input, err)
+				return
+			}
+
+			if result := expr.String(); result != tt.expected {
+				t.Errorf("Parse(%s).String() = %s, want %s", tt.input, result, tt.expected)
+			}
+		})
+	}
+
+	invalid := []string{
+		"\\begin{cases} x & x + 1 \\end{cases}",
+		"\\begin{cases} \\end{cases}",
+		"\\begin{cases} x & x < 0",
+		"\\begin{cases} x & \\text{sometimes} \\end{cases}",
+	}
+	for _, input := range invalid {
+		if _, err := Parse(input); err == nil {
+			t.Errorf("Parse(%s) should return an error", input)
+		}
+	}
+}
+
+func TestParseNumericLiterals(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		opts         []Options
+		expected     string
+		expectedType ast.ExprType
+	}{
+		{"big integer", "123456789012345678901234", nil, "123456789012345678901234", ast.TypeInt},
+		{"negative big integer", "-123456789012345678901234", nil, "-123456789012345678901234", ast.TypeInt},
+		{"float by default", "0.125", nil, "0.125", ast.TypeFloat},
+		{"exact decimal", "0.125", []Options{{ExactDecimals: true}}, "1/8", ast.TypeRational},
+		{"exact tenth", "0.1", []Options{{ExactDecimals: true}}, "1/10", ast.TypeRational},
+		{"negative exact decimal", "-0.5", []Options{{ExactDecimals: true}}, "-1/2", ast.TypeRational},
+		{"whole decimal", "2.0", []Options{{ExactDecimals: true}}, "2", ast.TypeInt},
+		{"leading dot", ".75", []Options{{ExactDecimals: true}}, "3/4", ast.TypeRational},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := Parse(tt.input, tt.opts...)
+			if err != nil {
+				t.Errorf("Parse(%s) returned error: %v", tt.input, err)
+				return
+			}
+
+			if result := expr.String(); result != tt.expected {
+				t.Errorf("Parse(%s).String() = %s, want %s", tt.input, result, tt.expected)
+			}
+			if expr.Type() != tt.expectedType {
+				t.Errorf("Parse(%s).Type() = %s, want %s", tt.input, expr.Type(), tt.expectedType)
+			}
+		})
+	}
+}
+
+func TestParseUnicode(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		spelled string // ASCII or LaTeX spelling that must give the same tree
+	}{
+		{"times", "2×3", "2*3"},
+		{"dot operator", "a⋅b", "a*b"},
+		{"middle dot", "a·b", "a*b"},
+		{"division sign", "6÷2", "6/2"},
+		{"unicode minus", "5 − x", "5 - x"},
+		{"square", "x²", "x^2"},
+		{"cube of group", "(x+1)³", "(x+1)^3"},
+		{"multi digit superscript", "x¹⁰", "x^10"},
+		{"negative superscript", "x⁻¹", "x^-1"},
+		{"superscript binds tighter than minus", "-x²", "-x^2"},
+		{"area of circle", "π r²", "\\pi r^2"},
+		{"sqrt", "√2", "\\sqrt{2}"},
+		{"sqrt of group", "√(x+1)", "\\sqrt{x+1}"},
+		{"cube root", "∛x", "\\sqrt[3]{x}"},
+		{"fourth root", "∜16", "\\sqrt[4]{16}"},
+		{"half", "½", "\\frac{1}{2}"},
+		{"three quarters of x", "¾x", "\\frac{3}{4}x"},
+		{"pi", "2π", "2\\pi"},
+		{"theta", "sin(θ)", "sin(\\theta)"},
+		{"greek letters", "α+β+ω", "alpha+beta+omega"},
+		{"less equal", "x ≤ 4", "x \\le 4"},
+		{"greater equal", "x ≥ 4", "x >= 4"},
+		{"not equal", "x ≠ 4", "x \\ne 4"},
+		{"chained", "0 ≤ x ≤ 1", "0 <= x <= 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse(%s) returned error: %v", tt.input, err)
+			}
+			want, err := Parse(tt.spelled)
+			if err != nil {
+				t.Fatalf("Parse(%s) returned error: %v", tt.spelled, err)
+			}
+
+			if expr.String() != want.String() {
+				t.Errorf("Parse(%s) = %s, want %s", tt.input, expr, want)
+			}
+		})
+	}
+
+	for _, input := range []string{"√", "x ≤", "⁻¹"} {
+		if _, err := Parse(input); err == nil {
+			t.Errorf("Parse(%s) should return an error", input)
+		}
+	}
+}
+
+func TestParseGreekLetters(t *testing.T) {
+	letters := []struct {
+		name     string
+		spelling []string // Unicode and LaTeX spellings of the letter
+	}{
+		{"epsilon", []string{"ε", "ϵ", "\\epsilon", "\\varepsilon"}},
+		{"theta", []string{"θ", "ϑ", "\\theta", "\\vartheta"}},
+		{"phi", []string{"φ", "ϕ", "\\phi", "\\varphi"}},
+		{"sigma", []string{"σ", "ς", "\\sigma", "\\varsigma"}},
+		{"tau", []string{"τ", "\\tau"}},
+		{"rho", []string{"ρ", "ϱ", "\\rho", "\\varrho"}},
+		{"Delta", []string{"Δ", "\\Delta"}},
+		{"Omega", []string{"Ω", "\\Omega"}},
+	}
+	for _, g := range ast.GreekLetters {
+		if g.Name != "pi" {
+			letters = append(letters, struct {
+				name     string
+				spelling []string
+			}{g.Name, []string{g.Char, "\\" + g.Name}})
+		}
+	}
+
+	for _, letter := range letters {
+		for _, spelling := range letter.spelling {
+			t.Run(spelling, func(t *testing.T) {
+				expr, err := Parse("2" + spelling + "x+1")
+				if err != nil {
+					t.Fatalf("Parse(%s) returned error: %v", spelling, err)
+				}
+				if want := "2*" + letter.name + "*x+1"; expr.String() != want {
+					t.Errorf("Parse(2%sx+1) = %s, want %s", spelling, expr, want)
+				}
+			})
+		}
+	}
+
+	// Only the spellings known before the full alphabet are read as one
+	// letter by default; any other name needs MultiLetterVariables
+	spelled := []struct {
+		input    string
+		options  Options
+		expected string
+	}{
+		{"2theta+1", Options{}, "2*theta+1"},
+		{"2omega+1", Options{}, "2*omega+1"},
+		{"2mu+1", Options{}, "2*m*u+1"},
+		{"2tau+1", Options{}, "2*t*a*u+1"},
+		{"2mu+1", Options{MultiLetterVariables: true}, "2*mu+1"},
+		{"2tau+1", Options{MultiLetterVariables: true}, "2*tau+1"},
+		{"\\pi+ϖ", Options{}, "pi+pi"},
+	}
+	for _, tt := range spelled {
+		expr, err := ParseWith(tt.input, tt.options)
+		if err != nil {
+			t.Errorf("ParseWith(%s, %+v) returned error: %v", tt.input, tt.options, err)
+			continue
+		}
+		if expr.String() != tt.expected {
+			t.Errorf("ParseWith(%s, %+v) = %s, want %s", tt.input, tt.options, expr, tt.expected)
+		}
+	}
+}
+
+func TestParseAbsoluteValue(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"|2x - 3| = 5", "abs(2*x+-3)=5"},
+		{"2|x|", "2*abs(x)"},
+		{"|x|y", "abs(x)*y"},
+		{"|x||y|", "abs(x)*abs(y)"},
+		{"|x - |y||", "abs(x+-1*abs(y))"},
+		{"-|x|^2", "-1*abs(x)^2"},
+		{"\\frac{|x|}{2}", "abs(x)*2^-1"},
+		{"\\left|x\\right| \\le 3", "abs(x)<=3"},
+		{"\\abs(x - 1)", "abs(x+-1)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			expr, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse(%s) returned error: %v", tt.input, err)
+			}
+			if expr.String() != tt.expected {
+				t.Errorf("Parse(%s) = %s, want %s", tt.input, expr, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseWith(t *testing.T) {
+	lenient := Options{Lenient: true}
+	letters := Options{MultiLetterVariables: true}
+	named := Options{Functions: []string{"f", "gcd"}, Variables: []string{"cost", "rate"}}
+
+	tests := []struct {
+		name         string
+		input        string
+		options      Options
+		expected     string
+		expectedType ast.ExprType
+	}{
+		{"kas negative zero", "-0", Options{}, "-1*0", ast.TypeMul},
+		{"lenient negative zero", "-0", lenient, "0", ast.TypeInt},
+		{"lenient unary plus", "+5", lenient, "5", ast.TypeInt},
+		{"unary plus", "2*+3", Options{UnaryPlus: true}, "2*3", ast.TypeMul},
+		{"unary plus before minus", "+-x", Options{UnaryPlus: true}, "-1*x", ast.TypeMul},
+		{"letters split", "xy", Options{}, "x*y", ast.TypeMul},
+		{"letters joined", "xy", letters, "xy", ast.TypeVar},
+		{"function after identifier", "2ab+\\sin x", letters, "2*ab+sin(x)", ast.TypeAdd},
+		{"differential after identifier", "\\int rate dx", letters, "int(rate, x)", ast.TypeFunc},
+		{"leibniz with identifiers", "dy/dx", letters, "y'", ast.TypeDerivative},
+		{"named variables", "cost*rate", named, "cost*rate", ast.TypeMul},
+		{"named variable before cos", "cost", named, "cost", ast.TypeVar},
+		{"named function", "gcd(a, b)", named, "gcd(a, b)", ast.TypeFunc},
+		{"unnamed variable multiplies", "a(b+c)", named, "a*(b+c)", ast.TypeMul},
+		{"every variable is a call", "a(b+c)", Options{}, "a(b+c)", ast.TypeFunc},
+		{"euler's number", "e", Options{}, "e", ast.TypeConst},
+		{"variable e", "e", Options{VariableE: true}, "e", ast.TypeVar},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := ParseWith(tt.input, tt.options)
+			if err != nil {
+				t.Fatalf("ParseWith(%s) returned error: %v", tt.input, err)
+			}
+
+			if result := expr.String(); result != tt.expected {
+				t.Errorf("ParseWith(%s).String() = %s, want %s", tt.input, result, tt.expected)
+			}
+			if expr.Type() != tt.expectedType {
+				t.Errorf("ParseWith(%s).Type() = %s, want %s", tt.input, expr.Type(), tt.expectedType)
+			}
+		})
+	}
+
+	for _, input := range []string{"+5", "2*+3"} {
+		if _, err := ParseWith(input, Options{}); err == nil {
+			t.Errorf("ParseWith(%s) should reject unary plus by default", input)
+		}
+	}
+	if expr, err := ParseAsciiMath("xy+ab", letters); err != nil || expr.String() != "xy+ab" {
+		t.Errorf("ParseAsciiMath(xy+ab) = %v, %v, want xy+ab", expr, err)
+	}
+}
+
+func TestParseNumberForms(t *testing.T) {
+	mixed := Options{MixedNumbers: true}
+	percent := Options{Percent: true}
+	scientific := Options{ScientificNotation: true}
+	repeating := Options{RepeatingDecimals: true}
+	european := Options{Locale: LocaleEuropean}
+
+	tests := []struct {
+		name         string
+		input        string
+		options      Options
+		expected     string
+		expectedType ast.ExprType
+	}{
+		{"mixed number", "2 1/2", mixed, "5/2", ast.TypeRational},
+		{"negative mixed number", "-2 1/2", mixed, "-5/2", ast.TypeRational},
+		{"mixed number with frac", "3\\frac{3}{4}", mixed, "15/4", ast.TypeRational},
+		{"mixed number with vulgar fraction", "2½", mixed, "5/2", ast.TypeRational},
+		{"mixed number times variable", "1 1/2 x", mixed, "3/2*x", ast.TypeMul},
+		{"unspaced fraction is not mixed", "21/2", mixed, "21*2^-1", ast.TypeMul},
+		{"percent", "35%", percent, "7/20", ast.TypeRational},
+		{"whole percent", "200\\%", percent, "2", ast.TypeInt},
+		{"decimal percent", "12.5%", percent, "1/8", ast.TypeRational},
+		{"negative percent", "-35%", percent, "-7/20", ast.TypeRational},
+		{"percent of a variable", "x%", percent, "x*1/100", ast.TypeMul},
+		{"times ten to the power", "3.2 \\times 10^{4}", scientific, "32000", ast.TypeInt},
+		{"negative power of ten", "5\\cdot10^{-2}", scientific, "1/20", ast.TypeRational},
+		{"superscript power of ten", "4×10⁴", scientific, "40000", ast.TypeInt},
+		{"e notation", "6.02e23", scientific, "602000000000000000000000", ast.TypeInt},
+		{"negative e notation", "1.5E-3", scientific, "3/2000", ast.TypeRational},
+		{"spaced e is euler's number", "2 e3", scientific, "2*e*3", ast.TypeMul},
+		{"variable power of ten", "2\\times 10^x", scientific, "2*10^x", ast.TypeMul},
+		{"overline repeating decimal", "0.\\overline{3}", repeating, "1/3", ast.TypeRational},
+		{"parenthesized repeating decimal", "0.(3)", repeating, "1/3", ast.TypeRational},
+		{"delayed repetend", "0.1\\overline{6}", repeating, "1/6", ast.TypeRational},
+		{"repetend with leading zero", "1.2(03)", repeating, "397/330", ast.TypeRational},
+		{"repeating nines", "0.(9)", repeating, "1", ast.TypeInt},
+		{"spaced parentheses multiply", "0.5 (3)", repeating, "0.5*3", ast.TypeMul},
+		{"decimal comma", "3,5", european, "3.5", ast.TypeFloat},
+		{"thousands point", "1.234,5 + x", european, "1234.5+x", ast.TypeAdd},
+		{"semicolon separates arguments", "f(3,5; 2)", european, "f(3.5, 2)", ast.TypeFunc},
+		{"one decimal-comma argument", "f(3,5)", european, "f(3.5)", ast.TypeFunc},
+		{"semicolon separates elements", "(1; 2,5)", european, "(1, 2.5)", ast.TypeTuple},
+		{"comma separates a list by default", "1,000", Options{}, "{1, 0}", ast.TypeSet},
+		{"thousands comma", "1,234,567", Options{Locale: LocaleUS}, "1234567", ast.TypeInt},
+		{"comma without groups of three", "f(1,23)", Options{Locale: LocaleUS}, "f(1, 23)", ast.TypeFunc},
+		{"comma separates arguments", "f(1,234)", Options{Locale: LocaleUS}, "f(1, 234)", ast.TypeFunc},
+		{"comma separates tuple elements", "(1,234.5)", Options{Locale: LocaleUS}, "(1, 234.5)", ast.TypeTuple},
+		{"grouped fraction", "\\frac{1,234}{2}", Options{Locale: LocaleUS}, "1234*2^-1", ast.TypeMul},
+		{"thousands space", "1 234,5", Options{Locale: LocaleSI}, "1234.5", ast.TypeFloat},
+		{"repeating decimal comma", "0,(3)", Options{Locale: LocaleEuropean, RepeatingDecimals: true}, "1/3", ast.TypeRational},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := ParseWith(tt.input, tt.options)
+			if err != nil {
+				t.Fatalf("ParseWith(%s) returned error: %v", tt.input, err)
+			}
+
+			if result := expr.String(); result != tt.expected {
+				t.Errorf("ParseWith(%s).String() = %s, want %s", tt.input, result, tt.expected)
+			}
+			if expr.Type() != tt.expectedType {
+				t.Errorf("ParseWith(%s).Type() = %s, want %s", tt.input, expr.Type(), tt.expectedType)
+			}
+		})
+	}
+
+	// Without the option the percent sign is not a token
+	if _, err := Parse("%"); err == nil {
+		t.Errorf("Parse(%%) should return an error")
+	}
+
+	// With a decimal comma only a semicolon separates arguments
+	for _, input := range []string{"f(3, 5)", "1, 2"} {
+		_, err := ParseWith(input, european)
+		if parseErr, ok := err.(*ParseError); !ok || parseErr.Code != ErrInvalidCharacter {
+			t.Errorf("ParseWith(%s) returned %v, want an invalid character error", input, err)
+		}
+	}
+
+	invalid := []struct {
+		name       string
+		input      string
+		options    Options
+		start, end int
+	}{
+		{"point without groups of three", "12.34", european, 0, 5},
+		{"point after four digits", "x + 1234.567", european, 4, 12},
+		{"empty overline", "0.\\overline{}", repeating, 2, 13},
+		{"digits after an overline", "0.\\overline{12}3", repeating, 0, 16},
+		{"digits after a parenthesized block", "0.1(6)3", repeating, 0, 7},
+		{"comma after a group of two", "1,000,00", Options{Locale: LocaleUS}, 0, 8},
+		{"improper mixed number", "2 3/2", mixed, 0, 5},
+		{"improper mixed number in a sum", "2 3/2 + 1", mixed, 0, 5},
+		{"improper mixed number with frac", "2\\frac{3}{2}", mixed, 0, 12},
+		{"mixed number over zero", "2 1/0", mixed, 0, 5},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseWith(tt.input, tt.options)
+			parseErr, ok := err.(*ParseError)
+			if !ok {
+				t.Fatalf("ParseWith(%s) returned %v, want a ParseError", tt.input, err)
+			}
+			if parseErr.Code != ErrInvalidNumber || parseErr.Start != tt.start || parseErr.End != tt.end {
+				t.Errorf("ParseWith(%s) error = %s [%d, %d), want %s [%d, %d)", tt.input,
+					parseErr.Code, parseErr.Start, parseErr.End, ErrInvalidNumber, tt.start, tt.end)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package parser
+
+import (
+	"github.com/quizizz/cas/pkg/ast"
+)
+
+// mark records the span of a node parsed from start up to the last consumed
+// token. A node that already has a span, such as the inner expression of
+// (x + 1), keeps it.
+func (p *Parser) mark(expr ast.Expr, start int) ast.Expr {
+	return setSpan(expr, ast.Span{Start: start, End: p.end})
+}
+
+// markToken records the span of a single token on a node built from it
+func markToken(expr ast.Expr, token Token) ast.Expr {
+	return setSpan(expr, ast.Span{Start: token.Pos, End: token.End})
+}
+
+// setSpan records span on expr unless it already has one
+func setSpan(expr ast.Expr, span ast.Span) ast.Expr {
+	if s, ok := expr.(ast.Spanned); ok {
+		if _, known := s.Span(); !known {
+			s.SetSpan(span)
+		}
+	}
+	return expr
+}
+
+// fillSpans gives each node without a span of its own, such as the -4
+// that -1*4 is folded into, the span of its parent
+func fillSpans(expr ast.Expr, parent ast.Span) {
+	setSpan(expr, parent)
+	span, _ := ast.SpanOf(expr)
+	for _, child := range ast.Children(expr) {
+		fillSpans(child, span)
+	}
+}
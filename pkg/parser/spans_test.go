@@ -0,0 +1,100 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/quizizz/cas/pkg/ast"
+)
+
+func TestParseSpans(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		// source text of the root and of each of its children
+		expected []string
+	}{
+		{"equation", "2x - 3 = 7", []string{"2x - 3 = 7", "2x - 3", "7"}},
+		{"sum", "\\frac{a}{b} + \\sqrt{x}", []string{"\\frac{a}{b} + \\sqrt{x}", "\\frac{a}{b}", "\\sqrt{x}"}},
+		{"division", "(x+1)^2 / y", []string{"(x+1)^2 / y", "(x+1)^2", "/ y"}},
+		{"parenthesized", "(x+1)", []string{"x+1", "x", "1"}},
+		{"function", "\\sin x", []string{"\\sin x", "x"}},
+		{"answer list", "x = 2, x = -1", []string{"x = 2, x = -1", "x = 2", "x = -1"}},
+		{"chain", "0 < x \\le 1", []string{"0 < x \\le 1", "0", "x", "1"}},
+		{"matrix", "[[1, 2], [3, a]]", []string{"[[1, 2], [3, a]]", "1", "2", "3", "a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse(%s) returned error: %v", tt.input, err)
+			}
+
+			nodes := append([]ast.Expr{expr}, ast.Children(expr)...)
+			if len(nodes) != len(tt.expected) {
+				t.Fatalf("Parse(%s) has %d children, want %d", tt.input, len(nodes)-1, len(tt.expected)-1)
+			}
+			for i, node := range nodes {
+				if got := sourceText(t, tt.input, node); got != tt.expected[i] {
+					t.Errorf("span of %s = %q, want %q", node, got, tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseSpansCoverEveryNode(t *testing.T) {
+	inputs := []string{
+		"-x + 4 - 2/y",
+		"-4 * -0",
+		"dy/dx = 3y",
+		"\\sqrt[3]{x} + e^{2\\pi}",
+		"f'(t) = \\ln t",
+		"abs(x - 1) \\ge 2",
+	}
+
+	for _, input := range inputs {
+		expr, err := Parse(input)
+		if err != nil {
+			t.Fatalf("Parse(%s) returned error: %v", input, err)
+		}
+		checkSpans(t, input, expr, ast.Span{Start: 0, End: len(input)})
+		checkSpans(t, input, expr.Clone(), ast.Span{Start: 0, End: len(input)})
+	}
+}
+
+func TestParseSpansLeaveConstantsUnchanged(t *testing.T) {
+	if _, err := Parse("x + pi"); err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	if _, ok := ast.SpanOf(ast.Pi); ok {
+		t.Errorf("parsing should not record a span on the shared pi constant")
+	}
+}
+
+// checkSpans verifies that every node has a span that lies within its
+// parent's span
+func checkSpans(t *testing.T, input string, expr ast.Expr, parent ast.Span) {
+	t.Helper()
+	span, ok := ast.SpanOf(expr)
+	if !ok {
+		t.Errorf("Parse(%s): node %s has no span", input, expr)
+		return
+	}
+	if span.Start < parent.Start || span.End > parent.End || span.Start > span.End {
+		t.Errorf("Parse(%s): node %s has span %v outside %v", input, expr, span, parent)
+	}
+	for _, child := range ast.Children(expr) {
+		checkSpans(t, input, child, span)
+	}
+}
+
+func sourceText(t *testing.T, input string, expr ast.Expr) string {
+	t.Helper()
+	span, ok := ast.SpanOf(expr)
+	if !ok {
+		t.Errorf("node %s has no span", expr)
+		return ""
+	}
+	return input[span.Start:span.End]
+}
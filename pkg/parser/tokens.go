@@ -2,7 +2,11 @@
 package parser
 
 import (
-	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/quizizz/cas/pkg/ast"
 )
 
 // TokenType represents the type of a token
@@ -54,6 +58,15 @@ const (
 	TokenSec
 	TokenCsc
 	TokenCot
+	TokenArcsec
+	TokenArccsc
+	TokenArccot
+	TokenArcsinh
+	TokenArccosh
+	TokenArctanh
+	TokenSech
+	TokenCsch
+	TokenCoth
 	TokenAbs
 	TokenPi
 	TokenE
@@ -61,6 +74,24 @@ const (
 	TokenPhi
 	TokenComma
 	TokenExclamation
+	TokenPrime
+	TokenBeginMatrix
+	TokenEndMatrix
+	TokenAmpersand
+	TokenRowSeparator
+	TokenVulgarFraction
+	TokenSum
+	TokenIntegral
+	TokenRoot
+	TokenPercent
+	TokenOverline
+	TokenPlusMinus
+	TokenDegree
+	TokenDoublePrime
+	TokenUnit
+	TokenBeginCases
+	TokenEndCases
+	TokenText
 	TokenError
 )
 
@@ -68,7 +99,10 @@ const (
 type Token struct {
 	Type  TokenType
 	Value string
-	Pos   int
+	// Pos and End are the byte offsets of the token in the input; End is
+	// exclusive
+	Pos int
+	End int
 }
 
 // String returns a string representation of the token type
@@ -104,8 +138,34 @@ func (t TokenType) String() string {
 		return "["
 	case TokenRightBracket:
 		return "]"
+	case TokenPipe, TokenLeftPipe, TokenRightPipe:
+		return "|"
+	case TokenEquals:
+		return "="
+	case TokenLessEqual:
+		return "<="
+	case TokenGreaterEqual:
+		return ">="
+	case TokenLess:
+		return "<"
+	case TokenGreater:
+		return ">"
+	case TokenNotEqual:
+		return "<>"
 	case TokenSqrt:
 		return "sqrt"
+	case TokenFrac:
+		return "\\frac"
+	case TokenDfrac:
+		return "\\dfrac"
+	case TokenLeft:
+		return "\\left"
+	case TokenRight:
+		return "\\right"
+	case TokenSubscript:
+		return "_"
+	case TokenSuperscript:
+		return "^"
 	case TokenLn:
 		return "ln"
 	case TokenLog:
@@ -116,12 +176,92 @@ func (t TokenType) String() string {
 		return "cos"
 	case TokenTan:
 		return "tan"
+	case TokenArcsin:
+		return "arcsin"
+	case TokenArccos:
+		return "arccos"
+	case TokenArctan:
+		return "arctan"
+	case TokenSinh:
+		return "sinh"
+	case TokenCosh:
+		return "cosh"
+	case TokenTanh:
+		return "tanh"
+	case TokenSec:
+		return "sec"
+	case TokenCsc:
+		return "csc"
+	case TokenCot:
+		return "cot"
+	case TokenArcsec:
+		return "arcsec"
+	case TokenArccsc:
+		return "arccsc"
+	case TokenArccot:
+		return "arccot"
+	case TokenArcsinh:
+		return "arcsinh"
+	case TokenArccosh:
+		return "arccosh"
+	case TokenArctanh:
+		return "arctanh"
+	case TokenSech:
+		return "sech"
+	case TokenCsch:
+		return "csch"
+	case TokenCoth:
+		return "coth"
+	case TokenAbs:
+		return "abs"
 	case TokenPi:
 		return "pi"
 	case TokenE:
 		return "e"
+	case TokenTheta:
+		return "theta"
+	case TokenPhi:
+		return "phi"
 	case TokenComma:
 		return ","
+	case TokenExclamation:
+		return "!"
+	case TokenPrime:
+		return "'"
+	case TokenBeginMatrix:
+		return "\\begin{pmatrix}"
+	case TokenEndMatrix:
+		return "\\end{pmatrix}"
+	case TokenAmpersand:
+		return "&"
+	case TokenRowSeparator:
+		return "\\\\"
+	case TokenVulgarFraction:
+		return "fraction"
+	case TokenSum:
+		return "sum"
+	case TokenIntegral:
+		return "int"
+	case TokenRoot:
+		return "root"
+	case TokenPercent:
+		return "%"
+	case TokenOverline:
+		return "\\overline"
+	case TokenPlusMinus:
+		return "±"
+	case TokenDegree:
+		return "°"
+	case TokenDoublePrime:
+		return "''"
+	case TokenUnit:
+		return "unit"
+	case TokenBeginCases:
+		return "\\begin{cases}"
+	case TokenEndCases:
+		return "\\end{cases}"
+	case TokenText:
+		return "text"
 	case TokenError:
 		return "ERROR"
 	default:
@@ -129,210 +269,684 @@ func (t TokenType) String() string {
 	}
 }
 
-// TokenRule represents a tokenization rule
-type TokenRule struct {
-	Pattern   *regexp.Regexp
-	TokenType TokenType
-	Transform func(string) string // Optional transformation function
+// rule is one entry of the scanner table. A rule either matches a literal
+// prefix or, for numbers, letters, superscripts and matrix environments,
+// calls a matcher that returns the length of the match (0 for none).
+type rule struct {
+	literal   string
+	match     func(s string) int
+	starts    string // bytes a matcher's match can begin with
+	tokenType TokenType
+	value     string                 // replaces the matched text when set
+	convert   func(string) string    // rewrites the matched text when set
+	retype    func(string) TokenType // chooses the type from the text when set
+}
+
+// rules are tried in order and the first match wins, so a shorter literal
+// listed before a longer one shadows it (\le before \leq, = before =/=)
+var rules = []rule{
+	// Numbers - float first to match decimals properly
+	{match: matchFloat, starts: digits, tokenType: TokenFloat},        // Handle "1." and "1.23"
+	{match: matchLeadingDotFloat, starts: ".", tokenType: TokenFloat}, // Handle ".5"
+	{match: matchDigits, starts: digits, tokenType: TokenInt},
+
+	// Operators
+	{literal: "**", tokenType: TokenPower},
+	{literal: "*", tokenType: TokenMultiply},
+	{literal: "\\cdot", tokenType: TokenMultiply},
+	{literal: "\\times", tokenType: TokenMultiply},
+	{literal: "\\ast", tokenType: TokenMultiply},
+	{literal: "/", tokenType: TokenDivide},
+	{literal: "\\div", tokenType: TokenDivide},
+	{literal: "-", tokenType: TokenMinus},
+	{literal: "\u2212", tokenType: TokenMinus}, // Unicode minus
+	{literal: "+", tokenType: TokenPlus},
+	{literal: "\\pm", tokenType: TokenPlusMinus},
+	{literal: "\u00b1", tokenType: TokenPlusMinus},
+	{literal: "^", tokenType: TokenPower},
+
+	// Parentheses and brackets
+	{literal: "(", tokenType: TokenLeftParen},
+	{literal: ")", tokenType: TokenRightParen},
+	{literal: "\\left(", tokenType: TokenLeftParen},
+	{literal: "\\right)", tokenType: TokenRightParen},
+	{literal: "[", tokenType: TokenLeftBracket},
+	{literal: "]", tokenType: TokenRightBracket},
+	{literal: "{", tokenType: TokenLeftBrace},
+	{literal: "}", tokenType: TokenRightBrace},
+	{literal: "\\left{", tokenType: TokenLeftBrace},
+	{literal: "\\right}", tokenType: TokenRightBrace},
+	{literal: "\\left\\{", tokenType: TokenLeftBrace},
+	{literal: "\\right\\}", tokenType: TokenRightBrace},
+	{literal: "\\{", tokenType: TokenLeftBrace},
+	{literal: "\\}", tokenType: TokenRightBrace},
+	{literal: "\\left|", tokenType: TokenLeftPipe}, // before \le, which it starts with
+	{literal: "\\right|", tokenType: TokenRightPipe},
+
+	// Matrix and cases environments
+	{literal: "\\begin{cases}", tokenType: TokenBeginCases},
+	{literal: "\\end{cases}", tokenType: TokenEndCases},
+	{match: matchEnvironment("\\begin{"), starts: "\\", tokenType: TokenBeginMatrix},
+	{match: matchEnvironment("\\end{"), starts: "\\", tokenType: TokenEndMatrix},
+	{literal: "&", tokenType: TokenAmpersand},
+	{literal: "\\\\", tokenType: TokenRowSeparator},
+
+	// Comparison operators
+	{literal: "<=", tokenType: TokenLessEqual},
+	{literal: ">=", tokenType: TokenGreaterEqual},
+	{literal: "<>", tokenType: TokenNotEqual},
+	{literal: "<", tokenType: TokenLess},
+	{literal: ">", tokenType: TokenGreater},
+	{literal: "=", tokenType: TokenEquals},
+	{literal: "\\le", tokenType: TokenLessEqual, value: "<="},
+	{literal: "\\ge", tokenType: TokenGreaterEqual, value: ">="},
+	{literal: "\\leq", tokenType: TokenLessEqual, value: "<="},
+	{literal: "\\geq", tokenType: TokenGreaterEqual, value: ">="},
+	{literal: "=/=", tokenType: TokenNotEqual, value: "<>"},
+	{literal: "\\ne", tokenType: TokenNotEqual, value: "<>"},
+
+	// Functions and special symbols
+	{literal: "\\sqrt", tokenType: TokenSqrt},
+	{literal: "\\frac", tokenType: TokenFrac},
+	{literal: "\\dfrac", tokenType: TokenDfrac},
+	{literal: "\\ln", tokenType: TokenLn},
+	{literal: "\\log", tokenType: TokenLog},
+	{literal: "\\sum", tokenType: TokenSum},
+	{literal: "\\int", tokenType: TokenIntegral},
+	{literal: "\\abs", tokenType: TokenAbs, value: "abs"},
+
+	// Inverse, hyperbolic and trigonometric functions; a name comes before
+	// any name it is a prefix of (\arcsinh before \arcsin, \sinh before \sin)
+	{literal: "\\arcsinh", tokenType: TokenArcsinh},
+	{literal: "\\arccosh", tokenType: TokenArccosh},
+	{literal: "\\arctanh", tokenType: TokenArctanh},
+	{literal: "\\arcsin", tokenType: TokenArcsin},
+	{literal: "\\arccos", tokenType: TokenArccos},
+	{literal: "\\arctan", tokenType: TokenArctan},
+	{match: matchReciprocal("\\arcsec"), starts: "\\", tokenType: TokenArcsec},
+	{match: matchReciprocal("\\arccsc"), starts: "\\", tokenType: TokenArccsc},
+	{match: matchReciprocal("\\arccot"), starts: "\\", tokenType: TokenArccot},
+	{literal: "\\sinh", tokenType: TokenSinh},
+	{literal: "\\cosh", tokenType: TokenCosh},
+	{literal: "\\tanh", tokenType: TokenTanh},
+	{literal: "\\sin", tokenType: TokenSin},
+	{literal: "\\cos", tokenType: TokenCos},
+	{literal: "\\tan", tokenType: TokenTan},
+	{literal: "\\sech", tokenType: TokenSech},
+	{literal: "\\csch", tokenType: TokenCsch},
+	{literal: "\\coth", tokenType: TokenCoth},
+	{literal: "\\sec", tokenType: TokenSec},
+	{literal: "\\csc", tokenType: TokenCsc},
+	{literal: "\\cot", tokenType: TokenCot},
+
+	// Functions without a LaTeX command of their own, as in
+	// \operatorname{arcsec}; other names are variables, so that
+	// \operatorname{sgn}(x) is a call
+	{match: matchOperatorName, starts: "\\", tokenType: TokenVar, convert: operatorName, retype: functionToken},
+
+	// Constants (must be before single char variables)
+	{literal: "pi", tokenType: TokenPi, value: "pi"},
+	{literal: "\\pi", tokenType: TokenPi, value: "pi"},
+
+	// Greek letters, named as in their ASCII spelling: \alpha, α and their
+	// variants such as \varphi and ϕ
+	{match: matchGreek, starts: "\\\xce\xcf", tokenType: TokenVar, convert: greekName, retype: greekToken},
+
+	// Functions (must be before single char variables)
+	{literal: "sqrt", tokenType: TokenSqrt},
+	{literal: "abs", tokenType: TokenAbs},
+	{literal: "ln", tokenType: TokenLn},
+	{literal: "log", tokenType: TokenLog},
+	{literal: "arcsinh", tokenType: TokenArcsinh},
+	{literal: "arccosh", tokenType: TokenArccosh},
+	{literal: "arctanh", tokenType: TokenArctanh},
+	{literal: "arcsin", tokenType: TokenArcsin},
+	{literal: "arccos", tokenType: TokenArccos},
+	{literal: "arctan", tokenType: TokenArctan},
+	{literal: "arcsec", tokenType: TokenArcsec},
+	{literal: "arccsc", tokenType: TokenArccsc},
+	{literal: "arccot", tokenType: TokenArccot},
+	{literal: "sinh", tokenType: TokenSinh},
+	{literal: "cosh", tokenType: TokenCosh},
+	{literal: "tanh", tokenType: TokenTanh},
+	{literal: "sin", tokenType: TokenSin},
+	{literal: "cos", tokenType: TokenCos},
+	{literal: "tan", tokenType: TokenTan},
+	{literal: "sech", tokenType: TokenSech},
+	{literal: "csch", tokenType: TokenCsch},
+	{literal: "coth", tokenType: TokenCoth},
+	{literal: "sec", tokenType: TokenSec},
+	{literal: "csc", tokenType: TokenCsc},
+	{literal: "cot", tokenType: TokenCot},
+
+	// Known multi-character variables and constants (must be before single char variables)
+	{literal: "theta", tokenType: TokenVar},
+	{literal: "alpha", tokenType: TokenVar},
+	{literal: "beta", tokenType: TokenVar},
+	{literal: "gamma", tokenType: TokenVar},
+	{literal: "delta", tokenType: TokenVar},
+	{literal: "epsilon", tokenType: TokenVar},
+	{literal: "phi", tokenType: TokenVar},
+	{literal: "psi", tokenType: TokenVar},
+	{literal: "omega", tokenType: TokenVar},
+
+	// Other symbols
+	{literal: "_", tokenType: TokenSubscript},
+	{literal: "|", tokenType: TokenPipe},
+	{literal: ",", tokenType: TokenComma},
+	{literal: "!", tokenType: TokenExclamation},
+	{literal: "'", tokenType: TokenPrime},
+	{literal: "\u2032", tokenType: TokenPrime},       // ′
+	{literal: "\u2033", tokenType: TokenDoublePrime}, // ″, seconds of arc
+	{literal: "\u00b0", tokenType: TokenDegree},      // °
+	{literal: "\\circ", tokenType: TokenDegree},      // as in 30^\circ
+	{literal: "\\degree", tokenType: TokenDegree},
+	{match: matchText, starts: "\\", tokenType: TokenText, convert: textContent}, // \text{otherwise}
+
+	// Unicode operators and relations, as typed on mobile keyboards
+	{literal: "\u00d7", tokenType: TokenMultiply},                  // ×
+	{literal: "\u22c5", tokenType: TokenMultiply},                  // ⋅
+	{literal: "\u00b7", tokenType: TokenMultiply},                  // ·
+	{literal: "\u2219", tokenType: TokenMultiply},                  // ∙
+	{literal: "\u2217", tokenType: TokenMultiply},                  // ∗
+	{literal: "\u00f7", tokenType: TokenDivide},                    // ÷
+	{literal: "\u2215", tokenType: TokenDivide},                    // ∕
+	{literal: "\u2044", tokenType: TokenDivide},                    // ⁄
+	{literal: "\u2264", tokenType: TokenLessEqual, value: "<="},    // ≤
+	{literal: "\u2a7d", tokenType: TokenLessEqual, value: "<="},    // ⩽
+	{literal: "\u2265", tokenType: TokenGreaterEqual, value: ">="}, // ≥
+	{literal: "\u2a7e", tokenType: TokenGreaterEqual, value: ">="}, // ⩾
+	{literal: "\u2260", tokenType: TokenNotEqual, value: "<>"},     // ≠
+	{literal: "\u221a", tokenType: TokenSqrt},                      // √
+	{literal: "\u221b", tokenType: TokenSqrt},                      // ∛
+	{literal: "\u221c", tokenType: TokenSqrt},                      // ∜
+
+	// Superscript exponents such as x² and x⁻¹
+	{match: matchSuperscript, starts: "\xc2\xe2", tokenType: TokenSuperscript, convert: superscriptValue},
+
+	// Vulgar fractions such as ½, whose value is written out as "1/2"
+	{literal: "\u00bd", tokenType: TokenVulgarFraction, value: "1/2"},
+	{literal: "\u2153", tokenType: TokenVulgarFraction, value: "1/3"},
+	{literal: "\u2154", tokenType: TokenVulgarFraction, value: "2/3"},
+	{literal: "\u00bc", tokenType: TokenVulgarFraction, value: "1/4"},
+	{literal: "\u00be", tokenType: TokenVulgarFraction, value: "3/4"},
+	{literal: "\u2155", tokenType: TokenVulgarFraction, value: "1/5"},
+	{literal: "\u2156", tokenType: TokenVulgarFraction, value: "2/5"},
+	{literal: "\u2157", tokenType: TokenVulgarFraction, value: "3/5"},
+	{literal: "\u2158", tokenType: TokenVulgarFraction, value: "4/5"},
+	{literal: "\u2159", tokenType: TokenVulgarFraction, value: "1/6"},
+	{literal: "\u215a", tokenType: TokenVulgarFraction, value: "5/6"},
+	{literal: "\u2150", tokenType: TokenVulgarFraction, value: "1/7"},
+	{literal: "\u215b", tokenType: TokenVulgarFraction, value: "1/8"},
+	{literal: "\u215c", tokenType: TokenVulgarFraction, value: "3/8"},
+	{literal: "\u215d", tokenType: TokenVulgarFraction, value: "5/8"},
+	{literal: "\u215e", tokenType: TokenVulgarFraction, value: "7/8"},
+	{literal: "\u2151", tokenType: TokenVulgarFraction, value: "1/9"},
+	{literal: "\u2152", tokenType: TokenVulgarFraction, value: "1/10"},
+
+	// Single character variables (everything else should be parsed as individual chars for implicit multiplication)
+	{match: matchLetter, starts: letters, tokenType: TokenVar},
+}
+
+const (
+	digits  = "0123456789"
+	letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+)
+
+// rulesByFirstByte lists, for each possible first byte, the rules that can
+// match there in table order. Built once, it lets NextToken skip every rule
+// that cannot apply.
+var rulesByFirstByte = indexRules(rules)
+
+func indexRules(rules []rule) [256][]*rule {
+	var index [256][]*rule
+	for i := range rules {
+		r := &rules[i]
+		if r.literal != "" {
+			index[r.literal[0]] = append(index[r.literal[0]], r)
+			continue
+		}
+		for j := 0; j < len(r.starts); j++ {
+			index[r.starts[j]] = append(index[r.starts[j]], r)
+		}
+	}
+	return index
+}
+
+// matchDigits matches [0-9]+
+func matchDigits(s string) int {
+	n := 0
+	for n < len(s) && isDigit(s[n]) {
+		n++
+	}
+	return n
+}
+
+// matchFloat matches [0-9]+\.[0-9]*
+func matchFloat(s string) int {
+	n := matchDigits(s)
+	if n == 0 || n >= len(s) || s[n] != '.' {
+		return 0
+	}
+	return n + 1 + matchDigits(s[n+1:])
+}
+
+// matchLeadingDotFloat matches \.[0-9]+
+func matchLeadingDotFloat(s string) int {
+	if len(s) < 2 || s[0] != '.' {
+		return 0
+	}
+	if n := matchDigits(s[1:]); n > 0 {
+		return n + 1
+	}
+	return 0
+}
+
+// matchLetter matches a single ASCII letter
+func matchLetter(s string) int {
+	if len(s) > 0 && strings.IndexByte(letters, s[0]) >= 0 {
+		return 1
+	}
+	return 0
+}
+
+// matchIdentifier matches a run of ASCII letters, except that d followed by
+// a single letter is left to be read as a differential such as dx
+func matchIdentifier(s string) int {
+	n := 0
+	for n < len(s) && strings.IndexByte(letters, s[n]) >= 0 {
+		n++
+	}
+	if n == 2 && s[0] == 'd' {
+		return 1
+	}
+	return n
+}
+
+// superscriptDigits maps each superscript digit to its ASCII digit
+var superscriptDigits = map[rune]byte{
+	'\u2070': '0', '\u00b9': '1', '\u00b2': '2', '\u00b3': '3', '\u2074': '4',
+	'\u2075': '5', '\u2076': '6', '\u2077': '7', '\u2078': '8', '\u2079': '9',
+}
+
+// matchSuperscript matches an optional superscript sign (⁺ or ⁻) followed
+// by one or more superscript digits
+func matchSuperscript(s string) int {
+	n := 0
+	if r, size := utf8.DecodeRuneInString(s); r == '\u207a' || r == '\u207b' {
+		n = size
+	}
+	digitsStart := n
+	for n < len(s) {
+		r, size := utf8.DecodeRuneInString(s[n:])
+		if _, ok := superscriptDigits[r]; !ok {
+			break
+		}
+		n += size
+	}
+	if n == digitsStart {
+		return 0
+	}
+	return n
+}
+
+// superscriptValue rewrites a superscript matched by matchSuperscript as
+// an ASCII integer, so ⁻¹² becomes "-12"
+func superscriptValue(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		switch r {
+		case '\u207b':
+			sb.WriteByte('-')
+		case '\u207a':
+		default:
+			sb.WriteByte(superscriptDigits[r])
+		}
+	}
+	return sb.String()
+}
+
+// matchEnvironment returns a matcher for prefix followed by an optional
+// p, b, B, v or V and then "matrix}"
+func matchEnvironment(prefix string) func(string) int {
+	return func(s string) int {
+		if !strings.HasPrefix(s, prefix) {
+			return 0
+		}
+		n := len(prefix)
+		if n < len(s) && strings.IndexByte("pbBvV", s[n]) >= 0 {
+			n++
+		}
+		if !strings.HasPrefix(s[n:], "matrix}") {
+			return 0
+		}
+		return n + len("matrix}")
+	}
+}
+
+// matchReciprocal returns a matcher for the command of an inverse
+// reciprocal function that is not followed by an h, which would make it
+// a hyperbolic function such as \arccoth rather than \arccot h. The name
+// may run into its argument, as in \arcsecx.
+func matchReciprocal(name string) func(string) int {
+	return func(s string) int {
+		if !strings.HasPrefix(s, name) || strings.HasPrefix(s[len(name):], "h") {
+			return 0
+		}
+		return len(name)
+	}
+}
+
+// matchGreek matches a Greek letter, written as its Unicode character or
+// as its LaTeX command
+func matchGreek(s string) int {
+	n := 0
+	for _, g := range greekSpellings {
+		if len(g) > n && strings.HasPrefix(s, g) {
+			n = len(g)
+		}
+	}
+	return n
+}
+
+// greekSpellings are the texts matchGreek accepts
+var greekSpellings = func() []string {
+	var spellings []string
+	for _, g := range ast.GreekLetters {
+		spellings = append(spellings, g.Char, "\\"+g.Name)
+	}
+	for _, v := range ast.GreekVariants {
+		spellings = append(spellings, v.Char, "\\"+v.Name)
+	}
+	return spellings
+}()
+
+// greekName returns the variable name of the letter matched by matchGreek
+func greekName(s string) string {
+	name, _ := ast.GreekName(strings.TrimPrefix(s, "\\"))
+	return name
+}
+
+// greekToken reads π and its variant ϖ as the constant
+func greekToken(name string) TokenType {
+	if name == "pi" {
+		return TokenPi
+	}
+	return TokenVar
+}
+
+// matchText matches text set in roman type, as in \text{otherwise} or
+// \textrm{if }
+func matchText(s string) int {
+	for _, command := range []string{"\\text{", "\\textrm{"} {
+		if strings.HasPrefix(s, command) {
+			if end := strings.IndexByte(s, '}'); end >= 0 {
+				return end + 1
+			}
+		}
+	}
+	return 0
+}
+
+// matchOperatorName matches a name set as an operator, as in
+// \operatorname{arcsec}, allowing spaces around the braces
+func matchOperatorName(s string) int {
+	const command = "\\operatorname"
+	if !strings.HasPrefix(s, command) {
+		return 0
+	}
+	n := len(command)
+	n += skipBlanks(s[n:])
+	if n >= len(s) || s[n] != '{' {
+		return 0
+	}
+	n++
+	n += skipBlanks(s[n:])
+	name := matchIdentifier(s[n:])
+	if name == 0 {
+		return 0
+	}
+	n += name
+	n += skipBlanks(s[n:])
+	if n >= len(s) || s[n] != '}' {
+		return 0
+	}
+	return n + 1
+}
+
+// operatorName returns the name matched by matchOperatorName
+func operatorName(s string) string {
+	return strings.TrimSpace(s[strings.IndexByte(s, '{')+1 : len(s)-1])
+}
+
+// functionToken returns the token of a named function, and TokenVar for
+// any other name
+func functionToken(name string) TokenType {
+	if long, ok := areaNames[name]; ok {
+		name = long
+	}
+	for _, f := range functions {
+		if f.name == name {
+			return f.tokenType
+		}
+	}
+	return TokenVar
+}
+
+// skipBlanks returns the number of leading spaces in s
+func skipBlanks(s string) int {
+	n := 0
+	for n < len(s) && isSpace(s[n]) {
+		n++
+	}
+	return n
+}
+
+// textContent returns the trimmed text matched by matchText
+func textContent(s string) string {
+	return strings.TrimSpace(s[strings.IndexByte(s, '{')+1 : len(s)-1])
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// skipSpace returns the length of leading whitespace or LaTeX spacing
+// (\space, "\ ", \quad, \qquad and the spaces \, \: and \;) in s
+func skipSpace(s string) int {
+	n := 0
+	for n < len(s) && isSpace(s[n]) {
+		n++
+	}
+	if n > 0 {
+		return n
+	}
+	if strings.HasPrefix(s, "\\space") {
+		return len("\\space")
+	}
+	if strings.HasPrefix(s, "\\ ") {
+		return len("\\ ")
+	}
+	if strings.HasPrefix(s, "\\quad") {
+		return len("\\quad")
+	}
+	if strings.HasPrefix(s, "\\qquad") {
+		return len("\\qquad")
+	}
+	if strings.HasPrefix(s, "\\,") || strings.HasPrefix(s, "\\:") || strings.HasPrefix(s, "\\;") {
+		return 2
+	}
+	return 0
+}
+
+// isSpace reports whether c is one of \t, \n, \f, \r or a space
+func isSpace(c byte) bool {
+	switch c {
+	case ' ', '\t', '\n', '\f', '\r':
+		return true
+	default:
+		return false
+	}
 }
 
 // Lexer tokenizes mathematical expressions
 type Lexer struct {
 	input string
 	pos   int
-	rules []TokenRule
+	// table is the scanner table the lexer was created with, and rules
+	// indexes it by first byte
+	table []rule
+	rules *[256][]*rule
 }
 
 // NewLexer creates a new lexer instance
 func NewLexer(input string) *Lexer {
-	lexer := &Lexer{
-		input: input,
-		pos:   0,
-	}
-	lexer.initRules()
-	return lexer
+	return &Lexer{input: input, table: rules, rules: &rulesByFirstByte}
 }
 
-// initRules initializes the tokenization rules
-func (l *Lexer) initRules() {
-	l.rules = []TokenRule{
-		// Skip whitespace and LaTeX spacing
-		{regexp.MustCompile(`^\s+`), TokenEOF, nil}, // Will be skipped
-		{regexp.MustCompile(`^\\space`), TokenEOF, nil},
-		{regexp.MustCompile(`^\\ `), TokenEOF, nil},
-
-		// Numbers - float first to match decimals properly
-		{regexp.MustCompile(`^[0-9]+\.[0-9]*`), TokenFloat, nil}, // Handle "1." and "1.23"
-		{regexp.MustCompile(`^\.[0-9]+`), TokenFloat, nil},       // Handle ".5"
-		{regexp.MustCompile(`^[0-9]+`), TokenInt, nil},
-
-		// Operators
-		{regexp.MustCompile(`^\*\*`), TokenPower, nil},
-		{regexp.MustCompile(`^\*`), TokenMultiply, nil},
-		{regexp.MustCompile(`^\\cdot`), TokenMultiply, nil},
-		{regexp.MustCompile(`^\\times`), TokenMultiply, nil},
-		{regexp.MustCompile(`^\\ast`), TokenMultiply, nil},
-		{regexp.MustCompile(`^/`), TokenDivide, nil},
-		{regexp.MustCompile(`^\\div`), TokenDivide, nil},
-		{regexp.MustCompile(`^-`), TokenMinus, nil},
-		{regexp.MustCompile("^\u2212"), TokenMinus, nil}, // Unicode minus
-		{regexp.MustCompile(`^\+`), TokenPlus, nil},
-		{regexp.MustCompile(`^\^`), TokenPower, nil},
-
-		// Parentheses and brackets
-		{regexp.MustCompile(`^\(`), TokenLeftParen, nil},
-		{regexp.MustCompile(`^\)`), TokenRightParen, nil},
-		{regexp.MustCompile(`^\\left\(`), TokenLeftParen, nil},
-		{regexp.MustCompile(`^\\right\)`), TokenRightParen, nil},
-		{regexp.MustCompile(`^\[`), TokenLeftBracket, nil},
-		{regexp.MustCompile(`^\]`), TokenRightBracket, nil},
-		{regexp.MustCompile(`^\{`), TokenLeftBrace, nil},
-		{regexp.MustCompile(`^\}`), TokenRightBrace, nil},
-		{regexp.MustCompile(`^\\left\{`), TokenLeftBrace, nil},
-		{regexp.MustCompile(`^\\right\}`), TokenRightBrace, nil},
-
-		// Comparison operators
-		{regexp.MustCompile(`^<=`), TokenLessEqual, nil},
-		{regexp.MustCompile(`^>=`), TokenGreaterEqual, nil},
-		{regexp.MustCompile(`^<>`), TokenNotEqual, nil},
-		{regexp.MustCompile(`^<`), TokenLess, nil},
-		{regexp.MustCompile(`^>`), TokenGreater, nil},
-		{regexp.MustCompile(`^=`), TokenEquals, nil},
-		{regexp.MustCompile(`^\\le`), TokenLessEqual, func(s string) string { return "<=" }},
-		{regexp.MustCompile(`^\\ge`), TokenGreaterEqual, func(s string) string { return ">=" }},
-		{regexp.MustCompile(`^\\leq`), TokenLessEqual, func(s string) string { return "<=" }},
-		{regexp.MustCompile(`^\\geq`), TokenGreaterEqual, func(s string) string { return ">=" }},
-		{regexp.MustCompile(`^=/=`), TokenNotEqual, func(s string) string { return "<>" }},
-		{regexp.MustCompile(`^\\ne`), TokenNotEqual, func(s string) string { return "<>" }},
-
-		// Functions and special symbols
-		{regexp.MustCompile(`^\\sqrt`), TokenSqrt, nil},
-		{regexp.MustCompile(`^\\frac`), TokenFrac, nil},
-		{regexp.MustCompile(`^\\dfrac`), TokenDfrac, nil},
-		{regexp.MustCompile(`^\\ln`), TokenLn, nil},
-		{regexp.MustCompile(`^\\log`), TokenLog, nil},
-
-		// Trigonometric functions
-		{regexp.MustCompile(`^\\arcsin`), TokenArcsin, nil},
-		{regexp.MustCompile(`^\\arccos`), TokenArccos, nil},
-		{regexp.MustCompile(`^\\arctan`), TokenArctan, nil},
-		{regexp.MustCompile(`^\\sin`), TokenSin, nil},
-		{regexp.MustCompile(`^\\cos`), TokenCos, nil},
-		{regexp.MustCompile(`^\\tan`), TokenTan, nil},
-		{regexp.MustCompile(`^\\sec`), TokenSec, nil},
-		{regexp.MustCompile(`^\\csc`), TokenCsc, nil},
-		{regexp.MustCompile(`^\\cot`), TokenCot, nil},
-
-		// Hyperbolic functions
-		{regexp.MustCompile(`^\\sinh`), TokenSinh, nil},
-		{regexp.MustCompile(`^\\cosh`), TokenCosh, nil},
-		{regexp.MustCompile(`^\\tanh`), TokenTanh, nil},
-
-		// Constants (must be before single char variables)
-		{regexp.MustCompile(`^pi`), TokenPi, func(s string) string { return "pi" }},
-		{regexp.MustCompile(`^\\pi`), TokenPi, func(s string) string { return "pi" }},
-		{regexp.MustCompile(`^\\theta`), TokenVar, func(s string) string { return "theta" }},
-		{regexp.MustCompile(`^\\phi`), TokenVar, func(s string) string { return "phi" }},
-
-		// Functions (must be before single char variables)
-		{regexp.MustCompile(`^sqrt`), TokenSqrt, nil},
-		{regexp.MustCompile(`^abs`), TokenAbs, nil},
-		{regexp.MustCompile(`^ln`), TokenLn, nil},
-		{regexp.MustCompile(`^log`), TokenLog, nil},
-		{regexp.MustCompile(`^sin`), TokenSin, nil},
-		{regexp.MustCompile(`^cos`), TokenCos, nil},
-		{regexp.MustCompile(`^tan`), TokenTan, nil},
-
-		// Known multi-character variables and constants (must be before single char variables)
-		{regexp.MustCompile(`^theta`), TokenVar, func(s string) string { return "theta" }},
-		{regexp.MustCompile(`^alpha`), TokenVar, func(s string) string { return "alpha" }},
-		{regexp.MustCompile(`^beta`), TokenVar, func(s string) string { return "beta" }},
-		{regexp.MustCompile(`^gamma`), TokenVar, func(s string) string { return "gamma" }},
-		{regexp.MustCompile(`^delta`), TokenVar, func(s string) string { return "delta" }},
-		{regexp.MustCompile(`^epsilon`), TokenVar, func(s string) string { return "epsilon" }},
-		{regexp.MustCompile(`^phi`), TokenVar, func(s string) string { return "phi" }},
-		{regexp.MustCompile(`^psi`), TokenVar, func(s string) string { return "psi" }},
-		{regexp.MustCompile(`^omega`), TokenVar, func(s string) string { return "omega" }},
-
-		// Other symbols
-		{regexp.MustCompile(`^_`), TokenSubscript, nil},
-		{regexp.MustCompile(`^\|`), TokenPipe, nil},
-		{regexp.MustCompile(`^\\left\|`), TokenLeftPipe, nil},
-		{regexp.MustCompile(`^\\right\|`), TokenRightPipe, nil},
-		{regexp.MustCompile(`^,`), TokenComma, nil},
-		{regexp.MustCompile(`^!`), TokenExclamation, nil},
-
-		// Single character variables (everything else should be parsed as individual chars for implicit multiplication)
-		{regexp.MustCompile(`^[a-zA-Z]`), TokenVar, nil},
+// configure rebuilds the scanner table for the variable and function names
+// of the options. The names come first, longest first, so that they win
+// over built-in names they start with (cost before cos), and a run of
+// letters replaces the single-letter rule when letters are not split. The
+// percent sign and \overline are only tokens when percentages and
+// repeating decimals are read, and the number rules follow the separators
+// of the locale.
+func (l *Lexer) configure(options Options) {
+	names := append(append([]string(nil), options.Variables...), options.Functions...)
+	sort.SliceStable(names, func(i, j int) bool {
+		return len(names[i]) > len(names[j])
+	})
+
+	table := make([]rule, 0, len(names)+len(l.table)+4)
+	if options.Percent {
+		table = append(table,
+			rule{literal: "%", tokenType: TokenPercent},
+			rule{literal: "\\%", tokenType: TokenPercent, value: "%"},
+		)
 	}
+	if options.RepeatingDecimals {
+		table = append(table, rule{literal: "\\overline", tokenType: TokenOverline})
+	}
+	if options.Locale.decimal() == ',' {
+		// The comma is taken, so arguments and elements are separated by
+		// semicolons
+		table = append(table, rule{literal: ";", tokenType: TokenComma, value: ","})
+	}
+	if options.Units {
+		table = append(table, rule{match: matchUnitText, starts: "\\", tokenType: TokenUnit, convert: unitTextSymbol})
+	}
+	for _, name := range names {
+		if name != "" {
+			table = append(table, rule{literal: name, tokenType: TokenVar})
+		}
+	}
+	for _, r := range l.table {
+		if r.match != nil && r.tokenType == TokenVar && options.Units {
+			// Unit symbols come after function names and constants, so
+			// sec is still the secant and pi is still π
+			table = append(table, rule{match: matchUnit, starts: unitStarts, tokenType: TokenUnit})
+		}
+		switch {
+		case r.literal == "," && options.Locale.decimal() == ',':
+			// A comma outside a number is left an error, so that f(3, 5)
+			// is not read as two arguments while f(3,5) is one
+			continue
+		case r.match != nil && r.tokenType == TokenVar && options.MultiLetterVariables:
+			r.match = matchIdentifier
+		case r.match != nil && (r.tokenType == TokenFloat || r.tokenType == TokenInt) && options.Locale != (Locale{}):
+			if r.starts == "." {
+				// .5 is only a number where the point is the decimal separator
+				if options.Locale.decimal() != '.' {
+					continue
+				}
+				break
+			}
+			r.match = options.Locale.matchNumber(r.tokenType == TokenFloat)
+			r.convert = options.Locale.normalize
+		}
+		table = append(table, r)
+	}
+
+	index := indexRules(table)
+	l.table, l.rules = table, &index
 }
 
 // NextToken returns the next token from the input
 func (l *Lexer) NextToken() Token {
 	for l.pos < len(l.input) {
+		rest := l.input[l.pos:]
+
 		// Skip whitespace and LaTeX spacing
-		if match := regexp.MustCompile(`^\s+`).FindString(l.input[l.pos:]); match != "" {
-			l.pos += len(match)
-			continue
-		}
-		if match := regexp.MustCompile(`^\\space`).FindString(l.input[l.pos:]); match != "" {
-			l.pos += len(match)
-			continue
-		}
-		if match := regexp.MustCompile(`^\\ `).FindString(l.input[l.pos:]); match != "" {
-			l.pos += len(match)
+		if n := skipSpace(rest); n > 0 {
+			l.pos += n
 			continue
 		}
 
-		// Try to match each rule
-		for _, rule := range l.rules {
-			if match := rule.Pattern.FindString(l.input[l.pos:]); match != "" {
-				token := Token{
-					Type:  rule.TokenType,
-					Value: match,
-					Pos:   l.pos,
-				}
-				if rule.Transform != nil {
-					token.Value = rule.Transform(match)
-				}
-				l.pos += len(match)
-
-				// Handle special variable cases
-				switch token.Value {
-				case "pi":
-					token.Type = TokenPi
-				case "e":
-					token.Type = TokenE
-				case "ln":
-					token.Type = TokenLn
-				case "log":
-					token.Type = TokenLog
-				case "sin":
-					token.Type = TokenSin
-				case "cos":
-					token.Type = TokenCos
-				case "tan":
-					token.Type = TokenTan
-				case "sqrt":
-					token.Type = TokenSqrt
-				case "abs":
-					token.Type = TokenAbs
-				}
+		// Try each rule that can start with this byte
+		for _, r := range l.rules[rest[0]] {
+			n := len(r.literal)
+			if r.match != nil {
+				n = r.match(rest)
+			} else if !strings.HasPrefix(rest, r.literal) {
+				n = 0
+			}
+			if n == 0 {
+				continue
+			}
 
-				return token
+			token := Token{
+				Type:  r.tokenType,
+				Value: rest[:n],
+				Pos:   l.pos,
+			}
+			if r.value != "" {
+				token.Value = r.value
+			} else if r.convert != nil {
+				token.Value = r.convert(token.Value)
 			}
+			if r.retype != nil {
+				token.Type = r.retype(token.Value)
+			}
+			l.pos += n
+			token.End = l.pos
+
+			// Handle special variable cases
+			switch token.Value {
+			case "pi":
+				token.Type = TokenPi
+			case "e":
+				token.Type = TokenE
+			case "ln":
+				token.Type = TokenLn
+			case "log":
+				token.Type = TokenLog
+			case "sin":
+				token.Type = TokenSin
+			case "cos":
+				token.Type = TokenCos
+			case "tan":
+				token.Type = TokenTan
+			case "sqrt":
+				token.Type = TokenSqrt
+			case "abs":
+				token.Type = TokenAbs
+			}
+
+			return token
 		}
 
-		// If no rule matched, it's an invalid character
-		char := string(l.input[l.pos])
+		// If no rule matched, it's an invalid character. The offending byte
+		// is reported as a rune, as error messages have always shown it.
+		_, size := utf8.DecodeRuneInString(rest)
 		return Token{
 			Type:  TokenError,
-			Value: char,
+			Value: string(rune(rest[0])),
 			Pos:   l.pos,
+			End:   l.pos + size,
 		}
 	}
 
-	return Token{Type: TokenEOF, Pos: l.pos}
+	return Token{Type: TokenEOF, Pos: l.pos, End: l.pos}
 }
 
 // Peek returns the next token without advancing the position
@@ -0,0 +1,166 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/quizizz/cas/pkg/ast"
+	"github.com/quizizz/cas/pkg/units"
+)
+
+// unitSymbols are the unit symbols the lexer knows, longest first
+var unitSymbols = units.Symbols()
+
+// unitStarts lists the bytes a unit symbol can begin with
+var unitStarts = func() string {
+	var sb strings.Builder
+	for _, symbol := range unitSymbols {
+		if strings.IndexByte(sb.String(), symbol[0]) < 0 {
+			sb.WriteByte(symbol[0])
+		}
+	}
+	return sb.String()
+}()
+
+// matchUnit matches a unit symbol that is not the start of a longer word,
+// so m is a unit in 5 m/s but not in max
+func matchUnit(s string) int {
+	for _, symbol := range unitSymbols {
+		if strings.HasPrefix(s, symbol) && (len(s) == len(symbol) || strings.IndexByte(letters, s[len(symbol)]) < 0) {
+			return len(symbol)
+		}
+	}
+	return 0
+}
+
+// unitTextCommands are the commands a unit symbol is written in, as in
+// \text{ km} or \mathrm{kg}
+var unitTextCommands = []string{"\\text{", "\\textrm{", "\\mathrm{"}
+
+// matchUnitText matches a single unit symbol written in text, with any
+// spaces around it
+func matchUnitText(s string) int {
+	for _, command := range unitTextCommands {
+		if !strings.HasPrefix(s, command) {
+			continue
+		}
+		end := strings.IndexByte(s, '}')
+		if end < 0 {
+			return 0
+		}
+		if _, ok := units.Lookup(strings.TrimSpace(s[len(command):end])); ok {
+			return end + 1
+		}
+	}
+	return 0
+}
+
+// unitTextSymbol returns the symbol in a unit matched by matchUnitText
+func unitTextSymbol(s string) string {
+	return strings.TrimSpace(s[strings.IndexByte(s, '{')+1 : len(s)-1])
+}
+
+// parseQuantity reads the unit written after value, as in 9.8 m/s^2 or
+// 5\,\mathrm{kg}\cdot\mathrm{m}. Units are joined by juxtaposition, * or
+// \cdot, and one after a / is divided by; a * or / that is not followed
+// by a unit is left for the caller.
+func (p *Parser) parseQuantity(value ast.Expr, start int) (ast.Expr, error) {
+	unitStart := p.current.Pos
+	factor, err := p.parseUnitFactor(false)
+	if err != nil {
+		return nil, err
+	}
+	factors := []ast.Expr{factor}
+
+	for {
+		divide := false
+		switch {
+		case p.current.Type == TokenUnit:
+		case (p.current.Type == TokenMultiply || p.current.Type == TokenDivide) && p.peek().Type == TokenUnit:
+			divide = p.current.Type == TokenDivide
+			p.advance()
+		default:
+			unit := factors[0]
+			if len(factors) > 1 {
+				unit = ast.NewMul(factors...)
+			}
+			return p.mark(ast.NewQuantity(value, p.mark(unit, unitStart)), start), nil
+		}
+
+		factor, err := p.parseUnitFactor(divide)
+		if err != nil {
+			return nil, err
+		}
+		factors = append(factors, factor)
+	}
+}
+
+// parseUnitFactor reads a unit symbol and an integer power on it, as in
+// s^2, s^{-1} or s², negating the power of a unit that divides
+func (p *Parser) parseUnitFactor(divide bool) (ast.Expr, error) {
+	token := p.current
+	p.advance()
+	unit := markToken(ast.NewUnit(token.Value), token)
+
+	power := int64(1)
+	switch p.current.Type {
+	case TokenSuperscript:
+		exponent, _ := ast.NewIntFromString(p.current.Value)
+		power = exponent.IntValue().Int64()
+		p.advance()
+	case TokenPower:
+		p.advance()
+		braced := p.current.Type == TokenLeftBrace
+		if braced {
+			p.advance()
+		}
+		sign := int64(1)
+		if p.current.Type == TokenMinus {
+			sign = -1
+			p.advance()
+		}
+		if p.current.Type != TokenInt {
+			return nil, p.unexpected(TokenInt)
+		}
+		exponent, _ := ast.NewIntFromString(p.current.Value)
+		power = sign * exponent.IntValue().Int64()
+		p.advance()
+		if braced {
+			if err := p.expectClosing(TokenRightBrace, "unit power"); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if divide {
+		power = -power
+	}
+	if power == 1 {
+		return unit, nil
+	}
+	return p.mark(ast.NewPow(unit, ast.NewInt(power)), token.Pos), nil
+}
+
+// parseUnitVariable reads a unit symbol with no value before it as
+// variables, as it would be read without units: m is the variable m, and
+// ms is m*s unless multi-letter variables are on
+func (p *Parser) parseUnitVariable() (ast.Expr, error) {
+	token := p.current
+	if len(token.Value) == 1 || p.options.MultiLetterVariables {
+		p.current.Type = TokenVar
+		return p.parseVariable()
+	}
+
+	p.advance()
+	letters := make([]ast.Expr, len(token.Value))
+	for i := range token.Value {
+		letter := ast.NewVar(token.Value[i : i+1])
+		if token.End-token.Pos == len(token.Value) {
+			setSpan(letter, ast.Span{Start: token.Pos + i, End: token.Pos + i + 1})
+		} else {
+			// A symbol in \text{} has no span of its own
+			markToken(letter, token)
+		}
+		letters[i] = letter
+	}
+	return p.mark(ast.NewMul(letters...), token.Pos), nil
+}
@@ -0,0 +1,111 @@
+package simplify
+
+import (
+	"math/big"
+
+	"github.com/quizizz/cas/pkg/ast"
+)
+
+// collectFunc simplifies function calls. Only the absolute value, the
+// square root and logarithms have rules of their own; other functions are
+// left as they are.
+func collectFunc(f *ast.Func, opts Options) ast.Expr {
+	if arg, ok := absArg(f); ok {
+		return simplifyAbs(Collect(arg, opts), opts)
+	}
+	if f.Name() == "ln" || f.Name() == "log" {
+		return simplifyLog(f, opts)
+	}
+	if f.Name() == "sqrt" && len(f.Args()) == 1 {
+		// sqrt(a^(2k)) = |a|^k for a real a, which is a^k when a >= 0
+		arg := Collect(f.Args()[0], opts)
+		if pow, ok := arg.(*ast.Pow); ok && isEven(pow.Exponent()) && opts.Assumptions.AllReal(pow.Base()) {
+			half := Collect(ast.NewMul(pow.Exponent(), ast.NewRational(1, 2)), opts)
+			return Collect(ast.NewPow(ast.NewFunc("abs", pow.Base()), half), opts)
+		}
+	}
+	return f
+}
+
+// simplifyAbs simplifies |u| for a collected u. A u of known sign loses the
+// bars, |a*b| = |a||b|, and |u^n| = |u|^n for an integer n.
+func simplifyAbs(arg ast.Expr, opts Options) ast.Expr {
+	switch {
+	case NonNegative(arg, opts.Assumptions):
+		return arg
+	case NonPositive(arg, opts.Assumptions):
+		return Collect(ast.NewMul(ast.NewInt(-1), arg), opts)
+	}
+
+	switch e := arg.(type) {
+	case *ast.Mul:
+		factors := e.Terms()
+		for i, factor := range factors {
+			factors[i] = simplifyAbs(factor, opts)
+		}
+		return Collect(ast.NewMul(factors...), opts)
+	case *ast.Pow:
+		if _, ok := e.Exponent().(*ast.Int); ok {
+			return ast.NewPow(simplifyAbs(e.Base(), opts), e.Exponent())
+		}
+	}
+	return ast.NewFunc("abs", arg)
+}
+
+// absArg returns u when expr is |u|
+func absArg(expr ast.Expr) (ast.Expr, bool) {
+	f, ok := expr.(*ast.Func)
+	if !ok || f.Name() != "abs" || len(f.Args()) != 1 {
+		return nil, false
+	}
+	return f.Args()[0], true
+}
+
+// isEven reports whether expr is an even integer
+func isEven(expr ast.Expr) bool {
+	n, ok := integerValue(expr)
+	return ok && n.Bit(0) == 0
+}
+
+// integerValue returns the value of expr when it is a constant integer,
+// such as 4 or 2*2^-1
+func integerValue(expr ast.Expr) (*big.Int, bool) {
+	if n, ok := expr.(*ast.Int); ok {
+		return n.IntValue(), true
+	}
+	if len(expr.Variables()) > 0 {
+		return nil, false
+	}
+	value, err := expr.Eval(nil)
+	if err != nil || !value.IsInt() {
+		return nil, false
+	}
+	n, _ := value.Int(nil)
+	return n, true
+}
+
+// isInteger reports whether expr is known to be an integer: a constant
+// integer, a variable assumed to be one, or a sum or product of those
+func isInteger(expr ast.Expr, assumptions ast.Assumptions) bool {
+	if _, ok := integerValue(expr); ok {
+		return true
+	}
+	switch e := expr.(type) {
+	case *ast.Var:
+		return assumptions.Has(e.Name(), ast.Integer)
+	case *ast.Add:
+		return allIntegers(e.Terms(), assumptions)
+	case *ast.Mul:
+		return allIntegers(e.Terms(), assumptions)
+	}
+	return false
+}
+
+func allIntegers(exprs []ast.Expr, assumptions ast.Assumptions) bool {
+	for _, e := range exprs {
+		if !isInteger(e, assumptions) {
+			return false
+		}
+	}
+	return true
+}
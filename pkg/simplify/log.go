@@ -0,0 +1,44 @@
+package simplify
+
+import "github.com/quizizz/cas/pkg/ast"
+
+// simplifyLog evaluates a logarithm of a power of its base, so that
+// log_b(b) = 1, log_2(1) = 0, log_2(8) = 3, log_2(1/8) = -3 and
+// log_b(b^u) = u. ln has the base e and log with one argument the base 10.
+func simplifyLog(f *ast.Func, opts Options) ast.Expr {
+	args := f.Args()
+	for i, arg := range args {
+		args[i] = Collect(arg, opts)
+	}
+	collected := ast.NewFunc(f.Name(), args...)
+
+	var base ast.Expr
+	switch {
+	case f.Name() == "ln" && len(args) == 1:
+		base = ast.E
+	case f.Name() == "log" && len(args) == 1:
+		base = ast.NewInt(10)
+	case f.Name() == "log" && len(args) == 2:
+		base = args[1]
+	default:
+		return collected
+	}
+	if isOne(base) {
+		return collected
+	}
+
+	arg := args[0]
+	switch {
+	case isOne(arg):
+		return ast.NewInt(0)
+	case arg.Equal(base):
+		return ast.NewInt(1)
+	}
+	if pow, ok := arg.(*ast.Pow); ok && pow.Base().Equal(base) {
+		return pow.Exponent()
+	}
+	if k, ok := ast.IntegerLog(arg, base); ok {
+		return ast.NewInt(k)
+	}
+	return collected
+}
@@ -0,0 +1,155 @@
+package simplify
+
+import (
+	"math/big"
+
+	"github.com/quizizz/cas/pkg/ast"
+)
+
+// signs is the set of signs an expression can take, one bit per sign
+type signs uint8
+
+const (
+	negative signs = 1 << iota
+	zero
+	positive
+
+	nonNegative = zero | positive
+	nonPositive = negative | zero
+	anySign     = negative | zero | positive
+)
+
+// NonNegative reports whether expr is known to be zero or positive for
+// every value of its variables allowed by assumptions, which may be nil
+func NonNegative(expr ast.Expr, assumptions ast.Assumptions) bool {
+	s := signOf(expr, assumptions)
+	return s != 0 && s&negative == 0
+}
+
+// NonPositive reports whether expr is known to be zero or negative for
+// every value of its variables allowed by assumptions, which may be nil
+func NonPositive(expr ast.Expr, assumptions ast.Assumptions) bool {
+	s := signOf(expr, assumptions)
+	return s != 0 && s&positive == 0
+}
+
+// signOf returns the signs expr can take, from the signs of its parts. A
+// variable can take any sign its assumptions allow; no sign at all means
+// expr is never defined.
+func signOf(expr ast.Expr, assumptions ast.Assumptions) signs {
+	switch e := expr.(type) {
+	case *ast.Var:
+		switch {
+		case assumptions.Has(e.Name(), ast.Positive):
+			return positive
+		case assumptions.Has(e.Name(), ast.Nonzero):
+			return negative | positive
+		}
+		return anySign
+	case ast.Numeric, *ast.Const:
+		value, err := expr.Eval(nil)
+		if err != nil {
+			return anySign
+		}
+		return signOfValue(value)
+	case *ast.Add:
+		result := zero
+		for _, term := range e.Terms() {
+			result = addSigns(result, signOf(term, assumptions))
+		}
+		return result
+	case *ast.Mul:
+		result := positive
+		for _, factor := range e.Terms() {
+			result = mulSigns(result, signOf(factor, assumptions))
+		}
+		return result
+	case *ast.Pow:
+		return powSigns(signOf(e.Base(), assumptions), e.Exponent())
+	case *ast.Func:
+		args := e.Args()
+		if len(args) != 1 {
+			return anySign
+		}
+		switch e.Name() {
+		case "abs":
+			if signOf(args[0], assumptions)&zero == 0 {
+				return positive
+			}
+			return nonNegative
+		case "sqrt":
+			return signOf(args[0], assumptions) & nonNegative
+		case "exp", "cosh":
+			return positive
+		}
+	}
+	return anySign
+}
+
+// signOfValue returns the sign of a number
+func signOfValue(value *big.Float) signs {
+	switch value.Sign() {
+	case -1:
+		return negative
+	case 0:
+		return zero
+	}
+	return positive
+}
+
+// addSigns returns the signs a sum can take: terms that are all
+// nonnegative (or all nonpositive) keep that sign, and the sum is zero only
+// if every term can be
+func addSigns(a, b signs) signs {
+	switch {
+	case (a|b)&negative == 0:
+		return a&b&zero | (a|b)&positive
+	case (a|b)&positive == 0:
+		return a&b&zero | (a|b)&negative
+	}
+	return anySign
+}
+
+// mulSigns returns the signs a product can take
+func mulSigns(a, b signs) signs {
+	var result signs
+	if a&zero != 0 || b&zero != 0 {
+		result |= zero
+	}
+	if a&positive != 0 && b&positive != 0 || a&negative != 0 && b&negative != 0 {
+		result |= positive
+	}
+	if a&positive != 0 && b&negative != 0 || a&negative != 0 && b&positive != 0 {
+		result |= negative
+	}
+	return result
+}
+
+// powSigns returns the signs a power can take, given those of its base. An
+// even power is never negative and an odd one keeps the sign of its base;
+// otherwise only a nonnegative base gives a known sign.
+func powSigns(base signs, exponent ast.Expr) signs {
+	n, ok := exponent.(*ast.Int)
+	if !ok {
+		if base&negative != 0 {
+			return anySign
+		}
+		return base
+	}
+	k := n.IntValue()
+	if k.Sign() < 0 {
+		// Zero has no negative powers
+		base &^= zero
+	}
+	switch {
+	case k.Sign() == 0:
+		return positive
+	case k.Bit(0) == 0:
+		result := base & zero
+		if base&(negative|positive) != 0 {
+			result |= positive
+		}
+		return result
+	}
+	return base
+}
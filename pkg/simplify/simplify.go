@@ -15,6 +15,9 @@ type Options struct {
 	KeepNegative bool
 	// MaxIterations limits the number of simplification iterations
 	MaxIterations int
+	// Assumptions about the variables, which rules such as sqrt(x^2) = x
+	// need before they apply
+	Assumptions ast.Assumptions
 }
 
 // DefaultOptions returns the default simplification options
@@ -80,6 +83,8 @@ func Collect(expr ast.Expr, opts ...Options) ast.Expr {
 		return collectMul(e, options)
 	case *ast.Pow:
 		return collectPow(e, options)
+	case *ast.Func:
+		return collectFunc(e, options)
 	case ast.Numeric:
 		return collectNumeric(e)
 	default:
@@ -153,14 +158,19 @@ func collectAdd(add *ast.Add, opts Options) ast.Expr {
 		allTerms[i] = Collect(term, opts)
 	}
 
-	// Group terms by their "like" structure
+	// Group terms by their "like" structure, keeping the groups in the
+	// order they first appear so the result does not depend on map order
 	termGroups := make(map[string][]ast.Expr)
 	coefficients := make(map[string]*big.Float)
+	var keys []string
 
 	for _, term := range allTerms {
 		baseForm, coeff := extractCoefficientAndBase(term)
 		key := baseForm.String()
 
+		if _, seen := termGroups[key]; !seen {
+			keys = append(keys, key)
+		}
 		termGroups[key] = append(termGroups[key], term)
 		if coefficients[key] == nil {
 			coefficients[key] = new(big.Float)
@@ -171,7 +181,8 @@ func collectAdd(add *ast.Add, opts Options) ast.Expr {
 	// Reconstruct the result
 	var result []ast.Expr
 
-	for key, termGroup := range termGroups {
+	for _, key := range keys {
+		termGroup := termGroups[key]
 		coeff := coefficients[key]
 		baseForm := termGroup[0]
 		if len(termGroup) > 1 || coeff.Cmp(big.NewFloat(1)) != 0 {
@@ -349,10 +360,26 @@ func collectPow(pow *ast.Pow, opts Options) ast.Expr {
 	base := Collect(pow.Base(), opts)
 	exp := Collect(pow.Exponent(), opts)
 
-	// Handle (a^b)^c = a^(bc)
+	// Handle (a^b)^c = a^(bc), which holds when c is an integer or a is
+	// positive, and (a^(2k))^c = |a|^(2kc) for a real a
 	if basePow, ok := base.(*ast.Pow); ok {
+		inner := basePow.Base()
 		newExp := Collect(ast.NewMul(basePow.Exponent(), exp), opts)
-		return ast.NewPow(basePow.Base(), newExp)
+		if n, ok := integerValue(newExp); ok {
+			newExp, _ = ast.NewIntFromString(n.String())
+		}
+		switch {
+		case isInteger(exp, opts.Assumptions) || signOf(inner, opts.Assumptions) == positive:
+			return Collect(ast.NewPow(inner, newExp), opts)
+		case isEven(basePow.Exponent()) && opts.Assumptions.AllReal(inner):
+			return Collect(ast.NewPow(ast.NewFunc("abs", inner), newExp), opts)
+		}
+		return ast.NewPow(base, exp)
+	}
+
+	// Handle |a|^n = a^n for even n and a real a
+	if arg, ok := absArg(base); ok && isEven(exp) && opts.Assumptions.AllReal(arg) {
+		return ast.NewPow(arg, exp)
 	}
 
 	// Handle a^0 = 1
@@ -429,43 +456,38 @@ func partitionMul(mul *ast.Mul) (numeric ast.Expr, others ast.Expr) {
 	return
 }
 
-func collectPowers(expr ast.Expr) map[string]powerInfo {
-	powers := make(map[string]powerInfo)
+func collectPowers(expr ast.Expr) []powerInfo {
+	var powers []powerInfo
+	index := make(map[string]int)
+
+	// add multiplies base^exp into the powers, keeping the bases in the
+	// order they first appear so the result does not depend on map order
+	add := func(base, exp ast.Expr) {
+		key := base.String()
+		if i, ok := index[key]; ok {
+			// Add exponents: a^m * a^n = a^(m+n)
+			powers[i].exponent = Collect(ast.NewAdd(powers[i].exponent, exp))
+			return
+		}
+		index[key] = len(powers)
+		powers = append(powers, powerInfo{base: base, exponent: exp})
+	}
 
 	switch e := expr.(type) {
 	case *ast.Mul:
 		for _, term := range e.Terms() {
 			if pow, ok := term.(*ast.Pow); ok {
-				base := pow.Base()
-				exp := pow.Exponent()
-				key := base.String()
-
-				if existing, ok := powers[key]; ok {
-					// Add exponents: a^m * a^n = a^(m+n)
-					newExp := Collect(ast.NewAdd(existing.exponent, exp))
-					powers[key] = powerInfo{base: base, exponent: newExp}
-				} else {
-					powers[key] = powerInfo{base: base, exponent: exp}
-				}
+				add(pow.Base(), pow.Exponent())
 			} else {
 				// Term without explicit exponent has exponent 1
-				key := term.String()
-				if existing, ok := powers[key]; ok {
-					// Add 1 to existing exponent
-					newExp := Collect(ast.NewAdd(existing.exponent, ast.NewInt(1)))
-					powers[key] = powerInfo{base: term, exponent: newExp}
-				} else {
-					powers[key] = powerInfo{base: term, exponent: ast.NewInt(1)}
-				}
+				add(term, ast.NewInt(1))
 			}
 		}
 	case *ast.Pow:
-		key := e.Base().String()
-		powers[key] = powerInfo{base: e.Base(), exponent: e.Exponent()}
+		add(e.Base(), e.Exponent())
 	default:
 		if !isOne(expr) {
-			key := expr.String()
-			powers[key] = powerInfo{base: expr, exponent: ast.NewInt(1)}
+			add(expr, ast.NewInt(1))
 		}
 	}
 
@@ -518,8 +540,20 @@ func factorAdd(add *ast.Add, opts Options) ast.Expr {
 		var factorizedTerms []ast.Expr
 		for _, term := range terms {
 			if mul, ok := term.(*ast.Mul); ok {
-				// Remove the common factor from this term
-				remaining := removeFactor(mul, commonFactor)
+				// Remove the common factor from this term, one part at a
+				// time when several factors were shared
+				var remaining ast.Expr = mul
+				parts := []ast.Expr{commonFactor}
+				if !containsFactor(mul, commonFactor) {
+					parts = extractFactors(commonFactor)
+				}
+				for _, factor := range parts {
+					if remainingMul, ok := remaining.(*ast.Mul); ok {
+						remaining = removeFactor(remainingMul, factor)
+					} else if termsEqual(remaining, factor) {
+						remaining = ast.NewInt(1)
+					}
+				}
 				factorizedTerms = append(factorizedTerms, remaining)
 			} else if termsEqual(term, commonFactor) {
 				factorizedTerms = append(factorizedTerms, ast.NewInt(1))
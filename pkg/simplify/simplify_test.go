@@ -120,6 +120,135 @@ func TestSimplify(t *testing.T) {
 	}
 }
 
+func TestSimplifyAbs(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"number", "abs(-5)", "5"},
+		{"product", "abs(x*y)", "abs(x)*abs(y)"},
+		{"negative coefficient", "abs(-3*x)", "3*abs(x)"},
+		{"even power", "abs(x^2)", "x^2"},
+		{"square of absolute value", "abs(x)^2", "abs(x)^2"},
+		{"odd power", "abs(x)^3", "abs(x)^3"},
+		{"nested", "abs(abs(x))", "abs(x)"},
+		{"always positive", "abs(x^2+1)", "x^2+1"},
+		{"always negative", "abs(-1*x^2-1)", "x^2+1"},
+		{"exponential", "abs(e^x)", "e^x"},
+		{"unknown sign", "abs(x)", "abs(x)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := parser.Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+
+			expected, err := parser.Parse(tt.expected)
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+
+			// Terms may come out in either order
+			if result := Simplify(expr); !Equal(result, expected) {
+				t.Errorf("Simplify(%s) = %s, want %s", tt.input, result.String(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestNonNegative(t *testing.T) {
+	tests := []struct {
+		input       string
+		nonNegative bool
+		nonPositive bool
+	}{
+		{"3", true, false},
+		{"0", true, true},
+		{"x", false, false},
+		{"x^2", true, false},
+		{"x^2+y^2", true, false},
+		{"-1*x^2", false, true},
+		{"x^3", false, false},
+		{"sqrt(x)", true, false},
+		{"pi*abs(x)", true, false},
+		{"x^2-1", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			expr, err := parser.Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+			if got := NonNegative(expr, nil); got != tt.nonNegative {
+				t.Errorf("NonNegative(%s) = %v, want %v", tt.input, got, tt.nonNegative)
+			}
+			if got := NonPositive(expr, nil); got != tt.nonPositive {
+				t.Errorf("NonPositive(%s) = %v, want %v", tt.input, got, tt.nonPositive)
+			}
+		})
+	}
+}
+
+func TestSimplifyAssumptions(t *testing.T) {
+	positive := ast.Assume("x", ast.Positive)
+	realX := ast.Assume("x", ast.Real)
+	tests := []struct {
+		name        string
+		input       string
+		assumptions ast.Assumptions
+		expected    string
+	}{
+		{"square root of a square", "sqrt(x^2)", nil, "sqrt(x^2)"},
+		{"square root of a real square", "sqrt(x^2)", realX, "abs(x)"},
+		{"square root of a positive square", "sqrt(x^2)", positive, "x"},
+		{"half power of a square", "(x^2)^(1/2)", positive, "x"},
+		{"square root of a fourth power", "sqrt(x^4)", realX, "x^2"},
+		{"power of a power", "(x^a)^b", nil, "(x^a)^b"},
+		{"power of a positive power", "(x^3)^b", positive, "x^(3*b)"},
+		{"integer outer exponent", "(x^3)^n", ast.Assume("n", ast.Integer), "x^(3*n)"},
+		{"constant integer exponent", "(x^a)^2", nil, "x^(2*a)"},
+		{"square of a square root", "(x^(1/2))^2", nil, "x"},
+		{"absolute value of a positive variable", "abs(x)", positive, "x"},
+		{"absolute value of a product", "abs(x*y)", positive, "x*abs(y)"},
+		{"square of a real absolute value", "abs(x)^2", realX, "x^2"},
+		{"power of a power is not a power tower", "(x^a)^b - x^(a^b)", nil, "(x^a)^b - x^(a^b)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := parser.Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+			expected, err := parser.Parse(tt.expected)
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+
+			opts := DefaultOptions()
+			opts.Assumptions = tt.assumptions
+			if result := Simplify(expr, opts); !Equal(result, expected) {
+				t.Errorf("Simplify(%s) = %s, want %s", tt.input, result.String(), tt.expected)
+			}
+		})
+	}
+
+	// A power of a power keeps its parentheses when written out, so it is
+	// not collected with a power tower
+	expr, _ := parser.Parse("(x^{1/2})^{1/2}")
+	if got, want := Simplify(expr).String(), "(x^2^-1)^2^-1"; got != want {
+		t.Errorf("Simplify(%s) = %s, want %s", expr, got, want)
+	}
+
+	if !NonNegative(ast.NewVar("x"), positive) || NonPositive(ast.NewVar("x"), positive) {
+		t.Errorf("a positive variable should be nonnegative and not nonpositive")
+	}
+}
+
 func TestHelperFunctions(t *testing.T) {
 	t.Run("isNumeric", func(t *testing.T) {
 		tests := []struct {
@@ -238,3 +367,33 @@ func BenchmarkCollect(b *testing.B) {
 		Collect(expr)
 	}
 }
+
+func TestSimplifyLogs(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"power of the base", "\\log_2 8", "3"},
+		{"base itself", "\\log_b b", "1"},
+		{"one", "\\log_2 1", "0"},
+		{"reciprocal power", "\\log_2(\\frac{1}{8})", "-3"},
+		{"symbolic exponent", "\\log_3(3^x)", "x"},
+		{"natural log of e", "\\ln(e)", "1"},
+		{"common log", "\\log(1000)", "3"},
+		{"not a power", "\\log_2 5", "log(5, 2)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := parser.Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+
+			if result := Simplify(expr).String(); result != tt.expected {
+				t.Errorf("Simplify(%s) = %s, want %s", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package solve
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/quizizz/cas/pkg/ast"
+	"github.com/quizizz/cas/pkg/simplify"
+)
+
+// solveAbs solves an equation containing |u| by cases: where u >= 0 the
+// bars can be dropped, and where u < 0, |u| = -u. Each case is solved on
+// its own and keeps only the solutions that lie in it, so |2x - 3| = 5
+// gives x = -1 and x = 4. There is no solution only when both cases were
+// solved and every root fell outside its case.
+func solveAbs(expr ast.Expr, abs *ast.Func, opts SolveOptions) SolutionSet {
+	arg := abs.Args()[0]
+	cases := []struct {
+		value    ast.Expr
+		relation ast.EqType
+	}{
+		{arg, ast.EqGreaterEqual},
+		{ast.NewMul(ast.NewInt(-1), arg), ast.EqLess},
+	}
+
+	type root struct {
+		solution Solution
+		value    float64
+	}
+	var roots []root
+	for _, c := range cases {
+		// An |u| inside a node replaceExpr cannot rebuild, such as a
+		// piecewise, would be found again on every pass
+		replaced := ast.Replace(expr, abs, c.value)
+		if replaced.Equal(expr) {
+			return SolutionSet{
+				Message:      fmt.Sprintf("Cannot split %s into cases", abs),
+				HasSolutions: false,
+			}
+		}
+		set := solveEquation(simplify.Simplify(replaced, simplifyOptions(opts)), opts)
+		condition := ast.NewEq(arg, ast.NewInt(0), c.relation)
+		if set.HasSolutions && len(set.Solutions) == 0 {
+			return SolutionSet{
+				Message:      fmt.Sprintf("Identity: true for all values of %s where %s", opts.Variable, condition),
+				HasSolutions: true,
+			}
+		}
+		// A case the solver gave up on says nothing about the equation
+		if !set.HasSolutions && !set.Empty {
+			return SolutionSet{
+				Message:      fmt.Sprintf("Cannot solve the case %s: %s", condition, set.Message),
+				HasSolutions: false,
+			}
+		}
+
+		for _, solution := range set.Solutions {
+			at, err := ast.Substitute(arg, opts.Variable, solution.Value).Eval(nil)
+			if err != nil {
+				return SolutionSet{
+					Message:      fmt.Sprintf("Cannot tell whether the solutions of the case %s lie in it", condition),
+					HasSolutions: false,
+				}
+			}
+			if !satisfies(c.relation, at.Sign()) {
+				continue
+			}
+			value, err := solution.Value.Eval(nil)
+			if err != nil {
+				continue
+			}
+			if r, ok := ast.ExactValue(solution.Value); ok {
+				solution.Value = ast.NewNumber(r)
+			}
+			f, _ := value.Float64()
+			roots = append(roots, root{solution, f})
+		}
+	}
+
+	if len(roots) == 0 {
+		return SolutionSet{
+			Message:      fmt.Sprintf("No solution in either case of %s", abs),
+			Empty:        true,
+			HasSolutions: false,
+		}
+	}
+
+	sort.Slice(roots, func(i, j int) bool { return roots[i].value < roots[j].value })
+	solutions := make([]Solution, len(roots))
+	for i, r := range roots {
+		solutions[i] = r.solution
+	}
+	return SolutionSet{
+		Solutions:    solutions,
+		Message:      "Absolute value equation solved",
+		HasSolutions: true,
+	}
+}
+
+// findAbs returns the first |u| in expr whose u depends on variable, or nil
+func findAbs(expr ast.Expr, variable string) *ast.Func {
+	if f, ok := expr.(*ast.Func); ok && f.Name() == "abs" && len(f.Args()) == 1 && containsVariable(f, variable) {
+		return f
+	}
+	for _, child := range ast.Children(expr) {
+		if abs := findAbs(child, variable); abs != nil {
+			return abs
+		}
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package solve
+
+import (
+	"math/big"
+
+	"github.com/quizizz/cas/pkg/ast"
+)
+
+// exactRoots finds the real roots of a polynomial of degree at most 2 with
+// rational coefficients, reporting false if a coefficient is not rational
+func exactRoots(expr ast.Expr, variable string) ([]ast.Expr, bool) {
+	a, b, c := extractQuadraticCoefficients(expr, variable)
+	ra, okA := ast.ExactValue(a)
+	rb, okB := ast.ExactValue(b)
+	rc, okC := ast.ExactValue(c)
+	if !okA || !okB || !okC {
+		return nil, false
+	}
+
+	switch {
+	case ra.Sign() != 0:
+		return QuadraticRoots(ra, rb, rc), true
+	case rb.Sign() != 0:
+		return []ast.Expr{ast.NewNumber(new(big.Rat).Quo(new(big.Rat).Neg(rc), rb))}, true
+	default:
+		return nil, true
+	}
+}
@@ -0,0 +1,486 @@
+package solve
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/quizizz/cas/pkg/ast"
+	"github.com/quizizz/cas/pkg/simplify"
+)
+
+// boundTolerance is used when comparing interval bounds numerically
+const boundTolerance = 1e-12
+
+// Interval is a connected range of real numbers. A nil bound is infinite.
+type Interval struct {
+	Lower       ast.Expr
+	Upper       ast.Expr
+	LowerClosed bool
+	UpperClosed bool
+}
+
+// String formats the interval in bracket notation, e.g. (-1, 2]
+func (i Interval) String() string {
+	lower, upper := "-inf", "inf"
+	if i.Lower != nil {
+		lower = i.Lower.String()
+	}
+	if i.Upper != nil {
+		upper = i.Upper.String()
+	}
+	return fmt.Sprintf("%s%s, %s%s", openBracket(i.LowerClosed), lower, upper, closeBracket(i.UpperClosed))
+}
+
+// LaTeX formats the interval in bracket notation
+func (i Interval) LaTeX() string {
+	lower, upper := "-\\infty", "\\infty"
+	if i.Lower != nil {
+		lower = i.Lower.LaTeX()
+	}
+	if i.Upper != nil {
+		upper = i.Upper.LaTeX()
+	}
+	return fmt.Sprintf("\\left%s%s, %s\\right%s", openBracket(i.LowerClosed), lower, upper, closeBracket(i.UpperClosed))
+}
+
+// Contains reports whether x lies in the interval
+func (i Interval) Contains(x float64) bool {
+	lower, upper := i.bounds()
+	if x < lower || (x == lower && !i.LowerClosed) {
+		return false
+	}
+	if x > upper || (x == upper && !i.UpperClosed) {
+		return false
+	}
+	return true
+}
+
+// bounds returns the numeric value of both bounds, using infinities for nil
+func (i Interval) bounds() (float64, float64) {
+	return boundValue(i.Lower, math.Inf(-1)), boundValue(i.Upper, math.Inf(1))
+}
+
+// IntervalSet is a union of disjoint intervals in increasing order
+type IntervalSet []Interval
+
+// RealLine returns the set of all real numbers
+func RealLine() IntervalSet {
+	return IntervalSet{{}}
+}
+
+// String formats the set as a union of intervals
+func (s IntervalSet) String() string {
+	if len(s) == 0 {
+		return "{}"
+	}
+	parts := make([]string, len(s))
+	for i, interval := range s {
+		parts[i] = interval.String()
+	}
+	return strings.Join(parts, " U ")
+}
+
+// LaTeX formats the set as a union of intervals
+func (s IntervalSet) LaTeX() string {
+	if len(s) == 0 {
+		return "\\emptyset"
+	}
+	parts := make([]string, len(s))
+	for i, interval := range s {
+		parts[i] = interval.LaTeX()
+	}
+	return strings.Join(parts, " \\cup ")
+}
+
+// Contains reports whether x lies in any interval of the set
+func (s IntervalSet) Contains(x float64) bool {
+	for _, interval := range s {
+		if interval.Contains(x) {
+			return true
+		}
+	}
+	return false
+}
+
+// Intersect returns the numbers that lie in both sets
+func (s IntervalSet) Intersect(other IntervalSet) IntervalSet {
+	result := IntervalSet{}
+	for _, a := range s {
+		for _, b := range other {
+			aLower, aUpper := a.bounds()
+			bLower, bUpper := b.bounds()
+
+			interval := a
+			lower := aLower
+			switch {
+			case bLower > aLower+boundTolerance:
+				interval.Lower, interval.LowerClosed, lower = b.Lower, b.LowerClosed, bLower
+			case math.Abs(bLower-aLower) <= boundTolerance:
+				interval.LowerClosed = a.LowerClosed && b.LowerClosed
+			}
+
+			upper := aUpper
+			switch {
+			case bUpper < aUpper-boundTolerance:
+				interval.Upper, interval.UpperClosed, upper = b.Upper, b.UpperClosed, bUpper
+			case math.Abs(bUpper-aUpper) <= boundTolerance:
+				interval.UpperClosed = a.UpperClosed && b.UpperClosed
+			}
+
+			if lower < upper-boundTolerance || (math.Abs(upper-lower) <= boundTolerance && interval.LowerClosed && interval.UpperClosed) {
+				result = append(result, interval)
+			}
+		}
+	}
+	return result
+}
+
+// Union returns the numbers that lie in either set, merging intervals that
+// overlap or touch
+func (s IntervalSet) Union(other IntervalSet) IntervalSet {
+	all := append(append(IntervalSet{}, s...), other...)
+	sort.SliceStable(all, func(i, j int) bool {
+		a, _ := all[i].bounds()
+		b, _ := all[j].bounds()
+		if sameBound(a, b) {
+			return all[i].LowerClosed && !all[j].LowerClosed
+		}
+		return a < b
+	})
+
+	result := IntervalSet{}
+	for _, interval := range all {
+		if n := len(result); n > 0 {
+			last := &result[n-1]
+			_, lastUpper := last.bounds()
+			lower, upper := interval.bounds()
+			touching := sameBound(lower, lastUpper) && (last.UpperClosed || interval.LowerClosed)
+			if lower < lastUpper && !sameBound(lower, lastUpper) || touching {
+				switch {
+				case sameBound(upper, lastUpper):
+					last.UpperClosed = last.UpperClosed || interval.UpperClosed
+				case upper > lastUpper:
+					last.Upper, last.UpperClosed = interval.Upper, interval.UpperClosed
+				}
+				continue
+			}
+		}
+		result = append(result, interval)
+	}
+	return result
+}
+
+// Equal reports whether both sets have numerically equal bounds with the
+// same open and closed ends
+func (s IntervalSet) Equal(other IntervalSet) bool {
+	if len(s) != len(other) {
+		return false
+	}
+	for i := range s {
+		aLower, aUpper := s[i].bounds()
+		bLower, bUpper := other[i].bounds()
+		if !sameBound(aLower, bLower) || !sameBound(aUpper, bUpper) {
+			return false
+		}
+		if s[i].LowerClosed != other[i].LowerClosed || s[i].UpperClosed != other[i].UpperClosed {
+			return false
+		}
+	}
+	return true
+}
+
+// SolveInequality solves an inequality or chained relation in one variable,
+// returning the values that satisfy it. Polynomial and rational relations
+// of any degree are supported, as are absolute values of them; an equation
+// yields its roots as single-point intervals.
+func SolveInequality(expr ast.Expr, opts ...SolveOptions) (IntervalSet, error) {
+	options := DefaultSolveOptions()
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+
+	// A positive variable can only take values above zero
+	domain := RealLine()
+	if options.Assumptions.Has(options.Variable, ast.Positive) {
+		domain = IntervalSet{{Lower: ast.NewInt(0)}}
+	}
+
+	switch e := expr.(type) {
+	case *ast.Eq:
+		set, err := solveRelation(e, options)
+		if err != nil {
+			return nil, err
+		}
+		return set.Intersect(domain), nil
+	case *ast.Chain:
+		result := domain
+		for _, link := range e.Links() {
+			set, err := solveRelation(link, options)
+			if err != nil {
+				return nil, err
+			}
+			result = result.Intersect(set)
+		}
+		return result, nil
+	default:
+		return nil, fmt.Errorf("expected an inequality, got %s", expr.Type())
+	}
+}
+
+// solveRelation solves a single comparison with a sign chart of lhs - rhs.
+// The difference is written as a quotient of polynomials; the real roots
+// of the numerator and the poles where the denominator vanishes split the
+// line into intervals on which the sign is constant, and one test point
+// gives the sign of each.
+func solveRelation(eq *ast.Eq, opts SolveOptions) (IntervalSet, error) {
+	difference := ast.NewAdd(eq.Left(), ast.NewMul(ast.NewInt(-1), eq.Right()))
+	f := simplify.Simplify(difference, simplifyOptions(opts))
+	for _, v := range f.Variables() {
+		if v != opts.Variable {
+			return nil, fmt.Errorf("cannot solve for %s: relation also depends on %s", opts.Variable, v)
+		}
+	}
+	if abs := findAbs(f, opts.Variable); abs != nil {
+		return solveAbsRelation(eq, abs, opts)
+	}
+
+	// The quotient is taken as written where possible, so that the pole
+	// of x/x >= 1 is not cancelled away
+	numerator, denominator, ok := rationalCoefficients(difference, opts.Variable)
+	if !ok {
+		numerator, denominator, ok = rationalCoefficients(f, opts.Variable)
+	}
+	if !ok {
+		return nil, fmt.Errorf("only polynomial and rational relations are supported")
+	}
+	if len(denominator) == 0 {
+		return nil, fmt.Errorf("relation %s divides by zero", eq)
+	}
+
+	zeros, completeZeros := polynomialRoots(numerator, opts.AllowApproximate)
+	poles, completePoles := polynomialRoots(denominator, opts.AllowApproximate)
+	if !completeZeros || !completePoles {
+		return nil, fmt.Errorf("relation %s has no exact critical points", eq)
+	}
+
+	// Critical points in increasing order; a pole is never part of the
+	// solution, even where the numerator vanishes too
+	type point struct {
+		expr  ast.Expr
+		value float64
+		pole  bool
+	}
+	var points []point
+	for _, root := range zeros {
+		points = append(points, point{root.Value, root.Approx, false})
+	}
+	for _, root := range poles {
+		points = append(points, point{root.Value, root.Approx, true})
+	}
+	sort.SliceStable(points, func(i, j int) bool { return points[i].value < points[j].value })
+	unique := points[:0]
+	for _, p := range points {
+		if n := len(unique); n > 0 && math.Abs(p.value-unique[n-1].value) <= boundTolerance*math.Max(1, math.Abs(p.value)) {
+			unique[n-1].pole = unique[n-1].pole || p.pole
+			continue
+		}
+		unique = append(unique, p)
+	}
+	points = unique
+
+	num64, den64 := floatCoefficients(numerator), floatCoefficients(denominator)
+	holds := func(x float64) bool {
+		value := evaluateFloat(num64, x) / evaluateFloat(den64, x)
+		sign := 0
+		switch {
+		case value > 0:
+			sign = 1
+		case value < 0:
+			sign = -1
+		}
+		return satisfies(eq.EqType(), sign)
+	}
+
+	result := IntervalSet{}
+	var current *Interval
+	extend := func(lower, upper ast.Expr, lowerClosed, upperClosed, ok bool) {
+		if !ok {
+			if current != nil {
+				result = append(result, *current)
+				current = nil
+			}
+			return
+		}
+		if current == nil {
+			current = &Interval{Lower: lower, LowerClosed: lowerClosed}
+		}
+		current.Upper, current.UpperClosed = upper, upperClosed
+	}
+
+	for i := 0; i <= len(points); i++ {
+		var lower, upper ast.Expr
+		var x float64
+		switch {
+		case len(points) == 0:
+			x = 0
+		case i == 0:
+			x = points[0].value - 1
+		case i == len(points):
+			x = points[i-1].value + 1
+		default:
+			x = (points[i-1].value + points[i].value) / 2
+		}
+		if i > 0 {
+			lower = points[i-1].expr
+		}
+		if i < len(points) {
+			upper = points[i].expr
+		}
+		extend(lower, upper, false, false, holds(x))
+
+		if i < len(points) {
+			p := points[i]
+			extend(p.expr, p.expr, true, true, !p.pole && satisfies(eq.EqType(), 0))
+		}
+	}
+	extend(nil, nil, false, false, false)
+
+	return result, nil
+}
+
+// solveAbsRelation solves a comparison containing |u| by cases, as solveAbs
+// does for equations: where u >= 0 the bars are dropped, where u < 0 |u| is
+// replaced by -u, and each case keeps the part of its solution inside it
+func solveAbsRelation(eq *ast.Eq, abs *ast.Func, opts SolveOptions) (IntervalSet, error) {
+	arg := abs.Args()[0]
+	cases := []struct {
+		value    ast.Expr
+		relation ast.EqType
+	}{
+		{arg, ast.EqGreaterEqual},
+		{ast.NewMul(ast.NewInt(-1), arg), ast.EqLess},
+	}
+
+	result := IntervalSet{}
+	for _, c := range cases {
+		replaced := ast.NewEq(ast.Replace(eq.Left(), abs, c.value), ast.Replace(eq.Right(), abs, c.value), eq.EqType())
+		if replaced.Equal(eq) {
+			return nil, fmt.Errorf("cannot split %s into cases", abs)
+		}
+		set, err := solveRelation(replaced, opts)
+		if err != nil {
+			return nil, err
+		}
+		region, err := solveRelation(ast.NewEq(arg, ast.NewInt(0), c.relation), opts)
+		if err != nil {
+			return nil, err
+		}
+		result = result.Union(set.Intersect(region))
+	}
+	return result, nil
+}
+
+// rationalCoefficients writes expr as a quotient of polynomials in
+// variable and returns their coefficients
+func rationalCoefficients(expr ast.Expr, variable string) ([]*big.Rat, []*big.Rat, bool) {
+	num, den := fraction(expr)
+	numerator, okNum := polynomialCoefficients(num, variable)
+	denominator, okDen := polynomialCoefficients(den, variable)
+	return numerator, denominator, okNum && okDen
+}
+
+// fraction writes expr as a quotient of two expressions without negative
+// powers: (x - 1)*(x + 2)^-1 is (x - 1)/(x + 2) and 1 + x^-1 is (x + 1)/x
+func fraction(expr ast.Expr) (ast.Expr, ast.Expr) {
+	switch e := expr.(type) {
+	case *ast.Add:
+		num, den := ast.Expr(ast.NewInt(0)), ast.Expr(ast.NewInt(1))
+		for _, term := range e.Terms() {
+			n, d := fraction(term)
+			if d.Equal(den) {
+				num = ast.NewAdd(num, n)
+				continue
+			}
+			num = ast.NewAdd(ast.NewMul(num, d), ast.NewMul(n, den))
+			den = ast.NewMul(den, d)
+		}
+		return num, den
+	case *ast.Mul:
+		var nums, dens []ast.Expr
+		for _, factor := range e.Terms() {
+			n, d := fraction(factor)
+			nums, dens = append(nums, n), append(dens, d)
+		}
+		return ast.NewMul(nums...), ast.NewMul(dens...)
+	case *ast.Pow:
+		exponent, ok := e.Exponent().(*ast.Int)
+		if !ok || !exponent.IntValue().IsInt64() {
+			break
+		}
+		num, den := fraction(e.Base())
+		k := exponent.IntValue().Int64()
+		if k < 0 {
+			num, den, k = den, num, -k
+		}
+		return ast.NewPow(num, ast.NewInt(k)), ast.NewPow(den, ast.NewInt(k))
+	}
+	return expr, ast.NewInt(1)
+}
+
+// satisfies reports whether a value with the given sign satisfies the
+// relation against zero
+func satisfies(relation ast.EqType, sign int) bool {
+	switch relation {
+	case ast.EqLess:
+		return sign < 0
+	case ast.EqGreater:
+		return sign > 0
+	case ast.EqLessEqual:
+		return sign <= 0
+	case ast.EqGreaterEqual:
+		return sign >= 0
+	case ast.EqNotEqual:
+		return sign != 0
+	default:
+		return sign == 0
+	}
+}
+
+// boundValue evaluates a bound, returning fallback for a nil bound or one
+// that cannot be evaluated
+func boundValue(bound ast.Expr, fallback float64) float64 {
+	if bound == nil {
+		return fallback
+	}
+	value, err := bound.Eval(make(map[string]*big.Float))
+	if err != nil {
+		return fallback
+	}
+	f, _ := value.Float64()
+	return f
+}
+
+// sameBound compares two bound values, treating equal infinities as equal
+func sameBound(a, b float64) bool {
+	if math.IsInf(a, 0) || math.IsInf(b, 0) {
+		return a == b
+	}
+	return math.Abs(a-b) <= boundTolerance*math.Max(1, math.Abs(a))
+}
+
+func openBracket(closed bool) string {
+	if closed {
+		return "["
+	}
+	return "("
+}
+
+func closeBracket(closed bool) string {
+	if closed {
+		return "]"
+	}
+	return ")"
+}
@@ -0,0 +1,131 @@
+package solve
+
+import (
+	"testing"
+
+	"github.com/quizizz/cas/pkg/parser"
+)
+
+func TestSolveInequality(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"-2 < 3x + 1 <= 7", "(-1, 2]"},
+		{"5 >= x > 1", "(1, 5]"},
+		{"2x + 1 >= x", "[-1, inf)"},
+		{"-x > 2", "(-inf, -2)"},
+		{"x^2 - 4 > 0", "(-inf, -2) U (2, inf)"},
+		{"x^2 - 4 <= 0", "[-2, 2]"},
+		{"x^2 < 2", "(-1*sqrt(2), sqrt(2))"},
+		{"(x-1)^2 <= 0", "[1, 1]"},
+		{"(x-1)^2 > 0", "(-inf, 1) U (1, inf)"},
+		{"x^2 + 1 > 0", "(-inf, inf)"},
+		{"x^2 + 1 < 0", "{}"},
+		{"1 < x < 0", "{}"},
+		{"x/2 <= 1/3", "(-inf, 2/3]"},
+		{"0 <= x <= 1 < 2", "[0, 1]"},
+		{"(x-1)(x-2)(x-3) > 0", "(1, 2) U (3, inf)"},
+		{"x^3 > 8", "(2, inf)"},
+		{"x^4 - 5x^2 + 4 <= 0", "[-2, -1] U [1, 2]"},
+		{"1/x > 0", "(0, inf)"},
+		{"1 < 1/x < 2", "(1/2, 1)"},
+		{"(x-1)/(x+2) >= 0", "(-inf, -2) U [1, inf)"},
+		{"x/x >= 1", "(-inf, 0) U (0, inf)"},
+		{"abs(x-1) < 3", "(-2, 4)"},
+		{"abs(2x-3) >= 5", "(-inf, -1] U [4, inf)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			expr, err := parser.Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+
+			result, err := SolveInequality(expr)
+			if err != nil {
+				t.Fatalf("SolveInequality error: %v", err)
+			}
+			if result.String() != tt.expected {
+				t.Errorf("SolveInequality(%s) = %s, expected %s", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSolveInequalityErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"not a relation", "x + 1"},
+		{"other variable", "x < y"},
+		{"root", "sqrt(x) < 2"},
+		{"exponential", "0 < 2^x < 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := parser.Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+			if _, err := SolveInequality(expr); err == nil {
+				t.Errorf("SolveInequality(%s) should fail", tt.input)
+			}
+		})
+	}
+}
+
+func TestIntervalSet(t *testing.T) {
+	expr, _ := parser.Parse("x^2 - 4 >= 0")
+	set, err := SolveInequality(expr)
+	if err != nil {
+		t.Fatalf("SolveInequality error: %v", err)
+	}
+
+	for _, x := range []float64{-3, -2, 2, 5} {
+		if !set.Contains(x) {
+			t.Errorf("%s should contain %v", set, x)
+		}
+	}
+	for _, x := range []float64{-1.9, 0, 1.5} {
+		if set.Contains(x) {
+			t.Errorf("%s should not contain %v", set, x)
+		}
+	}
+
+	if got, want := set.LaTeX(), "\\left(-\\infty, -2\\right] \\cup \\left[2, \\infty\\right)"; got != want {
+		t.Errorf("LaTeX() = %s, want %s", got, want)
+	}
+
+	other, _ := parser.Parse("2 <= x")
+	otherSet, _ := SolveInequality(other)
+	if got, want := set.Intersect(otherSet).String(), "[2, inf)"; got != want {
+		t.Errorf("Intersect() = %s, want %s", got, want)
+	}
+	if set.Equal(otherSet) {
+		t.Errorf("%s should not equal %s", set, otherSet)
+	}
+
+	unions := []struct {
+		a, b     string
+		expected string
+	}{
+		{"x < 0", "x > 0", "(-inf, 0) U (0, inf)"},
+		{"x < 0", "x >= 0", "(-inf, inf)"},
+		{"0 <= x <= 2", "1 < x < 3", "[0, 3)"},
+		{"x^2 - 4 >= 0", "-3 < x < 3", "(-inf, inf)"},
+		{"2 < x < 5", "x^2 < 1", "(-1, 1) U (2, 5)"},
+	}
+	for _, tt := range unions {
+		a, _ := parser.Parse(tt.a)
+		b, _ := parser.Parse(tt.b)
+		aSet, _ := SolveInequality(a)
+		bSet, _ := SolveInequality(b)
+		if got := aSet.Union(bSet).String(); got != tt.expected {
+			t.Errorf("(%s).Union(%s) = %s, want %s", aSet, bSet, got, tt.expected)
+		}
+	}
+}
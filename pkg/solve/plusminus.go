@@ -0,0 +1,189 @@
+package solve
+
+import (
+	"math/big"
+	"sort"
+
+	"github.com/quizizz/cas/pkg/ast"
+	"github.com/quizizz/cas/pkg/expand"
+)
+
+// maxRadicand bounds the numbers whose square factors are taken out of a
+// root
+const maxRadicand = 1 << 40
+
+// CollapseRoots writes two roots, or two solutions x = root, that differ
+// only in the sign of one term as one ± answer over a common denominator:
+// the roots of x^2+3x+1 give (-3 ± sqrt(5))/2, and 2 and -2 give ±2. The
+// roots are expanded into sums of terms in a fixed order first, so roots
+// written differently, as 2^-1*(-3+sqrt(5)) and -3*2^-1+-1*2^-1*sqrt(5),
+// still collapse.
+func CollapseRoots(a, b ast.Expr) (ast.Expr, bool) {
+	collapsed, ok := ast.CollapsePlusMinus(canonicalRoot(a), canonicalRoot(b))
+	if !ok {
+		return nil, false
+	}
+	if eq, ok := collapsed.(*ast.Eq); ok {
+		return ast.NewEq(eq.Left(), overDenominator(eq.Right().(*ast.PlusMinus)), eq.EqType()), true
+	}
+	return overDenominator(collapsed.(*ast.PlusMinus)), true
+}
+
+// canonicalRoot writes a root, or the right side of a solution x = root,
+// as a canonical sum
+func canonicalRoot(expr ast.Expr) ast.Expr {
+	if eq, ok := expr.(*ast.Eq); ok {
+		return ast.NewEq(eq.Left(), canonicalSum(eq.Right()), eq.EqType())
+	}
+	return canonicalSum(expr)
+}
+
+// overDenominator takes the least common denominator d of the coefficients
+// of a ± b out as a fraction, giving (d*a ± d*b)/d
+func overDenominator(pm *ast.PlusMinus) ast.Expr {
+	var terms []ast.Expr
+	if pm.Left() != nil {
+		terms = ast.Addends(pm.Left())
+	}
+	terms = append(terms, ast.Addends(pm.Right())...)
+
+	denominator := big.NewInt(1)
+	for _, term := range terms {
+		coefficient, _ := canonicalTerm(term)
+		gcd := new(big.Int).GCD(nil, nil, denominator, coefficient.Denom())
+		denominator.Mul(denominator, new(big.Int).Quo(coefficient.Denom(), gcd))
+	}
+	if denominator.Cmp(big.NewInt(1)) == 0 {
+		return pm
+	}
+
+	scale := new(big.Rat).SetInt(denominator)
+	var left ast.Expr
+	if pm.Left() != nil {
+		left = scaleSum(pm.Left(), scale)
+	}
+	numerator := ast.NewPlusMinus(left, scaleSum(pm.Right(), scale))
+	return ast.NewMul(numerator, ast.NewPow(ast.NewNumber(scale), ast.NewInt(-1)))
+}
+
+// scaleSum multiplies each term of a canonical sum by scale
+func scaleSum(expr ast.Expr, scale *big.Rat) ast.Expr {
+	var terms []ast.Expr
+	for _, term := range ast.Addends(expr) {
+		coefficient, rest := canonicalTerm(term)
+		terms = append(terms, termExpr(coefficient.Mul(coefficient, scale), rest))
+	}
+	if len(terms) == 1 {
+		return terms[0]
+	}
+	return ast.NewAdd(terms...)
+}
+
+// canonicalSum expands expr and writes each term as an exact coefficient
+// times its other factors, adding up like terms. The constant term comes
+// first and the others are ordered by their factors.
+func canonicalSum(expr ast.Expr) ast.Expr {
+	var keys []string
+	coefficients := map[string]*big.Rat{}
+	factors := map[string]ast.Expr{}
+	for _, term := range ast.Addends(expand.Expand(expr)) {
+		coefficient, rest := canonicalTerm(term)
+		key := ""
+		if rest != nil {
+			key = rest.String()
+		}
+		if sum, ok := coefficients[key]; ok {
+			sum.Add(sum, coefficient)
+			continue
+		}
+		keys = append(keys, key)
+		coefficients[key], factors[key] = coefficient, rest
+	}
+	sort.Strings(keys)
+
+	var terms []ast.Expr
+	for _, key := range keys {
+		if coefficients[key].Sign() != 0 {
+			terms = append(terms, termExpr(coefficients[key], factors[key]))
+		}
+	}
+	switch len(terms) {
+	case 0:
+		return ast.NewInt(0)
+	case 1:
+		return terms[0]
+	}
+	return ast.NewAdd(terms...)
+}
+
+// termExpr writes a term as its coefficient times rest, leaving out a
+// coefficient of 1 and a missing rest
+func termExpr(coefficient *big.Rat, rest ast.Expr) ast.Expr {
+	switch {
+	case rest == nil:
+		return ast.NewNumber(coefficient)
+	case coefficient.Cmp(big.NewRat(1, 1)) == 0:
+		return rest
+	}
+	return ast.NewMul(ast.NewNumber(coefficient), rest)
+}
+
+// canonicalTerm splits a product into its exact numeric coefficient and
+// the product of its other factors in a fixed order, or nil when there
+// are none. Numbers under a root are added up, so sqrt(3^2+-4) becomes
+// sqrt(5).
+func canonicalTerm(term ast.Expr) (*big.Rat, ast.Expr) {
+	coefficient := big.NewRat(1, 1)
+	var rest []ast.Expr
+	for _, factor := range ast.Factors(term) {
+		if value, ok := ast.ExactValue(factor); ok {
+			coefficient.Mul(coefficient, value)
+			continue
+		}
+		if fn, ok := factor.(*ast.Func); ok {
+			args := fn.Args()
+			for i, arg := range args {
+				args[i] = canonicalSum(arg)
+			}
+			factor = ast.NewFunc(fn.Name(), args...)
+			if outside, inside, ok := squareRoot(fn.Name(), args); ok {
+				coefficient.Mul(coefficient, outside)
+				if inside.Cmp(big.NewInt(1)) == 0 {
+					continue
+				}
+				factor = ast.NewFunc("sqrt", ast.NewNumber(new(big.Rat).SetInt(inside)))
+			}
+		}
+		rest = append(rest, factor)
+	}
+	sort.SliceStable(rest, func(i, j int) bool { return rest[i].String() < rest[j].String() })
+
+	switch len(rest) {
+	case 0:
+		return coefficient, nil
+	case 1:
+		return coefficient, rest[0]
+	}
+	return coefficient, ast.NewMul(rest...)
+}
+
+// squareRoot writes the square root of a non-negative number as
+// outside*sqrt(inside) with the integer inside free of square factors, so
+// sqrt(8) is 2*sqrt(2) and sqrt(1/2) is 1/2*sqrt(2)
+func squareRoot(name string, args []ast.Expr) (*big.Rat, *big.Int, bool) {
+	if name != "sqrt" || len(args) != 1 {
+		return nil, nil, false
+	}
+	value, ok := ast.ExactValue(args[0])
+	if !ok || value.Sign() < 0 {
+		return nil, nil, false
+	}
+
+	// sqrt(p/q) = sqrt(p*q)/q
+	product := new(big.Int).Mul(value.Num(), value.Denom())
+	if product.Cmp(big.NewInt(maxRadicand)) > 0 {
+		return nil, nil, false
+	}
+	outside, inside := squareFree(product)
+	return new(big.Rat).SetFrac(outside, value.Denom()), inside, true
+}
@@ -0,0 +1,69 @@
+package solve
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/quizizz/cas/pkg/ast"
+	"github.com/quizizz/cas/pkg/parser"
+)
+
+func TestCollapseRoots(t *testing.T) {
+	x := ast.NewVar("x")
+	roots := QuadraticRoots(big.NewRat(1, 1), big.NewRat(-2, 1), big.NewRat(-1, 1))
+	// solved returns the two roots Solve finds, as it writes them
+	solved := func(input string) (ast.Expr, ast.Expr) {
+		expr, err := parser.Parse(input)
+		if err != nil {
+			t.Fatalf("Parse error: %v", err)
+		}
+		solutions := Solve(expr).Solutions
+		if len(solutions) != 2 {
+			t.Fatalf("Solve(%s) found %d solutions, want 2", input, len(solutions))
+		}
+		return solutions[0].Value, solutions[1].Value
+	}
+
+	tests := []struct {
+		name     string
+		a, b     ast.Expr
+		expected string
+	}{
+		{"quadratic roots", roots[0], roots[1], "1±sqrt(2)"},
+		{"solutions", ast.NewEq(x, roots[0], ast.EqEqual), ast.NewEq(x, roots[1], ast.EqEqual), "x=1±sqrt(2)"},
+		{"opposite numbers", ast.NewInt(-2), ast.NewInt(2), "±2"},
+		{"not symmetric", ast.NewInt(1), ast.NewInt(3), ""},
+		{"different variables", ast.NewEq(x, ast.NewInt(1), ast.EqEqual), ast.NewEq(ast.NewVar("y"), ast.NewInt(-1), ast.EqEqual), ""},
+	}
+	for _, input := range []struct{ equation, expected string }{
+		{"x^2+3x+1", "(-3±sqrt(5))*2^-1"},
+		{"x^2-4x+1", "2±sqrt(3)"},
+		{"4x^2-5", "(±sqrt(5))*2^-1"},
+		{"x^2-5x+6", ""},
+	} {
+		a, b := solved(input.equation)
+		tests = append(tests, struct {
+			name     string
+			a, b     ast.Expr
+			expected string
+		}{"roots of " + input.equation, a, b, input.expected})
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, ok := CollapseRoots(tt.a, tt.b)
+			if tt.expected == "" {
+				if ok {
+					t.Errorf("CollapseRoots(%s, %s) = %s, want none", tt.a, tt.b, result)
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("CollapseRoots(%s, %s) failed, want %s", tt.a, tt.b, tt.expected)
+			}
+			if result.String() != tt.expected {
+				t.Errorf("CollapseRoots(%s, %s) = %s, want %s", tt.a, tt.b, result, tt.expected)
+			}
+		})
+	}
+}
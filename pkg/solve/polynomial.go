@@ -0,0 +1,348 @@
+package solve
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+
+	"github.com/quizizz/cas/pkg/ast"
+	"github.com/quizizz/cas/pkg/expand"
+)
+
+// maxRationalRootTerm bounds the constant and leading coefficients whose
+// divisors are tried as rational roots
+const maxRationalRootTerm = 1000000
+
+// polynomialRoot is a real root of a polynomial, exact when it is rational
+// or a quadratic surd and approximate otherwise
+type polynomialRoot struct {
+	Value   ast.Expr
+	Approx  float64
+	IsExact bool
+}
+
+// solvePolynomial solves a polynomial equation of degree 3 or more with
+// rational coefficients. Rational roots are found with the rational root
+// theorem and divided out; a remaining quadratic is solved exactly, and a
+// remaining factor of higher degree numerically when approximate solutions
+// are allowed.
+func solvePolynomial(expr ast.Expr, opts SolveOptions, degree int) SolutionSet {
+	coeffs, ok := polynomialCoefficients(expr, opts.Variable)
+	if !ok {
+		return SolutionSet{
+			Message:      fmt.Sprintf("Cannot solve polynomial of degree %d with non-rational coefficients", degree),
+			HasSolutions: false,
+		}
+	}
+
+	roots, complete := polynomialRoots(coeffs, opts.AllowApproximate)
+	if !complete {
+		return SolutionSet{
+			Message:      fmt.Sprintf("Polynomial degree %d has no exact solution", degree),
+			HasSolutions: false,
+		}
+	}
+	if len(roots) == 0 {
+		return SolutionSet{
+			Message:      "No real solutions",
+			Empty:        true,
+			HasSolutions: false,
+		}
+	}
+
+	solutions := make([]Solution, len(roots))
+	for i, root := range roots {
+		solutions[i] = Solution{Variable: opts.Variable, Value: root.Value, IsReal: true, IsExact: root.IsExact}
+	}
+	return SolutionSet{
+		Solutions:    solutions,
+		Message:      "Polynomial equation solved",
+		HasSolutions: true,
+	}
+}
+
+// polynomialCoefficients lists the coefficients of expr as a polynomial in
+// variable, constant term first, reporting false when a coefficient is not
+// rational
+func polynomialCoefficients(expr ast.Expr, variable string) ([]*big.Rat, bool) {
+	var coeffs []*big.Rat
+	for _, term := range ast.Addends(expand.Expand(expr)) {
+		degree := 0
+		coeff := big.NewRat(1, 1)
+		for _, factor := range ast.Factors(term) {
+			if n, ok := variablePower(factor, variable); ok {
+				degree += n
+				continue
+			}
+			value, ok := ast.ExactValue(factor)
+			if !ok {
+				return nil, false
+			}
+			coeff.Mul(coeff, value)
+		}
+		for len(coeffs) <= degree {
+			coeffs = append(coeffs, new(big.Rat))
+		}
+		coeffs[degree].Add(coeffs[degree], coeff)
+	}
+	return trimCoefficients(coeffs), true
+}
+
+// variablePower returns n for a factor variable^n
+func variablePower(factor ast.Expr, variable string) (int, bool) {
+	switch f := factor.(type) {
+	case *ast.Var:
+		return 1, f.Name() == variable
+	case *ast.Pow:
+		v, ok := f.Base().(*ast.Var)
+		n, isInt := f.Exponent().(*ast.Int)
+		if ok && isInt && v.Name() == variable && n.IntValue().IsInt64() && n.IntValue().Sign() > 0 {
+			return int(n.IntValue().Int64()), true
+		}
+	}
+	return 0, false
+}
+
+// trimCoefficients drops zero leading coefficients
+func trimCoefficients(coeffs []*big.Rat) []*big.Rat {
+	for len(coeffs) > 0 && coeffs[len(coeffs)-1].Sign() == 0 {
+		coeffs = coeffs[:len(coeffs)-1]
+	}
+	return coeffs
+}
+
+// polynomialRoots returns the distinct real roots of a polynomial with
+// rational coefficients in ascending order. It reports false when part of
+// the polynomial has no exact roots and approximate ones are not allowed.
+func polynomialRoots(coeffs []*big.Rat, allowApproximate bool) ([]polynomialRoot, bool) {
+	var roots []polynomialRoot
+	addRoot := func(root polynomialRoot) {
+		for _, existing := range roots {
+			if math.Abs(existing.Approx-root.Approx) < boundTolerance*(1+math.Abs(root.Approx)) {
+				return
+			}
+		}
+		roots = append(roots, root)
+	}
+
+	coeffs = trimCoefficients(coeffs)
+	for len(coeffs) > 3 {
+		root, ok := RationalRoot(coeffs)
+		if !ok {
+			break
+		}
+		f, _ := root.Float64()
+		addRoot(polynomialRoot{Value: ast.NewNumber(root), Approx: f, IsExact: true})
+		coeffs = Deflate(coeffs, root)
+	}
+
+	complete := true
+	switch {
+	case len(coeffs) <= 3:
+		a, b, c := new(big.Rat), new(big.Rat), new(big.Rat)
+		for i, target := range []*big.Rat{c, b, a} {
+			if i < len(coeffs) {
+				target.Set(coeffs[i])
+			}
+		}
+		var exact []ast.Expr
+		switch {
+		case a.Sign() != 0:
+			exact = QuadraticRoots(a, b, c)
+		case b.Sign() != 0:
+			exact = []ast.Expr{ast.NewNumber(new(big.Rat).Quo(new(big.Rat).Neg(c), b))}
+		}
+		for _, root := range exact {
+			addRoot(polynomialRoot{Value: root, Approx: approximate(root), IsExact: true})
+		}
+	case allowApproximate:
+		for _, root := range realRoots(floatCoefficients(coeffs)) {
+			addRoot(polynomialRoot{Value: ast.NewFloat(root), Approx: root})
+		}
+	default:
+		complete = false
+	}
+
+	sort.Slice(roots, func(i, j int) bool { return roots[i].Approx < roots[j].Approx })
+	return roots, complete
+}
+
+// RationalRoot finds a rational root p/q of a polynomial with rational
+// coefficients, constant term first, where p divides the constant term and
+// q the leading coefficient once the coefficients are cleared to integers
+func RationalRoot(coeffs []*big.Rat) (*big.Rat, bool) {
+	if coeffs[0].Sign() == 0 {
+		return new(big.Rat), true
+	}
+
+	ints := integerCoefficients(coeffs)
+	constant := new(big.Int).Abs(ints[0])
+	leading := new(big.Int).Abs(ints[len(ints)-1])
+	limit := big.NewInt(maxRationalRootTerm)
+	if constant.Cmp(limit) > 0 || leading.Cmp(limit) > 0 {
+		return nil, false
+	}
+
+	for _, p := range divisors(constant.Int64()) {
+		for _, q := range divisors(leading.Int64()) {
+			for _, sign := range []int64{1, -1} {
+				candidate := big.NewRat(sign*p, q)
+				if evaluateRat(coeffs, candidate).Sign() == 0 {
+					return candidate, true
+				}
+			}
+		}
+	}
+	return nil, false
+}
+
+// integerCoefficients scales rational coefficients by the least common
+// multiple of their denominators
+func integerCoefficients(coeffs []*big.Rat) []*big.Int {
+	lcm := big.NewInt(1)
+	for _, c := range coeffs {
+		gcd := new(big.Int).GCD(nil, nil, lcm, c.Denom())
+		lcm.Mul(lcm, new(big.Int).Quo(c.Denom(), gcd))
+	}
+	ints := make([]*big.Int, len(coeffs))
+	for i, c := range coeffs {
+		scaled := new(big.Rat).Mul(c, new(big.Rat).SetInt(lcm))
+		ints[i] = new(big.Int).Set(scaled.Num())
+	}
+	return ints
+}
+
+// divisors lists the positive divisors of n
+func divisors(n int64) []int64 {
+	var result []int64
+	for d := int64(1); d*d <= n; d++ {
+		if n%d == 0 {
+			result = append(result, d)
+			if d*d != n {
+				result = append(result, n/d)
+			}
+		}
+	}
+	return result
+}
+
+// evaluateRat evaluates a polynomial at a rational point with Horner's rule
+func evaluateRat(coeffs []*big.Rat, x *big.Rat) *big.Rat {
+	result := new(big.Rat)
+	for i := len(coeffs) - 1; i >= 0; i-- {
+		result.Mul(result, x)
+		result.Add(result, coeffs[i])
+	}
+	return result
+}
+
+// Deflate divides a polynomial, constant term first, by (x - root) with
+// synthetic division
+func Deflate(coeffs []*big.Rat, root *big.Rat) []*big.Rat {
+	quotient := make([]*big.Rat, len(coeffs)-1)
+	carry := new(big.Rat)
+	for i := len(coeffs) - 1; i > 0; i-- {
+		carry = new(big.Rat).Add(coeffs[i], new(big.Rat).Mul(carry, root))
+		quotient[i-1] = carry
+	}
+	return quotient
+}
+
+// floatCoefficients converts rational coefficients to floats
+func floatCoefficients(coeffs []*big.Rat) []float64 {
+	floats := make([]float64, len(coeffs))
+	for i, c := range coeffs {
+		floats[i], _ = c.Float64()
+	}
+	return floats
+}
+
+// approximate evaluates a constant expression as a float
+func approximate(expr ast.Expr) float64 {
+	val, err := expr.Eval(make(map[string]*big.Float))
+	if err != nil {
+		return math.NaN()
+	}
+	f, _ := val.Float64()
+	return f
+}
+
+// realRoots finds the real roots of a polynomial numerically. The roots of
+// its derivative split the line into pieces on which it is monotonic, and
+// each piece holds at most one root, found by bisection.
+func realRoots(coeffs []float64) []float64 {
+	for len(coeffs) > 0 && coeffs[len(coeffs)-1] == 0 {
+		coeffs = coeffs[:len(coeffs)-1]
+	}
+	n := len(coeffs) - 1
+	switch {
+	case n < 1:
+		return nil
+	case n == 1:
+		return []float64{-coeffs[0] / coeffs[1]}
+	}
+
+	derivative := make([]float64, n)
+	for i := 1; i <= n; i++ {
+		derivative[i-1] = float64(i) * coeffs[i]
+	}
+
+	// Every root lies within the Cauchy bound
+	bound := 0.0
+	for _, c := range coeffs[:n] {
+		bound = math.Max(bound, math.Abs(c/coeffs[n]))
+	}
+	bound++
+
+	points := append([]float64{-bound}, realRoots(derivative)...)
+	points = append(points, bound)
+
+	var roots []float64
+	for i := 0; i+1 < len(points); i++ {
+		a, b := points[i], points[i+1]
+		fa, fb := evaluateFloat(coeffs, a), evaluateFloat(coeffs, b)
+		switch {
+		case math.Abs(fa) < 1e-12:
+			roots = appendRoot(roots, a)
+		case fa*fb < 0:
+			roots = appendRoot(roots, bisect(coeffs, a, b))
+		}
+	}
+	return roots
+}
+
+// appendRoot adds a root unless it repeats the previous one
+func appendRoot(roots []float64, root float64) []float64 {
+	if len(roots) > 0 && math.Abs(roots[len(roots)-1]-root) < 1e-9 {
+		return roots
+	}
+	return append(roots, root)
+}
+
+// bisect narrows a sign change of the polynomial on [a, b] to a root
+func bisect(coeffs []float64, a, b float64) float64 {
+	fa := evaluateFloat(coeffs, a)
+	for i := 0; i < 200 && b-a > 1e-15*(1+math.Abs(a)); i++ {
+		mid := (a + b) / 2
+		fm := evaluateFloat(coeffs, mid)
+		if fm == 0 {
+			return mid
+		}
+		if (fa < 0) == (fm < 0) {
+			a, fa = mid, fm
+		} else {
+			b = mid
+		}
+	}
+	return (a + b) / 2
+}
+
+// evaluateFloat evaluates a polynomial with Horner's rule
+func evaluateFloat(coeffs []float64, x float64) float64 {
+	result := 0.0
+	for i := len(coeffs) - 1; i >= 0; i-- {
+		result = result*x + coeffs[i]
+	}
+	return result
+}
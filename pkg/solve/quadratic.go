@@ -0,0 +1,66 @@
+package solve
+
+import (
+	"math/big"
+
+	"github.com/quizizz/cas/pkg/ast"
+)
+
+// QuadraticRoots returns the real roots of ax² + bx + c = 0 with rational
+// coefficients in ascending order, or nil when there are none. Irrational
+// roots are written p ± q·sqrt(d) with d square-free; a repeated root is
+// returned twice.
+func QuadraticRoots(a, b, c *big.Rat) []ast.Expr {
+	disc := new(big.Rat).Mul(b, b)
+	disc.Sub(disc, new(big.Rat).Mul(big.NewRat(4, 1), new(big.Rat).Mul(a, c)))
+	if disc.Sign() < 0 {
+		return nil
+	}
+
+	twoA := new(big.Rat).Mul(big.NewRat(2, 1), a)
+	center := new(big.Rat).Quo(new(big.Rat).Neg(b), twoA)
+	if disc.Sign() == 0 {
+		return []ast.Expr{ast.NewNumber(center), ast.NewNumber(center)}
+	}
+
+	// sqrt(p/q) = sqrt(pq)/q, then pull square factors out of pq
+	outside, inside := squareFree(new(big.Int).Mul(disc.Num(), disc.Denom()))
+	scale := new(big.Rat).SetFrac(outside, disc.Denom())
+	scale.Quo(scale, twoA)
+	scale.Abs(scale)
+
+	if inside.Cmp(big.NewInt(1)) == 0 {
+		return []ast.Expr{
+			ast.NewNumber(new(big.Rat).Sub(center, scale)),
+			ast.NewNumber(new(big.Rat).Add(center, scale)),
+		}
+	}
+
+	surd := func(sign int64) ast.Expr {
+		radicand, _ := ast.NewIntFromString(inside.String())
+		root := ast.Expr(ast.NewFunc("sqrt", radicand))
+		coeff := new(big.Rat).Mul(scale, big.NewRat(sign, 1))
+		if coeff.Cmp(big.NewRat(1, 1)) != 0 {
+			root = ast.NewMul(ast.NewNumber(coeff), root)
+		}
+		if center.Sign() == 0 {
+			return root
+		}
+		return ast.NewAdd(ast.NewNumber(center), root)
+	}
+	return []ast.Expr{surd(-1), surd(1)}
+}
+
+// squareFree writes n as outside²·inside with inside square-free
+func squareFree(n *big.Int) (*big.Int, *big.Int) {
+	outside := big.NewInt(1)
+	inside := new(big.Int).Set(n)
+	for f := big.NewInt(2); new(big.Int).Mul(f, f).Cmp(inside) <= 0; f.Add(f, big.NewInt(1)) {
+		square := new(big.Int).Mul(f, f)
+		for new(big.Int).Mod(inside, square).Sign() == 0 {
+			inside.Quo(inside, square)
+			outside.Mul(outside, f)
+		}
+	}
+	return outside, inside
+}
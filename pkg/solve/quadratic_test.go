@@ -0,0 +1,34 @@
+package solve
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestQuadraticRoots(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b, c  int64
+		expected []string
+	}{
+		{"rational roots", 1, -3, 2, []string{"1", "2"}},
+		{"fractional roots", 2, -1, -1, []string{"-1/2", "1"}},
+		{"surds", 1, -2, -1, []string{"1+-1*sqrt(2)", "1+sqrt(2)"}},
+		{"repeated root", 1, 2, 1, []string{"-1", "-1"}},
+		{"no real roots", 1, 0, 1, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			roots := QuadraticRoots(big.NewRat(tt.a, 1), big.NewRat(tt.b, 1), big.NewRat(tt.c, 1))
+			if len(roots) != len(tt.expected) {
+				t.Fatalf("QuadraticRoots(%d, %d, %d) = %v, want %v", tt.a, tt.b, tt.c, roots, tt.expected)
+			}
+			for i, root := range roots {
+				if root.String() != tt.expected[i] {
+					t.Errorf("root %d = %s, want %s", i, root, tt.expected[i])
+				}
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"math/big"
 
 	"github.com/quizizz/cas/pkg/ast"
+	"github.com/quizizz/cas/pkg/expand"
 	"github.com/quizizz/cas/pkg/simplify"
 )
 
@@ -22,6 +23,9 @@ type SolutionSet struct {
 	Solutions    []Solution
 	Message      string
 	HasSolutions bool
+	// Empty is set when the equation was shown to have no solutions, as
+	// opposed to the solver giving up on it
+	Empty bool
 }
 
 // SolveOptions controls equation solving behavior
@@ -32,8 +36,11 @@ type SolveOptions struct {
 	AllowComplex bool
 	// AllowApproximate enables approximate numerical solutions
 	AllowApproximate bool
-	// MaxDegree limits the polynomial degree for solving
+	// MaxDegree limits the polynomial degree for solving; zero means no limit
 	MaxDegree int
+	// Assumptions about the variables; solutions that break them are
+	// dropped
+	Assumptions ast.Assumptions
 }
 
 // DefaultSolveOptions returns default solving options
@@ -53,11 +60,22 @@ func Solve(expr ast.Expr, opts ...SolveOptions) SolutionSet {
 		options = opts[0]
 	}
 
+	// An equation lhs = rhs is solved as lhs - rhs = 0
+	if eq, ok := expr.(*ast.Eq); ok {
+		if eq.EqType() != ast.EqEqual {
+			return SolutionSet{
+				Message:      fmt.Sprintf("%s is not an equation; use SolveInequality", eq),
+				HasSolutions: false,
+			}
+		}
+		return SolveEquation(eq.Left(), eq.Right(), options)
+	}
+
 	// Simplify the expression first
-	simplified := simplify.Simplify(expr)
+	simplified := simplify.Simplify(expr, simplifyOptions(options))
 
 	// Determine equation type and solve accordingly
-	return solveEquation(simplified, options)
+	return keepAssumed(solveEquation(simplified, options), options)
 }
 
 // SolveEquation solves equation lhs = rhs for the specified variable
@@ -69,9 +87,45 @@ func SolveEquation(lhs, rhs ast.Expr, opts ...SolveOptions) SolutionSet {
 
 	// Convert to standard form: lhs - rhs = 0
 	diff := ast.NewAdd(lhs, ast.NewMul(ast.NewInt(-1), rhs))
-	simplified := simplify.Simplify(diff)
+	simplified := simplify.Simplify(diff, simplifyOptions(options))
+
+	return keepAssumed(solveEquation(simplified, options), options)
+}
 
-	return solveEquation(simplified, options)
+// simplifyOptions returns the simplification options for solving under
+// opts, which carry its assumptions
+func simplifyOptions(opts SolveOptions) simplify.Options {
+	options := simplify.DefaultOptions()
+	options.Assumptions = opts.Assumptions
+	return options
+}
+
+// keepAssumed drops the solutions whose values break the assumptions on
+// the variable solved for
+func keepAssumed(set SolutionSet, opts SolveOptions) SolutionSet {
+	if opts.Assumptions[opts.Variable] == 0 || len(set.Solutions) == 0 {
+		return set
+	}
+
+	var kept []Solution
+	for _, solution := range set.Solutions {
+		value, err := solution.Value.Eval(nil)
+		if err != nil {
+			continue
+		}
+		if f, _ := value.Float64(); opts.Assumptions.Allows(opts.Variable, f) {
+			kept = append(kept, solution)
+		}
+	}
+	if len(kept) == 0 {
+		return SolutionSet{
+			Message:      "No solution satisfies the assumptions on " + opts.Variable,
+			Empty:        true,
+			HasSolutions: false,
+		}
+	}
+	set.Solutions = kept
+	return set
 }
 
 // solveEquation is the main solving dispatcher
@@ -104,11 +158,17 @@ func solveEquation(expr ast.Expr, opts SolveOptions) SolutionSet {
 		} else {
 			return SolutionSet{
 				Message:      "No solution: expression is never zero",
+				Empty:        true,
 				HasSolutions: false,
 			}
 		}
 	}
 
+	// Absolute values of the variable are solved case by case
+	if abs := findAbs(expr, opts.Variable); abs != nil {
+		return solveAbs(expr, abs, opts)
+	}
+
 	// Classify equation type
 	degree := getPolynomialDegree(expr, opts.Variable)
 
@@ -119,25 +179,58 @@ func solveEquation(expr ast.Expr, opts SolveOptions) SolutionSet {
 		return solveLinear(expr, opts)
 	case degree == 2:
 		return solveQuadratic(expr, opts)
-	case degree == 3:
-		return solveCubic(expr, opts)
-	case degree == 4:
-		return solveQuartic(expr, opts)
-	case degree > 4:
+	case opts.MaxDegree > 0 && degree > opts.MaxDegree:
 		return SolutionSet{
 			Message:      fmt.Sprintf("Polynomial degree %d too high for exact solution", degree),
 			HasSolutions: false,
 		}
+	case degree >= 3:
+		return solvePolynomial(expr, opts, degree)
 	default:
 		return solveGeneral(expr, opts)
 	}
 }
 
-// getPolynomialDegree determines the degree of a polynomial in the given variable
+// getPolynomialDegree determines the degree of a polynomial in the given
+// variable, or -1 when expr is not a polynomial in it
 func getPolynomialDegree(expr ast.Expr, variable string) int {
+	if !isPolynomial(expr, variable) {
+		return -1
+	}
 	return getExpressionDegree(expr, variable)
 }
 
+// isPolynomial reports whether expr is a polynomial in variable: every
+// part of it that depends on variable is a sum, a product or a
+// non-negative integer power. getExpressionDegree alone can miss a
+// non-polynomial term, such as sqrt(x) in sqrt(x) - 2.
+func isPolynomial(expr ast.Expr, variable string) bool {
+	if !containsVariable(expr, variable) {
+		return true
+	}
+	switch e := expr.(type) {
+	case *ast.Var:
+		return true
+	case *ast.Add:
+		return allPolynomial(e.Terms(), variable)
+	case *ast.Mul:
+		return allPolynomial(e.Terms(), variable)
+	case *ast.Pow:
+		n, ok := e.Exponent().(*ast.Int)
+		return ok && n.IntValue().Sign() >= 0 && isPolynomial(e.Base(), variable)
+	}
+	return false
+}
+
+func allPolynomial(exprs []ast.Expr, variable string) bool {
+	for _, e := range exprs {
+		if !isPolynomial(e, variable) {
+			return false
+		}
+	}
+	return true
+}
+
 func getExpressionDegree(expr ast.Expr, variable string) int {
 	switch e := expr.(type) {
 	case *ast.Var:
@@ -210,6 +303,7 @@ func solveConstant(expr ast.Expr, opts SolveOptions) SolutionSet {
 	} else {
 		return SolutionSet{
 			Message:      "No solution: " + result.Text('g', -1) + " ≠ 0",
+			Empty:        true,
 			HasSolutions: false,
 		}
 	}
@@ -217,12 +311,16 @@ func solveConstant(expr ast.Expr, opts SolveOptions) SolutionSet {
 
 // solveLinear solves linear equations ax + b = 0
 func solveLinear(expr ast.Expr, opts SolveOptions) SolutionSet {
-	// Extract coefficients: ax + b = 0
-	a, b := extractLinearCoefficients(expr, opts.Variable)
+	// Extract coefficients: ax + b = 0, expanding factored forms like -1*(C+-1) first
+	a, b := extractLinearCoefficients(expand.Expand(expr), opts.Variable)
 
-	// Check if 'a' is zero
+	// Check if 'a' is zero; if so the variable cancels, as in
+	// x - 1*(x - 2) - 4, and what is left is a constant equation
 	aVal, err := a.Eval(make(map[string]*big.Float))
-	if err != nil || aVal.Sign() == 0 {
+	if err == nil && aVal.Sign() == 0 {
+		return solveConstant(b, opts)
+	}
+	if err != nil {
 		return SolutionSet{
 			Message:      "Not a linear equation in " + opts.Variable,
 			HasSolutions: false,
@@ -256,7 +354,7 @@ func extractLinearCoefficients(expr ast.Expr, variable string) (a, b ast.Expr) {
 
 	switch e := expr.(type) {
 	case *ast.Add:
-		for _, term := range e.Terms() {
+		for _, term := range ast.Addends(e) {
 			if containsVariable(term, variable) {
 				// Extract coefficient of the variable
 				coeff := extractCoefficient(term, variable)
@@ -300,7 +398,7 @@ func extractCoefficient(term ast.Expr, variable string) ast.Expr {
 	case *ast.Mul:
 		var coeff ast.Expr = ast.NewInt(1)
 		hasVar := false
-		for _, factor := range t.Terms() {
+		for _, factor := range ast.Factors(t) {
 			if v, ok := factor.(*ast.Var); ok && v.Name() == variable {
 				hasVar = true
 			} else if !containsVariable(factor, variable) {
@@ -362,6 +460,7 @@ func solveQuadratic(expr ast.Expr, opts SolveOptions) SolutionSet {
 		if !opts.AllowComplex {
 			return SolutionSet{
 				Message:      "No real solutions (discriminant < 0)",
+				Empty:        true,
 				HasSolutions: false,
 			}
 		}
@@ -428,7 +527,7 @@ func extractQuadraticCoefficients(expr ast.Expr, variable string) (a, b, c ast.E
 	// A complete implementation would need more sophisticated term analysis
 	switch e := expr.(type) {
 	case *ast.Add:
-		for _, term := range e.Terms() {
+		for _, term := range ast.Addends(e) {
 			degree := getExpressionDegree(term, variable)
 			switch degree {
 			case 2:
@@ -484,7 +583,7 @@ func extractCoefficientForDegree(term ast.Expr, variable string, targetDegree in
 		var coeff ast.Expr = ast.NewInt(1)
 		varPower := 0
 
-		for _, factor := range t.Terms() {
+		for _, factor := range ast.Factors(t) {
 			if v, ok := factor.(*ast.Var); ok && v.Name() == variable {
 				varPower++
 			} else if pow, ok := factor.(*ast.Pow); ok {
@@ -511,21 +610,7 @@ func extractCoefficientForDegree(term ast.Expr, variable string, targetDegree in
 	}
 }
 
-// Placeholder implementations for higher-order equations
-func solveCubic(expr ast.Expr, opts SolveOptions) SolutionSet {
-	return SolutionSet{
-		Message:      "Cubic equation solving not yet implemented",
-		HasSolutions: false,
-	}
-}
-
-func solveQuartic(expr ast.Expr, opts SolveOptions) SolutionSet {
-	return SolutionSet{
-		Message:      "Quartic equation solving not yet implemented",
-		HasSolutions: false,
-	}
-}
-
+// solveGeneral is a placeholder for equations that are not polynomial
 func solveGeneral(expr ast.Expr, opts SolveOptions) SolutionSet {
 	return SolutionSet{
 		Message:      "General equation solving not yet implemented",
@@ -151,6 +151,87 @@ func TestSolveQuadraticEquations(t *testing.T) {
 	}
 }
 
+func TestSolveEmpty(t *testing.T) {
+	// Only an equation shown to have no solutions is empty; one the
+	// solver gives up on is not
+	tests := []struct {
+		equation string
+		empty    bool
+	}{
+		{"x^2+1", true},
+		{"x^4+x^2+1", true},
+		{"|x|+1", true},
+		{"0*x+3", true},
+		{"x^2-4", false},
+		{"x^5-x-1", false},
+		{"\\sin(x)-x^3", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.equation, func(t *testing.T) {
+			expr, err := parser.Parse(tt.equation)
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+			result := Solve(expr)
+			if result.Empty != tt.empty {
+				t.Errorf("Solve(%s).Empty = %v, want %v (%s)", tt.equation, result.Empty, tt.empty, result.Message)
+			}
+		})
+	}
+}
+
+func TestSolvePolynomialEquations(t *testing.T) {
+	tests := []struct {
+		name      string
+		equation  string
+		solutions []string
+	}{
+		{"rational roots", "x^3-6*x^2+11*x-6", []string{"1", "2", "3"}},
+		{"root at zero", "4*x^3-4*x", []string{"-1", "0", "1"}},
+		{"repeated root", "x^4-2*x^3+x^2", []string{"0", "1"}},
+		{"surds after a rational root", "x^3-x^2-2*x+2", []string{"-1*sqrt(2)", "1", "sqrt(2)"}},
+		{"biquadratic", "x^4-5*x^2+4", []string{"-2", "-1", "1", "2"}},
+		{"fractional root", "2*x^3-x^2-2*x+1", []string{"-1", "1/2", "1"}},
+		{"no real roots", "x^4+1", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := parser.Parse(tt.equation)
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+
+			result := Solve(expr)
+			if len(result.Solutions) != len(tt.solutions) {
+				t.Fatalf("Solve(%s) = %v (%s), expected %v", tt.equation, result.Solutions, result.Message, tt.solutions)
+			}
+			for i, sol := range result.Solutions {
+				if got := sol.Value.String(); got != tt.solutions[i] {
+					t.Errorf("Solution %d = %s, want %s", i+1, got, tt.solutions[i])
+				}
+				if !sol.IsExact {
+					t.Errorf("Solution %d = %s should be exact", i+1, sol.Value)
+				}
+			}
+		})
+	}
+
+	// x^3 - 2 has no rational root and is solved numerically
+	expr, _ := parser.Parse("x^3-2")
+	result := Solve(expr)
+	if len(result.Solutions) != 1 || result.Solutions[0].IsExact {
+		t.Fatalf("Solve(x^3-2) = %v, expected one approximate solution", result.Solutions)
+	}
+	if !validateSolution(result.Solutions[0].Value, expr, "x") {
+		t.Errorf("Solve(x^3-2) = %s does not satisfy the equation", result.Solutions[0].Value)
+	}
+	if result := Solve(expr, SolveOptions{Variable: "x"}); result.HasSolutions {
+		t.Errorf("Solve(x^3-2) without approximation = %v, expected no exact solution", result.Solutions)
+	}
+}
+
 func TestSolveEquationForm(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -408,6 +489,119 @@ func TestComplexExpressions(t *testing.T) {
 	}
 }
 
+func TestSolveAbsoluteValue(t *testing.T) {
+	tests := []struct {
+		name      string
+		equation  string
+		solutions []string
+		message   string
+	}{
+		{"both cases", "abs(2x-3)=5", []string{"-1", "4"}, "Absolute value equation solved"},
+		{"bars", "|2x - 3| = 5", []string{"-1", "4"}, "Absolute value equation solved"},
+		{"scaled and shifted", "2abs(x-1)+1=7", []string{"-2", "4"}, "Absolute value equation solved"},
+		{"one case outside its region", "abs(x+1)=2x", []string{"1"}, "Absolute value equation solved"},
+		{"absolute value on both sides", "abs(x-1)=abs(2x+1)", []string{"-2", "0"}, "Absolute value equation solved"},
+		{"two absolute values", "abs(x)+abs(x-2)=4", []string{"-1", "3"}, "Absolute value equation solved"},
+		{"negative right side", "abs(x)=-1", nil, "No solution in either case of abs(x)"},
+		{"identity on one side", "abs(x)=x", nil, "Identity: true for all values of x where x>=0"},
+		{"cubic cases", "abs(x)^3=8", []string{"-2", "2"}, "Absolute value equation solved"},
+		{"unsolved root case", "sqrt(abs(x))=2", nil, "Cannot solve the case x>=0: General equation solving not yet implemented"},
+		{"unsolved reciprocal case", "1/abs(x)=2", nil, "Cannot solve the case x>=0: General equation solving not yet implemented"},
+		{"root in another variable", "abs(x)=y", nil, "Cannot tell whether the solutions of the case x>=0 lie in it"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := parser.Parse(tt.equation)
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+			eq := expr.(*ast.Eq)
+
+			for _, result := range []SolutionSet{SolveEquation(eq.Left(), eq.Right()), Solve(eq)} {
+				if result.Message != tt.message {
+					t.Errorf("Message = %q, want %q", result.Message, tt.message)
+				}
+				if len(result.Solutions) != len(tt.solutions) {
+					t.Fatalf("Expected %d solutions, got %d", len(tt.solutions), len(result.Solutions))
+				}
+				for i, sol := range result.Solutions {
+					if got := sol.Value.String(); got != tt.solutions[i] {
+						t.Errorf("Solution %d = %s, want %s", i+1, got, tt.solutions[i])
+					}
+				}
+			}
+		})
+	}
+
+	// An |u| the solver cannot split into cases must not send it round in
+	// circles
+	x := ast.NewVar("x")
+	piecewise := ast.NewPiecewise(
+		[]ast.Expr{ast.NewFunc("abs", x), x},
+		[]ast.Expr{ast.NewEq(x, ast.NewInt(0), ast.EqGreater), nil},
+	)
+	if result := SolveEquation(piecewise, ast.NewInt(1)); result.HasSolutions {
+		t.Errorf("SolveEquation(%s, 1) should not claim solutions: %v", piecewise, result)
+	}
+	inequality, _ := parser.Parse("abs(x) < 2")
+	if result := Solve(inequality); result.HasSolutions || result.Message != "abs(x)<2 is not an equation; use SolveInequality" {
+		t.Errorf("Solve(%s) = %v", inequality, result)
+	}
+}
+
+func TestSolveAssumptions(t *testing.T) {
+	tests := []struct {
+		name        string
+		equation    string
+		assumptions ast.Assumptions
+		solutions   int
+	}{
+		{"no assumptions", "x^2-4", nil, 2},
+		{"positive root only", "x^2-4", ast.Assume("x", ast.Positive), 1},
+		{"integer root only", "2*x^2-x-1", ast.Assume("x", ast.Integer), 1},
+		{"no positive root", "x+3", ast.Assume("x", ast.Positive), 0},
+		{"nonzero", "x^2-x", ast.Assume("x", ast.Nonzero), 1},
+		{"assumption on another variable", "x^2-4", ast.Assume("y", ast.Positive), 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := parser.Parse(tt.equation)
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+
+			opts := DefaultSolveOptions()
+			opts.Assumptions = tt.assumptions
+			result := Solve(expr, opts)
+			if len(result.Solutions) != tt.solutions {
+				t.Errorf("Expected %d solutions, got %d: %s", tt.solutions, len(result.Solutions), result.Message)
+			}
+			for _, sol := range result.Solutions {
+				value, _ := sol.Value.Eval(nil)
+				if f, _ := value.Float64(); !tt.assumptions.Allows("x", f) {
+					t.Errorf("Solution %s breaks the assumptions", sol.Value)
+				}
+			}
+			if tt.solutions == 0 && result.HasSolutions {
+				t.Errorf("Expected no solutions: %s", result.Message)
+			}
+		})
+	}
+
+	rel, _ := parser.Parse("x^2 < 4")
+	opts := DefaultSolveOptions()
+	opts.Assumptions = ast.Assume("x", ast.Positive)
+	set, err := SolveInequality(rel, opts)
+	if err != nil {
+		t.Fatalf("SolveInequality error: %v", err)
+	}
+	if got := set.String(); got != "(0, 2)" {
+		t.Errorf("SolveInequality(x^2 < 4) for positive x = %s, want (0, 2)", got)
+	}
+}
+
 // Helper function
 func containsSubstring(str, substr string) bool {
 	if len(str) < len(substr) {
@@ -0,0 +1,313 @@
+package solve
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/quizizz/cas/pkg/ast"
+	"github.com/quizizz/cas/pkg/expand"
+	"github.com/quizizz/cas/pkg/simplify"
+)
+
+// SolveSystem solves the system exprs[i] = 0 for the given variables by
+// successive substitution. Each equation is solved for the variable with the
+// lowest polynomial degree, the result is substituted into the remaining
+// equations, and the process repeats. An equation that is a product splits
+// into one branch per factor. Every returned map assigns a value to each of
+// the requested variables; an equation the solver cannot handle is an
+// error rather than an empty result.
+func SolveSystem(exprs []ast.Expr, variables []string, opts ...SolveOptions) ([]map[string]ast.Expr, error) {
+	options := DefaultSolveOptions()
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+
+	return solveSystem(exprs, variables, options)
+}
+
+func solveSystem(exprs []ast.Expr, variables []string, opts SolveOptions) ([]map[string]ast.Expr, error) {
+	// Drop equations that no longer mention any unknown, rejecting the
+	// branch when one of them is a contradiction
+	var remaining []ast.Expr
+	for _, expr := range exprs {
+		if mentionsAny(expr, variables) {
+			remaining = append(remaining, expr)
+			continue
+		}
+		val, err := expr.Eval(make(map[string]*big.Float))
+		if err != nil {
+			return nil, fmt.Errorf("cannot evaluate %s: %v", expr.String(), err)
+		}
+		if !isNearZero(val) {
+			return nil, nil
+		}
+	}
+
+	if len(variables) == 0 {
+		return []map[string]ast.Expr{{}}, nil
+	}
+	if len(remaining) == 0 {
+		return nil, fmt.Errorf("system has infinitely many solutions: %v are unconstrained", variables)
+	}
+
+	// A product is zero when any of its factors is, so xy = 0 splits into
+	// the branches x = 0 and y = 0. Factors that cannot vanish, as e^u,
+	// are dropped, and a product of nothing but such factors has no zeros.
+	for i, expr := range remaining {
+		factors, ok := zeroFactors(expr, variables)
+		if !ok {
+			continue
+		}
+		switch len(factors) {
+		case 0:
+			return nil, nil
+		case 1:
+			remaining[i] = factors[0]
+			continue
+		}
+		var results []map[string]ast.Expr
+		for _, factor := range factors {
+			branch := append([]ast.Expr{}, remaining...)
+			branch[i] = factor
+			subResults, err := solveSystem(branch, variables, opts)
+			if err != nil {
+				return nil, err
+			}
+			for _, sub := range subResults {
+				if !containsPoint(results, sub) {
+					results = append(results, sub)
+				}
+			}
+		}
+		return results, nil
+	}
+
+	// Try the easiest equation and variable first, moving on when the
+	// solver cannot isolate it, as with x in xy - 1 = 0
+	pivots := pickPivots(remaining, variables)
+	if len(pivots) == 0 {
+		return nil, fmt.Errorf("cannot isolate any of %v in %s", variables, remaining[0].String())
+	}
+	var index int
+	var variable string
+	var solved SolutionSet
+	for _, pivot := range pivots {
+		options := opts
+		options.Variable = pivot.variable
+		index, variable = pivot.index, pivot.variable
+		solved = solvePivot(remaining[index], variables, options)
+		if len(solved.Solutions) > 0 || solved.Empty {
+			break
+		}
+	}
+	if solved.Empty {
+		return nil, nil
+	}
+	if len(solved.Solutions) == 0 {
+		return nil, fmt.Errorf("cannot solve %s for %s: %s", remaining[index].String(), variable, solved.Message)
+	}
+
+	var others []string
+	for _, v := range variables {
+		if v != variable {
+			others = append(others, v)
+		}
+	}
+
+	var results []map[string]ast.Expr
+	for _, solution := range solved.Solutions {
+		var reduced []ast.Expr
+		for i, expr := range remaining {
+			if i != index {
+				// Expand so the substituted equation is again a plain polynomial
+				substituted := expand.Expand(ast.Substitute(expr, variable, solution.Value))
+				reduced = append(reduced, simplify.Simplify(substituted))
+			}
+		}
+
+		subResults, err := solveSystem(reduced, others, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, sub := range subResults {
+			// Back-substitute the values found for the other unknowns
+			approximate := !solution.IsExact
+			for _, value := range sub {
+				approximate = approximate || isApproximate(value)
+			}
+			sub[variable] = normalizeValue(ast.SubstituteAll(solution.Value, sub), approximate)
+			if !containsPoint(results, sub) {
+				results = append(results, sub)
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// pivot is an equation, by index, and a variable to solve it for
+type pivot struct {
+	index    int
+	variable string
+}
+
+// pickPivots lists the equations and variables that can be solved as
+// polynomials, lowest degree first
+func pickPivots(exprs []ast.Expr, variables []string) []pivot {
+	var pivots []pivot
+	maxDegree := 0
+	for _, expr := range exprs {
+		for _, v := range variables {
+			if degree := getPolynomialDegree(expr, v); degree > maxDegree {
+				maxDegree = degree
+			}
+		}
+	}
+	for degree := 1; degree <= maxDegree; degree++ {
+		for i, expr := range exprs {
+			for _, v := range variables {
+				if containsVariable(expr, v) && getPolynomialDegree(expr, v) == degree {
+					pivots = append(pivots, pivot{i, v})
+				}
+			}
+		}
+	}
+	return pivots
+}
+
+// solvePivot solves an equation for opts.Variable. An equation in that
+// unknown alone with rational coefficients is solved through its exact
+// roots, so that a surd such as sqrt(2) stays exact instead of passing
+// through the quadratic formula.
+func solvePivot(expr ast.Expr, variables []string, opts SolveOptions) SolutionSet {
+	for _, v := range variables {
+		if v != opts.Variable && containsVariable(expr, v) {
+			return Solve(expr, opts)
+		}
+	}
+	degree := getPolynomialDegree(expr, opts.Variable)
+	if _, ok := polynomialCoefficients(expr, opts.Variable); !ok || degree < 1 {
+		return Solve(expr, opts)
+	}
+	return keepAssumed(solvePolynomial(expr, opts, degree), opts)
+}
+
+// zeroFactors returns the factors of a product that depend on the
+// unknowns and can vanish, with powers u^n reduced to u. It reports false
+// when expr is neither such a product nor a lone factor that cannot
+// vanish, as e^x.
+func zeroFactors(expr ast.Expr, variables []string) ([]ast.Expr, bool) {
+	terms := []ast.Expr{expr}
+	if mul, ok := expr.(*ast.Mul); ok {
+		terms = mul.Terms()
+	}
+	var factors []ast.Expr
+	dropped := false
+	for _, factor := range terms {
+		if !mentionsAny(factor, variables) {
+			continue
+		}
+		if neverZero(factor, variables) {
+			dropped = true
+			continue
+		}
+		if pow, ok := factor.(*ast.Pow); ok {
+			if n, ok := pow.Exponent().(*ast.Int); ok && n.IntValue().Sign() > 0 {
+				factor = pow.Base()
+			}
+		}
+		factors = append(factors, factor)
+	}
+	if len(factors) == 1 && !dropped {
+		return nil, false
+	}
+	return factors, true
+}
+
+// neverZero reports whether a factor is a positive constant raised to a
+// power, as e^u or 2^u, or exp(u), none of which can vanish
+func neverZero(factor ast.Expr, variables []string) bool {
+	switch f := factor.(type) {
+	case *ast.Pow:
+		if mentionsAny(f.Base(), variables) {
+			return false
+		}
+		val, err := f.Base().Eval(make(map[string]*big.Float))
+		return err == nil && val.Sign() > 0
+	case *ast.Func:
+		return f.Name() == "exp"
+	}
+	return false
+}
+
+// mentionsAny reports whether expr contains at least one of the variables
+func mentionsAny(expr ast.Expr, variables []string) bool {
+	for _, v := range variables {
+		if containsVariable(expr, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeValue evaluates a back-substituted value exactly to an Int or
+// a Rational when it is built from exact numbers, and simplifies it
+// otherwise, as for a surd or a value computed from a numerical root
+func normalizeValue(expr ast.Expr, approximate bool) ast.Expr {
+	if !approximate {
+		if value, ok := ast.ExactValue(expr); ok {
+			return ast.NewNumber(value)
+		}
+	}
+	return simplify.Simplify(expr)
+}
+
+// isApproximate reports whether a sum, product or power contains a
+// decimal, as a value computed from a numerical root does
+func isApproximate(expr ast.Expr) bool {
+	switch e := expr.(type) {
+	case *ast.Float:
+		return true
+	case *ast.Add:
+		return anyApproximate(e.Terms())
+	case *ast.Mul:
+		return anyApproximate(e.Terms())
+	case *ast.Pow:
+		return isApproximate(e.Base()) || isApproximate(e.Exponent())
+	}
+	return false
+}
+
+func anyApproximate(exprs []ast.Expr) bool {
+	for _, e := range exprs {
+		if isApproximate(e) {
+			return true
+		}
+	}
+	return false
+}
+
+func isNearZero(val *big.Float) bool {
+	f, _ := val.Float64()
+	return math.Abs(f) < 1e-9
+}
+
+// containsPoint checks whether an identical assignment is already present
+func containsPoint(points []map[string]ast.Expr, point map[string]ast.Expr) bool {
+	for _, existing := range points {
+		same := len(existing) == len(point)
+		for name, value := range point {
+			other, ok := existing[name]
+			if !ok || other.String() != value.String() {
+				same = false
+				break
+			}
+		}
+		if same {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+package solve
+
+import (
+	"math"
+	"testing"
+
+	"github.com/quizizz/cas/pkg/ast"
+	"github.com/quizizz/cas/pkg/parser"
+)
+
+func TestSolveSystem(t *testing.T) {
+	tests := []struct {
+		name      string
+		equations []string
+		expected  []map[string]string
+	}{
+		{
+			"linear system",
+			[]string{"2*x+y-3", "x+2*y"},
+			[]map[string]string{{"x": "2", "y": "-1"}},
+		},
+		{
+			"fractional solution",
+			[]string{"2*x-1", "y-x"},
+			[]map[string]string{{"x": "1/2", "y": "1/2"}},
+		},
+		{
+			"quadratic branch",
+			[]string{"x^2-4", "y-x"},
+			[]map[string]string{{"x": "2", "y": "2"}, {"x": "-2", "y": "-2"}},
+		},
+		{
+			"non-constant coefficient",
+			[]string{"x*y-1", "x+y-2"},
+			[]map[string]string{{"x": "1", "y": "1"}},
+		},
+		{
+			"zero product",
+			[]string{"2*x*y", "x+y-1"},
+			[]map[string]string{{"x": "0", "y": "1"}, {"x": "1", "y": "0"}},
+		},
+		{
+			"inconsistent",
+			[]string{"x+y-1", "x+y-2"},
+			nil,
+		},
+		{
+			"never zero",
+			[]string{"e^x", "y"},
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var exprs []ast.Expr
+			for _, eq := range tt.equations {
+				expr, err := parser.Parse(eq)
+				if err != nil {
+					t.Fatalf("Parse error: %v", err)
+				}
+				exprs = append(exprs, expr)
+			}
+
+			solutions, err := SolveSystem(exprs, []string{"x", "y"})
+			if err != nil {
+				t.Fatalf("SolveSystem error: %v", err)
+			}
+
+			if len(solutions) != len(tt.expected) {
+				t.Fatalf("SolveSystem(%v) = %v, expected %v", tt.equations, solutions, tt.expected)
+			}
+
+			for _, want := range tt.expected {
+				found := false
+				for _, got := range solutions {
+					if got["x"].String() == want["x"] && got["y"].String() == want["y"] {
+						found = true
+						break
+					}
+				}
+				if !found {
+					t.Errorf("solution %v missing from %v", want, solutions)
+				}
+			}
+		})
+	}
+}
+
+func TestSolveSystemUnderdetermined(t *testing.T) {
+	expr, err := parser.Parse("x-1")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	if _, err := SolveSystem([]ast.Expr{expr}, []string{"x", "y"}); err == nil {
+		t.Errorf("expected an error for an unconstrained variable")
+	}
+}
+
+func TestSolveSystemUnsolved(t *testing.T) {
+	// Eliminating y leaves the cubic 2x^3 - 1 = 0, which has no exact root;
+	// without approximate solutions that is an error, not an empty solution
+	// set
+	var exprs []ast.Expr
+	for _, eq := range []string{"x^3+y-1", "x^3-y"} {
+		expr, err := parser.Parse(eq)
+		if err != nil {
+			t.Fatalf("Parse error: %v", err)
+		}
+		exprs = append(exprs, expr)
+	}
+
+	if solutions, err := SolveSystem(exprs, []string{"x", "y"}, SolveOptions{}); err == nil {
+		t.Errorf("expected an error, got %v", solutions)
+	}
+
+	solutions, err := SolveSystem(exprs, []string{"x", "y"})
+	if err != nil {
+		t.Fatalf("SolveSystem error: %v", err)
+	}
+	if len(solutions) != 1 {
+		t.Fatalf("SolveSystem(%v) = %v, expected one approximate solution", exprs, solutions)
+	}
+	x, _ := solutions[0]["x"].Eval(nil)
+	if f, _ := x.Float64(); math.Abs(f-math.Cbrt(0.5)) > 1e-9 {
+		t.Errorf("x = %v, expected the cube root of 1/2", f)
+	}
+}
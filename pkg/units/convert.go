@@ -0,0 +1,267 @@
+package units
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/quizizz/cas/pkg/ast"
+)
+
+// ToSI rewrites the quantities in expr in SI base units, so 5 km becomes
+// 5*1000, and returns the dimension of the result. Sums and equations
+// must relate quantities of the same dimension and functions other than
+// sqrt and abs take dimensionless arguments; anything else is an error.
+func ToSI(expr ast.Expr) (ast.Expr, Dimension, error) {
+	switch e := expr.(type) {
+	case *ast.Quantity:
+		value, d, err := ToSI(e.Value())
+		if err != nil {
+			return nil, d, err
+		}
+		scale, unitDim, err := measure(e.Unit())
+		if err != nil {
+			return nil, d, err
+		}
+		return scaled(value, scale), d.times(unitDim), nil
+	case *ast.Unit:
+		scale, d, err := measure(e)
+		if err != nil {
+			return nil, d, err
+		}
+		return rat(scale), d, nil
+	case *ast.Add:
+		terms, d, err := sameDimension(e.Terms(), "add")
+		if err != nil {
+			return nil, d, err
+		}
+		return ast.NewAdd(terms...), d, nil
+	case *ast.PlusMinus:
+		operands := []ast.Expr{e.Right()}
+		if left := e.Left(); left != nil {
+			operands = append([]ast.Expr{left}, operands...)
+		}
+		converted, d, err := sameDimension(operands, "add")
+		if err != nil {
+			return nil, d, err
+		}
+		if len(converted) == 1 {
+			return ast.NewPlusMinus(nil, converted[0]), d, nil
+		}
+		return ast.NewPlusMinus(converted[0], converted[1]), d, nil
+	case *ast.Mul:
+		var d Dimension
+		factors := make([]ast.Expr, len(e.Terms()))
+		for i, factor := range e.Terms() {
+			converted, factorDim, err := ToSI(factor)
+			if err != nil {
+				return nil, d, err
+			}
+			factors[i] = converted
+			d = d.times(factorDim)
+		}
+		return ast.NewMul(factors...), d, nil
+	case *ast.Pow:
+		base, d, err := ToSI(e.Base())
+		if err != nil {
+			return nil, d, err
+		}
+		exponent, exponentDim, err := ToSI(e.Exponent())
+		if err != nil {
+			return nil, d, err
+		}
+		if exponentDim != Dimensionless {
+			return nil, d, fmt.Errorf("exponent %s has dimension %s", e.Exponent(), exponentDim)
+		}
+		if d == Dimensionless {
+			return ast.NewPow(base, exponent), d, nil
+		}
+		n, ok := integerExponent(e.Exponent())
+		if !ok {
+			return nil, d, fmt.Errorf("cannot raise %s to the power %s", d, e.Exponent())
+		}
+		return ast.NewPow(base, exponent), d.power(n), nil
+	case *ast.Func:
+		return funcToSI(e)
+	case *ast.Eq:
+		sides, d, err := sameDimension([]ast.Expr{e.Left(), e.Right()}, "compare")
+		if err != nil {
+			return nil, d, err
+		}
+		return ast.NewEq(sides[0], sides[1], e.EqType()), d, nil
+	case *ast.Tuple:
+		elements, d, err := sameDimension(e.Elements(), "list")
+		if err != nil {
+			return nil, d, err
+		}
+		return ast.NewTuple(elements...), d, nil
+	case *ast.Set:
+		elements, d, err := sameDimension(e.Elements(), "list")
+		if err != nil {
+			return nil, d, err
+		}
+		return ast.NewSet(elements...), d, nil
+	}
+	return expr.Clone(), Dimensionless, nil
+}
+
+// Convert expresses a quantity in another unit of the same dimension, so
+// 5 km in m gives 5000 m
+func Convert(q *ast.Quantity, to ast.Expr) (*ast.Quantity, error) {
+	fromScale, fromDim, err := measure(q.Unit())
+	if err != nil {
+		return nil, err
+	}
+	toScale, toDim, err := measure(to)
+	if err != nil {
+		return nil, err
+	}
+	if fromDim != toDim {
+		return nil, fmt.Errorf("cannot convert %s to %s: dimensions %s and %s differ", q.Unit(), to, fromDim, toDim)
+	}
+	factor := new(big.Rat).Quo(fromScale, toScale)
+	return ast.NewQuantity(scaled(q.Value(), factor).Simplify(), to.Clone()), nil
+}
+
+// measure returns the number of SI base units in a unit, a product of
+// powers of unit symbols, and its dimension
+func measure(unit ast.Expr) (*big.Rat, Dimension, error) {
+	switch u := unit.(type) {
+	case *ast.Unit:
+		known, ok := Lookup(u.Name())
+		if !ok {
+			return nil, Dimensionless, fmt.Errorf("unknown unit %s", u.Name())
+		}
+		return known.Scale, known.Dimension, nil
+	case *ast.Mul:
+		scale, d := big.NewRat(1, 1), Dimensionless
+		for _, factor := range u.Terms() {
+			factorScale, factorDim, err := measure(factor)
+			if err != nil {
+				return nil, d, err
+			}
+			scale.Mul(scale, factorScale)
+			d = d.times(factorDim)
+		}
+		return scale, d, nil
+	case *ast.Pow:
+		n, ok := integerExponent(u.Exponent())
+		if !ok {
+			return nil, Dimensionless, fmt.Errorf("unit power %s is not an integer", u.Exponent())
+		}
+		scale, d, err := measure(u.Base())
+		if err != nil {
+			return nil, d, err
+		}
+		return ratPower(scale, n), d.power(n), nil
+	}
+	return nil, Dimensionless, fmt.Errorf("%s is not a unit", unit)
+}
+
+// funcToSI converts the arguments of a function. The square root halves
+// the dimension of its argument and the absolute value keeps it; other
+// functions need dimensionless arguments.
+func funcToSI(f *ast.Func) (ast.Expr, Dimension, error) {
+	args := make([]ast.Expr, len(f.Args()))
+	dims := make([]Dimension, len(f.Args()))
+	for i, arg := range f.Args() {
+		converted, d, err := ToSI(arg)
+		if err != nil {
+			return nil, d, err
+		}
+		args[i], dims[i] = converted, d
+	}
+	result := ast.NewFunc(f.Name(), args...)
+
+	switch f.Name() {
+	case "abs":
+		if len(dims) == 1 {
+			return result, dims[0], nil
+		}
+	case "sqrt":
+		if len(dims) == 1 {
+			half, ok := dims[0].half()
+			if !ok {
+				return nil, dims[0], fmt.Errorf("cannot take the square root of %s", dims[0])
+			}
+			return result, half, nil
+		}
+	}
+	for i, d := range dims {
+		if d != Dimensionless {
+			return nil, d, fmt.Errorf("argument %s of %s has dimension %s", f.Args()[i], f.Name(), d)
+		}
+	}
+	return result, Dimensionless, nil
+}
+
+// sameDimension converts expressions that must share a dimension, such as
+// the terms of a sum, and returns that dimension
+func sameDimension(exprs []ast.Expr, verb string) ([]ast.Expr, Dimension, error) {
+	converted := make([]ast.Expr, len(exprs))
+	var d Dimension
+	for i, expr := range exprs {
+		result, exprDim, err := ToSI(expr)
+		if err != nil {
+			return nil, d, err
+		}
+		if i > 0 && exprDim != d {
+			return nil, d, fmt.Errorf("cannot %s %s and %s", verb, d, exprDim)
+		}
+		converted[i], d = result, exprDim
+	}
+	return converted, d, nil
+}
+
+// half returns the dimension whose square is d, if every power is even
+func (d Dimension) half() (Dimension, bool) {
+	for i := range d {
+		if d[i]%2 != 0 {
+			return d, false
+		}
+		d[i] /= 2
+	}
+	return d, true
+}
+
+// integerExponent returns the value of an integer exponent, including a
+// negated one such as -2 written as -1*2
+func integerExponent(exponent ast.Expr) (int, bool) {
+	value, err := exponent.Simplify().Eval(nil)
+	if err != nil || !value.IsInt() {
+		return 0, false
+	}
+	n, accuracy := value.Int64()
+	if accuracy != big.Exact || n > 1<<10 || n < -(1<<10) {
+		return 0, false
+	}
+	return int(n), true
+}
+
+// scaled returns value times scale, leaving value alone when scale is 1
+func scaled(value ast.Expr, scale *big.Rat) ast.Expr {
+	if scale.Cmp(big.NewRat(1, 1)) == 0 {
+		return value
+	}
+	return ast.NewMul(value, rat(scale))
+}
+
+// rat returns a rational number as an Int or Rational
+func rat(r *big.Rat) ast.Expr {
+	if r.IsInt() {
+		integer, _ := ast.NewIntFromString(r.Num().String())
+		return integer
+	}
+	return ast.NewRationalFromInts(r.Num(), r.Denom())
+}
+
+// ratPower returns r^n exactly
+func ratPower(r *big.Rat, n int) *big.Rat {
+	result := big.NewRat(1, 1)
+	for i := 0; i < n || i < -n; i++ {
+		result.Mul(result, r)
+	}
+	if n < 0 {
+		result.Inv(result)
+	}
+	return result
+}
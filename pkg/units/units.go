@@ -0,0 +1,154 @@
+// Package units knows the units of measure that quantities such as
+// 9.8 m/s^2 are written in. It converts quantities between compatible
+// units and checks the dimensions of expressions built from them.
+package units
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+// Dimension gives the powers of the SI base quantities a unit measures:
+// length, mass, time, electric current, temperature and amount of
+// substance. Velocity is length^1 time^-1.
+type Dimension [6]int
+
+// baseSymbols are the SI units of the base quantities, in Dimension order
+var baseSymbols = [6]string{"m", "kg", "s", "A", "K", "mol"}
+
+// Dimensionless is the dimension of a pure number
+var Dimensionless Dimension
+
+// String writes the dimension in SI base units, as in m*s^-2
+func (d Dimension) String() string {
+	var parts []string
+	for i, power := range d {
+		switch power {
+		case 0:
+		case 1:
+			parts = append(parts, baseSymbols[i])
+		default:
+			parts = append(parts, fmt.Sprintf("%s^%d", baseSymbols[i], power))
+		}
+	}
+	if len(parts) == 0 {
+		return "dimensionless"
+	}
+	return strings.Join(parts, "*")
+}
+
+// times returns the dimension of a product
+func (d Dimension) times(other Dimension) Dimension {
+	for i := range d {
+		d[i] += other[i]
+	}
+	return d
+}
+
+// power returns the dimension of a power
+func (d Dimension) power(n int) Dimension {
+	for i := range d {
+		d[i] *= n
+	}
+	return d
+}
+
+// Unit is a unit of measure: the number of SI base units it stands for
+// and the dimension it measures
+type Unit struct {
+	Symbol    string
+	Name      string
+	Scale     *big.Rat
+	Dimension Dimension
+}
+
+// dim builds a dimension from powers of m, kg, s, A, K and mol
+func dim(powers ...int) Dimension {
+	var d Dimension
+	copy(d[:], powers)
+	return d
+}
+
+var (
+	length      = dim(1)
+	mass        = dim(0, 1)
+	duration    = dim(0, 0, 1)
+	current     = dim(0, 0, 0, 1)
+	temperature = dim(0, 0, 0, 0, 1)
+	amount      = dim(0, 0, 0, 0, 0, 1)
+	volume      = dim(3)
+	frequency   = dim(0, 0, -1)
+	force       = dim(1, 1, -2)
+	energy      = dim(2, 1, -2)
+	power       = dim(2, 1, -3)
+	pressure    = dim(-1, 1, -2)
+	voltage     = dim(2, 1, -3, -1)
+)
+
+// table lists the known units. Temperatures other than kelvin are left
+// out, as their zero is offset and they do not scale.
+var table = []Unit{
+	{"m", "metre", big.NewRat(1, 1), length},
+	{"km", "kilometre", big.NewRat(1000, 1), length},
+	{"cm", "centimetre", big.NewRat(1, 100), length},
+	{"mm", "millimetre", big.NewRat(1, 1000), length},
+	{"in", "inch", big.NewRat(127, 5000), length},
+	{"ft", "foot", big.NewRat(381, 1250), length},
+	{"yd", "yard", big.NewRat(1143, 1250), length},
+	{"mi", "mile", big.NewRat(201168, 125), length},
+	{"kg", "kilogram", big.NewRat(1, 1), mass},
+	{"g", "gram", big.NewRat(1, 1000), mass},
+	{"mg", "milligram", big.NewRat(1, 1000000), mass},
+	{"lb", "pound", big.NewRat(45359237, 100000000), mass},
+	{"oz", "ounce", big.NewRat(45359237, 1600000000), mass},
+	{"s", "second", big.NewRat(1, 1), duration},
+	{"ms", "millisecond", big.NewRat(1, 1000), duration},
+	{"min", "minute", big.NewRat(60, 1), duration},
+	{"h", "hour", big.NewRat(3600, 1), duration},
+	{"A", "ampere", big.NewRat(1, 1), current},
+	{"mA", "milliampere", big.NewRat(1, 1000), current},
+	{"K", "kelvin", big.NewRat(1, 1), temperature},
+	{"mol", "mole", big.NewRat(1, 1), amount},
+	{"L", "litre", big.NewRat(1, 1000), volume},
+	{"mL", "millilitre", big.NewRat(1, 1000000), volume},
+	{"Hz", "hertz", big.NewRat(1, 1), frequency},
+	{"N", "newton", big.NewRat(1, 1), force},
+	{"kN", "kilonewton", big.NewRat(1000, 1), force},
+	{"J", "joule", big.NewRat(1, 1), energy},
+	{"kJ", "kilojoule", big.NewRat(1000, 1), energy},
+	{"W", "watt", big.NewRat(1, 1), power},
+	{"kW", "kilowatt", big.NewRat(1000, 1), power},
+	{"Pa", "pascal", big.NewRat(1, 1), pressure},
+	{"kPa", "kilopascal", big.NewRat(1000, 1), pressure},
+	{"V", "volt", big.NewRat(1, 1), voltage},
+}
+
+// bySymbol indexes the table by symbol
+var bySymbol = func() map[string]Unit {
+	m := make(map[string]Unit, len(table))
+	for _, unit := range table {
+		m[unit.Symbol] = unit
+	}
+	return m
+}()
+
+// Lookup returns the unit with the given symbol
+func Lookup(symbol string) (Unit, bool) {
+	unit, ok := bySymbol[symbol]
+	return unit, ok
+}
+
+// Symbols returns the symbols of the known units, longest first, so that a
+// scanner trying them in order reads km before m
+func Symbols() []string {
+	symbols := make([]string, len(table))
+	for i, unit := range table {
+		symbols[i] = unit.Symbol
+	}
+	sort.SliceStable(symbols, func(i, j int) bool {
+		return len(symbols[i]) > len(symbols[j])
+	})
+	return symbols
+}
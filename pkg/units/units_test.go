@@ -0,0 +1,136 @@
+package units
+
+import (
+	"math"
+	"testing"
+
+	"github.com/quizizz/cas/pkg/ast"
+)
+
+// per builds the unit numerator/denominator^power
+func per(numerator string, denominator string, power int64) ast.Expr {
+	return ast.NewMul(ast.NewUnit(numerator), ast.NewPow(ast.NewUnit(denominator), ast.NewInt(-power)))
+}
+
+func TestSymbols(t *testing.T) {
+	symbols := Symbols()
+	position := make(map[string]int, len(symbols))
+	for i, symbol := range symbols {
+		position[symbol] = i
+		if _, ok := Lookup(symbol); !ok {
+			t.Errorf("Lookup(%q) failed for a listed symbol", symbol)
+		}
+	}
+	if position["km"] > position["m"] || position["mol"] > position["m"] {
+		t.Errorf("Symbols() = %v, want longer symbols first", symbols)
+	}
+	if _, ok := Lookup("furlong"); ok {
+		t.Errorf("Lookup(furlong) should fail")
+	}
+}
+
+func TestDimensionString(t *testing.T) {
+	tests := []struct {
+		dimension Dimension
+		want      string
+	}{
+		{Dimensionless, "dimensionless"},
+		{length, "m"},
+		{dim(1, 0, -2), "m*s^-2"},
+		{force, "m*kg*s^-2"},
+	}
+	for _, tt := range tests {
+		if got := tt.dimension.String(); got != tt.want {
+			t.Errorf("%v.String() = %s, want %s", [6]int(tt.dimension), got, tt.want)
+		}
+	}
+}
+
+func TestToSI(t *testing.T) {
+	tests := []struct {
+		name      string
+		expr      ast.Expr
+		want      float64
+		dimension Dimension
+	}{
+		{"kilometres", ast.NewQuantity(ast.NewInt(5), ast.NewUnit("km")), 5000, length},
+		{"acceleration", ast.NewQuantity(ast.NewFloat(9.8), per("m", "s", 2)), 9.8, dim(1, 0, -2)},
+		{"speed in km/h", ast.NewQuantity(ast.NewInt(36), per("km", "h", 1)), 10, dim(1, 0, -1)},
+		{"sum of lengths", ast.NewAdd(
+			ast.NewQuantity(ast.NewInt(1), ast.NewUnit("m")),
+			ast.NewQuantity(ast.NewInt(50), ast.NewUnit("cm")),
+		), 1.5, length},
+		{"area", ast.NewPow(ast.NewQuantity(ast.NewInt(2), ast.NewUnit("cm")), ast.NewInt(2)), 0.0004, dim(2)},
+		{"square root", ast.NewFunc("sqrt", ast.NewQuantity(ast.NewInt(9), ast.NewPow(ast.NewUnit("m"), ast.NewInt(2)))), 3, length},
+		{"plain number", ast.NewInt(7), 7, Dimensionless},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			converted, dimension, err := ToSI(tt.expr)
+			if err != nil {
+				t.Fatalf("ToSI(%s) error: %v", tt.expr, err)
+			}
+			if dimension != tt.dimension {
+				t.Errorf("ToSI(%s) dimension = %s, want %s", tt.expr, dimension, tt.dimension)
+			}
+			value, err := converted.Eval(nil)
+			if err != nil {
+				t.Fatalf("Eval(%s) error: %v", converted, err)
+			}
+			if got, _ := value.Float64(); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("ToSI(%s) = %v, want %v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToSIErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		expr ast.Expr
+	}{
+		{"adding length and time", ast.NewAdd(
+			ast.NewQuantity(ast.NewInt(1), ast.NewUnit("m")),
+			ast.NewQuantity(ast.NewInt(1), ast.NewUnit("s")),
+		)},
+		{"sine of a length", ast.NewFunc("sin", ast.NewQuantity(ast.NewInt(1), ast.NewUnit("m")))},
+		{"square root of a length", ast.NewFunc("sqrt", ast.NewQuantity(ast.NewInt(4), ast.NewUnit("m")))},
+		{"unknown unit", ast.NewQuantity(ast.NewInt(1), ast.NewUnit("furlong"))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := ToSI(tt.expr); err == nil {
+				t.Errorf("ToSI(%s) should fail", tt.expr)
+			}
+		})
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name     string
+		quantity *ast.Quantity
+		to       ast.Expr
+		want     string
+	}{
+		{"km to m", ast.NewQuantity(ast.NewInt(5), ast.NewUnit("km")), ast.NewUnit("m"), "5000 m"},
+		{"m to km", ast.NewQuantity(ast.NewInt(250), ast.NewUnit("m")), ast.NewUnit("km"), "0.25 km"},
+		{"km/h to m/s", ast.NewQuantity(ast.NewInt(72), per("km", "h", 1)), per("m", "s", 1), "20 m/s"},
+		{"ft to in", ast.NewQuantity(ast.NewInt(3), ast.NewUnit("ft")), ast.NewUnit("in"), "36 in"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			converted, err := Convert(tt.quantity, tt.to)
+			if err != nil {
+				t.Fatalf("Convert(%s) error: %v", tt.quantity, err)
+			}
+			if got := converted.String(); got != tt.want {
+				t.Errorf("Convert(%s) = %s, want %s", tt.quantity, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := Convert(ast.NewQuantity(ast.NewInt(1), ast.NewUnit("kg")), ast.NewUnit("m")); err == nil {
+		t.Errorf("Convert(1 kg) to m should fail")
+	}
+}